@@ -0,0 +1,273 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type AlertMetric string
+
+const (
+	// AlertMetricOpenTickets is the number of currently open tickets.
+	AlertMetricOpenTickets AlertMetric = "open_tickets"
+	// AlertMetricAverageWaitMinutes is the average number of minutes that open tickets without a staff response have
+	// been waiting for.
+	AlertMetricAverageWaitMinutes AlertMetric = "average_wait_minutes"
+)
+
+type AlertThreshold struct {
+	Id              int         `json:"id"`
+	GuildId         uint64      `json:"guild_id,string"`
+	Metric          AlertMetric `json:"metric"`
+	Threshold       int         `json:"threshold"`
+	ChannelId       uint64      `json:"channel_id,string"`
+	MentionRoleId   *uint64     `json:"mention_role_id,string"`
+	CooldownMinutes int         `json:"cooldown_minutes"`
+	Enabled         bool        `json:"enabled"`
+}
+
+// BreachedThreshold is a threshold that is currently exceeded, along with the current value of its metric.
+type BreachedThreshold struct {
+	AlertThreshold
+	Value float64 `json:"value"`
+}
+
+type AlertThresholdsTable struct {
+	*pgxpool.Pool
+}
+
+func newAlertThresholdsTable(db *pgxpool.Pool) *AlertThresholdsTable {
+	return &AlertThresholdsTable{
+		db,
+	}
+}
+
+func (a AlertThresholdsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS alert_thresholds(
+	"id" SERIAL NOT NULL UNIQUE,
+	"guild_id" int8 NOT NULL,
+	"metric" varchar(32) NOT NULL,
+	"threshold" int4 NOT NULL,
+	"channel_id" int8 NOT NULL,
+	"mention_role_id" int8 DEFAULT NULL,
+	"cooldown_minutes" int4 NOT NULL DEFAULT 60,
+	"enabled" bool NOT NULL DEFAULT true,
+	CHECK("metric" IN ('open_tickets', 'average_wait_minutes')),
+	CHECK("threshold" >= 0),
+	CHECK("cooldown_minutes" >= 0),
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS alert_thresholds_guild_id ON alert_thresholds("guild_id");
+
+CREATE TABLE IF NOT EXISTS alert_events(
+	"id" BIGSERIAL NOT NULL,
+	"threshold_id" int NOT NULL,
+	"value" float8 NOT NULL,
+	"triggered_at" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("threshold_id") REFERENCES alert_thresholds("id") ON DELETE CASCADE,
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS alert_events_threshold_id_triggered_at ON alert_events("threshold_id", "triggered_at");
+`
+}
+
+func (a *AlertThresholdsTable) Get(ctx context.Context, guildId uint64, id int) (AlertThreshold, bool, error) {
+	query := `
+SELECT "id", "guild_id", "metric", "threshold", "channel_id", "mention_role_id", "cooldown_minutes", "enabled"
+FROM alert_thresholds
+WHERE "guild_id" = $1 AND "id" = $2;`
+
+	var threshold AlertThreshold
+	if err := a.QueryRow(ctx, query, guildId, id).Scan(threshold.fieldPtrs()...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return AlertThreshold{}, false, nil
+		} else {
+			return AlertThreshold{}, false, err
+		}
+	}
+
+	return threshold, true, nil
+}
+
+func (a *AlertThresholdsTable) GetByGuild(ctx context.Context, guildId uint64) ([]AlertThreshold, error) {
+	query := `
+SELECT "id", "guild_id", "metric", "threshold", "channel_id", "mention_role_id", "cooldown_minutes", "enabled"
+FROM alert_thresholds
+WHERE "guild_id" = $1
+ORDER BY "id" ASC;`
+
+	rows, err := a.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var thresholds []AlertThreshold
+	for rows.Next() {
+		var threshold AlertThreshold
+		if err := rows.Scan(threshold.fieldPtrs()...); err != nil {
+			return nil, err
+		}
+
+		thresholds = append(thresholds, threshold)
+	}
+
+	return thresholds, nil
+}
+
+func (a *AlertThresholdsTable) Create(ctx context.Context, threshold AlertThreshold) (id int, err error) {
+	query := `
+INSERT INTO alert_thresholds("guild_id", "metric", "threshold", "channel_id", "mention_role_id", "cooldown_minutes", "enabled")
+VALUES($1, $2, $3, $4, $5, $6, $7)
+RETURNING "id";`
+
+	err = a.QueryRow(ctx, query,
+		threshold.GuildId,
+		threshold.Metric,
+		threshold.Threshold,
+		threshold.ChannelId,
+		threshold.MentionRoleId,
+		threshold.CooldownMinutes,
+		threshold.Enabled,
+	).Scan(&id)
+	return
+}
+
+func (a *AlertThresholdsTable) Update(ctx context.Context, threshold AlertThreshold) (err error) {
+	query := `
+UPDATE alert_thresholds
+SET "metric" = $3,
+	"threshold" = $4,
+	"channel_id" = $5,
+	"mention_role_id" = $6,
+	"cooldown_minutes" = $7,
+	"enabled" = $8
+WHERE "guild_id" = $1 AND "id" = $2;`
+
+	_, err = a.Exec(ctx, query,
+		threshold.GuildId,
+		threshold.Id,
+		threshold.Metric,
+		threshold.Threshold,
+		threshold.ChannelId,
+		threshold.MentionRoleId,
+		threshold.CooldownMinutes,
+		threshold.Enabled,
+	)
+	return
+}
+
+func (a *AlertThresholdsTable) Delete(ctx context.Context, guildId uint64, id int) (err error) {
+	query := `DELETE FROM alert_thresholds WHERE "guild_id" = $1 AND "id" = $2;`
+	_, err = a.Exec(ctx, query, guildId, id)
+	return
+}
+
+// EvaluateThresholds returns the guild's enabled thresholds whose metric currently exceeds the threshold. Cooldowns
+// are not taken into account; use RecordAlert to deduplicate notifications. Snoozed tickets are not counted.
+func (a *AlertThresholdsTable) EvaluateThresholds(ctx context.Context, guildId uint64) ([]BreachedThreshold, error) {
+	query := `
+WITH metrics AS (
+	SELECT
+		(
+			SELECT COUNT(*)
+			FROM tickets
+			WHERE tickets.guild_id = $1 AND tickets.open AND NOT EXISTS(
+				SELECT 1
+				FROM ticket_snoozes
+				WHERE ticket_snoozes.guild_id = tickets.guild_id AND ticket_snoozes.ticket_id = tickets.id AND ticket_snoozes.until > NOW()
+			)
+		)::float8 AS open_tickets,
+		COALESCE((
+			SELECT AVG(EXTRACT(EPOCH FROM NOW() - tickets.open_time) / 60)
+			FROM tickets
+			WHERE tickets.guild_id = $1 AND tickets.open AND NOT EXISTS(
+				SELECT 1
+				FROM first_response_time
+				WHERE first_response_time.guild_id = tickets.guild_id AND first_response_time.ticket_id = tickets.id
+			) AND NOT EXISTS(
+				SELECT 1
+				FROM ticket_snoozes
+				WHERE ticket_snoozes.guild_id = tickets.guild_id AND ticket_snoozes.ticket_id = tickets.id AND ticket_snoozes.until > NOW()
+			)
+		), 0)::float8 AS average_wait_minutes
+), evaluated AS (
+	SELECT
+		alert_thresholds.*,
+		CASE alert_thresholds.metric
+			WHEN 'open_tickets' THEN metrics.open_tickets
+			WHEN 'average_wait_minutes' THEN metrics.average_wait_minutes
+		END AS value
+	FROM alert_thresholds, metrics
+	WHERE alert_thresholds.guild_id = $1 AND alert_thresholds.enabled
+)
+SELECT "id", "guild_id", "metric", "threshold", "channel_id", "mention_role_id", "cooldown_minutes", "enabled", "value"
+FROM evaluated
+WHERE "value" > "threshold"
+ORDER BY "id" ASC;`
+
+	rows, err := a.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var breached []BreachedThreshold
+	for rows.Next() {
+		var threshold BreachedThreshold
+		if err := rows.Scan(append(threshold.fieldPtrs(), &threshold.Value)...); err != nil {
+			return nil, err
+		}
+
+		breached = append(breached, threshold)
+	}
+
+	return breached, nil
+}
+
+// RecordAlert logs an alert for the threshold, unless one has already been logged within the threshold's cooldown.
+// Returns true if the alert was logged, in which case the caller should send the notification.
+func (a *AlertThresholdsTable) RecordAlert(ctx context.Context, thresholdId int, value float64) (recorded bool, err error) {
+	query := `
+INSERT INTO alert_events("threshold_id", "value", "triggered_at")
+SELECT alert_thresholds.id, $2, NOW()
+FROM alert_thresholds
+WHERE alert_thresholds.id = $1 AND NOT EXISTS(
+	SELECT 1
+	FROM alert_events
+	WHERE alert_events.threshold_id = alert_thresholds.id
+		AND alert_events.triggered_at > NOW() - make_interval(mins => alert_thresholds.cooldown_minutes)
+);`
+
+	res, err := a.Exec(ctx, query, thresholdId, value)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// GetLastAlert returns the time the threshold last triggered an alert, or nil if it never has.
+func (a *AlertThresholdsTable) GetLastAlert(ctx context.Context, thresholdId int) (triggeredAt *time.Time, err error) {
+	query := `SELECT MAX("triggered_at") FROM alert_events WHERE "threshold_id" = $1;`
+	err = a.QueryRow(ctx, query, thresholdId).Scan(&triggeredAt)
+	return
+}
+
+func (t *AlertThreshold) fieldPtrs() []interface{} {
+	return []interface{}{
+		&t.Id,
+		&t.GuildId,
+		&t.Metric,
+		&t.Threshold,
+		&t.ChannelId,
+		&t.MentionRoleId,
+		&t.CooldownMinutes,
+		&t.Enabled,
+	}
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// AnonymizedUserId replaces the user ID of the ticket opener and other identifying user ID columns once a ticket has
+// been anonymized.
+const AnonymizedUserId uint64 = 0
+
+const anonymizationRequestType = "ticket_anonymization"
+
+// AnonymizeTicketsPastPolicy anonymizes up to batchSize closed tickets that were closed longer ago than their guild's
+// anonymization policy allows. Identifying user IDs are removed from the tickets and their child tables, while data
+// used for aggregate statistics (e.g. claims, response times and ratings) is kept. A gdpr_logs entry is written for
+// each guild processed. Returns the number of tickets anonymized; callers should repeat until this is 0.
+func (d *Database) AnonymizeTicketsPastPolicy(ctx context.Context, batchSize int) (anonymized int, err error) {
+	err = d.WithTx(ctx, func(tx pgx.Tx) error {
+		batchQuery := `
+SELECT tickets.guild_id, tickets.id
+FROM tickets
+INNER JOIN anonymization_policies
+	ON anonymization_policies.guild_id = tickets.guild_id
+WHERE anonymization_policies.enabled
+	AND NOT tickets.open
+	AND tickets.close_time IS NOT NULL
+	AND tickets.close_time < NOW() - make_interval(days => anonymization_policies.retention_days)
+	AND tickets.user_id <> $2
+ORDER BY tickets.close_time ASC
+LIMIT $1
+FOR UPDATE OF tickets SKIP LOCKED;`
+
+		rows, err := tx.Query(ctx, batchQuery, batchSize, AnonymizedUserId)
+		if err != nil {
+			return err
+		}
+
+		var guildIds []uint64
+		var ticketIds []int
+		perGuild := make(map[uint64]int)
+		for rows.Next() {
+			var guildId uint64
+			var ticketId int
+			if err := rows.Scan(&guildId, &ticketId); err != nil {
+				rows.Close()
+				return err
+			}
+
+			guildIds = append(guildIds, guildId)
+			ticketIds = append(ticketIds, ticketId)
+			perGuild[guildId]++
+		}
+
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if len(ticketIds) == 0 {
+			return nil
+		}
+
+		// $1 and $2 are the guild and ticket ID arrays
+		scrubQueries := []string{
+			`DELETE FROM participant WHERE ("guild_id", "ticket_id") IN (SELECT * FROM unnest($1::int8[], $2::int4[]));`,
+			`DELETE FROM ticket_members WHERE ("guild_id", "ticket_id") IN (SELECT * FROM unnest($1::int8[], $2::int4[]));`,
+			`UPDATE ticket_last_message SET "user_id" = NULL WHERE ("guild_id", "ticket_id") IN (SELECT * FROM unnest($1::int8[], $2::int4[]));`,
+			`UPDATE close_reason SET "closed_by" = NULL WHERE ("guild_id", "ticket_id") IN (SELECT * FROM unnest($1::int8[], $2::int4[]));`,
+		}
+
+		for _, query := range scrubQueries {
+			if _, err := tx.Exec(ctx, query, guildIds, ticketIds); err != nil {
+				return fmt.Errorf("failed to anonymize tickets: %w", err)
+			}
+		}
+
+		// $3 is the replacement user ID, for columns that cannot be null
+		replaceQueries := []string{
+			`UPDATE close_request SET "user_id" = $3 WHERE ("guild_id", "ticket_id") IN (SELECT * FROM unnest($1::int8[], $2::int4[]));`,
+			`UPDATE tickets SET "user_id" = $3 WHERE ("guild_id", "id") IN (SELECT * FROM unnest($1::int8[], $2::int4[]));`,
+		}
+
+		for _, query := range replaceQueries {
+			if _, err := tx.Exec(ctx, query, guildIds, ticketIds, AnonymizedUserId); err != nil {
+				return fmt.Errorf("failed to anonymize tickets: %w", err)
+			}
+		}
+
+		for guildId, count := range perGuild {
+			if _, err := tx.Exec(ctx, `UPDATE anonymization_policies SET "last_run_at" = NOW() WHERE "guild_id" = $1;`, guildId); err != nil {
+				return err
+			}
+
+			hash := sha256.Sum256([]byte(fmt.Sprintf("guild:%d", guildId)))
+			logQuery := `INSERT INTO gdpr_logs (requester, request_type, status) VALUES ($1, $2, $3);`
+			if _, err := tx.Exec(ctx, logQuery, hex.EncodeToString(hash[:]), anonymizationRequestType, fmt.Sprintf("Anonymized %d tickets", count)); err != nil {
+				return err
+			}
+		}
+
+		anonymized = len(ticketIds)
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return anonymized, nil
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type AnonymizationPolicy struct {
+	GuildId       uint64     `json:"guild_id,string"`
+	RetentionDays int        `json:"retention_days"`
+	Enabled       bool       `json:"enabled"`
+	LastRunAt     *time.Time `json:"last_run_at"`
+}
+
+type AnonymizationPoliciesTable struct {
+	*pgxpool.Pool
+}
+
+func newAnonymizationPoliciesTable(db *pgxpool.Pool) *AnonymizationPoliciesTable {
+	return &AnonymizationPoliciesTable{
+		db,
+	}
+}
+
+func (a AnonymizationPoliciesTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS anonymization_policies(
+	"guild_id" int8 NOT NULL,
+	"retention_days" int4 NOT NULL,
+	"enabled" bool NOT NULL DEFAULT true,
+	"last_run_at" timestamptz DEFAULT NULL,
+	CHECK("retention_days" > 0),
+	PRIMARY KEY("guild_id")
+);
+`
+}
+
+func (a *AnonymizationPoliciesTable) Get(ctx context.Context, guildId uint64) (AnonymizationPolicy, bool, error) {
+	query := `
+SELECT "guild_id", "retention_days", "enabled", "last_run_at"
+FROM anonymization_policies
+WHERE "guild_id" = $1;`
+
+	var policy AnonymizationPolicy
+	if err := a.QueryRow(ctx, query, guildId).Scan(
+		&policy.GuildId,
+		&policy.RetentionDays,
+		&policy.Enabled,
+		&policy.LastRunAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return AnonymizationPolicy{}, false, nil
+		} else {
+			return AnonymizationPolicy{}, false, err
+		}
+	}
+
+	return policy, true, nil
+}
+
+func (a *AnonymizationPoliciesTable) Set(ctx context.Context, guildId uint64, retentionDays int, enabled bool) (err error) {
+	query := `
+INSERT INTO anonymization_policies("guild_id", "retention_days", "enabled")
+VALUES($1, $2, $3)
+ON CONFLICT("guild_id") DO UPDATE SET "retention_days" = EXCLUDED."retention_days", "enabled" = EXCLUDED."enabled";`
+
+	_, err = a.Exec(ctx, query, guildId, retentionDays, enabled)
+	return
+}
+
+func (a *AnonymizationPoliciesTable) Delete(ctx context.Context, guildId uint64) (err error) {
+	query := `DELETE FROM anonymization_policies WHERE "guild_id" = $1;`
+	_, err = a.Exec(ctx, query, guildId)
+	return
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var ErrApiQuotaNotFound = errors.New("guild has no api quota")
+
+type ApiQuota struct {
+	GuildId     uint64        `json:"guild_id,string"`
+	Window      time.Duration `json:"window"`
+	Limit       int           `json:"limit"`
+	Used        int           `json:"used"`
+	WindowStart time.Time     `json:"window_start"`
+}
+
+type ApiQuotasTable struct {
+	*pgxpool.Pool
+}
+
+func newApiQuotasTable(db *pgxpool.Pool) *ApiQuotasTable {
+	return &ApiQuotasTable{
+		db,
+	}
+}
+
+func (a ApiQuotasTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS api_quotas(
+	"guild_id" int8 NOT NULL,
+	"window" interval NOT NULL,
+	"limit" int4 NOT NULL,
+	"used" int4 NOT NULL DEFAULT 0,
+	"window_start" timestamptz NOT NULL DEFAULT NOW(),
+	CHECK("window" > INTERVAL '0'),
+	CHECK("limit" >= 0),
+	PRIMARY KEY("guild_id")
+);
+`
+}
+
+func (a *ApiQuotasTable) Get(ctx context.Context, guildId uint64) (ApiQuota, bool, error) {
+	query := `
+SELECT "guild_id", "window", "limit", "used", "window_start"
+FROM api_quotas
+WHERE "guild_id" = $1;`
+
+	var quota ApiQuota
+	if err := a.QueryRow(ctx, query, guildId).Scan(
+		&quota.GuildId,
+		&quota.Window,
+		&quota.Limit,
+		&quota.Used,
+		&quota.WindowStart,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ApiQuota{}, false, nil
+		} else {
+			return ApiQuota{}, false, err
+		}
+	}
+
+	return quota, true, nil
+}
+
+// Set creates or updates the guild's quota. Usage within the current window is preserved.
+func (a *ApiQuotasTable) Set(ctx context.Context, guildId uint64, window time.Duration, limit int) (err error) {
+	query := `
+INSERT INTO api_quotas("guild_id", "window", "limit", "used", "window_start")
+VALUES($1, $2, $3, 0, NOW())
+ON CONFLICT("guild_id") DO UPDATE SET "window" = EXCLUDED."window", "limit" = EXCLUDED."limit";`
+
+	_, err = a.Exec(ctx, query, guildId, window, limit)
+	return
+}
+
+func (a *ApiQuotasTable) Delete(ctx context.Context, guildId uint64) (err error) {
+	query := `DELETE FROM api_quotas WHERE "guild_id" = $1;`
+	_, err = a.Exec(ctx, query, guildId)
+	return
+}
+
+// ConsumeQuota atomically uses n units of the guild's quota, starting a new window first if the current one has
+// elapsed. If consuming n units would exceed the limit, nothing is consumed and ok is false. Returns
+// ErrApiQuotaNotFound if the guild has no quota.
+func (a *ApiQuotasTable) ConsumeQuota(ctx context.Context, guildId uint64, n int) (ok bool, remaining int, err error) {
+	query := `
+WITH current AS (
+	SELECT
+		"guild_id",
+		"window_start" + "window" <= NOW() AS expired
+	FROM api_quotas
+	WHERE "guild_id" = $1
+	FOR UPDATE
+), consumed AS (
+	UPDATE api_quotas
+	SET "used" = CASE WHEN current.expired THEN $2 ELSE api_quotas."used" + $2 END,
+		"window_start" = CASE WHEN current.expired THEN NOW() ELSE api_quotas."window_start" END
+	FROM current
+	WHERE api_quotas."guild_id" = current."guild_id"
+		AND (CASE WHEN current.expired THEN $2 ELSE api_quotas."used" + $2 END) <= api_quotas."limit"
+	RETURNING api_quotas."limit" - api_quotas."used" AS remaining
+)
+SELECT
+	EXISTS(SELECT 1 FROM current),
+	EXISTS(SELECT 1 FROM consumed),
+	COALESCE((SELECT remaining FROM consumed), 0);`
+
+	var exists bool
+	if err := a.QueryRow(ctx, query, guildId, n).Scan(&exists, &ok, &remaining); err != nil {
+		return false, 0, err
+	}
+
+	if !exists {
+		return false, 0, ErrApiQuotaNotFound
+	}
+
+	return ok, remaining, nil
+}
+
+// ResetExpired starts a new window for every quota whose current window has elapsed. ConsumeQuota rolls windows over
+// by itself, so this only needs to run periodically to keep Get accurate for idle guilds.
+func (a *ApiQuotasTable) ResetExpired(ctx context.Context) (err error) {
+	query := `
+UPDATE api_quotas
+SET "used" = 0, "window_start" = NOW()
+WHERE "window_start" + "window" <= NOW();`
+
+	_, err = a.Exec(ctx, query)
+	return
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
@@ -53,6 +54,44 @@ func (c *ArchiveChannel) GetByPanel(ctx context.Context, guildId uint64, panelId
 	return
 }
 
+// GetDestinationByPanel resolves where transcripts of tickets opened from the panel should be sent. The panel's
+// transcript destination takes priority, followed by the panel's transcript channel, and then the guild's archive
+// channel. If the panel does not exist (e.g. it has been deleted since the ticket was opened), the guild's archive
+// channel is used. Returns false if none are set.
+func (c *ArchiveChannel) GetDestinationByPanel(ctx context.Context, guildId uint64, panelId int) (TranscriptDestination, bool, error) {
+	query := `
+SELECT
+	COALESCE(ptd.type, $3),
+	CASE WHEN ptd.panel_id IS NULL THEN COALESCE(p.transcript_channel_id, ac.channel_id) ELSE ptd.channel_id END,
+	ptd.webhook_url,
+	ptd.storage_uri
+FROM (SELECT $2::int8 AS guild_id) g
+LEFT JOIN panels p ON p.panel_id = $1 AND p.guild_id = g.guild_id
+LEFT JOIN panel_transcript_destinations ptd ON ptd.panel_id = p.panel_id
+LEFT JOIN archive_channel ac ON ac.guild_id = g.guild_id;
+`
+
+	var destination TranscriptDestination
+	if err := c.QueryRow(ctx, query, panelId, guildId, TranscriptDestinationChannel).Scan(
+		&destination.Type,
+		&destination.ChannelId,
+		&destination.WebhookUrl,
+		&destination.StorageUri,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TranscriptDestination{}, false, nil
+		} else {
+			return TranscriptDestination{}, false, err
+		}
+	}
+
+	if destination.Type == TranscriptDestinationChannel && destination.ChannelId == nil {
+		return TranscriptDestination{}, false, nil
+	}
+
+	return destination, true, nil
+}
+
 func (c *ArchiveChannel) Set(ctx context.Context, guildId uint64, archiveChannel *uint64) (err error) {
 	query := `
 INSERT INTO archive_channel("guild_id", "channel_id")
@@ -2,7 +2,6 @@ package database
 
 import (
 	"context"
-	_ "embed"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
@@ -23,14 +22,10 @@ func newArchiveDmMessages(db *pgxpool.Pool) *ArchiveDmMessages {
 }
 
 var (
-	//go:embed sql/archive_dm_messages/schema.sql
-	archiveDmMessagesSchema string
-
-	//go:embed sql/archive_dm_messages/insert.sql
-	archiveDmMessagesInsert string
-
-	//go:embed sql/archive_dm_messages/get.sql
-	archiveDmMessagesGet string
+	archiveDmMessagesSql    = loadSqlTable("archive_dm_messages")
+	archiveDmMessagesSchema = archiveDmMessagesSql.schema()
+	archiveDmMessagesInsert = archiveDmMessagesSql.query("insert")
+	archiveDmMessagesGet    = archiveDmMessagesSql.query("get")
 )
 
 func (d *ArchiveDmMessages) Schema() string {
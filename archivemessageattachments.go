@@ -0,0 +1,200 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const defaultArchiveMessageAttachmentsStreamBatchSize = 1000
+
+// ArchiveMessageAttachment is the metadata of an attachment on a transcript message. Either StorageKey or Url is set:
+// StorageKey if the attachment has been copied to our own storage, otherwise Url is the Discord CDN URL, which stops
+// working at ExpiresAt.
+type ArchiveMessageAttachment struct {
+	MessageId    uint64     `json:"message_id,string"`
+	AttachmentId uint64     `json:"attachment_id,string"`
+	Filename     string     `json:"filename"`
+	ContentType  *string    `json:"content_type"`
+	Size         int64      `json:"size"`
+	StorageKey   *string    `json:"storage_key"`
+	Url          *string    `json:"url"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+}
+
+type ArchiveMessageAttachments struct {
+	*pgxpool.Pool
+}
+
+func newArchiveMessageAttachments(db *pgxpool.Pool) *ArchiveMessageAttachments {
+	return &ArchiveMessageAttachments{
+		db,
+	}
+}
+
+var (
+	//go:embed sql/archive_message_attachments/schema.sql
+	archiveMessageAttachmentsSchema string
+
+	//go:embed sql/archive_message_attachments/insert.sql
+	archiveMessageAttachmentsInsert string
+
+	//go:embed sql/archive_message_attachments/get_by_ticket.sql
+	archiveMessageAttachmentsGetByTicket string
+)
+
+func (a *ArchiveMessageAttachments) Schema() string {
+	return archiveMessageAttachmentsSchema
+}
+
+// BulkInsert stores the metadata of the attachments on the ticket's transcript messages, overwriting any attachments
+// already stored with the same ID.
+func (a *ArchiveMessageAttachments) BulkInsert(ctx context.Context, guildId uint64, ticketId int, attachments []ArchiveMessageAttachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	var (
+		messageIds    = make([]uint64, len(attachments))
+		attachmentIds = make([]uint64, len(attachments))
+		filenames     = make([]string, len(attachments))
+		contentTypes  = make([]*string, len(attachments))
+		sizes         = make([]int64, len(attachments))
+		storageKeys   = make([]*string, len(attachments))
+		urls          = make([]*string, len(attachments))
+		expiresAt     = make([]*time.Time, len(attachments))
+	)
+
+	for i, attachment := range attachments {
+		messageIds[i] = attachment.MessageId
+		attachmentIds[i] = attachment.AttachmentId
+		filenames[i] = attachment.Filename
+		contentTypes[i] = attachment.ContentType
+		sizes[i] = attachment.Size
+		storageKeys[i] = attachment.StorageKey
+		urls[i] = attachment.Url
+		expiresAt[i] = attachment.ExpiresAt
+	}
+
+	_, err := a.Exec(ctx, archiveMessageAttachmentsInsert,
+		guildId,
+		ticketId,
+		messageIds,
+		attachmentIds,
+		filenames,
+		contentTypes,
+		sizes,
+		storageKeys,
+		urls,
+		expiresAt,
+	)
+	return err
+}
+
+func (a *ArchiveMessageAttachments) GetByTicket(ctx context.Context, guildId uint64, ticketId int) ([]ArchiveMessageAttachment, error) {
+	rows, err := a.Query(ctx, archiveMessageAttachmentsGetByTicket, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []ArchiveMessageAttachment
+	for rows.Next() {
+		var attachment ArchiveMessageAttachment
+		if err := rows.Scan(
+			&attachment.MessageId,
+			&attachment.AttachmentId,
+			&attachment.Filename,
+			&attachment.ContentType,
+			&attachment.Size,
+			&attachment.StorageKey,
+			&attachment.Url,
+			&attachment.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+// Stream calls fn with the ticket's attachments in batches of up to batchSize, in the same order as GetByTicket,
+// reading them through a server-side cursor so that tickets with very large transcripts do not need to be loaded into
+// memory at once. The batch slice is reused between calls, so fn must not retain it. If fn returns an error, streaming
+// stops and the error is returned.
+func (a *ArchiveMessageAttachments) Stream(ctx context.Context, guildId uint64, ticketId int, fn func(batch []ArchiveMessageAttachment) error, batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = defaultArchiveMessageAttachmentsStreamBatchSize
+	}
+
+	// Cursors only exist for the duration of the transaction
+	tx, err := a.BeginTx(ctx, pgx.TxOptions{
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	cursorQuery := strings.TrimSuffix(strings.TrimSpace(archiveMessageAttachmentsGetByTicket), ";")
+	if _, err := tx.Exec(ctx, "DECLARE archive_message_attachments_stream NO SCROLL CURSOR FOR "+cursorQuery, guildId, ticketId); err != nil {
+		return err
+	}
+
+	fetchQuery := fmt.Sprintf("FETCH FORWARD %d FROM archive_message_attachments_stream;", batchSize)
+	batch := make([]ArchiveMessageAttachment, 0, batchSize)
+
+	for {
+		rows, err := tx.Query(ctx, fetchQuery)
+		if err != nil {
+			return err
+		}
+
+		batch = batch[:0]
+		for rows.Next() {
+			var attachment ArchiveMessageAttachment
+			if err := rows.Scan(
+				&attachment.MessageId,
+				&attachment.AttachmentId,
+				&attachment.Filename,
+				&attachment.ContentType,
+				&attachment.Size,
+				&attachment.StorageKey,
+				&attachment.Url,
+				&attachment.ExpiresAt,
+			); err != nil {
+				rows.Close()
+				return err
+			}
+
+			batch = append(batch, attachment)
+		}
+
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if len(batch) == 0 {
+			break
+		}
+
+		if err := fn(batch); err != nil {
+			return err
+		}
+
+		if len(batch) < batchSize {
+			break
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type ArchiveMessageReaction struct {
+	MessageId uint64 `json:"message_id,string"`
+	Emoji     string `json:"emoji"` // Unicode emoji, or <:name:id> for custom emojis
+	Count     int    `json:"count"`
+}
+
+type ArchiveMessageReactions struct {
+	*pgxpool.Pool
+}
+
+func newArchiveMessageReactions(db *pgxpool.Pool) *ArchiveMessageReactions {
+	return &ArchiveMessageReactions{
+		db,
+	}
+}
+
+var (
+	//go:embed sql/archive_message_reactions/schema.sql
+	archiveMessageReactionsSchema string
+
+	//go:embed sql/archive_message_reactions/insert.sql
+	archiveMessageReactionsInsert string
+
+	//go:embed sql/archive_message_reactions/get.sql
+	archiveMessageReactionsGet string
+)
+
+func (a *ArchiveMessageReactions) Schema() string {
+	return archiveMessageReactionsSchema
+}
+
+// BulkInsert stores the reactions on the ticket's transcript messages when it is archived, overwriting the count of
+// any reactions already stored.
+func (a *ArchiveMessageReactions) BulkInsert(ctx context.Context, guildId uint64, ticketId int, reactions []ArchiveMessageReaction) error {
+	if len(reactions) == 0 {
+		return nil
+	}
+
+	messageIds := make([]uint64, len(reactions))
+	emojis := make([]string, len(reactions))
+	counts := make([]int32, len(reactions))
+	for i, reaction := range reactions {
+		messageIds[i] = reaction.MessageId
+		emojis[i] = reaction.Emoji
+		counts[i] = int32(reaction.Count)
+	}
+
+	_, err := a.Exec(ctx, archiveMessageReactionsInsert, guildId, ticketId, messageIds, emojis, counts)
+	return err
+}
+
+// GetByTicket returns message ID -> reactions for the ticket, most used first. Reactions are only returned once the
+// ticket's transcript has been archived.
+func (a *ArchiveMessageReactions) GetByTicket(ctx context.Context, guildId uint64, ticketId int) (map[uint64][]ArchiveMessageReaction, error) {
+	rows, err := a.Query(ctx, archiveMessageReactionsGet, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reactions := make(map[uint64][]ArchiveMessageReaction)
+	for rows.Next() {
+		var reaction ArchiveMessageReaction
+		if err := rows.Scan(&reaction.MessageId, &reaction.Emoji, &reaction.Count); err != nil {
+			return nil, err
+		}
+
+		reactions[reaction.MessageId] = append(reactions[reaction.MessageId], reaction)
+	}
+
+	return reactions, nil
+}
@@ -2,7 +2,7 @@ package database
 
 import (
 	"context"
-	_ "embed"
+
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
@@ -23,14 +23,10 @@ func newArchiveMessages(db *pgxpool.Pool) *ArchiveMessages {
 }
 
 var (
-	//go:embed sql/archive_messages/schema.sql
-	archiveMessagesSchema string
-
-	//go:embed sql/archive_messages/insert.sql
-	archiveMessagesInsert string
-
-	//go:embed sql/archive_messages/get.sql
-	archiveMessagesGet string
+	archiveMessagesSql    = loadSqlTable("archive_messages")
+	archiveMessagesSchema = archiveMessagesSql.schema()
+	archiveMessagesInsert = archiveMessagesSql.query("insert")
+	archiveMessagesGet    = archiveMessagesSql.query("get")
 )
 
 func (a *ArchiveMessages) Schema() string {
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// AuditActor is the user performing a change. If a context carrying an actor is passed to an audited method (e.g.
+// PanelTable.Create, FormsTable.Delete, SettingsTable.Set, TicketLabelsTable.Update), an audit log entry is written in
+// the same transaction as the change. Without an actor, no entry is written.
+type AuditActor struct {
+	UserId uint64
+}
+
+// SystemAuditActor is the actor that changes made by the bot itself, rather than on behalf of a user, are recorded as,
+// e.g. by SystemPurgeGuildData.
+var SystemAuditActor = AuditActor{UserId: 0}
+
+type auditActorKey struct{}
+
+func WithAuditActor(ctx context.Context, actor AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+func AuditActorFromContext(ctx context.Context) (AuditActor, bool) {
+	actor, ok := ctx.Value(auditActorKey{}).(AuditActor)
+	return actor, ok
+}
+
+// withAuditTx runs f in a new transaction, which is committed if f succeeds.
+func withAuditTx(ctx context.Context, pool *pgxpool.Pool, f func(tx pgx.Tx) error) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := f(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// execAudited runs a statement returning the affected row's "guild_id", and records an entry for the change in the same
+// transaction. If no row is affected, no entry is recorded.
+func execAudited(ctx context.Context, pool *pgxpool.Pool, actionType AuditActionType, resourceType AuditResourceType, resourceId string, newData interface{}, query string, args ...interface{}) error {
+	return withAuditTx(ctx, pool, func(tx pgx.Tx) error {
+		var guildId uint64
+		if err := tx.QueryRow(ctx, query, args...).Scan(&guildId); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+
+			return err
+		}
+
+		return recordAudit(ctx, tx, guildId, actionType, resourceType, resourceId, newData)
+	})
+}
+
+// recordAudit writes an entry for the change on behalf of the context's actor, if there is one.
+func recordAudit(ctx context.Context, tx pgx.Tx, guildId uint64, actionType AuditActionType, resourceType AuditResourceType, resourceId string, newData interface{}) error {
+	return recordAuditEntry(ctx, tx, &guildId, actionType, resourceType, resourceId, newData)
+}
+
+// recordGlobalAuditAs writes an entry for a change that does not belong to a guild, e.g. to bot staff, on behalf of
+// actorId. Unlike recordAudit, the entry is always written, whether or not the context carries an actor.
+func recordGlobalAuditAs(ctx context.Context, tx pgx.Tx, actorId uint64, actionType AuditActionType, resourceType AuditResourceType, resourceId string, newData interface{}) error {
+	return writeAuditEntry(ctx, tx, actorId, nil, actionType, resourceType, resourceId, newData)
+}
+
+func recordAuditEntry(ctx context.Context, tx pgx.Tx, guildId *uint64, actionType AuditActionType, resourceType AuditResourceType, resourceId string, newData interface{}) error {
+	actor, ok := AuditActorFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	return writeAuditEntry(ctx, tx, actor.UserId, guildId, actionType, resourceType, resourceId, newData)
+}
+
+func writeAuditEntry(ctx context.Context, tx pgx.Tx, actorId uint64, guildId *uint64, actionType AuditActionType, resourceType AuditResourceType, resourceId string, newData interface{}) error {
+	entry := AuditLogEntry{
+		GuildId:      guildId,
+		UserId:       actorId,
+		ActionType:   actionType,
+		ResourceType: resourceType,
+		ResourceId:   &resourceId,
+	}
+
+	if newData != nil {
+		encoded, err := json.Marshal(newData)
+		if err != nil {
+			return err
+		}
+
+		entry.NewData = ptr(string(encoded))
+	}
+
+	_, err := insertAuditLogEntry(ctx, tx, entry)
+	return err
+}
+
+func auditResourceId(id int) string {
+	return strconv.Itoa(id)
+}
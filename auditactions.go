@@ -0,0 +1,174 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownAuditAction is returned when inserting an audit log entry with an action type that has not been registered
+// with RegisterAuditAction, or when parsing an unknown action name.
+var ErrUnknownAuditAction = errors.New("unknown audit action")
+
+// AuditAction is a registered action type and its name, e.g. for building filters in the dashboard.
+type AuditAction struct {
+	Type AuditActionType `json:"type"`
+	Name string          `json:"name"`
+}
+
+var auditActions = struct {
+	sync.RWMutex
+	byType map[AuditActionType]string
+	byName map[string]AuditActionType
+}{
+	byType: make(map[AuditActionType]string),
+	byName: make(map[string]AuditActionType),
+}
+
+func init() {
+	for action, name := range map[AuditActionType]string{
+		AuditActionSettingsUpdate:               "settings_update",
+		AuditActionPanelCreate:                  "panel_create",
+		AuditActionPanelUpdate:                  "panel_update",
+		AuditActionPanelDelete:                  "panel_delete",
+		AuditActionPanelResend:                  "panel_resend",
+		AuditActionPanelResetCooldowns:          "panel_reset_cooldowns",
+		AuditActionMultiPanelCreate:             "multi_panel_create",
+		AuditActionMultiPanelUpdate:             "multi_panel_update",
+		AuditActionMultiPanelDelete:             "multi_panel_delete",
+		AuditActionMultiPanelResend:             "multi_panel_resend",
+		AuditActionSupportHoursSet:              "support_hours_set",
+		AuditActionSupportHoursDelete:           "support_hours_delete",
+		AuditActionFormCreate:                   "form_create",
+		AuditActionFormUpdate:                   "form_update",
+		AuditActionFormDelete:                   "form_delete",
+		AuditActionFormInputsUpdate:             "form_inputs_update",
+		AuditActionTagCreate:                    "tag_create",
+		AuditActionTagDelete:                    "tag_delete",
+		AuditActionTeamCreate:                   "team_create",
+		AuditActionTeamDelete:                   "team_delete",
+		AuditActionTeamUpdate:                   "team_update",
+		AuditActionTeamMemberAdd:                "team_member_add",
+		AuditActionTeamMemberRemove:             "team_member_remove",
+		AuditActionStaffOverrideCreate:          "staff_override_create",
+		AuditActionStaffOverrideDelete:          "staff_override_delete",
+		AuditActionBlacklistAdd:                 "blacklist_add",
+		AuditActionBlacklistRemoveUser:          "blacklist_remove_user",
+		AuditActionBlacklistRemoveRole:          "blacklist_remove_role",
+		AuditActionTicketSendMessage:            "ticket_send_message",
+		AuditActionTicketSendTag:                "ticket_send_tag",
+		AuditActionTicketClose:                  "ticket_close",
+		AuditActionTicketCloseReasonUpdate:      "ticket_close_reason_update",
+		AuditActionGuildIntegrationActivate:     "guild_integration_activate",
+		AuditActionGuildIntegrationUpdate:       "guild_integration_update",
+		AuditActionGuildIntegrationDeactivate:   "guild_integration_deactivate",
+		AuditActionImportTrigger:                "import_trigger",
+		AuditActionPremiumSetActiveGuilds:       "premium_set_active_guilds",
+		AuditActionTicketLabelCreate:            "ticket_label_create",
+		AuditActionTicketLabelUpdate:            "ticket_label_update",
+		AuditActionTicketLabelDelete:            "ticket_label_delete",
+		AuditActionTicketLabelAssign:            "ticket_label_assign",
+		AuditActionTicketLabelUnassign:          "ticket_label_unassign",
+		AuditActionUserIntegrationCreate:        "user_integration_create",
+		AuditActionUserIntegrationUpdate:        "user_integration_update",
+		AuditActionUserIntegrationDelete:        "user_integration_delete",
+		AuditActionUserIntegrationSetPublic:     "user_integration_set_public",
+		AuditActionWhitelabelCreate:             "whitelabel_create",
+		AuditActionWhitelabelDelete:             "whitelabel_delete",
+		AuditActionWhitelabelCreateInteractions: "whitelabel_create_interactions",
+		AuditActionWhitelabelStatusSet:          "whitelabel_status_set",
+		AuditActionWhitelabelStatusDelete:       "whitelabel_status_delete",
+		AuditActionBotStaffAdd:                  "bot_staff_add",
+		AuditActionBotStaffRemove:               "bot_staff_remove",
+		AuditActionDestructiveOperationStage:    "destructive_operation_stage",
+		AuditActionDestructiveOperationExecute:  "destructive_operation_execute",
+	} {
+		if err := RegisterAuditAction(action, name); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterAuditAction registers an action type so that entries with it can be inserted. Both the code and the name
+// must be unique. The actions defined by this package are registered automatically.
+func RegisterAuditAction(action AuditActionType, name string) error {
+	if name == "" {
+		return fmt.Errorf("audit action %d must have a name", action)
+	}
+
+	auditActions.Lock()
+	defer auditActions.Unlock()
+
+	if existing, ok := auditActions.byType[action]; ok {
+		return fmt.Errorf("audit action %d is already registered as %s", action, existing)
+	}
+
+	if existing, ok := auditActions.byName[name]; ok {
+		return fmt.Errorf("audit action name %s is already registered to %d", name, existing)
+	}
+
+	auditActions.byType[action] = name
+	auditActions.byName[name] = action
+	return nil
+}
+
+// ParseAuditAction returns the registered action type with the given name, or ErrUnknownAuditAction.
+func ParseAuditAction(name string) (AuditActionType, error) {
+	auditActions.RLock()
+	defer auditActions.RUnlock()
+
+	action, ok := auditActions.byName[name]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownAuditAction, name)
+	}
+
+	return action, nil
+}
+
+// ListActions returns every registered action type, ordered by code.
+func ListActions() []AuditAction {
+	auditActions.RLock()
+	defer auditActions.RUnlock()
+
+	actions := make([]AuditAction, 0, len(auditActions.byType))
+	for action, name := range auditActions.byType {
+		actions = append(actions, AuditAction{
+			Type: action,
+			Name: name,
+		})
+	}
+
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].Type < actions[j].Type
+	})
+
+	return actions
+}
+
+func (a AuditActionType) IsRegistered() bool {
+	auditActions.RLock()
+	defer auditActions.RUnlock()
+
+	_, ok := auditActions.byType[a]
+	return ok
+}
+
+func (a AuditActionType) String() string {
+	auditActions.RLock()
+	defer auditActions.RUnlock()
+
+	if name, ok := auditActions.byType[a]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("unknown(%d)", int16(a))
+}
+
+func validateAuditAction(action AuditActionType) error {
+	if !action.IsRegistered() {
+		return fmt.Errorf("%w: %d", ErrUnknownAuditAction, action)
+	}
+
+	return nil
+}
@@ -0,0 +1,440 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const complianceExportPageSize = 500
+
+// ComplianceAuditActions are the staff actions included in compliance exports: ticket closes, blacklist changes, and
+// changes to who has staff permissions.
+var ComplianceAuditActions = []AuditActionType{
+	AuditActionTicketClose,
+	AuditActionBlacklistAdd,
+	AuditActionBlacklistRemoveUser,
+	AuditActionBlacklistRemoveRole,
+	AuditActionTeamCreate,
+	AuditActionTeamDelete,
+	AuditActionTeamUpdate,
+	AuditActionTeamMemberAdd,
+	AuditActionTeamMemberRemove,
+	AuditActionStaffOverrideCreate,
+	AuditActionStaffOverrideDelete,
+}
+
+type AuditExportJobStatus string
+
+const (
+	AuditExportJobStatusRunning   AuditExportJobStatus = "running"
+	AuditExportJobStatusCompleted AuditExportJobStatus = "completed"
+	AuditExportJobStatusFailed    AuditExportJobStatus = "failed"
+)
+
+type AuditExportJob struct {
+	Id          int64                `json:"id"`
+	GuildId     uint64               `json:"guild_id,string"`
+	From        time.Time            `json:"from"`
+	To          time.Time            `json:"to"`
+	Status      AuditExportJobStatus `json:"status"`
+	EntryCount  int                  `json:"entry_count"`
+	PageCount   int                  `json:"page_count"`
+	FinalDigest *string              `json:"final_digest"` // Hex encoded digest of the last page, set on completion
+	Error       *string              `json:"error"`
+	CreatedAt   time.Time            `json:"created_at"`
+	CompletedAt *time.Time           `json:"completed_at"`
+}
+
+// complianceExportHeader is the first line of a compliance export. Its digest seeds the hash chain.
+type complianceExportHeader struct {
+	Type        string    `json:"type"`
+	JobId       int64     `json:"job_id"`
+	GuildId     uint64    `json:"guild_id,string"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+type complianceExportEntry struct {
+	Type string `json:"type"`
+	auditLogArchiveRecord
+}
+
+// complianceExportPageDigest follows each page of entries. Digest is the SHA-256 of PreviousDigest's raw bytes
+// followed by the exact bytes of the page's entry lines, so modifying, removing or reordering any entry or page breaks
+// the chain from that point.
+type complianceExportPageDigest struct {
+	Type           string `json:"type"`
+	Page           int    `json:"page"`
+	FirstId        int64  `json:"first_id"`
+	LastId         int64  `json:"last_id"`
+	EntryCount     int    `json:"entry_count"`
+	PreviousDigest string `json:"previous_digest"`
+	Digest         string `json:"digest"`
+}
+
+// AuditExportJobsTable records compliance exports generated by GenerateComplianceExport, including the final digest
+// of each, so that a copy of an export can later be checked against the digest recorded when it was generated.
+type AuditExportJobsTable struct {
+	*pgxpool.Pool
+}
+
+func newAuditExportJobsTable(db *pgxpool.Pool) *AuditExportJobsTable {
+	return &AuditExportJobsTable{
+		db,
+	}
+}
+
+func (AuditExportJobsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS audit_export_jobs(
+	"id" BIGSERIAL NOT NULL,
+	"guild_id" int8 NOT NULL,
+	"range_from" timestamptz NOT NULL,
+	"range_to" timestamptz NOT NULL,
+	"status" varchar(16) NOT NULL DEFAULT 'running',
+	"entry_count" int4 NOT NULL DEFAULT 0,
+	"page_count" int4 NOT NULL DEFAULT 0,
+	"final_digest" char(64) DEFAULT NULL,
+	"error" text DEFAULT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"completed_at" timestamptz DEFAULT NULL,
+	CHECK("status" IN ('running', 'completed', 'failed')),
+	CHECK("range_from" < "range_to"),
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS audit_export_jobs_guild_id_created_at ON audit_export_jobs("guild_id", "created_at" DESC);
+`
+}
+
+func (a *AuditExportJobsTable) Get(ctx context.Context, id int64) (AuditExportJob, bool, error) {
+	query := `
+SELECT "id", "guild_id", "range_from", "range_to", "status", "entry_count", "page_count", "final_digest", "error", "created_at", "completed_at"
+FROM audit_export_jobs
+WHERE "id" = $1;`
+
+	var job AuditExportJob
+	if err := a.QueryRow(ctx, query, id).Scan(job.fieldPtrs()...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return AuditExportJob{}, false, nil
+		} else {
+			return AuditExportJob{}, false, err
+		}
+	}
+
+	return job, true, nil
+}
+
+// ListByGuild returns the guild's export jobs, most recent first.
+func (a *AuditExportJobsTable) ListByGuild(ctx context.Context, guildId uint64, limit int) ([]AuditExportJob, error) {
+	query := `
+SELECT "id", "guild_id", "range_from", "range_to", "status", "entry_count", "page_count", "final_digest", "error", "created_at", "completed_at"
+FROM audit_export_jobs
+WHERE "guild_id" = $1
+ORDER BY "created_at" DESC
+LIMIT $2;`
+
+	rows, err := a.Query(ctx, query, guildId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []AuditExportJob
+	for rows.Next() {
+		var job AuditExportJob
+		if err := rows.Scan(job.fieldPtrs()...); err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+func (j *AuditExportJob) fieldPtrs() []interface{} {
+	return []interface{}{
+		&j.Id,
+		&j.GuildId,
+		&j.From,
+		&j.To,
+		&j.Status,
+		&j.EntryCount,
+		&j.PageCount,
+		&j.FinalDigest,
+		&j.Error,
+		&j.CreatedAt,
+		&j.CompletedAt,
+	}
+}
+
+// GenerateComplianceExport writes the guild's ComplianceAuditActions entries created in [from, to) to w as newline
+// delimited JSON, oldest first. The first line is a header, followed by pages of up to 500 entries, each followed by a
+// page digest line chaining the SHA-256 of the page to the previous digest, starting from the digest of the header.
+// The entries are read from a single snapshot, so the export is consistent even if entries are inserted while it is
+// generated. The job is recorded in audit_export_jobs, along with the final digest once the export has completed.
+// Returns the job, which is marked as failed if an error occurs.
+func (d *Database) GenerateComplianceExport(ctx context.Context, guildId uint64, from, to time.Time, w io.Writer) (AuditExportJob, error) {
+	if !from.Before(to) {
+		return AuditExportJob{}, fmt.Errorf("compliance export range is empty: %s to %s", from, to)
+	}
+
+	job := AuditExportJob{
+		GuildId: guildId,
+		From:    from,
+		To:      to,
+		Status:  AuditExportJobStatusRunning,
+	}
+
+	createQuery := `
+INSERT INTO audit_export_jobs("guild_id", "range_from", "range_to")
+VALUES($1, $2, $3)
+RETURNING "id", "created_at";`
+
+	if err := d.pool.QueryRow(ctx, createQuery, guildId, from, to).Scan(&job.Id, &job.CreatedAt); err != nil {
+		return AuditExportJob{}, err
+	}
+
+	digest, err := d.writeComplianceExport(ctx, &job, w)
+	if err != nil {
+		errorMessage := err.Error()
+		job.Status = AuditExportJobStatusFailed
+		job.Error = &errorMessage
+
+		failQuery := `UPDATE audit_export_jobs SET "status" = 'failed', "error" = $2, "completed_at" = NOW() WHERE "id" = $1;`
+		if _, failErr := d.pool.Exec(ctx, failQuery, job.Id, errorMessage); failErr != nil {
+			return job, errors.Join(err, failErr)
+		}
+
+		return job, err
+	}
+
+	job.Status = AuditExportJobStatusCompleted
+	job.FinalDigest = &digest
+
+	completeQuery := `
+UPDATE audit_export_jobs
+SET "status" = 'completed', "entry_count" = $2, "page_count" = $3, "final_digest" = $4, "completed_at" = NOW()
+WHERE "id" = $1
+RETURNING "completed_at";`
+
+	if err := d.pool.QueryRow(ctx, completeQuery, job.Id, job.EntryCount, job.PageCount, digest).Scan(&job.CompletedAt); err != nil {
+		return job, err
+	}
+
+	return job, nil
+}
+
+// writeComplianceExport writes the export, updating the job's entry and page counts, and returns the final digest.
+func (d *Database) writeComplianceExport(ctx context.Context, job *AuditExportJob, w io.Writer) (string, error) {
+	tx, err := d.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	defer tx.Rollback(ctx)
+
+	header, err := json.Marshal(complianceExportHeader{
+		Type:        "header",
+		JobId:       job.Id,
+		GuildId:     job.GuildId,
+		From:        job.From,
+		To:          job.To,
+		GeneratedAt: job.CreatedAt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	header = append(header, '\n')
+	if _, err := w.Write(header); err != nil {
+		return "", err
+	}
+
+	digest := sha256.Sum256(header)
+
+	query := `
+SELECT "id", "guild_id", "user_id", "action_type", "resource_type", "resource_id", "old_data", "new_data", "metadata", "created_at"
+FROM audit_logs
+WHERE "guild_id" = $1 AND "created_at" >= $2 AND "created_at" < $3 AND "action_type" = ANY($4) AND "id" > $5
+ORDER BY "id" ASC
+LIMIT $6;`
+
+	actionTypes := make([]int16, len(ComplianceAuditActions))
+	for i, action := range ComplianceAuditActions {
+		actionTypes[i] = int16(action)
+	}
+
+	var lastId int64
+	for {
+		rows, err := tx.Query(ctx, query, job.GuildId, job.From, job.To, actionTypes, lastId, complianceExportPageSize)
+		if err != nil {
+			return "", err
+		}
+
+		var (
+			page    bytes.Buffer
+			count   int
+			firstId int64
+		)
+
+		encoder := json.NewEncoder(&page)
+		for rows.Next() {
+			var entry AuditLogEntry
+			if err := rows.Scan(
+				&entry.Id,
+				&entry.GuildId,
+				&entry.UserId,
+				&entry.ActionType,
+				&entry.ResourceType,
+				&entry.ResourceId,
+				&entry.OldData,
+				&entry.NewData,
+				&entry.Metadata,
+				&entry.CreatedAt,
+			); err != nil {
+				rows.Close()
+				return "", err
+			}
+
+			// Encoder.Encode terminates each record with a newline
+			if err := encoder.Encode(complianceExportEntry{
+				Type:                  "entry",
+				auditLogArchiveRecord: newAuditLogArchiveRecord(entry),
+			}); err != nil {
+				rows.Close()
+				return "", err
+			}
+
+			if count == 0 {
+				firstId = entry.Id
+			}
+
+			lastId = entry.Id
+			count++
+		}
+
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+
+		if count == 0 {
+			break
+		}
+
+		pageDigest := complianceExportPageDigest{
+			Type:           "page_digest",
+			Page:           job.PageCount + 1,
+			FirstId:        firstId,
+			LastId:         lastId,
+			EntryCount:     count,
+			PreviousDigest: hex.EncodeToString(digest[:]),
+		}
+
+		digest = sha256.Sum256(append(digest[:], page.Bytes()...))
+		pageDigest.Digest = hex.EncodeToString(digest[:])
+
+		if err := encoder.Encode(pageDigest); err != nil {
+			return "", err
+		}
+
+		if _, err := w.Write(page.Bytes()); err != nil {
+			return "", err
+		}
+
+		job.EntryCount += count
+		job.PageCount++
+
+		if count < complianceExportPageSize {
+			break
+		}
+	}
+
+	return hex.EncodeToString(digest[:]), nil
+}
+
+// VerifyComplianceExport recomputes the hash chain of an export written by GenerateComplianceExport, returning an
+// error if any page digest does not match its entries. Returns the final digest, which should be compared against the
+// job's FinalDigest to detect truncation.
+func VerifyComplianceExport(r io.Reader) (string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return "", err
+		}
+
+		return "", errors.New("compliance export is empty")
+	}
+
+	digest := sha256.Sum256(append(scanner.Bytes(), '\n'))
+
+	var (
+		page  bytes.Buffer
+		count int
+	)
+
+	for line := 2; scanner.Scan(); line++ {
+		var record struct {
+			Type           string `json:"type"`
+			EntryCount     int    `json:"entry_count"`
+			PreviousDigest string `json:"previous_digest"`
+			Digest         string `json:"digest"`
+		}
+
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return "", fmt.Errorf("line %d: %w", line, err)
+		}
+
+		switch record.Type {
+		case "entry":
+			page.Write(scanner.Bytes())
+			page.WriteByte('\n')
+			count++
+		case "page_digest":
+			if record.PreviousDigest != hex.EncodeToString(digest[:]) {
+				return "", fmt.Errorf("line %d: previous digest does not match", line)
+			}
+
+			if record.EntryCount != count {
+				return "", fmt.Errorf("line %d: expected %d entries, found %d", line, record.EntryCount, count)
+			}
+
+			digest = sha256.Sum256(append(digest[:], page.Bytes()...))
+			if record.Digest != hex.EncodeToString(digest[:]) {
+				return "", fmt.Errorf("line %d: digest does not match", line)
+			}
+
+			page.Reset()
+			count = 0
+		default:
+			return "", fmt.Errorf("line %d: unexpected record type %q", line, record.Type)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if count > 0 {
+		return "", fmt.Errorf("%d entries are not covered by a page digest", count)
+	}
+
+	return hex.EncodeToString(digest[:]), nil
+}
@@ -1,15 +1,30 @@
 package database
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	jsoniter "github.com/json-iterator/go"
 )
 
+const defaultAuditLogArchiveBatchSize = 1000
+
+// auditLogStreamBatchSize is the maximum number of entries StreamEntries reads at once while catching up.
+const auditLogStreamBatchSize = 500
+
+// auditLogStreamReorderWindow is how long StreamEntries keeps re-reading entries below the highest ID it has sent. IDs
+// are allocated when an entry is inserted, but the entry only becomes visible once its transaction commits, so it can
+// become visible after entries with higher IDs.
+const auditLogStreamReorderWindow = time.Minute
+
 type AuditActionType int16
 
 const (
@@ -84,6 +99,9 @@ const (
 
 	AuditActionBotStaffAdd    AuditActionType = 300
 	AuditActionBotStaffRemove AuditActionType = 301
+
+	AuditActionDestructiveOperationStage   AuditActionType = 310
+	AuditActionDestructiveOperationExecute AuditActionType = 311
 )
 
 type AuditResourceType int16
@@ -109,6 +127,7 @@ const (
 	AuditResourceBotStaff              AuditResourceType = 18
 	AuditResourceTicketLabel           AuditResourceType = 19
 	AuditResourceTicketLabelAssignment AuditResourceType = 20
+	AuditResourceDestructiveOperation  AuditResourceType = 21
 )
 
 type AuditLogEntry struct {
@@ -135,6 +154,27 @@ type AuditLogQueryOptions struct {
 	Offset       int
 }
 
+// AuditLogArchiveProgress records how far ArchiveTo has got, across all runs.
+type AuditLogArchiveProgress struct {
+	LastArchivedId int64     `json:"last_archived_id"`
+	ArchivedCount  int64     `json:"archived_count"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// auditLogArchiveRecord is the NDJSON representation of an AuditLogEntry written by ArchiveTo.
+type auditLogArchiveRecord struct {
+	Id           int64               `json:"id"`
+	GuildId      *uint64             `json:"guild_id,string"`
+	UserId       uint64              `json:"user_id,string"`
+	ActionType   AuditActionType     `json:"action_type"`
+	ResourceType AuditResourceType   `json:"resource_type"`
+	ResourceId   *string             `json:"resource_id"`
+	OldData      jsoniter.RawMessage `json:"old_data,omitempty"`
+	NewData      jsoniter.RawMessage `json:"new_data,omitempty"`
+	Metadata     jsoniter.RawMessage `json:"metadata,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+}
+
 type AuditLogTable struct {
 	*pgxpool.Pool
 }
@@ -164,15 +204,57 @@ CREATE INDEX IF NOT EXISTS audit_logs_user_id_idx ON audit_logs("user_id");
 CREATE INDEX IF NOT EXISTS audit_logs_action_type_idx ON audit_logs("action_type");
 CREATE INDEX IF NOT EXISTS audit_logs_resource_type_idx ON audit_logs("resource_type");
 CREATE INDEX IF NOT EXISTS audit_logs_created_at_idx ON audit_logs("created_at" DESC);
+CREATE TABLE IF NOT EXISTS audit_log_archive_progress (
+	"id"               INT2            PRIMARY KEY DEFAULT 1 CHECK("id" = 1),
+	"last_archived_id" INT8            NOT NULL,
+	"archived_count"   INT8            NOT NULL,
+	"updated_at"       TIMESTAMPTZ     NOT NULL DEFAULT NOW()
+);
 `
 }
 
+// Insert returns ErrUnknownAuditAction if the entry's action type has not been registered with RegisterAuditAction.
 func (t *AuditLogTable) Insert(ctx context.Context, entry AuditLogEntry) error {
+	_, err := t.InsertAndNotify(ctx, entry)
+	return err
+}
+
+// InsertAndNotify inserts the entry, and notifies listeners on the guild's channel (see AuditLogNotifyChannel) with the
+// ID of the new entry once the insert has been committed. Entries without a guild ID are inserted without notifying.
+func (t *AuditLogTable) InsertAndNotify(ctx context.Context, entry AuditLogEntry) (id int64, err error) {
+	tx, err := t.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	id, err = insertAuditLogEntry(ctx, tx, entry)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// insertAuditLogEntry inserts the entry in the transaction, returning its ID. If the entry has a guild ID, listeners on
+// the guild's channel are notified with the ID once the transaction is committed, so that every entry is delivered to
+// StreamEntries as it is inserted.
+func insertAuditLogEntry(ctx context.Context, tx pgx.Tx, entry AuditLogEntry) (id int64, err error) {
+	if err := validateAuditAction(entry.ActionType); err != nil {
+		return 0, err
+	}
+
 	query := `
 INSERT INTO audit_logs ("guild_id", "user_id", "action_type", "resource_type", "resource_id", "old_data", "new_data", "metadata")
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING "id";`
 
-	_, err := t.Exec(ctx, query,
+	if err := tx.QueryRow(ctx, query,
 		entry.GuildId,
 		entry.UserId,
 		entry.ActionType,
@@ -181,8 +263,140 @@ VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`
 		entry.OldData,
 		entry.NewData,
 		entry.Metadata,
-	)
-	return err
+	).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	if entry.GuildId != nil {
+		if _, err := tx.Exec(ctx, `SELECT pg_notify($1, $2);`, AuditLogNotifyChannel(*entry.GuildId), strconv.FormatInt(id, 10)); err != nil {
+			return 0, err
+		}
+	}
+
+	return id, nil
+}
+
+// AuditLogNotifyChannel returns the name of the channel that is notified when an entry is inserted for the guild.
+func AuditLogNotifyChannel(guildId uint64) string {
+	return fmt.Sprintf("audit_logs_%d", guildId)
+}
+
+// StreamEntries sends the guild's entries with an ID greater than fromId to ch in ascending order, followed by new
+// entries as they are inserted. Each entry is sent once, but an entry whose transaction committed after that of an
+// entry with a higher ID is sent after it. It holds a connection from the pool, and blocks until ctx is cancelled or an
+// error occurs.
+func (t *AuditLogTable) StreamEntries(ctx context.Context, guildId uint64, fromId int64, ch chan<- AuditLogEntry) error {
+	conn, err := t.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Release()
+
+	channel := pgx.Identifier{AuditLogNotifyChannel(guildId)}.Sanitize()
+	if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return err
+	}
+
+	defer func() {
+		// The connection is returned to the pool, so must stop listening even if ctx has been cancelled
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTransactionTimeout)
+		defer cancel()
+
+		_, _ = conn.Exec(ctx, "UNLISTEN "+channel)
+	}()
+
+	// Catch up after subscribing, so that no entries inserted in between are missed. Entries are read in pages, so that
+	// a long history is not loaded into memory at once. Entries with an ID above the watermark are read again each time,
+	// skipping those already sent, until they have been sent for longer than auditLogStreamReorderWindow, so that
+	// entries committed out of order are not missed.
+	watermark := fromId
+	sent := make(map[int64]time.Time)
+	for {
+		cursor := watermark
+		for {
+			entries, err := getAuditLogEntriesAfter(ctx, conn, guildId, cursor, auditLogStreamBatchSize)
+			if err != nil {
+				return err
+			}
+
+			for _, entry := range entries {
+				cursor = entry.Id
+				if _, ok := sent[entry.Id]; ok {
+					continue
+				}
+
+				select {
+				case ch <- entry:
+					sent[entry.Id] = time.Now()
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			if len(entries) < auditLogStreamBatchSize {
+				break
+			}
+		}
+
+		// Advance the watermark past entries sent before the window, as any entry with a lower ID that is still not
+		// visible must belong to a transaction that has been open for longer than the window
+		settledBefore := time.Now().Add(-auditLogStreamReorderWindow)
+		for id, sentAt := range sent {
+			if sentAt.Before(settledBefore) && id > watermark {
+				watermark = id
+			}
+		}
+
+		for id := range sent {
+			if id <= watermark {
+				delete(sent, id)
+			}
+		}
+
+		if _, err := conn.Conn().WaitForNotification(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// getAuditLogEntriesAfter returns up to limit of the guild's entries with an ID greater than afterId, in ascending order.
+func getAuditLogEntriesAfter(ctx context.Context, conn *pgxpool.Conn, guildId uint64, afterId int64, limit int) ([]AuditLogEntry, error) {
+	query := `
+SELECT "id", "guild_id", "user_id", "action_type", "resource_type", "resource_id", "old_data", "new_data", "metadata", "created_at"
+FROM audit_logs
+WHERE "guild_id" = $1 AND "id" > $2
+ORDER BY "id" ASC
+LIMIT $3;`
+
+	rows, err := conn.Query(ctx, query, guildId, afterId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AuditLogEntry
+	for rows.Next() {
+		var entry AuditLogEntry
+		if err := rows.Scan(
+			&entry.Id,
+			&entry.GuildId,
+			&entry.UserId,
+			&entry.ActionType,
+			&entry.ResourceType,
+			&entry.ResourceId,
+			&entry.OldData,
+			&entry.NewData,
+			&entry.Metadata,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
 }
 
 func (t *AuditLogTable) Query(ctx context.Context, opts AuditLogQueryOptions) ([]AuditLogEntry, error) {
@@ -281,3 +495,160 @@ func buildAuditLogQuery(base string, opts AuditLogQueryOptions) (string, []inter
 
 	return base, args
 }
+
+// ArchiveTo writes entries created before olderThan to w as newline delimited JSON, oldest first, deleting each batch
+// in the same transaction once it has been written successfully. Progress is recorded in audit_log_archive_progress as
+// each batch is deleted, so an interrupted archive can be resumed by calling ArchiveTo again. If the transaction fails
+// to commit after a batch has been written, the batch is written again by the next call, so consumers should
+// deduplicate on id. Batches are locked with SKIP LOCKED, so concurrent calls archive disjoint entries. Returns the
+// number of entries archived.
+func (t *AuditLogTable) ArchiveTo(ctx context.Context, w io.Writer, olderThan time.Time, batchSize int) (archived int, err error) {
+	if batchSize <= 0 {
+		batchSize = defaultAuditLogArchiveBatchSize
+	}
+
+	for {
+		count, err := t.archiveBatch(ctx, w, olderThan, batchSize)
+		if err != nil {
+			return archived, err
+		}
+
+		archived += count
+
+		if count < batchSize {
+			return archived, nil
+		}
+	}
+}
+
+func (t *AuditLogTable) archiveBatch(ctx context.Context, w io.Writer, olderThan time.Time, batchSize int) (int, error) {
+	tx, err := t.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	selectQuery := `
+SELECT "id", "guild_id", "user_id", "action_type", "resource_type", "resource_id", "old_data", "new_data", "metadata", "created_at"
+FROM audit_logs
+WHERE "created_at" < $1
+ORDER BY "id" ASC
+LIMIT $2
+FOR UPDATE SKIP LOCKED;`
+
+	rows, err := tx.Query(ctx, selectQuery, olderThan, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		buf bytes.Buffer
+		ids []int64
+	)
+
+	encoder := json.NewEncoder(&buf)
+	for rows.Next() {
+		var entry AuditLogEntry
+		if err := rows.Scan(
+			&entry.Id,
+			&entry.GuildId,
+			&entry.UserId,
+			&entry.ActionType,
+			&entry.ResourceType,
+			&entry.ResourceId,
+			&entry.OldData,
+			&entry.NewData,
+			&entry.Metadata,
+			&entry.CreatedAt,
+		); err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		// Encoder.Encode terminates each record with a newline
+		if err := encoder.Encode(newAuditLogArchiveRecord(entry)); err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		ids = append(ids, entry.Id)
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return 0, fmt.Errorf("failed to write audit log batch: %w", err)
+	}
+
+	deleteQuery := `DELETE FROM audit_logs WHERE "id" = ANY($1);`
+	if _, err := tx.Exec(ctx, deleteQuery, ids); err != nil {
+		return 0, err
+	}
+
+	progressQuery := `
+INSERT INTO audit_log_archive_progress("id", "last_archived_id", "archived_count", "updated_at")
+VALUES(1, $1, $2, NOW())
+ON CONFLICT("id") DO UPDATE
+SET "last_archived_id" = GREATEST(audit_log_archive_progress."last_archived_id", EXCLUDED."last_archived_id"),
+	"archived_count" = audit_log_archive_progress."archived_count" + EXCLUDED."archived_count",
+	"updated_at" = EXCLUDED."updated_at";`
+
+	if _, err := tx.Exec(ctx, progressQuery, ids[len(ids)-1], len(ids)); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return len(ids), nil
+}
+
+func (t *AuditLogTable) GetArchiveProgress(ctx context.Context) (AuditLogArchiveProgress, bool, error) {
+	query := `SELECT "last_archived_id", "archived_count", "updated_at" FROM audit_log_archive_progress WHERE "id" = 1;`
+
+	var progress AuditLogArchiveProgress
+	if err := t.QueryRow(ctx, query).Scan(&progress.LastArchivedId, &progress.ArchivedCount, &progress.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return AuditLogArchiveProgress{}, false, nil
+		} else {
+			return AuditLogArchiveProgress{}, false, err
+		}
+	}
+
+	return progress, true, nil
+}
+
+func newAuditLogArchiveRecord(entry AuditLogEntry) auditLogArchiveRecord {
+	record := auditLogArchiveRecord{
+		Id:           entry.Id,
+		GuildId:      entry.GuildId,
+		UserId:       entry.UserId,
+		ActionType:   entry.ActionType,
+		ResourceType: entry.ResourceType,
+		ResourceId:   entry.ResourceId,
+		CreatedAt:    entry.CreatedAt,
+	}
+
+	if entry.OldData != nil {
+		record.OldData = jsoniter.RawMessage(*entry.OldData)
+	}
+
+	if entry.NewData != nil {
+		record.NewData = jsoniter.RawMessage(*entry.NewData)
+	}
+
+	if entry.Metadata != nil {
+		record.Metadata = jsoniter.RawMessage(*entry.Metadata)
+	}
+
+	return record
+}
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type AutoResponder struct {
+	Id              int     `json:"id"`
+	GuildId         uint64  `json:"guild_id,string"`
+	PanelId         *int    `json:"panel_id"` // Null if the responder applies to tickets from every panel
+	TriggerPattern  string  `json:"trigger_pattern"`
+	ResponseContent *string `json:"response_content"`
+	TagId           *string `json:"tag_id"` // Either ResponseContent or TagId must be set
+	Enabled         bool    `json:"enabled"`
+	CooldownSeconds int     `json:"cooldown_seconds"`
+}
+
+type AutoRespondersTable struct {
+	*pgxpool.Pool
+}
+
+func newAutoRespondersTable(db *pgxpool.Pool) *AutoRespondersTable {
+	return &AutoRespondersTable{
+		db,
+	}
+}
+
+func (a AutoRespondersTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS auto_responders(
+	"id" SERIAL NOT NULL UNIQUE,
+	"guild_id" int8 NOT NULL,
+	"panel_id" int DEFAULT NULL,
+	"trigger_pattern" varchar(255) NOT NULL,
+	"response_content" text DEFAULT NULL CONSTRAINT response_content_length CHECK (length(response_content) <= 4096),
+	"tag_id" varchar(16) DEFAULT NULL,
+	"enabled" bool NOT NULL DEFAULT true,
+	"cooldown_seconds" int NOT NULL DEFAULT 0,
+	FOREIGN KEY("panel_id") REFERENCES panels("panel_id") ON DELETE CASCADE,
+	FOREIGN KEY("guild_id", "tag_id") REFERENCES tags("guild_id", "tag_id") ON DELETE CASCADE ON UPDATE CASCADE,
+	CHECK("response_content" IS NOT NULL OR "tag_id" IS NOT NULL),
+	CHECK("cooldown_seconds" >= 0),
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS auto_responders_guild_id ON auto_responders("guild_id");
+`
+}
+
+func (a *AutoRespondersTable) Get(ctx context.Context, guildId uint64, id int) (AutoResponder, bool, error) {
+	query := `
+SELECT "id", "guild_id", "panel_id", "trigger_pattern", "response_content", "tag_id", "enabled", "cooldown_seconds"
+FROM auto_responders
+WHERE "guild_id" = $1 AND "id" = $2;`
+
+	var responder AutoResponder
+	if err := a.QueryRow(ctx, query, guildId, id).Scan(responder.fieldPtrs()...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return AutoResponder{}, false, nil
+		} else {
+			return AutoResponder{}, false, err
+		}
+	}
+
+	return responder, true, nil
+}
+
+func (a *AutoRespondersTable) GetByGuild(ctx context.Context, guildId uint64) ([]AutoResponder, error) {
+	query := `
+SELECT "id", "guild_id", "panel_id", "trigger_pattern", "response_content", "tag_id", "enabled", "cooldown_seconds"
+FROM auto_responders
+WHERE "guild_id" = $1
+ORDER BY "id" ASC;`
+
+	rows, err := a.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAutoResponders(rows)
+}
+
+// GetActive returns the enabled responders that apply to a ticket opened from the given panel, including those that
+// apply to every panel. If panelId is nil, only responders that apply to every panel are returned.
+func (a *AutoRespondersTable) GetActive(ctx context.Context, guildId uint64, panelId *int) ([]AutoResponder, error) {
+	query := `
+SELECT "id", "guild_id", "panel_id", "trigger_pattern", "response_content", "tag_id", "enabled", "cooldown_seconds"
+FROM auto_responders
+WHERE "guild_id" = $1 AND "enabled" AND ("panel_id" IS NULL OR "panel_id" = $2)
+ORDER BY "id" ASC;`
+
+	rows, err := a.Query(ctx, query, guildId, panelId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAutoResponders(rows)
+}
+
+func (a *AutoRespondersTable) Create(ctx context.Context, responder AutoResponder) (id int, err error) {
+	query := `
+INSERT INTO auto_responders("guild_id", "panel_id", "trigger_pattern", "response_content", "tag_id", "enabled", "cooldown_seconds")
+VALUES($1, $2, $3, $4, $5, $6, $7)
+RETURNING "id";`
+
+	err = a.QueryRow(ctx, query,
+		responder.GuildId,
+		responder.PanelId,
+		responder.TriggerPattern,
+		responder.ResponseContent,
+		responder.TagId,
+		responder.Enabled,
+		responder.CooldownSeconds,
+	).Scan(&id)
+	return
+}
+
+func (a *AutoRespondersTable) Update(ctx context.Context, responder AutoResponder) (err error) {
+	query := `
+UPDATE auto_responders
+SET
+	"panel_id" = $3,
+	"trigger_pattern" = $4,
+	"response_content" = $5,
+	"tag_id" = $6,
+	"enabled" = $7,
+	"cooldown_seconds" = $8
+WHERE "guild_id" = $1 AND "id" = $2;`
+
+	_, err = a.Exec(ctx, query,
+		responder.GuildId,
+		responder.Id,
+		responder.PanelId,
+		responder.TriggerPattern,
+		responder.ResponseContent,
+		responder.TagId,
+		responder.Enabled,
+		responder.CooldownSeconds,
+	)
+	return
+}
+
+func (a *AutoRespondersTable) SetEnabled(ctx context.Context, guildId uint64, id int, enabled bool) (err error) {
+	query := `UPDATE auto_responders SET "enabled" = $3 WHERE "guild_id" = $1 AND "id" = $2;`
+	_, err = a.Exec(ctx, query, guildId, id, enabled)
+	return
+}
+
+func (a *AutoRespondersTable) Delete(ctx context.Context, guildId uint64, id int) (err error) {
+	query := `DELETE FROM auto_responders WHERE "guild_id" = $1 AND "id" = $2;`
+	_, err = a.Exec(ctx, query, guildId, id)
+	return
+}
+
+func (r *AutoResponder) fieldPtrs() []interface{} {
+	return []interface{}{
+		&r.Id,
+		&r.GuildId,
+		&r.PanelId,
+		&r.TriggerPattern,
+		&r.ResponseContent,
+		&r.TagId,
+		&r.Enabled,
+		&r.CooldownSeconds,
+	}
+}
+
+func scanAutoResponders(rows pgx.Rows) ([]AutoResponder, error) {
+	var responders []AutoResponder
+	for rows.Next() {
+		var responder AutoResponder
+		if err := rows.Scan(responder.fieldPtrs()...); err != nil {
+			return nil, err
+		}
+
+		responders = append(responders, responder)
+	}
+
+	return responders, nil
+}
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// BlacklistNetwork is a group of guilds that share their blacklists: a user blacklisted in any member guild is treated
+// as blacklisted in all of them.
+type BlacklistNetwork struct {
+	Id           int       `json:"id"`
+	OwnerGuildId uint64    `json:"owner_guild_id,string"`
+	Name         string    `json:"name"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type BlacklistNetworksTable struct {
+	*pgxpool.Pool
+}
+
+func newBlacklistNetworksTable(db *pgxpool.Pool) *BlacklistNetworksTable {
+	return &BlacklistNetworksTable{
+		db,
+	}
+}
+
+func (b BlacklistNetworksTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS blacklist_networks(
+	"id" SERIAL NOT NULL UNIQUE,
+	"owner_guild_id" int8 NOT NULL,
+	"name" varchar(100) NOT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS blacklist_networks_owner_guild_id ON blacklist_networks("owner_guild_id");
+
+CREATE TABLE IF NOT EXISTS blacklist_network_members(
+	"network_id" int NOT NULL,
+	"guild_id" int8 NOT NULL,
+	FOREIGN KEY("network_id") REFERENCES blacklist_networks("id") ON DELETE CASCADE,
+	PRIMARY KEY("network_id", "guild_id")
+);
+CREATE INDEX IF NOT EXISTS blacklist_network_members_guild_id ON blacklist_network_members("guild_id");
+`
+}
+
+func (b *BlacklistNetworksTable) Get(ctx context.Context, networkId int) (BlacklistNetwork, bool, error) {
+	query := `SELECT "id", "owner_guild_id", "name", "created_at" FROM blacklist_networks WHERE "id" = $1;`
+
+	var network BlacklistNetwork
+	if err := b.QueryRow(ctx, query, networkId).Scan(
+		&network.Id,
+		&network.OwnerGuildId,
+		&network.Name,
+		&network.CreatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return BlacklistNetwork{}, false, nil
+		} else {
+			return BlacklistNetwork{}, false, err
+		}
+	}
+
+	return network, true, nil
+}
+
+// GetByGuild returns the networks that the guild is a member of.
+func (b *BlacklistNetworksTable) GetByGuild(ctx context.Context, guildId uint64) ([]BlacklistNetwork, error) {
+	query := `
+SELECT blacklist_networks.id, blacklist_networks.owner_guild_id, blacklist_networks.name, blacklist_networks.created_at
+FROM blacklist_networks
+INNER JOIN blacklist_network_members
+ON blacklist_networks.id = blacklist_network_members.network_id
+WHERE blacklist_network_members.guild_id = $1
+ORDER BY blacklist_networks.id ASC;`
+
+	rows, err := b.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var networks []BlacklistNetwork
+	for rows.Next() {
+		var network BlacklistNetwork
+		if err := rows.Scan(&network.Id, &network.OwnerGuildId, &network.Name, &network.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+// Create creates a new network, with the owner guild as its first member.
+func (b *BlacklistNetworksTable) Create(ctx context.Context, ownerGuildId uint64, name string) (int, error) {
+	tx, err := b.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO blacklist_networks("owner_guild_id", "name") VALUES($1, $2) RETURNING "id";`
+
+	var networkId int
+	if err := tx.QueryRow(ctx, query, ownerGuildId, name).Scan(&networkId); err != nil {
+		return 0, err
+	}
+
+	query = `INSERT INTO blacklist_network_members("network_id", "guild_id") VALUES($1, $2);`
+	if _, err := tx.Exec(ctx, query, networkId, ownerGuildId); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return networkId, nil
+}
+
+func (b *BlacklistNetworksTable) Rename(ctx context.Context, networkId int, name string) (err error) {
+	query := `UPDATE blacklist_networks SET "name" = $2 WHERE "id" = $1;`
+	_, err = b.Exec(ctx, query, networkId, name)
+	return
+}
+
+func (b *BlacklistNetworksTable) Delete(ctx context.Context, networkId int) (err error) {
+	query := `DELETE FROM blacklist_networks WHERE "id" = $1;`
+	_, err = b.Exec(ctx, query, networkId)
+	return
+}
+
+func (b *BlacklistNetworksTable) GetMembers(ctx context.Context, networkId int) ([]uint64, error) {
+	query := `SELECT "guild_id" FROM blacklist_network_members WHERE "network_id" = $1;`
+
+	rows, err := b.Query(ctx, query, networkId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var guildIds []uint64
+	for rows.Next() {
+		var guildId uint64
+		if err := rows.Scan(&guildId); err != nil {
+			return nil, err
+		}
+
+		guildIds = append(guildIds, guildId)
+	}
+
+	return guildIds, nil
+}
+
+func (b *BlacklistNetworksTable) AddMember(ctx context.Context, networkId int, guildId uint64) (err error) {
+	query := `INSERT INTO blacklist_network_members("network_id", "guild_id") VALUES($1, $2) ON CONFLICT DO NOTHING;`
+	_, err = b.Exec(ctx, query, networkId, guildId)
+	return
+}
+
+func (b *BlacklistNetworksTable) RemoveMember(ctx context.Context, networkId int, guildId uint64) (err error) {
+	query := `DELETE FROM blacklist_network_members WHERE "network_id" = $1 AND "guild_id" = $2;`
+	_, err = b.Exec(ctx, query, networkId, guildId)
+	return
+}
+
+// IsBlacklistedInNetwork returns whether the user is blacklisted in the guild itself, or in any guild that shares a
+// network with it.
+func (b *BlacklistNetworksTable) IsBlacklistedInNetwork(ctx context.Context, guildId, userId uint64) (blacklisted bool, err error) {
+	query := `
+SELECT EXISTS(
+	SELECT 1 FROM blacklist WHERE "guild_id" = $1 AND "user_id" = $2
+) OR EXISTS(
+	SELECT 1
+	FROM blacklist_network_members AS self
+	INNER JOIN blacklist_network_members AS peers
+	ON peers.network_id = self.network_id
+	INNER JOIN blacklist
+	ON blacklist.guild_id = peers.guild_id
+	WHERE self.guild_id = $1 AND blacklist.user_id = $2
+);`
+
+	err = b.QueryRow(ctx, query, guildId, userId).Scan(&blacklisted)
+	return
+}
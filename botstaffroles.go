@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type BotStaffRoleType string
+
+const (
+	BotStaffRoleAdmin   BotStaffRoleType = "admin"
+	BotStaffRoleSupport BotStaffRoleType = "support"
+	BotStaffRoleViewer  BotStaffRoleType = "viewer"
+)
+
+type BotStaffRole struct {
+	UserId    uint64           `json:"user_id,string"`
+	Role      BotStaffRoleType `json:"role"`
+	GrantedBy uint64           `json:"granted_by,string"`
+	GrantedAt time.Time        `json:"granted_at"`
+	ExpiresAt *time.Time       `json:"expires_at"` // Null if the role does not expire
+}
+
+// BotStaffRolesTable grants bot staff a role determining what they may do, unlike BotStaff which only records whether a
+// user is staff. Every change is audited on behalf of the user granting or revoking the role.
+type BotStaffRolesTable struct {
+	*pgxpool.Pool
+}
+
+func newBotStaffRolesTable(db *pgxpool.Pool) *BotStaffRolesTable {
+	return &BotStaffRolesTable{
+		db,
+	}
+}
+
+func (b BotStaffRolesTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS bot_staff_roles(
+	"user_id" int8 NOT NULL,
+	"role" varchar(16) NOT NULL,
+	"granted_by" int8 NOT NULL,
+	"granted_at" timestamptz NOT NULL DEFAULT NOW(),
+	"expires_at" timestamptz DEFAULT NULL,
+	CHECK("role" IN ('admin', 'support', 'viewer')),
+	PRIMARY KEY("user_id")
+);
+`
+}
+
+// Get returns the user's role, if they have one that has not expired.
+func (b *BotStaffRolesTable) Get(ctx context.Context, userId uint64) (BotStaffRole, bool, error) {
+	query := `
+SELECT "user_id", "role", "granted_by", "granted_at", "expires_at"
+FROM bot_staff_roles
+WHERE "user_id" = $1 AND ("expires_at" IS NULL OR "expires_at" > NOW());`
+
+	var role BotStaffRole
+	if err := b.QueryRow(ctx, query, userId).Scan(
+		&role.UserId,
+		&role.Role,
+		&role.GrantedBy,
+		&role.GrantedAt,
+		&role.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return BotStaffRole{}, false, nil
+		} else {
+			return BotStaffRole{}, false, err
+		}
+	}
+
+	return role, true, nil
+}
+
+// GetAll returns all roles that have not expired.
+func (b *BotStaffRolesTable) GetAll(ctx context.Context) ([]BotStaffRole, error) {
+	query := `
+SELECT "user_id", "role", "granted_by", "granted_at", "expires_at"
+FROM bot_staff_roles
+WHERE "expires_at" IS NULL OR "expires_at" > NOW()
+ORDER BY "granted_at" ASC;`
+
+	rows, err := b.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []BotStaffRole
+	for rows.Next() {
+		var role BotStaffRole
+		if err := rows.Scan(&role.UserId, &role.Role, &role.GrantedBy, &role.GrantedAt, &role.ExpiresAt); err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, role)
+	}
+
+	return roles, nil
+}
+
+// Grant gives the user the role, replacing any role they already have. The change is audited with grantedBy as the
+// actor.
+func (b *BotStaffRolesTable) Grant(ctx context.Context, userId uint64, role BotStaffRoleType, grantedBy uint64, expiresAt *time.Time) error {
+	return withAuditTx(ctx, b.Pool, func(tx pgx.Tx) error {
+		query := `
+INSERT INTO bot_staff_roles("user_id", "role", "granted_by", "granted_at", "expires_at")
+VALUES($1, $2, $3, NOW(), $4)
+ON CONFLICT("user_id") DO UPDATE
+SET "role" = EXCLUDED."role", "granted_by" = EXCLUDED."granted_by", "granted_at" = EXCLUDED."granted_at", "expires_at" = EXCLUDED."expires_at"
+RETURNING "user_id", "role", "granted_by", "granted_at", "expires_at";`
+
+		var granted BotStaffRole
+		if err := tx.QueryRow(ctx, query, userId, role, grantedBy, expiresAt).Scan(
+			&granted.UserId,
+			&granted.Role,
+			&granted.GrantedBy,
+			&granted.GrantedAt,
+			&granted.ExpiresAt,
+		); err != nil {
+			return err
+		}
+
+		return recordGlobalAuditAs(ctx, tx, grantedBy, AuditActionBotStaffAdd, AuditResourceBotStaff, strconv.FormatUint(userId, 10), granted)
+	})
+}
+
+// Revoke removes the user's role, if they have one. The change is audited with revokedBy as the actor.
+func (b *BotStaffRolesTable) Revoke(ctx context.Context, userId, revokedBy uint64) error {
+	return withAuditTx(ctx, b.Pool, func(tx pgx.Tx) error {
+		res, err := tx.Exec(ctx, `DELETE FROM bot_staff_roles WHERE "user_id" = $1;`, userId)
+		if err != nil {
+			return err
+		}
+
+		if res.RowsAffected() == 0 {
+			return nil
+		}
+
+		return recordGlobalAuditAs(ctx, tx, revokedBy, AuditActionBotStaffRemove, AuditResourceBotStaff, strconv.FormatUint(userId, 10), nil)
+	})
+}
+
+// DeleteExpired removes expired roles. Expired roles are already ignored by the getters, so this is only for cleanup.
+func (b *BotStaffRolesTable) DeleteExpired(ctx context.Context) (err error) {
+	query := `DELETE FROM bot_staff_roles WHERE "expires_at" <= NOW();`
+	_, err = b.Exec(ctx, query)
+	return
+}
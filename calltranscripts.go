@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type CallTranscript struct {
+	GuildId         uint64    `json:"guild_id,string"`
+	TicketId        int       `json:"ticket_id"`
+	ObjectKey       string    `json:"object_key"`
+	DurationSeconds int       `json:"duration_seconds"`
+	Language        *string   `json:"language"` // Null if the language could not be detected
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type CallTranscriptsTable struct {
+	*pgxpool.Pool
+}
+
+func newCallTranscriptsTable(db *pgxpool.Pool) *CallTranscriptsTable {
+	return &CallTranscriptsTable{
+		db,
+	}
+}
+
+func (c CallTranscriptsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS call_transcripts(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"object_key" varchar(255) NOT NULL,
+	"duration_seconds" int4 NOT NULL,
+	"language" varchar(16) DEFAULT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	CHECK("duration_seconds" >= 0),
+	PRIMARY KEY("guild_id", "ticket_id")
+);
+`
+}
+
+func (c *CallTranscriptsTable) Get(ctx context.Context, guildId uint64, ticketId int) (CallTranscript, bool, error) {
+	query := `
+SELECT "guild_id", "ticket_id", "object_key", "duration_seconds", "language", "created_at"
+FROM call_transcripts
+WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+
+	var transcript CallTranscript
+	if err := c.QueryRow(ctx, query, guildId, ticketId).Scan(
+		&transcript.GuildId,
+		&transcript.TicketId,
+		&transcript.ObjectKey,
+		&transcript.DurationSeconds,
+		&transcript.Language,
+		&transcript.CreatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return CallTranscript{}, false, nil
+		} else {
+			return CallTranscript{}, false, err
+		}
+	}
+
+	return transcript, true, nil
+}
+
+func (c *CallTranscriptsTable) Set(ctx context.Context, transcript CallTranscript) (err error) {
+	query := `
+INSERT INTO call_transcripts("guild_id", "ticket_id", "object_key", "duration_seconds", "language", "created_at")
+VALUES($1, $2, $3, $4, $5, NOW())
+ON CONFLICT("guild_id", "ticket_id") DO UPDATE
+SET "object_key" = EXCLUDED."object_key", "duration_seconds" = EXCLUDED."duration_seconds", "language" = EXCLUDED."language", "created_at" = EXCLUDED."created_at";`
+
+	_, err = c.Exec(ctx, query, transcript.GuildId, transcript.TicketId, transcript.ObjectKey, transcript.DurationSeconds, transcript.Language)
+	return
+}
+
+// Delete removes the pointer only. The object itself must be removed from storage by the caller.
+func (c *CallTranscriptsTable) Delete(ctx context.Context, guildId uint64, ticketId int) (err error) {
+	query := `DELETE FROM call_transcripts WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+	_, err = c.Exec(ctx, query, guildId, ticketId)
+	return
+}
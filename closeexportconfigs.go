@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type CloseExportFormat string
+
+const (
+	CloseExportFormatJson           CloseExportFormat = "json"
+	CloseExportFormatFormUrlEncoded CloseExportFormat = "form_urlencoded"
+)
+
+// CloseExportConfig is where a summary of each of the guild's tickets is POSTed to when it is closed.
+type CloseExportConfig struct {
+	GuildId uint64            `json:"guild_id,string"`
+	Url     string            `json:"url"`
+	Secret  string            `json:"-"` // Used to sign payloads, so the receiver can verify they were sent by us
+	Format  CloseExportFormat `json:"format"`
+	Enabled bool              `json:"enabled"`
+}
+
+type CloseExportConfigsTable struct {
+	*pgxpool.Pool
+}
+
+func newCloseExportConfigsTable(db *pgxpool.Pool) *CloseExportConfigsTable {
+	return &CloseExportConfigsTable{
+		db,
+	}
+}
+
+func (c CloseExportConfigsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS close_export_configs(
+	"guild_id" int8 NOT NULL,
+	"url" varchar(2048) NOT NULL,
+	"secret" varchar(255) NOT NULL,
+	"format" varchar(32) NOT NULL DEFAULT 'json',
+	"enabled" bool NOT NULL DEFAULT true,
+	CHECK("format" IN ('json', 'form_urlencoded')),
+	PRIMARY KEY("guild_id")
+);
+`
+}
+
+func (c *CloseExportConfigsTable) Get(ctx context.Context, guildId uint64) (CloseExportConfig, bool, error) {
+	query := `SELECT "guild_id", "url", "secret", "format", "enabled" FROM close_export_configs WHERE "guild_id" = $1;`
+
+	var config CloseExportConfig
+	if err := c.QueryRow(ctx, query, guildId).Scan(
+		&config.GuildId,
+		&config.Url,
+		&config.Secret,
+		&config.Format,
+		&config.Enabled,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return CloseExportConfig{}, false, nil
+		} else {
+			return CloseExportConfig{}, false, err
+		}
+	}
+
+	return config, true, nil
+}
+
+func (c *CloseExportConfigsTable) Set(ctx context.Context, config CloseExportConfig) (err error) {
+	query := `
+INSERT INTO close_export_configs("guild_id", "url", "secret", "format", "enabled")
+VALUES($1, $2, $3, $4, $5)
+ON CONFLICT("guild_id") DO UPDATE
+SET "url" = EXCLUDED."url", "secret" = EXCLUDED."secret", "format" = EXCLUDED."format", "enabled" = EXCLUDED."enabled";`
+
+	_, err = c.Exec(ctx, query, config.GuildId, config.Url, config.Secret, config.Format, config.Enabled)
+	return
+}
+
+func (c *CloseExportConfigsTable) SetEnabled(ctx context.Context, guildId uint64, enabled bool) (err error) {
+	query := `UPDATE close_export_configs SET "enabled" = $2 WHERE "guild_id" = $1;`
+	_, err = c.Exec(ctx, query, guildId, enabled)
+	return
+}
+
+func (c *CloseExportConfigsTable) Delete(ctx context.Context, guildId uint64) (err error) {
+	query := `DELETE FROM close_export_configs WHERE "guild_id" = $1;`
+	_, err = c.Exec(ctx, query, guildId)
+	return
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type CloseExportQueueItem struct {
+	Id         int64     `json:"id"`
+	GuildId    uint64    `json:"guild_id,string"`
+	TicketId   int       `json:"ticket_id"`
+	Attempts   int       `json:"attempts"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+}
+
+// CloseExportQueueTable holds ticket close events waiting to be delivered to the guild's CloseExportConfig.
+type CloseExportQueueTable struct {
+	*pgxpool.Pool
+}
+
+func newCloseExportQueueTable(db *pgxpool.Pool) *CloseExportQueueTable {
+	return &CloseExportQueueTable{
+		db,
+	}
+}
+
+func (c CloseExportQueueTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS close_export_queue(
+	"id" BIGSERIAL NOT NULL,
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"attempts" int4 NOT NULL DEFAULT 0,
+	"enqueued_at" timestamptz NOT NULL DEFAULT NOW(),
+	"available_at" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS close_export_queue_available_at ON close_export_queue("available_at");
+CREATE INDEX IF NOT EXISTS close_export_queue_guild_id_ticket_id ON close_export_queue("guild_id", "ticket_id");
+`
+}
+
+// Enqueue adds the closed ticket to the queue, if the guild has an enabled close export config. Returns whether the
+// ticket was queued.
+func (c *CloseExportQueueTable) Enqueue(ctx context.Context, guildId uint64, ticketId int) (bool, error) {
+	query := `
+INSERT INTO close_export_queue("guild_id", "ticket_id")
+SELECT $1, $2
+WHERE EXISTS(SELECT 1 FROM close_export_configs WHERE "guild_id" = $1 AND "enabled");`
+
+	res, err := c.Exec(ctx, query, guildId, ticketId)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// Claim returns up to limit items that are due for delivery, hiding them from other workers until visibilityTimeout
+// has elapsed. Rows locked by another worker are skipped. Items are returned to the queue automatically if the worker
+// crashes; otherwise the worker should call Complete or Retry for each item.
+func (c *CloseExportQueueTable) Claim(ctx context.Context, limit int, visibilityTimeout time.Duration) ([]CloseExportQueueItem, error) {
+	query := `
+UPDATE close_export_queue
+SET "attempts" = "attempts" + 1, "available_at" = NOW() + $2::interval
+WHERE "id" IN (
+	SELECT "id"
+	FROM close_export_queue
+	WHERE "available_at" <= NOW()
+	ORDER BY "available_at" ASC
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING "id", "guild_id", "ticket_id", "attempts", "enqueued_at";`
+
+	rows, err := c.Query(ctx, query, limit, visibilityTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []CloseExportQueueItem
+	for rows.Next() {
+		var item CloseExportQueueItem
+		if err := rows.Scan(&item.Id, &item.GuildId, &item.TicketId, &item.Attempts, &item.EnqueuedAt); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// Complete removes the item from the queue once it has been delivered, or delivery has been given up on.
+func (c *CloseExportQueueTable) Complete(ctx context.Context, id int64) (err error) {
+	query := `DELETE FROM close_export_queue WHERE "id" = $1;`
+	_, err = c.Exec(ctx, query, id)
+	return
+}
+
+// Retry makes the item available for delivery again at retryAt.
+func (c *CloseExportQueueTable) Retry(ctx context.Context, id int64, retryAt time.Time) (err error) {
+	query := `UPDATE close_export_queue SET "available_at" = $2 WHERE "id" = $1;`
+	_, err = c.Exec(ctx, query, id, retryAt)
+	return
+}
+
+func (c *CloseExportQueueTable) GetQueueLength(ctx context.Context, guildId uint64) (count int, err error) {
+	query := `SELECT COUNT(*) FROM close_export_queue WHERE "guild_id" = $1;`
+	err = c.QueryRow(ctx, query, guildId).Scan(&count)
+	return
+}
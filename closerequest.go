@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"time"
@@ -127,3 +128,31 @@ WHERE "guild_id" = $1 AND "ticket_id" = $2;
 	_, err = c.Exec(ctx, query, guildId, ticketId)
 	return
 }
+
+// DeleteByTickets deletes any pending close requests for the given tickets, e.g. when they are bulk closed.
+func (c *CloseRequestTable) DeleteByTickets(ctx context.Context, guildId uint64, ticketIds []int) (err error) {
+	query := `
+DELETE
+FROM close_request
+WHERE "guild_id" = $1 AND "ticket_id" = ANY($2);
+`
+
+	array := &pgtype.Int4Array{}
+	if err := array.Set(ticketIds); err != nil {
+		return err
+	}
+
+	_, err = c.Exec(ctx, query, guildId, array)
+	return
+}
+
+func (c *CloseRequestTable) DeleteAllForGuild(ctx context.Context, guildId uint64) (err error) {
+	query := `
+DELETE
+FROM close_request
+WHERE "guild_id" = $1;
+`
+
+	_, err = c.Exec(ctx, query, guildId)
+	return
+}
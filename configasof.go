@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgtype"
+	jsoniter "github.com/json-iterator/go"
+)
+
+// ConfigAsOf is a guild's configuration as it was at a point in time, reconstructed from the audit log.
+type ConfigAsOf struct {
+	At       time.Time `json:"at"`
+	Settings *Settings `json:"settings"` // Nil if the settings had not been changed by then
+	Panels   []Panel   `json:"panels"`
+	Forms    []Form    `json:"forms"`
+}
+
+// GetConfigAsOf reconstructs the guild's settings, panels and forms as they were at the given time by replaying the
+// audit log up to that time. Only changes made with an AuditActor are audited, so resources created or last changed
+// without one, or before audit logging was introduced, are missing or stale. Soft deleted forms are returned with
+// DeletedAt set to the time of deletion.
+func (d *Database) GetConfigAsOf(ctx context.Context, guildId uint64, at time.Time) (ConfigAsOf, error) {
+	query := `
+SELECT "resource_type", "resource_id", "action_type", "new_data", "created_at"
+FROM audit_logs
+WHERE "guild_id" = $1
+	AND "resource_type" = ANY($2)
+	AND "action_type" = ANY($3)
+	AND "created_at" <= $4
+ORDER BY "created_at" ASC, "id" ASC;`
+
+	resourceTypes := &pgtype.Int2Array{}
+	if err := resourceTypes.Set([]int16{
+		int16(AuditResourceSettings),
+		int16(AuditResourcePanel),
+		int16(AuditResourceForm),
+	}); err != nil {
+		return ConfigAsOf{}, err
+	}
+
+	actionTypes := &pgtype.Int2Array{}
+	if err := actionTypes.Set([]int16{
+		int16(AuditActionSettingsUpdate),
+		int16(AuditActionPanelCreate),
+		int16(AuditActionPanelUpdate),
+		int16(AuditActionPanelDelete),
+		int16(AuditActionFormCreate),
+		int16(AuditActionFormUpdate),
+		int16(AuditActionFormDelete),
+	}); err != nil {
+		return ConfigAsOf{}, err
+	}
+
+	rows, err := d.pool.Query(ctx, query, guildId, resourceTypes, actionTypes, at)
+	if err != nil {
+		return ConfigAsOf{}, err
+	}
+	defer rows.Close()
+
+	type resourceKey struct {
+		resourceType AuditResourceType
+		resourceId   string
+	}
+
+	// Updates may only contain the changed fields, so the state of each resource is built by merging the top-level
+	// fields of each entry in turn
+	states := make(map[resourceKey]map[string]jsoniter.RawMessage)
+	for rows.Next() {
+		var (
+			key        resourceKey
+			resourceId *string
+			actionType AuditActionType
+			newData    *string
+			createdAt  time.Time
+		)
+
+		if err := rows.Scan(&key.resourceType, &resourceId, &actionType, &newData, &createdAt); err != nil {
+			return ConfigAsOf{}, err
+		}
+
+		if resourceId != nil {
+			key.resourceId = *resourceId
+		}
+
+		isDelete := actionType == AuditActionPanelDelete || actionType == AuditActionFormDelete
+		if isDelete && newData == nil {
+			delete(states, key)
+			continue
+		}
+
+		state, ok := states[key]
+		if !ok {
+			state = make(map[string]jsoniter.RawMessage)
+			states[key] = state
+		}
+
+		if newData != nil {
+			var fields map[string]jsoniter.RawMessage
+			if err := json.Unmarshal([]byte(*newData), &fields); err != nil {
+				return ConfigAsOf{}, err
+			}
+
+			for field, value := range fields {
+				state[field] = value
+			}
+
+			// Restorations are recorded as updates setting deleted_at to null
+			if value, ok := fields["deleted_at"]; ok && string(value) == "null" {
+				delete(state, "deleted_at")
+				delete(state, "soft_deleted")
+			}
+		}
+
+		// Deletions with data are soft deletions
+		if isDelete {
+			deletedAt, err := json.Marshal(createdAt)
+			if err != nil {
+				return ConfigAsOf{}, err
+			}
+
+			state["deleted_at"] = deletedAt
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return ConfigAsOf{}, err
+	}
+
+	config := ConfigAsOf{
+		At: at,
+	}
+
+	for key, state := range states {
+		encoded, err := json.Marshal(state)
+		if err != nil {
+			return ConfigAsOf{}, err
+		}
+
+		switch key.resourceType {
+		case AuditResourceSettings:
+			var settings Settings
+			if err := json.Unmarshal(encoded, &settings); err != nil {
+				return ConfigAsOf{}, err
+			}
+
+			config.Settings = &settings
+		case AuditResourcePanel:
+			var panel Panel
+			if err := json.Unmarshal(encoded, &panel); err != nil {
+				return ConfigAsOf{}, err
+			}
+
+			config.Panels = append(config.Panels, panel)
+		case AuditResourceForm:
+			var form Form
+			if err := json.Unmarshal(encoded, &form); err != nil {
+				return ConfigAsOf{}, err
+			}
+
+			config.Forms = append(config.Forms, form)
+		}
+	}
+
+	sort.Slice(config.Panels, func(i, j int) bool {
+		return config.Panels[i].PanelId < config.Panels[j].PanelId
+	})
+
+	sort.Slice(config.Forms, func(i, j int) bool {
+		return config.Forms[i].Id < config.Forms[j].Id
+	})
+
+	return config, nil
+}
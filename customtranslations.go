@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type CustomTranslations struct {
+	*pgxpool.Pool
+}
+
+func newCustomTranslations(db *pgxpool.Pool) *CustomTranslations {
+	return &CustomTranslations{
+		db,
+	}
+}
+
+func (c CustomTranslations) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS custom_translations(
+	"guild_id" int8 NOT NULL,
+	"message_key" varchar(255) NOT NULL,
+	"locale" varchar(8) NOT NULL,
+	"value" text NOT NULL,
+	PRIMARY KEY("guild_id", "locale", "message_key")
+);
+`
+}
+
+// GetAll returns message key -> value for the guild's overrides in the locale. Keys without an override are omitted,
+// and should fall back to the bundled translation.
+func (c *CustomTranslations) GetAll(ctx context.Context, guildId uint64, locale string) (map[string]string, error) {
+	query := `SELECT "message_key", "value" FROM custom_translations WHERE "guild_id" = $1 AND "locale" = $2;`
+
+	rows, err := c.Query(ctx, query, guildId, locale)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	translations := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+
+		translations[key] = value
+	}
+
+	return translations, nil
+}
+
+func (c *CustomTranslations) Upsert(ctx context.Context, guildId uint64, messageKey, locale, value string) (err error) {
+	query := `
+INSERT INTO custom_translations("guild_id", "message_key", "locale", "value")
+VALUES($1, $2, $3, $4)
+ON CONFLICT("guild_id", "locale", "message_key") DO UPDATE SET "value" = EXCLUDED."value";`
+
+	_, err = c.Exec(ctx, query, guildId, messageKey, locale, value)
+	return
+}
+
+func (c *CustomTranslations) Delete(ctx context.Context, guildId uint64, messageKey, locale string) (err error) {
+	query := `DELETE FROM custom_translations WHERE "guild_id" = $1 AND "message_key" = $2 AND "locale" = $3;`
+	_, err = c.Exec(ctx, query, guildId, messageKey, locale)
+	return
+}
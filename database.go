@@ -12,18 +12,32 @@ const defaultTransactionTimeout = time.Second * 3
 
 type Database struct {
 	pool                           *pgxpool.Pool
+	primary                        *Database // Set if this is a regional database
+	regions                        map[string]*Database
 	ActiveLanguage                 *ActiveLanguage
+	AlertThresholds                *AlertThresholdsTable
+	AnonymizationPolicies          *AnonymizationPoliciesTable
+	ApiQuotas                      *ApiQuotasTable
 	ArchiveChannel                 *ArchiveChannel
 	AuditLog                       *AuditLogTable
+	AuditExportJobs                *AuditExportJobsTable
 	ArchiveMessages                *ArchiveMessages
+	ArchiveMessageAttachments      *ArchiveMessageAttachments
+	ArchiveMessageReactions        *ArchiveMessageReactions
 	AutoClose                      *AutoCloseTable
 	AutoCloseExclude               *AutoCloseExclude
+	AutoResponders                 *AutoRespondersTable
 	Blacklist                      *Blacklist
+	BlacklistNetworks              *BlacklistNetworksTable
 	BotStaff                       *BotStaff
+	BotStaffRoles                  *BotStaffRolesTable
+	CallTranscripts                *CallTranscriptsTable
 	CategoryUpdateQueue            *CategoryUpdateQueue
 	ChannelCategory                *ChannelCategory
 	ClaimSettings                  *ClaimSettingsTable
 	CloseConfirmation              *CloseConfirmation
+	CloseExportConfigs             *CloseExportConfigsTable
+	CloseExportQueue               *CloseExportQueueTable
 	CloseReason                    *CloseMetadataTable
 	CloseRequest                   *CloseRequestTable
 	CustomIntegrations             *CustomIntegrationTable
@@ -34,98 +48,171 @@ type Database struct {
 	CustomIntegrationSecretValues  *CustomIntegrationSecretValuesTable
 	CustomIntegrationSecrets       *CustomIntegrationSecretsTable
 	CustomColours                  *CustomColours
+	CustomTranslations             *CustomTranslations
 	DashboardUsers                 *DashboardUsersTable
+	DataRequests                   *DataRequestsTable
 	ArchiveDmMessages              *ArchiveDmMessages
 	DiscordEntitlements            *DiscordEntitlements
 	DiscordStoreSkus               *DiscordStoreSkus
 	EmbedFields                    *EmbedFieldsTable
+	EscalationRules                *EscalationRulesTable
 	Embeds                         *EmbedsTable
 	Entitlements                   *Entitlements
+	EntitlementSyncLog             *EntitlementSyncLog
 	ExitSurveyResponses            *ExitSurveyResponses
+	ExternalConfigImports          *ExternalConfigImportsTable
 	Experiment                     *ExperimentTable
 	FeedbackEnabled                *FeedbackEnabled
 	FirstResponseTime              *FirstResponseTime
 	FormInput                      *FormInputTable
 	FormInputOption                *FormInputOptionTable
+	FormSubmissionLimits           *FormSubmissionLimitsTable
 	Forms                          *FormsTable
+	FormDrafts                     *FormDraftsTable
 	FormInputApiConfig             *FormInputApiConfigTable
 	FormInputApiHeaders            *FormInputApiHeaderTable
 	GdprLogs                       *GDPRLogsTable
 	GlobalBlacklist                *GlobalBlacklist
+	GuildDashboardCache            *GuildDashboardCacheTable
+	GuildDataResidency             *GuildDataResidencyTable
+	GuildEmojiAssets               *GuildEmojiAssetsTable
+	GuildFreezes                   *GuildFreezesTable
 	GuildLeaveTime                 *GuildLeaveTime
+	GuildLocaleSettings            *GuildLocaleSettingsTable
 	GuildMetadata                  *GuildMetadataTable
+	GuildSequences                 *GuildSequencesTable
+	GuildTrustSignals              *GuildTrustSignalsTable
+	IdempotencyKeys                *IdempotencyKeysTable
 	ImportLogs                     *ImportLogsTable
 	ImportMappingTable             *ImportMappingTable
+	InteractionCustomIds           *InteractionCustomIdsTable
+	Jobs                           *JobQueue
+	KbArticles                     *KbArticlesTable
+	LegacyEntitlementMigrations    *LegacyEntitlementMigrations
 	LegacyPremiumEntitlementGuilds *LegacyPremiumEntitlementGuilds
 	LegacyPremiumEntitlements      *LegacyPremiumEntitlements
+	MaintenanceFlags               *MaintenanceFlagsTable
 	MultiPanels                    *MultiPanelTable
 	MultiPanelTargets              *MultiPanelTargets
 	MultiServerSkus                *MultiServerSkus
 	NamingScheme                   *TicketNamingScheme
 	OnCall                         *OnCall
+	OrphanedTickets                *OrphanedTicketsTable
 	Panel                          *PanelTable
 	PanelAccessControlRules        *PanelAccessControlRules
+	PanelLocalizations             *PanelLocalizationsTable
+	PanelResendJobs                *PanelResendJobsTable
 	PanelRoleMentions              *PanelRoleMentions
+	PanelRoleRestrictions          *PanelRoleRestrictionsTable
 	PanelSupportHours              *PanelSupportHoursTable
 	PanelSupportHoursSettings      *PanelSupportHoursSettingsTable
+	PanelTeamFallbacks             *PanelTeamFallbacksTable
 	PanelTeams                     *PanelTeamsTable
 	PanelTicketPermissions         *PanelTicketPermissionsTable
+	PanelTranscriptDestinations    *PanelTranscriptDestinationsTable
+	PanelVerificationRequirements  *PanelVerificationRequirementsTable
 	PanelUserMention               *PanelUserMention
 	PanelHereMention               *PanelHereMention
 	Participants                   *ParticipantTable
 	PatreonEntitlements            *PatreonEntitlements
+	PendingDestructiveOperations   *PendingDestructiveOperationsTable
+	PendingRatingPrompts           *PendingRatingPrompts
 	Permissions                    *Permissions
 	PremiumGuilds                  *PremiumGuilds
+	PremiumEvents                  *PremiumEvents
 	PremiumKeys                    *PremiumKeys
+	PremiumPrices                  *PremiumPrices
+	PremiumSeats                   *PremiumSeats
+	PromoCodes                     *PromoCodes
+	PurgedGuildSnapshots           *PurgedGuildSnapshotsTable
+	QueuedTicketRequests           *QueuedTicketRequestsTable
+	RatingPromptSchedule           *RatingPromptScheduleTable
+	Referrals                      *ReferralsTable
 	RoleBlacklist                  *RoleBlacklist
 	RolePermissions                *RolePermissions
 	ServerBlacklist                *ServerBlacklist
 	ServiceRatings                 *ServiceRatings
 	Settings                       *SettingsTable
+	StaffAvailability              *StaffAvailabilityTable
 	StaffOverride                  *StaffOverride
+	StaffReminders                 *StaffRemindersTable
+	StatsReportSchedules           *StatsReportSchedulesTable
+	StatusNotices                  *StatusNoticesTable
 	SubscriptionSkus               *SubscriptionSkus
 	SupportTeam                    *SupportTeamTable
 	SupportTeamMembers             *SupportTeamMembersTable
 	SupportTeamPermissions         *SupportTeamPermissionsTable
 	SupportTeamRoles               *SupportTeamRolesTable
 	Tag                            *TagsTable
+	TicketChannelState             *TicketChannelStateTable
 	TicketClaims                   *TicketClaims
+	TicketFieldDefinitions         *TicketFieldDefinitionsTable
+	TicketFieldValues              *TicketFieldValuesTable
 	TicketLastMessage              *TicketLastMessageTable
 	TicketLimit                    *TicketLimit
 	TicketMembers                  *TicketMembers
+	TicketOpenEvents               *TicketOpenEvents
+	TicketOpenLocks                *TicketOpenLocksTable
+	TicketPanelTransfers           *TicketPanelTransfersTable
 	TicketPermissions              *TicketPermissionsTable
+	TicketPinnedMessages           *TicketPinnedMessagesTable
+	TicketSentiment                *TicketSentimentTable
+	TicketSnoozes                  *TicketSnoozesTable
+	TicketSummaries                *TicketSummariesTable
+	TicketTemplates                *TicketTemplatesTable
+	ThreadState                    *ThreadStateTable
 	Tickets                        *TicketTable
+	TierLimits                     *TierLimits
 	UsedKeys                       *UsedKeys
 	UsersCanClose                  *UsersCanClose
 	UserGuilds                     *UserGuildsTable
+	UserVerification               *UserVerificationTable
 	VoteCredits                    *VoteCredits
+	VoiceSessions                  *VoiceSessionsTable
 	Votes                          *Votes
+	WebhookDeliveries              *WebhookDeliveriesTable
 	Webhooks                       *WebhookTable
 	WelcomeMessages                *WelcomeMessages
-	TicketLabels               *TicketLabelsTable
-	TicketLabelAssignments     *TicketLabelAssignmentsTable
+	TicketLabels                   *TicketLabelsTable
+	TicketLabelAssignments         *TicketLabelAssignmentsTable
+	TicketFollowups                *TicketFollowupsTable
 	Whitelabel                     *WhitelabelBotTable
+	WhitelabelBranding             *WhitelabelBrandingTable
 	WhitelabelErrors               *WhitelabelErrors
 	WhitelabelGuilds               *WhitelabelGuilds
 	WhitelabelStatuses             *WhitelabelStatuses
 	WhitelabelUsers                *WhitelabelUsers
 }
 
-func NewDatabase(pool *pgxpool.Pool) *Database {
+// NewDatabase creates a Database using pool as the primary database. Any regional pools passed are used for
+// guild-scoped data of guilds resident in their region, which can be accessed through ForGuild.
+func NewDatabase(pool *pgxpool.Pool, regionalPools ...RegionalPool) *Database {
 	db := &Database{
 		pool:                           pool,
 		ActiveLanguage:                 newActiveLanguage(pool),
+		AlertThresholds:                newAlertThresholdsTable(pool),
+		AnonymizationPolicies:          newAnonymizationPoliciesTable(pool),
+		ApiQuotas:                      newApiQuotasTable(pool),
 		ArchiveChannel:                 newArchiveChannel(pool),
 		AuditLog:                       newAuditLogTable(pool),
+		AuditExportJobs:                newAuditExportJobsTable(pool),
 		ArchiveMessages:                newArchiveMessages(pool),
+		ArchiveMessageAttachments:      newArchiveMessageAttachments(pool),
+		ArchiveMessageReactions:        newArchiveMessageReactions(pool),
 		AutoClose:                      newAutoCloseTable(pool),
 		AutoCloseExclude:               newAutoCloseExclude(pool),
+		AutoResponders:                 newAutoRespondersTable(pool),
 		Blacklist:                      newBlacklist(pool),
+		BlacklistNetworks:              newBlacklistNetworksTable(pool),
 		BotStaff:                       newBotStaff(pool),
+		BotStaffRoles:                  newBotStaffRolesTable(pool),
+		CallTranscripts:                newCallTranscriptsTable(pool),
 		CategoryUpdateQueue:            newCategoryUpdateQueueTable(pool),
 		ChannelCategory:                newChannelCategory(pool),
 		ClaimSettings:                  newClaimSettingsTable(pool),
 		CloseConfirmation:              newCloseConfirmation(pool),
+		CloseExportConfigs:             newCloseExportConfigsTable(pool),
+		CloseExportQueue:               newCloseExportQueueTable(pool),
 		CloseReason:                    newCloseReasonTable(pool),
 		CloseRequest:                   newCloseRequestTable(pool),
 		CustomIntegrations:             newCustomIntegrationTable(pool),
@@ -136,83 +223,149 @@ func NewDatabase(pool *pgxpool.Pool) *Database {
 		CustomIntegrationSecretValues:  newCustomIntegrationSecretValuesTable(pool),
 		CustomIntegrationSecrets:       newCustomIntegrationSecretsTable(pool),
 		CustomColours:                  newCustomColours(pool),
+		CustomTranslations:             newCustomTranslations(pool),
 		DashboardUsers:                 newDashboardUsersTable(pool),
+		DataRequests:                   newDataRequestsTable(pool),
 		ArchiveDmMessages:              newArchiveDmMessages(pool),
 		DiscordEntitlements:            newDiscordEntitlementsTable(pool),
 		DiscordStoreSkus:               newDiscordStoreSkusTable(pool),
 		EmbedFields:                    newEmbedFieldsTable(pool),
+		EscalationRules:                newEscalationRulesTable(pool),
 		Embeds:                         newEmbedsTable(pool),
 		Entitlements:                   newEntitlementsTable(pool),
+		EntitlementSyncLog:             newEntitlementSyncLog(pool),
 		ExitSurveyResponses:            newExitSurveyResponses(pool),
+		ExternalConfigImports:          newExternalConfigImportsTable(pool),
 		Experiment:                     newExperimentTable(pool),
 		FeedbackEnabled:                newFeedbackEnabled(pool),
 		FirstResponseTime:              newFirstResponseTime(pool),
 		FormInput:                      newFormInputTable(pool),
 		Forms:                          newFormsTable(pool),
+		FormDrafts:                     newFormDraftsTable(pool),
 		FormInputApiConfig:             newFormInputApiConfigTable(pool),
 		FormInputApiHeaders:            newFormInputApiHeaderTable(pool),
 		FormInputOption:                newFormInputOptionTable(pool),
+		FormSubmissionLimits:           newFormSubmissionLimitsTable(pool),
 		GdprLogs:                       newGDPRLogs(pool),
 		GlobalBlacklist:                newGlobalBlacklist(pool),
+		GuildDashboardCache:            newGuildDashboardCacheTable(pool),
+		GuildDataResidency:             newGuildDataResidencyTable(pool),
+		GuildEmojiAssets:               newGuildEmojiAssetsTable(pool),
+		GuildFreezes:                   newGuildFreezesTable(pool),
 		GuildLeaveTime:                 newGuildLeaveTime(pool),
+		GuildLocaleSettings:            newGuildLocaleSettingsTable(pool),
 		GuildMetadata:                  newGuildMetadataTable(pool),
+		GuildSequences:                 newGuildSequencesTable(pool),
+		GuildTrustSignals:              newGuildTrustSignalsTable(pool),
+		IdempotencyKeys:                newIdempotencyKeysTable(pool),
 		ImportLogs:                     newImportLogs(pool),
 		ImportMappingTable:             newImportMapping(pool),
+		InteractionCustomIds:           newInteractionCustomIdsTable(pool),
+		Jobs:                           newJobQueue(pool),
+		KbArticles:                     newKbArticlesTable(pool),
+		LegacyEntitlementMigrations:    newLegacyEntitlementMigrations(pool),
 		LegacyPremiumEntitlementGuilds: newLegacyPremiumEntitlementGuildsTable(pool),
 		LegacyPremiumEntitlements:      newLegacyPremiumEntitlement(pool),
+		MaintenanceFlags:               newMaintenanceFlagsTable(pool),
 		MultiPanels:                    newMultiMultiPanelTable(pool),
 		MultiPanelTargets:              newMultiPanelTargets(pool),
 		MultiServerSkus:                newMultiServerSkusTable(pool),
 		NamingScheme:                   newTicketNamingScheme(pool),
 		OnCall:                         newOnCall(pool),
+		OrphanedTickets:                newOrphanedTicketsTable(pool),
 		Panel:                          newPanelTable(pool),
 		PanelAccessControlRules:        newPanelAccessControlRules(pool),
+		PanelLocalizations:             newPanelLocalizationsTable(pool),
+		PanelResendJobs:                newPanelResendJobsTable(pool),
 		PanelRoleMentions:              newPanelRoleMentions(pool),
+		PanelRoleRestrictions:          newPanelRoleRestrictionsTable(pool),
 		PanelSupportHours:              newPanelSupportHoursTable(pool),
 		PanelSupportHoursSettings:      newPanelSupportHoursSettingsTable(pool),
+		PanelTeamFallbacks:             newPanelTeamFallbacksTable(pool),
 		PanelTeams:                     newPanelTeamsTable(pool),
 		PanelTicketPermissions:         newPanelTicketPermissionsTable(pool),
+		PanelTranscriptDestinations:    newPanelTranscriptDestinationsTable(pool),
+		PanelVerificationRequirements:  newPanelVerificationRequirementsTable(pool),
 		PanelUserMention:               newPanelUserMention(pool),
 		PanelHereMention:               newPanelHereMention(pool),
 		Participants:                   newParticipantTable(pool),
 		PatreonEntitlements:            newPatreonEntitlements(pool),
+		PendingDestructiveOperations:   newPendingDestructiveOperationsTable(pool),
+		PendingRatingPrompts:           newPendingRatingPrompts(pool),
 		Permissions:                    newPermissions(pool),
 		PremiumGuilds:                  newPremiumGuilds(pool),
+		PremiumEvents:                  newPremiumEvents(pool),
 		PremiumKeys:                    newPremiumKeys(pool),
+		PremiumPrices:                  newPremiumPrices(pool),
+		PremiumSeats:                   newPremiumSeats(pool),
+		PromoCodes:                     newPromoCodes(pool),
+		PurgedGuildSnapshots:           newPurgedGuildSnapshotsTable(pool),
+		QueuedTicketRequests:           newQueuedTicketRequestsTable(pool),
+		RatingPromptSchedule:           newRatingPromptScheduleTable(pool),
+		Referrals:                      newReferralsTable(pool),
 		RoleBlacklist:                  newRoleBlacklist(pool),
 		RolePermissions:                newRolePermissions(pool),
 		ServerBlacklist:                newServerBlacklist(pool),
 		ServiceRatings:                 newServiceRatings(pool),
 		Settings:                       newSettingsTable(pool),
+		StaffAvailability:              newStaffAvailabilityTable(pool),
 		StaffOverride:                  newStaffOverride(pool),
+		StaffReminders:                 newStaffRemindersTable(pool),
+		StatsReportSchedules:           newStatsReportSchedulesTable(pool),
+		StatusNotices:                  newStatusNoticesTable(pool),
 		SubscriptionSkus:               newSubscriptionSkusTable(pool),
 		SupportTeam:                    newSupportTeamTable(pool),
 		SupportTeamMembers:             newSupportTeamMembersTable(pool),
 		SupportTeamPermissions:         newSupportTeamPermissionsTable(pool),
 		SupportTeamRoles:               newSupportTeamRolesTable(pool),
 		Tag:                            newTag(pool),
+		TicketChannelState:             newTicketChannelStateTable(pool),
 		TicketClaims:                   newTicketClaims(pool),
+		TicketFieldDefinitions:         newTicketFieldDefinitionsTable(pool),
+		TicketFieldValues:              newTicketFieldValuesTable(pool),
 		TicketLastMessage:              newTicketLastMessageTable(pool),
 		TicketLimit:                    newTicketLimit(pool),
 		TicketMembers:                  newTicketMembers(pool),
+		TicketOpenEvents:               newTicketOpenEvents(pool),
+		TicketOpenLocks:                newTicketOpenLocksTable(pool),
+		TicketPanelTransfers:           newTicketPanelTransfersTable(pool),
 		TicketPermissions:              newTicketPermissionsTable(pool),
+		TicketPinnedMessages:           newTicketPinnedMessagesTable(pool),
+		TicketSentiment:                newTicketSentimentTable(pool),
+		TicketSnoozes:                  newTicketSnoozesTable(pool),
+		TicketSummaries:                newTicketSummariesTable(pool),
+		TicketTemplates:                newTicketTemplatesTable(pool),
+		ThreadState:                    newThreadStateTable(pool),
 		Tickets:                        newTicketTable(pool),
+		TierLimits:                     newTierLimits(pool),
 		UsedKeys:                       newUsedKeys(pool),
 		UsersCanClose:                  newUsersCanClose(pool),
 		UserGuilds:                     newUserGuildsTable(pool),
+		UserVerification:               newUserVerificationTable(pool),
 		VoteCredits:                    newVoteCreditsTable(pool),
+		VoiceSessions:                  newVoiceSessionsTable(pool),
 		Votes:                          newVotes(pool),
+		WebhookDeliveries:              newWebhookDeliveriesTable(pool),
 		Webhooks:                       newWebhookTable(pool),
 		WelcomeMessages:                newWelcomeMessages(pool),
-		TicketLabels:               newTicketLabelsTable(pool),
-		TicketLabelAssignments:     newTicketLabelAssignmentsTable(pool),
+		TicketLabels:                   newTicketLabelsTable(pool),
+		TicketLabelAssignments:         newTicketLabelAssignmentsTable(pool),
+		TicketFollowups:                newTicketFollowupsTable(pool),
 		Whitelabel:                     newWhitelabelBotTable(pool),
+		WhitelabelBranding:             newWhitelabelBrandingTable(pool),
 		WhitelabelErrors:               newWhitelabelErrors(pool),
 		WhitelabelGuilds:               newWhitelabelGuilds(pool),
 		WhitelabelStatuses:             newWhitelabelStatuses(pool),
 		WhitelabelUsers:                newWhitelabelUsers(pool),
 	}
 
+	if len(regionalPools) > 0 {
+		db.regions = make(map[string]*Database, len(regionalPools))
+		for _, regional := range regionalPools {
+			db.regions[regional.Region] = newRegionalDatabase(db, regional.Pool)
+		}
+	}
+
 	return db
 }
 
@@ -241,15 +394,25 @@ func (d *Database) WithTx(ctx context.Context, f func(tx pgx.Tx) error) error {
 }
 
 func (d *Database) CreateTables(ctx context.Context, pool *pgxpool.Pool) {
-	mustCreate(ctx, pool,
+	mustCreate(ctx, pool, d.Tables()...)
+	d.mustValidateSql(ctx, pool)
+}
+
+// Tables returns all tables defined by this package, in the order they must be created. For regional databases, only
+// the guild-scoped tables are returned, as the global tables are stored in the primary database.
+func (d *Database) Tables() []Table {
+	return d.withoutPrimaryTables([]Table{
 		d.ActiveLanguage,
 		d.ArchiveChannel,
 		d.AutoClose,
 		d.Blacklist,
+		d.BlacklistNetworks,
 		d.BotStaff,
+		d.BotStaffRoles,
 		d.ChannelCategory,
 		d.ClaimSettings,
 		d.CloseConfirmation,
+		d.CloseExportConfigs,
 		d.CustomIntegrations,
 		d.CustomIntegrationGuilds,
 		d.CustomIntegrationGuildCounts,
@@ -258,6 +421,7 @@ func (d *Database) CreateTables(ctx context.Context, pool *pgxpool.Pool) {
 		d.CustomIntegrationSecrets,
 		d.CustomIntegrationSecretValues,
 		d.CustomColours,
+		d.CustomTranslations,
 		d.DashboardUsers,
 		d.Embeds,
 		d.EmbedFields, // depends on embeds
@@ -266,28 +430,60 @@ func (d *Database) CreateTables(ctx context.Context, pool *pgxpool.Pool) {
 		d.DiscordEntitlements, // depends on entitlements
 		d.DiscordStoreSkus,    // depends on skus
 		d.SubscriptionSkus,    // depends on skus
+		d.PremiumEvents,       // depends on entitlements
+		d.EntitlementSyncLog,
+		d.PromoCodes,    // depends on skus
+		d.PremiumPrices, // depends on skus
+		d.PremiumSeats,  // depends on entitlements
+		d.TierLimits,
+		d.Referrals,
+		d.RatingPromptSchedule,
 		d.FeedbackEnabled,
 		d.Forms,
-		d.FormInput,           // depends on forms
-		d.FormInputOption,     // depends on form inputs
-		d.FormInputApiConfig,  // depends on form inputs
-		d.FormInputApiHeaders, // depends on form input api config
+		d.FormInput,            // depends on forms
+		d.FormInputOption,      // depends on form inputs
+		d.FormInputApiConfig,   // depends on form inputs
+		d.FormInputApiHeaders,  // depends on form input api config
+		d.FormSubmissionLimits, // depends on forms
+		d.FormDrafts,           // depends on forms
 		d.GdprLogs,
+		d.DataRequests, // Must be created after GdprLogs table
+		d.AnonymizationPolicies,
+		d.ApiQuotas,
 		d.GlobalBlacklist,
 		d.GuildLeaveTime,
 		d.GuildMetadata,
+		d.GuildDataResidency,
+		d.GuildFreezes,
+		d.GuildLocaleSettings,
+		d.GuildSequences,
+		d.GuildEmojiAssets,
+		d.GuildTrustSignals,
 		d.ImportLogs,
+		d.IdempotencyKeys,
+		d.Jobs,
 		d.ImportMappingTable,
+		d.ExternalConfigImports,
+		d.KbArticles,
 		d.LegacyPremiumEntitlements,
+		d.MaintenanceFlags,
 		d.LegacyPremiumEntitlementGuilds,
+		d.LegacyEntitlementMigrations,
 		d.MultiPanels,
 		d.MultiServerSkus,
 		d.NamingScheme,
 		d.OnCall,
 		d.Panel,
-		d.PanelTicketPermissions, // must be created after panels table
-		d.PanelAccessControlRules, // must be created after panels table
-		d.MultiPanelTargets,       // must be created after panels table
+		d.PanelTicketPermissions,        // must be created after panels table
+		d.PanelAccessControlRules,       // must be created after panels table
+		d.PanelVerificationRequirements, // must be created after panels table
+		d.PanelRoleRestrictions,         // must be created after panels table
+		d.PanelResendJobs,               // must be created after panels table
+		d.QueuedTicketRequests,          // must be created after panels table
+		d.InteractionCustomIds,          // depends on panels & forms
+		d.PanelTranscriptDestinations,   // must be created after panels table
+		d.PanelLocalizations,            // must be created after panels table
+		d.MultiPanelTargets,             // must be created after panels table
 		d.PanelRoleMentions,
 		d.PanelSupportHours,         // must be created after panels table
 		d.PanelSupportHoursSettings, // must be created after panels table
@@ -296,53 +492,92 @@ func (d *Database) CreateTables(ctx context.Context, pool *pgxpool.Pool) {
 		d.PatreonEntitlements,
 		d.Permissions,
 		d.PremiumGuilds,
+		d.PurgedGuildSnapshots,
 		d.PremiumKeys,
 		d.RoleBlacklist,
 		d.RolePermissions,
 		d.ServerBlacklist,
 		d.Settings,
+		d.StatsReportSchedules,
+		d.StatusNotices,
 		d.StaffOverride,
+		d.StaffAvailability,
 		d.SupportTeam,
 		d.SupportTeamMembers,
 		d.SupportTeamRoles,
 		d.SupportTeamPermissions, // must be created after support_team table
 		d.PanelTeams,             // Must be created after panels & support teams tables
+		d.PanelTeamFallbacks,     // Must be created after panels & support teams tables
+		d.TicketTemplates,        // Must be created after panels & embeds tables
 		d.Tag,
+		d.AutoResponders, // Must be created after panels & tags tables
 		d.TicketLimit,
 		d.TicketPermissions,
-		d.Tickets,             // Must be created before members table
-		d.TicketLastMessage,   // Must be created after Tickets table
-		d.Participants,        // Must be created after Tickets table
-		d.AutoCloseExclude,    // Must be created after Tickets table
-		d.CloseReason,         // Must be created after Tickets table
-		d.CloseRequest,        // Must be created after Tickets table
-		d.ServiceRatings,      // Must be created after Tickets table
-		d.ExitSurveyResponses, // Must be created after Tickets table
-		d.ArchiveMessages,     // Must be created after Tickets table
-		d.ArchiveDmMessages,   // Must be created after Tickets table
-		d.CategoryUpdateQueue, // Must be created after Tickets table
-		d.TicketLabels,            // Must be created after Tickets table
-		d.TicketLabelAssignments,  // Must be created after Tickets and TicketLabels tables
+		d.Tickets,           // Must be created before members table
+		d.TicketLastMessage, // Must be created after Tickets table
+		d.TicketOpenLocks,
+		d.TicketFieldDefinitions,
+		d.TicketFieldValues,         // Must be created after Tickets & ticket field definitions tables
+		d.OrphanedTickets,           // Must be created after Tickets table
+		d.TicketPinnedMessages,      // Must be created after Tickets table
+		d.Participants,              // Must be created after Tickets table
+		d.AutoCloseExclude,          // Must be created after Tickets table
+		d.CloseReason,               // Must be created after Tickets table
+		d.CloseRequest,              // Must be created after Tickets table
+		d.CloseExportQueue,          // Must be created after Tickets table
+		d.ServiceRatings,            // Must be created after Tickets table
+		d.PendingRatingPrompts,      // Must be created after Tickets table
+		d.ExitSurveyResponses,       // Must be created after Tickets table
+		d.ArchiveMessages,           // Must be created after Tickets table
+		d.ArchiveMessageAttachments, // Must be created after Tickets table
+		d.ArchiveMessageReactions,   // Must be created after Tickets table
+		d.ArchiveDmMessages,         // Must be created after Tickets table
+		d.CategoryUpdateQueue,       // Must be created after Tickets table
+		d.TicketLabels,              // Must be created after Tickets table
+		d.TicketLabelAssignments,    // Must be created after Tickets and TicketLabels tables
+		d.TicketFollowups,           // Must be created after Tickets table
+		d.StaffReminders,            // Must be created after Tickets table
+		d.TicketSummaries,           // Must be created after Tickets table
+		d.TicketSentiment,           // Must be created after Tickets table
+		d.TicketSnoozes,             // Must be created after Tickets table
+		d.EscalationRules,           // Must be created after Tickets & support team tables
+		d.ThreadState,               // Must be created after Tickets table
+		d.TicketChannelState,        // Must be created after Tickets table
+		d.VoiceSessions,             // Must be created after Tickets table
+		d.CallTranscripts,           // Must be created after Tickets table
 		d.FirstResponseTime,
+		d.AlertThresholds, // Must be created after Tickets & first response time tables
 		d.TicketMembers,
 		d.TicketClaims,
+		d.TicketPanelTransfers, // Must be created after Tickets & panels tables
 		d.UsedKeys,
+		d.TicketOpenEvents,
 		d.UsersCanClose,
 		d.UserGuilds,
+		d.UserVerification,
 		d.VoteCredits,
 		d.Votes,
 		d.Webhooks,
+		d.WebhookDeliveries, // Must be created after Webhooks table
 		d.WelcomeMessages,
 		d.Whitelabel,
+		d.WhitelabelBranding, // Must be created after Whitelabel table
 		d.WhitelabelErrors,
 		d.WhitelabelGuilds,
+		d.GuildDashboardCache, // Must be created after UserGuilds, Permissions, WhitelabelGuilds & SubscriptionSkus tables
 		d.WhitelabelStatuses,
 		d.WhitelabelUsers,
 		d.AuditLog,
-	)
+		d.AuditExportJobs,
+		d.PendingDestructiveOperations,
+	})
 }
 
 func (d *Database) Views() []View {
+	if d.primary != nil {
+		return nil
+	}
+
 	return []View{
 		d.CustomIntegrationGuildCounts,
 	}
@@ -0,0 +1,246 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type DataRequestType string
+
+const (
+	DataRequestTypeExport DataRequestType = "export"
+	DataRequestTypeDelete DataRequestType = "delete"
+)
+
+type DataRequestStatus string
+
+const (
+	DataRequestStatusPending    DataRequestStatus = "pending"
+	DataRequestStatusInProgress DataRequestStatus = "in_progress"
+	DataRequestStatusCompleted  DataRequestStatus = "completed"
+	DataRequestStatusRejected   DataRequestStatus = "rejected"
+)
+
+var ErrInvalidDataRequestTransition = errors.New("data request does not exist or is not in a state that allows the transition")
+
+// DataRequest is a request from a user to export or delete their data. Each request is mirrored by a gdpr_logs entry,
+// whose status is kept in sync.
+type DataRequest struct {
+	Id            int               `json:"id"`
+	GdprLogId     int               `json:"gdpr_log_id"`
+	Requester     string            `json:"requester"` // Sha256 hash of the requester identifier
+	Type          DataRequestType   `json:"type"`
+	TargetGuildId *uint64           `json:"target_guild_id,string"` // Null if the request covers all of the user's data
+	Status        DataRequestStatus `json:"status"`
+	AssignedTo    *uint64           `json:"assigned_to,string"` // The bot staff member handling the request
+	ArtifactKey   *string           `json:"artifact_key"`       // Object storage key of the export, once completed
+	RejectReason  *string           `json:"reject_reason"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	Deadline      time.Time         `json:"deadline"`
+}
+
+type DataRequestsTable struct {
+	*pgxpool.Pool
+}
+
+func newDataRequestsTable(db *pgxpool.Pool) *DataRequestsTable {
+	return &DataRequestsTable{
+		db,
+	}
+}
+
+func (d DataRequestsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS data_requests(
+	"id" SERIAL NOT NULL UNIQUE,
+	"gdpr_log_id" int NOT NULL,
+	"requester" varchar(256) NOT NULL,
+	"type" varchar(16) NOT NULL,
+	"target_guild_id" int8 DEFAULT NULL,
+	"status" varchar(16) NOT NULL DEFAULT 'pending',
+	"assigned_to" int8 DEFAULT NULL,
+	"artifact_key" text DEFAULT NULL,
+	"reject_reason" text DEFAULT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"updated_at" timestamptz NOT NULL DEFAULT NOW(),
+	"deadline" timestamptz NOT NULL,
+	FOREIGN KEY("gdpr_log_id") REFERENCES gdpr_logs("id") ON DELETE CASCADE,
+	CHECK("type" IN ('export', 'delete')),
+	CHECK("status" IN ('pending', 'in_progress', 'completed', 'rejected')),
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS data_requests_requester ON data_requests("requester");
+CREATE INDEX IF NOT EXISTS data_requests_deadline_open ON data_requests("deadline") WHERE "status" IN ('pending', 'in_progress');
+`
+}
+
+func (d *DataRequestsTable) Get(ctx context.Context, id int) (DataRequest, bool, error) {
+	query := `
+SELECT "id", "gdpr_log_id", "requester", "type", "target_guild_id", "status", "assigned_to", "artifact_key", "reject_reason", "created_at", "updated_at", "deadline"
+FROM data_requests
+WHERE "id" = $1;`
+
+	var request DataRequest
+	if err := d.QueryRow(ctx, query, id).Scan(request.fieldPtrs()...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return DataRequest{}, false, nil
+		} else {
+			return DataRequest{}, false, err
+		}
+	}
+
+	return request, true, nil
+}
+
+func (d *DataRequestsTable) GetByRequester(ctx context.Context, requester string) ([]DataRequest, error) {
+	query := `
+SELECT "id", "gdpr_log_id", "requester", "type", "target_guild_id", "status", "assigned_to", "artifact_key", "reject_reason", "created_at", "updated_at", "deadline"
+FROM data_requests
+WHERE "requester" = $1
+ORDER BY "created_at" DESC;`
+
+	return d.query(ctx, query, requester)
+}
+
+// GetDueBefore returns the pending and in progress requests whose deadline is before t, soonest first. Passing the
+// current time returns the requests that are overdue.
+func (d *DataRequestsTable) GetDueBefore(ctx context.Context, t time.Time) ([]DataRequest, error) {
+	query := `
+SELECT "id", "gdpr_log_id", "requester", "type", "target_guild_id", "status", "assigned_to", "artifact_key", "reject_reason", "created_at", "updated_at", "deadline"
+FROM data_requests
+WHERE "status" IN ('pending', 'in_progress') AND "deadline" < $1
+ORDER BY "deadline" ASC;`
+
+	return d.query(ctx, query, t)
+}
+
+// Create records a new pending request, along with its gdpr_logs entry.
+func (d *DataRequestsTable) Create(ctx context.Context, requester string, requestType DataRequestType, targetGuildId *uint64, deadline time.Time) (id int, err error) {
+	tx, err := d.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var gdprLogId int
+	logQuery := `INSERT INTO gdpr_logs (requester, request_type, status) VALUES ($1, $2, $3) RETURNING id;`
+	if err := tx.QueryRow(ctx, logQuery, requester, requestType, DataRequestStatusPending).Scan(&gdprLogId); err != nil {
+		return 0, err
+	}
+
+	query := `
+INSERT INTO data_requests("gdpr_log_id", "requester", "type", "target_guild_id", "deadline")
+VALUES($1, $2, $3, $4, $5)
+RETURNING "id";`
+
+	if err := tx.QueryRow(ctx, query, gdprLogId, requester, requestType, targetGuildId, deadline).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Assign moves a pending request to in progress, assigned to the staff member. In progress requests may also be
+// reassigned.
+func (d *DataRequestsTable) Assign(ctx context.Context, id int, staffId uint64) error {
+	query := `
+UPDATE data_requests
+SET "status" = 'in_progress', "assigned_to" = $2, "updated_at" = NOW()
+WHERE "id" = $1 AND "status" IN ('pending', 'in_progress')
+RETURNING "gdpr_log_id";`
+
+	return d.transition(ctx, query, DataRequestStatusInProgress, id, staffId)
+}
+
+// Complete marks an in progress request as completed. artifactKey should be set for export requests.
+func (d *DataRequestsTable) Complete(ctx context.Context, id int, artifactKey *string) error {
+	query := `
+UPDATE data_requests
+SET "status" = 'completed', "artifact_key" = $2, "updated_at" = NOW()
+WHERE "id" = $1 AND "status" = 'in_progress'
+RETURNING "gdpr_log_id";`
+
+	return d.transition(ctx, query, DataRequestStatusCompleted, id, artifactKey)
+}
+
+// Reject marks a pending or in progress request as rejected, e.g. if the requester's identity could not be verified.
+func (d *DataRequestsTable) Reject(ctx context.Context, id int, reason string) error {
+	query := `
+UPDATE data_requests
+SET "status" = 'rejected', "reject_reason" = $2, "updated_at" = NOW()
+WHERE "id" = $1 AND "status" IN ('pending', 'in_progress')
+RETURNING "gdpr_log_id";`
+
+	return d.transition(ctx, query, DataRequestStatusRejected, id, reason)
+}
+
+// transition runs the update query, which must return the request's gdpr_log_id, and updates the gdpr_logs entry to
+// match. Returns ErrInvalidDataRequestTransition if no request was updated.
+func (d *DataRequestsTable) transition(ctx context.Context, query string, status DataRequestStatus, args ...interface{}) error {
+	tx, err := d.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var gdprLogId int
+	if err := tx.QueryRow(ctx, query, args...).Scan(&gdprLogId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidDataRequestTransition
+		} else {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE gdpr_logs SET status = $1 WHERE id = $2;`, status, gdprLogId); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (d *DataRequestsTable) query(ctx context.Context, query string, args ...interface{}) ([]DataRequest, error) {
+	rows, err := d.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []DataRequest
+	for rows.Next() {
+		var request DataRequest
+		if err := rows.Scan(request.fieldPtrs()...); err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, request)
+	}
+
+	return requests, nil
+}
+
+func (r *DataRequest) fieldPtrs() []interface{} {
+	return []interface{}{
+		&r.Id,
+		&r.GdprLogId,
+		&r.Requester,
+		&r.Type,
+		&r.TargetGuildId,
+		&r.Status,
+		&r.AssignedTo,
+		&r.ArtifactKey,
+		&r.RejectReason,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+		&r.Deadline,
+	}
+}
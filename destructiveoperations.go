@@ -0,0 +1,326 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+)
+
+const defaultDestructiveOperationTtl = 5 * time.Minute
+
+var (
+	ErrDestructiveOperationNotConfirmed = errors.New("destructive operation has not been staged, or the confirmation has expired")
+	ErrDestructiveOperationNoActor      = errors.New("destructive operations must be performed with an audit actor")
+	ErrDestructiveOperationUserActor    = errors.New("destructive operations on behalf of a user must be staged and confirmed")
+)
+
+type DestructiveOperation string
+
+const (
+	DestructiveOperationPurgeGuildData   DestructiveOperation = "purge_guild_data"
+	DestructiveOperationForceDeletePanel DestructiveOperation = "force_delete_panel"
+)
+
+// PendingDestructiveOperation is a destructive operation that has been requested, and can be executed by presenting
+// its token before it expires.
+type PendingDestructiveOperation struct {
+	Token       uuid.UUID            `json:"token"`
+	Operation   DestructiveOperation `json:"operation"`
+	GuildId     uint64               `json:"guild_id,string"`
+	ResourceId  *string              `json:"resource_id"`
+	RequestedBy uint64               `json:"requested_by,string"`
+	CreatedAt   time.Time            `json:"created_at"`
+	ExpiresAt   time.Time            `json:"expires_at"`
+}
+
+// destructiveOperationAuditData is recorded as the new data of both the stage and execute audit log entries.
+type destructiveOperationAuditData struct {
+	Operation  DestructiveOperation `json:"operation"`
+	ResourceId *string              `json:"resource_id,omitempty"`
+	ExpiresAt  *time.Time           `json:"expires_at,omitempty"`
+}
+
+type PendingDestructiveOperationsTable struct {
+	*pgxpool.Pool
+}
+
+var (
+	pendingDestructiveOperationsSql           = loadSqlTable("pending_destructive_operations")
+	pendingDestructiveOperationsSchema        = pendingDestructiveOperationsSql.schema()
+	pendingDestructiveOperationsGet           = pendingDestructiveOperationsSql.query("get")
+	pendingDestructiveOperationsDeleteExpired = pendingDestructiveOperationsSql.query("delete_expired")
+	pendingDestructiveOperationsStage         = pendingDestructiveOperationsSql.query("stage")
+	pendingDestructiveOperationsConsume       = pendingDestructiveOperationsSql.query("consume")
+)
+
+func newPendingDestructiveOperationsTable(db *pgxpool.Pool) *PendingDestructiveOperationsTable {
+	return &PendingDestructiveOperationsTable{
+		db,
+	}
+}
+
+func (PendingDestructiveOperationsTable) Schema() string {
+	return pendingDestructiveOperationsSchema
+}
+
+func (p *PendingDestructiveOperationsTable) Get(ctx context.Context, token uuid.UUID) (PendingDestructiveOperation, bool, error) {
+	var operation PendingDestructiveOperation
+	if err := p.QueryRow(ctx, pendingDestructiveOperationsGet, token).Scan(
+		&operation.Token,
+		&operation.Operation,
+		&operation.GuildId,
+		&operation.ResourceId,
+		&operation.RequestedBy,
+		&operation.CreatedAt,
+		&operation.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PendingDestructiveOperation{}, false, nil
+		} else {
+			return PendingDestructiveOperation{}, false, err
+		}
+	}
+
+	return operation, true, nil
+}
+
+func (p *PendingDestructiveOperationsTable) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := p.Exec(ctx, pendingDestructiveOperationsDeleteExpired)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// StagePurgeGuildData records a request to purge the guild's data, which can be run with ExecutePurgeGuildData within
+// ttl, or 5 minutes if ttl is not positive. The context must carry an audit actor. Purges are always staged in the
+// primary database, as they span both the primary and the guild's regional database.
+func (d *Database) StagePurgeGuildData(ctx context.Context, guildId uint64, ttl time.Duration) (PendingDestructiveOperation, error) {
+	return d.primaryDatabase().stageDestructiveOperation(ctx, DestructiveOperationPurgeGuildData, guildId, nil, ttl)
+}
+
+// StageForceDeletePanel records a request to force delete the guild's panel, which can be run with
+// ExecuteForceDeletePanel within ttl, or 5 minutes if ttl is not positive. The context must carry an audit actor.
+func (d *Database) StageForceDeletePanel(ctx context.Context, guildId uint64, panelId int, ttl time.Duration) (PendingDestructiveOperation, error) {
+	resourceId := auditResourceId(panelId)
+	return d.stageDestructiveOperation(ctx, DestructiveOperationForceDeletePanel, guildId, &resourceId, ttl)
+}
+
+// ExecutePurgeGuildData deletes all data associated with the guild if the token matches an unexpired purge staged for
+// the guild, returning ErrDestructiveOperationNotConfirmed otherwise. The token is consumed in the same transaction as
+// the purge of the primary database, so it can only be used once, and is left intact if the purge fails.
+func (d *Database) ExecutePurgeGuildData(ctx context.Context, token uuid.UUID, guildId uint64, logger *zap.Logger) error {
+	err := d.primaryDatabase().purgeGuildData(ctx, guildId, logger, func(tx pgx.Tx) error {
+		_, err := consumeDestructiveOperation(ctx, tx, token, DestructiveOperationPurgeGuildData, guildId, nil)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Successfully completed guild data purge", zap.Uint64("guild_id", guildId))
+	return nil
+}
+
+// SystemPurgeGuildData deletes all data associated with the guild straight away, for purges initiated by the bot
+// itself, e.g. when it is removed from the guild or by retention workers. The purge is audited as SystemAuditActor.
+// Returns ErrDestructiveOperationUserActor if the context carries an audit actor, as purges requested by a user must be
+// staged with StagePurgeGuildData and run with ExecutePurgeGuildData.
+func (d *Database) SystemPurgeGuildData(ctx context.Context, guildId uint64, logger *zap.Logger) error {
+	ctx, err := systemDestructiveOperationContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = d.primaryDatabase().purgeGuildData(ctx, guildId, logger, func(tx pgx.Tx) error {
+		return recordSystemDestructiveOperation(ctx, tx, DestructiveOperationPurgeGuildData, guildId, nil)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Successfully completed guild data purge", zap.Uint64("guild_id", guildId))
+	return nil
+}
+
+// ExecuteForceDeletePanel deletes the panel and detaches everything that references it, as reported by
+// GetPanelDependencies, if the token matches an unexpired deletion staged for the guild's panel, returning
+// ErrDestructiveOperationNotConfirmed otherwise. The token is consumed in the same transaction as the
+// deletion, so it can only be used once, and is left intact if the deletion fails.
+func (d *Database) ExecuteForceDeletePanel(ctx context.Context, token uuid.UUID, guildId uint64, panelId int) (PanelDependencies, error) {
+	resourceId := auditResourceId(panelId)
+
+	var dependencies PanelDependencies
+	err := d.WithTx(ctx, func(tx pgx.Tx) error {
+		if _, err := consumeDestructiveOperation(ctx, tx, token, DestructiveOperationForceDeletePanel, guildId, &resourceId); err != nil {
+			return err
+		}
+
+		var (
+			panelGuildId uint64
+			err          error
+		)
+
+		dependencies, panelGuildId, err = forceDeletePanelTx(ctx, tx, panelId)
+		if err != nil {
+			return err
+		}
+
+		if panelGuildId != guildId {
+			return ErrPanelNotFound
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return PanelDependencies{}, err
+	}
+
+	return dependencies, nil
+}
+
+// SystemForceDeletePanel deletes the guild's panel and detaches everything that references it straight away, as
+// ExecuteForceDeletePanel does, for deletions initiated by the bot itself. The deletion is audited as
+// SystemAuditActor. Returns ErrDestructiveOperationUserActor if the context carries an audit actor, as deletions
+// requested by a user must be staged with StageForceDeletePanel and run with ExecuteForceDeletePanel.
+func (d *Database) SystemForceDeletePanel(ctx context.Context, guildId uint64, panelId int) (PanelDependencies, error) {
+	ctx, err := systemDestructiveOperationContext(ctx)
+	if err != nil {
+		return PanelDependencies{}, err
+	}
+
+	resourceId := auditResourceId(panelId)
+
+	var dependencies PanelDependencies
+	err = d.WithTx(ctx, func(tx pgx.Tx) error {
+		var (
+			panelGuildId uint64
+			err          error
+		)
+
+		dependencies, panelGuildId, err = forceDeletePanelTx(ctx, tx, panelId)
+		if err != nil {
+			return err
+		}
+
+		if panelGuildId != guildId {
+			return ErrPanelNotFound
+		}
+
+		return recordSystemDestructiveOperation(ctx, tx, DestructiveOperationForceDeletePanel, guildId, &resourceId)
+	})
+
+	if err != nil {
+		return PanelDependencies{}, err
+	}
+
+	return dependencies, nil
+}
+
+// systemDestructiveOperationContext returns ctx carrying SystemAuditActor, or ErrDestructiveOperationUserActor if ctx
+// already carries an audit actor.
+func systemDestructiveOperationContext(ctx context.Context) (context.Context, error) {
+	if _, ok := AuditActorFromContext(ctx); ok {
+		return nil, ErrDestructiveOperationUserActor
+	}
+
+	return WithAuditActor(ctx, SystemAuditActor), nil
+}
+
+// recordSystemDestructiveOperation records the execution of an operation that was not staged, under a new token.
+func recordSystemDestructiveOperation(ctx context.Context, tx pgx.Tx, operation DestructiveOperation, guildId uint64, resourceId *string) error {
+	data := destructiveOperationAuditData{
+		Operation:  operation,
+		ResourceId: resourceId,
+	}
+
+	return recordAudit(ctx, tx, guildId, AuditActionDestructiveOperationExecute, AuditResourceDestructiveOperation, uuid.NewString(), data)
+}
+
+func (d *Database) stageDestructiveOperation(ctx context.Context, operation DestructiveOperation, guildId uint64, resourceId *string, ttl time.Duration) (PendingDestructiveOperation, error) {
+	actor, ok := AuditActorFromContext(ctx)
+	if !ok {
+		return PendingDestructiveOperation{}, ErrDestructiveOperationNoActor
+	}
+
+	if ttl <= 0 {
+		ttl = defaultDestructiveOperationTtl
+	}
+
+	pending := PendingDestructiveOperation{
+		Operation:   operation,
+		GuildId:     guildId,
+		ResourceId:  resourceId,
+		RequestedBy: actor.UserId,
+	}
+
+	err := d.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, pendingDestructiveOperationsStage, operation, guildId, resourceId, actor.UserId, ttl).Scan(
+			&pending.Token,
+			&pending.CreatedAt,
+			&pending.ExpiresAt,
+		); err != nil {
+			return err
+		}
+
+		data := destructiveOperationAuditData{
+			Operation:  operation,
+			ResourceId: resourceId,
+			ExpiresAt:  &pending.ExpiresAt,
+		}
+
+		return recordAudit(ctx, tx, guildId, AuditActionDestructiveOperationStage, AuditResourceDestructiveOperation, pending.Token.String(), data)
+	})
+
+	if err != nil {
+		return PendingDestructiveOperation{}, err
+	}
+
+	return pending, nil
+}
+
+// consumeDestructiveOperation deletes the pending operation matching the token, operation, guild and resource, and
+// records its execution. Returns ErrDestructiveOperationNotConfirmed if there is no matching unexpired operation.
+func consumeDestructiveOperation(ctx context.Context, tx pgx.Tx, token uuid.UUID, operation DestructiveOperation, guildId uint64, resourceId *string) (PendingDestructiveOperation, error) {
+	if _, ok := AuditActorFromContext(ctx); !ok {
+		return PendingDestructiveOperation{}, ErrDestructiveOperationNoActor
+	}
+
+	var pending PendingDestructiveOperation
+	if err := tx.QueryRow(ctx, pendingDestructiveOperationsConsume, token, operation, guildId, resourceId).Scan(
+		&pending.Token,
+		&pending.Operation,
+		&pending.GuildId,
+		&pending.ResourceId,
+		&pending.RequestedBy,
+		&pending.CreatedAt,
+		&pending.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PendingDestructiveOperation{}, ErrDestructiveOperationNotConfirmed
+		}
+
+		return PendingDestructiveOperation{}, fmt.Errorf("failed to consume destructive operation: %w", err)
+	}
+
+	data := destructiveOperationAuditData{
+		Operation:  operation,
+		ResourceId: resourceId,
+	}
+
+	if err := recordAudit(ctx, tx, guildId, AuditActionDestructiveOperationExecute, AuditResourceDestructiveOperation, token.String(), data); err != nil {
+		return PendingDestructiveOperation{}, err
+	}
+
+	return pending, nil
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"time"
@@ -62,7 +63,17 @@ CREATE INDEX IF NOT EXISTS embeds_guild_id ON embeds("guild_id");
 `
 }
 
-func (s *EmbedsTable) GetEmbed(ctx context.Context, id int) (embed CustomEmbed, err error) {
+// Deprecated: use Get, which distinguishes a missing embed from an error.
+func (s *EmbedsTable) GetEmbed(ctx context.Context, id int) (CustomEmbed, error) {
+	embed, ok, err := s.Get(ctx, id)
+	if err == nil && !ok {
+		return CustomEmbed{}, pgx.ErrNoRows
+	}
+
+	return embed, err
+}
+
+func (s *EmbedsTable) Get(ctx context.Context, id int) (embed CustomEmbed, ok bool, e error) {
 	query := `
 SELECT 
 	"id",
@@ -83,7 +94,7 @@ FROM embeds
 WHERE "id" = $1;
 `
 
-	err = s.QueryRow(ctx, query, id).Scan(
+	err := s.QueryRow(ctx, query, id).Scan(
 		&embed.Id,
 		&embed.GuildId,
 		&embed.Title,
@@ -100,7 +111,15 @@ WHERE "id" = $1;
 		&embed.Timestamp,
 	)
 
-	return
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return CustomEmbed{}, false, nil
+		} else {
+			return CustomEmbed{}, false, err
+		}
+	}
+
+	return embed, true, nil
 }
 
 func (s *EmbedsTable) Create(ctx context.Context, embed *CustomEmbed) (id int, err error) {
@@ -4,6 +4,7 @@ import (
 	"context"
 	_ "embed"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/TicketsBot-cloud/common/model"
@@ -32,8 +33,12 @@ var (
 	//go:embed sql/entitlements/delete_by_id.sql
 	entitlementsDeleteById string
 
+	//go:embed sql/entitlements/guild_tiers.sql
+	entitlementsGuildTiers string
+
 	//go:embed sql/entitlements/get_guild_tiers.sql
-	entitlementsGetGuildTiers string
+	entitlementsGetGuildTiersTemplate string
+	entitlementsGetGuildTiers         = withGuildTiers(entitlementsGetGuildTiersTemplate)
 
 	//go:embed sql/entitlements/list_guild_subscriptions.sql
 	entitlementsListGuildSubscriptions string
@@ -51,6 +56,15 @@ var (
 	entitlementsUpdateExpiresAt string
 )
 
+// guildTiersPlaceholder marks where sql/entitlements/guild_tiers.sql is inserted into a query. The fragment selects the
+// tier and priority of every entitlement that applies to guild $1 with owner $2, grace period $3 and whether voting
+// entitlements are included $4, so that every query resolving a guild's tiers agrees on which entitlements apply.
+const guildTiersPlaceholder = "/* guild_tiers */"
+
+func withGuildTiers(query string) string {
+	return strings.Replace(query, guildTiersPlaceholder, strings.TrimSpace(entitlementsGuildTiers), 1)
+}
+
 func newEntitlementsTable(db *pgxpool.Pool) *Entitlements {
 	return &Entitlements{
 		db,
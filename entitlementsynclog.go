@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type EntitlementSyncResult string
+
+const (
+	EntitlementSyncResultSuccess EntitlementSyncResult = "success"
+	EntitlementSyncResultFailed  EntitlementSyncResult = "failed"
+	EntitlementSyncResultIgnored EntitlementSyncResult = "ignored"
+)
+
+type EntitlementSyncLogEntry struct {
+	Provider    string
+	ExternalId  string
+	PayloadHash []byte
+	Result      EntitlementSyncResult
+	Error       *string
+	Attempts    int
+	ProcessedAt time.Time
+}
+
+type EntitlementSyncLog struct {
+	*pgxpool.Pool
+}
+
+var (
+	//go:embed sql/entitlement_sync_log/schema.sql
+	entitlementSyncLogSchema string
+
+	//go:embed sql/entitlement_sync_log/record.sql
+	entitlementSyncLogRecord string
+
+	//go:embed sql/entitlement_sync_log/has_processed.sql
+	entitlementSyncLogHasProcessed string
+
+	//go:embed sql/entitlement_sync_log/list_failed.sql
+	entitlementSyncLogListFailed string
+
+	//go:embed sql/entitlement_sync_log/result_counts.sql
+	entitlementSyncLogResultCounts string
+)
+
+func newEntitlementSyncLog(db *pgxpool.Pool) *EntitlementSyncLog {
+	return &EntitlementSyncLog{
+		db,
+	}
+}
+
+func (EntitlementSyncLog) Schema() string {
+	return entitlementSyncLogSchema
+}
+
+// Record stores the outcome of processing a provider event. Recording the same event again (e.g. a retry after a
+// failure) overwrites the previous outcome and increments the attempt counter.
+func (l *EntitlementSyncLog) Record(ctx context.Context, provider, externalId string, payloadHash []byte, result EntitlementSyncResult, errorMessage *string) error {
+	_, err := l.Exec(ctx, entitlementSyncLogRecord, provider, externalId, payloadHash, result, errorMessage)
+	return err
+}
+
+// HasProcessed returns true if the event has already been handled, either successfully or by deliberately ignoring
+// it. Events that previously failed are not considered processed, so that they can be retried.
+func (l *EntitlementSyncLog) HasProcessed(ctx context.Context, provider, externalId string) (processed bool, err error) {
+	err = l.QueryRow(ctx, entitlementSyncLogHasProcessed, provider, externalId).Scan(&processed)
+	return
+}
+
+// ListFailed returns events whose most recent attempt failed at or after since, oldest first.
+func (l *EntitlementSyncLog) ListFailed(ctx context.Context, since time.Time) ([]EntitlementSyncLogEntry, error) {
+	rows, err := l.Query(ctx, entitlementSyncLogListFailed, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []EntitlementSyncLogEntry
+	for rows.Next() {
+		var entry EntitlementSyncLogEntry
+		if err := rows.Scan(
+			&entry.Provider,
+			&entry.ExternalId,
+			&entry.PayloadHash,
+			&entry.Result,
+			&entry.Error,
+			&entry.Attempts,
+			&entry.ProcessedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// GetResultCounts returns provider -> result -> count for events processed at or after since.
+func (l *EntitlementSyncLog) GetResultCounts(ctx context.Context, since time.Time) (map[string]map[EntitlementSyncResult]int, error) {
+	rows, err := l.Query(ctx, entitlementSyncLogResultCounts, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]map[EntitlementSyncResult]int)
+	for rows.Next() {
+		var provider string
+		var result EntitlementSyncResult
+		var count int
+		if err := rows.Scan(&provider, &result, &count); err != nil {
+			return nil, err
+		}
+
+		if _, ok := counts[provider]; !ok {
+			counts[provider] = make(map[EntitlementSyncResult]int)
+		}
+
+		counts[provider][result] = count
+	}
+
+	return counts, nil
+}
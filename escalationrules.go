@@ -0,0 +1,335 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type EscalationCondition string
+
+const (
+	EscalationConditionNoResponseFor EscalationCondition = "no_response_for"
+	EscalationConditionPriority      EscalationCondition = "priority"
+	EscalationConditionKeyword       EscalationCondition = "keyword"
+)
+
+type EscalationRule struct {
+	Id                int                 `json:"id"`
+	GuildId           uint64              `json:"guild_id,string"`
+	Condition         EscalationCondition `json:"condition"`
+	NoResponseSeconds *int                `json:"no_response_seconds,omitempty"` // Set if Condition is no_response_for
+	Priority          *int16              `json:"priority,omitempty"`            // Set if Condition is priority
+	Keyword           *string             `json:"keyword,omitempty"`             // Set if Condition is keyword
+	TargetTeamId      *int                `json:"target_team_id"`                // Null for the default team
+	NotifyChannelId   *uint64             `json:"notify_channel_id,string"`
+	Enabled           bool                `json:"enabled"`
+}
+
+type EscalationEvent struct {
+	Id           int64     `json:"id"`
+	GuildId      uint64    `json:"guild_id,string"`
+	TicketId     int       `json:"ticket_id"`
+	RuleId       *int      `json:"rule_id"` // Null if the rule has since been deleted
+	TargetTeamId *int      `json:"target_team_id"`
+	EscalatedAt  time.Time `json:"escalated_at"`
+}
+
+// EscalationTicketState is the state of a ticket that is not stored in the database, which rules are matched against
+// by GetMatchingRules.
+type EscalationTicketState struct {
+	Priority *int16 // Nil if the ticket does not have a priority
+	Content  string // The text that keyword rules are matched against, e.g. the ticket's latest message
+}
+
+// EscalationRulesTable stores each guild's escalation rules. When a rule's target team is deleted, the rule is disabled
+// and its target reset to the default team, rather than being deleted, so that it can be reviewed and re-enabled.
+type EscalationRulesTable struct {
+	*pgxpool.Pool
+}
+
+func newEscalationRulesTable(db *pgxpool.Pool) *EscalationRulesTable {
+	return &EscalationRulesTable{
+		db,
+	}
+}
+
+func (e EscalationRulesTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS escalation_rules(
+	"id" SERIAL NOT NULL UNIQUE,
+	"guild_id" int8 NOT NULL,
+	"condition" varchar(32) NOT NULL,
+	"no_response_seconds" int DEFAULT NULL,
+	"priority" int2 DEFAULT NULL,
+	"keyword" varchar(100) DEFAULT NULL,
+	"target_team_id" int DEFAULT NULL,
+	"notify_channel_id" int8 DEFAULT NULL,
+	"enabled" bool NOT NULL DEFAULT true,
+	FOREIGN KEY("target_team_id") REFERENCES support_team("id") ON DELETE SET NULL,
+	CHECK(
+		("condition" = 'no_response_for' AND "no_response_seconds" IS NOT NULL AND "no_response_seconds" > 0) OR
+		("condition" = 'priority' AND "priority" IS NOT NULL) OR
+		("condition" = 'keyword' AND "keyword" IS NOT NULL)
+	),
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS escalation_rules_guild_id ON escalation_rules("guild_id");
+
+CREATE TABLE IF NOT EXISTS escalation_events(
+	"id" BIGSERIAL NOT NULL,
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"rule_id" int DEFAULT NULL,
+	"target_team_id" int DEFAULT NULL,
+	"escalated_at" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	FOREIGN KEY("rule_id") REFERENCES escalation_rules("id") ON DELETE SET NULL,
+	FOREIGN KEY("target_team_id") REFERENCES support_team("id") ON DELETE SET NULL,
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS escalation_events_guild_ticket ON escalation_events("guild_id", "ticket_id");
+
+DO $$
+BEGIN
+	IF EXISTS(
+		SELECT 1
+		FROM pg_constraint
+		WHERE conrelid = 'escalation_rules'::regclass AND conname = 'escalation_rules_target_team_id_fkey' AND confdeltype = 'c'
+	) THEN
+		ALTER TABLE escalation_rules DROP CONSTRAINT escalation_rules_target_team_id_fkey;
+		ALTER TABLE escalation_rules ADD CONSTRAINT escalation_rules_target_team_id_fkey FOREIGN KEY("target_team_id") REFERENCES support_team("id") ON DELETE SET NULL;
+	END IF;
+END $$;
+
+CREATE OR REPLACE FUNCTION escalation_rules_team_deleted()
+RETURNS TRIGGER AS $$
+BEGIN
+	UPDATE escalation_rules SET "enabled" = false WHERE "target_team_id" = OLD."id";
+	RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER support_team_escalation_rules
+BEFORE DELETE ON support_team
+FOR EACH ROW
+EXECUTE FUNCTION escalation_rules_team_deleted();
+`
+}
+
+func (e *EscalationRulesTable) Get(ctx context.Context, guildId uint64, id int) (EscalationRule, bool, error) {
+	query := `
+SELECT "id", "guild_id", "condition", "no_response_seconds", "priority", "keyword", "target_team_id", "notify_channel_id", "enabled"
+FROM escalation_rules
+WHERE "guild_id" = $1 AND "id" = $2;`
+
+	var rule EscalationRule
+	if err := e.QueryRow(ctx, query, guildId, id).Scan(rule.fieldPtrs()...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return EscalationRule{}, false, nil
+		} else {
+			return EscalationRule{}, false, err
+		}
+	}
+
+	return rule, true, nil
+}
+
+func (e *EscalationRulesTable) GetByGuild(ctx context.Context, guildId uint64) ([]EscalationRule, error) {
+	query := `
+SELECT "id", "guild_id", "condition", "no_response_seconds", "priority", "keyword", "target_team_id", "notify_channel_id", "enabled"
+FROM escalation_rules
+WHERE "guild_id" = $1
+ORDER BY "id" ASC;`
+
+	rows, err := e.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanEscalationRules(rows)
+}
+
+// GetMatchingRules returns the guild's enabled rules that match the ticket and have not already been applied to it,
+// in order of ID. no_response_for rules match if the last message is not from staff and was sent at least the rule's
+// duration ago, or the ticket has no messages and was opened that long ago. priority rules match if the ticket's
+// priority is at least the rule's, and keyword rules match if the content contains the keyword, ignoring case. Closed
+// and snoozed tickets match no rules.
+func (e *EscalationRulesTable) GetMatchingRules(ctx context.Context, guildId uint64, ticketId int, state EscalationTicketState) ([]EscalationRule, error) {
+	query := `
+SELECT
+	escalation_rules.id,
+	escalation_rules.guild_id,
+	escalation_rules.condition,
+	escalation_rules.no_response_seconds,
+	escalation_rules.priority,
+	escalation_rules.keyword,
+	escalation_rules.target_team_id,
+	escalation_rules.notify_channel_id,
+	escalation_rules.enabled
+FROM escalation_rules
+INNER JOIN tickets
+	ON tickets.guild_id = escalation_rules.guild_id AND tickets.id = $2
+LEFT JOIN ticket_last_message
+	ON ticket_last_message.guild_id = tickets.guild_id AND ticket_last_message.ticket_id = tickets.id
+WHERE escalation_rules.guild_id = $1
+	AND escalation_rules.enabled
+	AND tickets.open
+	AND NOT EXISTS(
+		SELECT 1
+		FROM ticket_snoozes
+		WHERE ticket_snoozes.guild_id = tickets.guild_id AND ticket_snoozes.ticket_id = tickets.id AND ticket_snoozes.until > NOW()
+	)
+	AND NOT EXISTS(
+		SELECT 1
+		FROM escalation_events
+		WHERE escalation_events.guild_id = tickets.guild_id
+			AND escalation_events.ticket_id = tickets.id
+			AND escalation_events.rule_id = escalation_rules.id
+	)
+	AND (
+		(
+			escalation_rules.condition = 'no_response_for'
+			AND NOT COALESCE(ticket_last_message.user_is_staff, false)
+			AND COALESCE(ticket_last_message.last_message_time, tickets.open_time) <= NOW() - escalation_rules.no_response_seconds * INTERVAL '1 second'
+		)
+		OR (escalation_rules.condition = 'priority' AND $3::int2 >= escalation_rules.priority)
+		OR (escalation_rules.condition = 'keyword' AND strpos(lower($4::text), lower(escalation_rules.keyword)) > 0)
+	)
+ORDER BY escalation_rules.id ASC;`
+
+	rows, err := e.Query(ctx, query, guildId, ticketId, state.Priority, state.Content)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanEscalationRules(rows)
+}
+
+func (e *EscalationRulesTable) Create(ctx context.Context, rule EscalationRule) (id int, err error) {
+	query := `
+INSERT INTO escalation_rules("guild_id", "condition", "no_response_seconds", "priority", "keyword", "target_team_id", "notify_channel_id", "enabled")
+VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING "id";`
+
+	err = e.QueryRow(ctx, query,
+		rule.GuildId,
+		rule.Condition,
+		rule.NoResponseSeconds,
+		rule.Priority,
+		rule.Keyword,
+		rule.TargetTeamId,
+		rule.NotifyChannelId,
+		rule.Enabled,
+	).Scan(&id)
+	return
+}
+
+func (e *EscalationRulesTable) Update(ctx context.Context, rule EscalationRule) (err error) {
+	query := `
+UPDATE escalation_rules
+SET
+	"condition" = $3,
+	"no_response_seconds" = $4,
+	"priority" = $5,
+	"keyword" = $6,
+	"target_team_id" = $7,
+	"notify_channel_id" = $8,
+	"enabled" = $9
+WHERE "guild_id" = $1 AND "id" = $2;`
+
+	_, err = e.Exec(ctx, query,
+		rule.GuildId,
+		rule.Id,
+		rule.Condition,
+		rule.NoResponseSeconds,
+		rule.Priority,
+		rule.Keyword,
+		rule.TargetTeamId,
+		rule.NotifyChannelId,
+		rule.Enabled,
+	)
+	return
+}
+
+func (e *EscalationRulesTable) Delete(ctx context.Context, guildId uint64, id int) (err error) {
+	query := `DELETE FROM escalation_rules WHERE "guild_id" = $1 AND "id" = $2;`
+	_, err = e.Exec(ctx, query, guildId, id)
+	return
+}
+
+func (e *EscalationRulesTable) LogEvent(ctx context.Context, guildId uint64, ticketId int, ruleId, targetTeamId *int) (id int64, err error) {
+	query := `
+INSERT INTO escalation_events("guild_id", "ticket_id", "rule_id", "target_team_id")
+VALUES($1, $2, $3, $4)
+RETURNING "id";`
+
+	err = e.QueryRow(ctx, query, guildId, ticketId, ruleId, targetTeamId).Scan(&id)
+	return
+}
+
+// HasEscalated returns whether the rule has already been applied to the ticket, so that it is not applied twice.
+func (e *EscalationRulesTable) HasEscalated(ctx context.Context, guildId uint64, ticketId, ruleId int) (escalated bool, err error) {
+	query := `SELECT EXISTS(SELECT 1 FROM escalation_events WHERE "guild_id" = $1 AND "ticket_id" = $2 AND "rule_id" = $3);`
+	err = e.QueryRow(ctx, query, guildId, ticketId, ruleId).Scan(&escalated)
+	return
+}
+
+func (e *EscalationRulesTable) GetEventsByTicket(ctx context.Context, guildId uint64, ticketId int) ([]EscalationEvent, error) {
+	query := `
+SELECT "id", "guild_id", "ticket_id", "rule_id", "target_team_id", "escalated_at"
+FROM escalation_events
+WHERE "guild_id" = $1 AND "ticket_id" = $2
+ORDER BY "escalated_at" ASC;`
+
+	rows, err := e.Query(ctx, query, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []EscalationEvent
+	for rows.Next() {
+		var event EscalationEvent
+		if err := rows.Scan(&event.Id, &event.GuildId, &event.TicketId, &event.RuleId, &event.TargetTeamId, &event.EscalatedAt); err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func (r *EscalationRule) fieldPtrs() []interface{} {
+	return []interface{}{
+		&r.Id,
+		&r.GuildId,
+		&r.Condition,
+		&r.NoResponseSeconds,
+		&r.Priority,
+		&r.Keyword,
+		&r.TargetTeamId,
+		&r.NotifyChannelId,
+		&r.Enabled,
+	}
+}
+
+func scanEscalationRules(rows pgx.Rows) ([]EscalationRule, error) {
+	var rules []EscalationRule
+	for rows.Next() {
+		var rule EscalationRule
+		if err := rows.Scan(rule.fieldPtrs()...); err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
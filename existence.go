@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type ExistenceCheckType string
+
+const (
+	ExistenceCheckPanel  ExistenceCheckType = "panel"
+	ExistenceCheckForm   ExistenceCheckType = "form"
+	ExistenceCheckTicket ExistenceCheckType = "ticket"
+	ExistenceCheckLabel  ExistenceCheckType = "label"
+)
+
+type ExistenceCheck struct {
+	Type    ExistenceCheckType
+	GuildId uint64
+	Id      int
+}
+
+// Key is the key used for this check in the map returned by Database.Exists
+func (c ExistenceCheck) Key() string {
+	return fmt.Sprintf("%s:%d:%d", c.Type, c.GuildId, c.Id)
+}
+
+func (c ExistenceCheck) query(keyParam, guildParam, idParam int) (string, error) {
+	var table, idColumn string
+	switch c.Type {
+	case ExistenceCheckPanel:
+		table, idColumn = "panels", "panel_id"
+	case ExistenceCheckForm:
+		table, idColumn = "forms", "form_id"
+	case ExistenceCheckTicket:
+		table, idColumn = "tickets", "id"
+	case ExistenceCheckLabel:
+		table, idColumn = "ticket_labels", "label_id"
+	default:
+		return "", fmt.Errorf("unknown existence check type %s", c.Type)
+	}
+
+	return fmt.Sprintf(`SELECT $%d::text FROM %s WHERE "guild_id" = $%d AND "%s" = $%d`, keyParam, table, guildParam, idColumn, idParam), nil
+}
+
+// Exists validates all checks in a single query. Every check's key is present in the returned map.
+func (d *Database) Exists(ctx context.Context, checks []ExistenceCheck) (map[string]bool, error) {
+	exists := make(map[string]bool, len(checks))
+	if len(checks) == 0 {
+		return exists, nil
+	}
+
+	var subQueries []string
+	var args []interface{}
+	for _, check := range checks {
+		key := check.Key()
+		if _, ok := exists[key]; ok {
+			continue
+		}
+
+		exists[key] = false
+
+		subQuery, err := check.query(len(args)+1, len(args)+2, len(args)+3)
+		if err != nil {
+			return nil, err
+		}
+
+		subQueries = append(subQueries, subQuery)
+		args = append(args, key, check.GuildId, check.Id)
+	}
+
+	rows, err := d.pool.Query(ctx, strings.Join(subQueries, "\nUNION ALL\n")+";", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+
+		exists[key] = true
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+type ExportMaskingLevel string
+
+const (
+	// ExportMaskingNone exports all data as-is
+	ExportMaskingNone ExportMaskingLevel = ""
+	// ExportMaskingIds replaces the IDs of users other than the subject with a hash, so that rows by the same user can
+	// still be correlated within the export
+	ExportMaskingIds ExportMaskingLevel = "ids"
+	// ExportMaskingFull masks IDs as ExportMaskingIds does, and also removes message content, e.g. close reasons
+	ExportMaskingFull ExportMaskingLevel = "full"
+)
+
+type ExportOptions struct {
+	Masking ExportMaskingLevel
+	// The user the export is for, whose ID is never masked. 0 if the export is not for a specific user.
+	SubjectUserId uint64
+}
+
+// exportSaltLength is the length in bytes of the random salt that masked user IDs are hashed with.
+const exportSaltLength = 32
+
+// newExportSalt returns a random salt for masking the user IDs of a single export. A new salt is used for each export,
+// so that masked IDs cannot be reversed by hashing the guild's member list, nor correlated between exports.
+func newExportSalt() ([]byte, error) {
+	salt := make([]byte, exportSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return salt, nil
+}
+
+// maskedUserIdColumn returns an SQL expression evaluating to the user ID column as text, or its hash salted with the
+// export's salt if masking is enabled. The masking level, subject user ID and salt, as returned by newExportSalt, are
+// read from the levelParam, subjectParam and saltParam placeholders. Hashing is performed by the database so that raw
+// IDs are never sent to the application.
+func maskedUserIdColumn(column string, levelParam, subjectParam, saltParam int) string {
+	return fmt.Sprintf(
+		`CASE WHEN $%[2]d::text IN ('ids', 'full') AND %[1]s <> $%[3]d::int8 THEN encode(sha256($%[4]d::bytea || convert_to(%[1]s::text, 'UTF8')), 'hex') ELSE %[1]s::text END`,
+		column, levelParam, subjectParam, saltParam,
+	)
+}
+
+// maskedContentColumn returns an SQL expression evaluating to the column, or NULL if content masking is enabled.
+func maskedContentColumn(column string, levelParam int) string {
+	return fmt.Sprintf(`CASE WHEN $%[2]d::text = 'full' THEN NULL ELSE %[1]s END`, column, levelParam)
+}
@@ -0,0 +1,513 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	externalConfigRunType = "CONFIG"
+
+	importLogEntityImported = "ENTITY_IMPORTED"
+	importLogEntitySkipped  = "ENTITY_SKIPPED"
+	importLogEntityFailed   = "ENTITY_FAILED"
+	importLogRunComplete    = "RUN_COMPLETE"
+
+	// import_logs.message is a varchar(255)
+	importLogMessageMaxLength = 255
+)
+
+type ExternalConfigImportStatus string
+
+const (
+	ExternalConfigImportStatusPending   ExternalConfigImportStatus = "pending"
+	ExternalConfigImportStatusCompleted ExternalConfigImportStatus = "completed"
+	ExternalConfigImportStatusFailed    ExternalConfigImportStatus = "failed" // The payload could not be parsed
+)
+
+// ExternalConfig is the documented structure of the payload accepted by ImportExternalConfig. Converters for other
+// ticket bots produce it from the other bot's export. IDs are the other bot's IDs, which only need to be unique per
+// entity type within the guild and source: they are recorded in import_mapping under the source passed to
+// ImportExternalConfig, so that importing the same entity again is skipped, and are used to resolve references between
+// entities, e.g. from a panel to its form. Teams are imported first, then forms, then panels.
+//
+//	{
+//	  "teams": [{"id": 1, "name": "Support", "member_ids": [123]}],
+//	  "forms": [{"id": 1, "title": "Report", "inputs": [{"id": 1, "label": "What happened?", "style": 2, "required": true}]}],
+//	  "panels": [{"id": 1, "channel_id": "456", "title": "Open a ticket", "content": "...", "colour": 3066993,
+//	    "button_label": "Open", "form_id": 1, "team_ids": [1]}]
+//	}
+type ExternalConfig struct {
+	Teams  []ExternalTeam  `json:"teams"`
+	Forms  []ExternalForm  `json:"forms"`
+	Panels []ExternalPanel `json:"panels"`
+}
+
+type ExternalTeam struct {
+	Id        int      `json:"id"`
+	Name      string   `json:"name"`
+	MemberIds []uint64 `json:"member_ids"`
+}
+
+type ExternalForm struct {
+	Id     int                 `json:"id"`
+	Title  string              `json:"title"`
+	Inputs []ExternalFormInput `json:"inputs"`
+}
+
+type ExternalFormInput struct {
+	Id          int     `json:"id"`
+	Label       string  `json:"label"`
+	Description *string `json:"description"`
+	Placeholder *string `json:"placeholder"`
+	Style       uint8   `json:"style"` // 1 = short, 2 = paragraph
+	Required    bool    `json:"required"`
+	MinLength   *uint16 `json:"min_length"`
+	MaxLength   *uint16 `json:"max_length"`
+}
+
+type ExternalPanel struct {
+	Id           int     `json:"id"`
+	ChannelId    uint64  `json:"channel_id,string"`
+	Title        string  `json:"title"`
+	Content      string  `json:"content"`
+	Colour       int32   `json:"colour"`
+	CategoryId   *uint64 `json:"category_id,string"`
+	ButtonLabel  string  `json:"button_label"`
+	ButtonStyle  int     `json:"button_style"` // Defaults to 1 (primary)
+	EmojiName    *string `json:"emoji_name"`
+	EmojiId      *uint64 `json:"emoji_id,string"`
+	NamingScheme *string `json:"naming_scheme"`
+	FormId       *int    `json:"form_id"`  // ID of a form in the same payload, or a previous import
+	TeamIds      []int   `json:"team_ids"` // IDs of teams in the same payload, or a previous import. Empty for the default team.
+}
+
+type ExternalConfigImport struct {
+	Id          int64                      `json:"id"`
+	GuildId     uint64                     `json:"guild_id,string"`
+	Source      string                     `json:"source"`
+	Status      ExternalConfigImportStatus `json:"status"`
+	RunId       *int                       `json:"run_id"`
+	Imported    int                        `json:"imported"`
+	Skipped     int                        `json:"skipped"`
+	Failed      int                        `json:"failed"`
+	Error       *string                    `json:"error"`
+	CreatedAt   time.Time                  `json:"created_at"`
+	CompletedAt *time.Time                 `json:"completed_at"`
+}
+
+// ExternalConfigImportsTable stages the payloads passed to ImportExternalConfig, along with the outcome of each import.
+type ExternalConfigImportsTable struct {
+	*pgxpool.Pool
+}
+
+func newExternalConfigImportsTable(db *pgxpool.Pool) *ExternalConfigImportsTable {
+	return &ExternalConfigImportsTable{
+		db,
+	}
+}
+
+func (e ExternalConfigImportsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS external_config_imports(
+	"id" BIGSERIAL NOT NULL,
+	"guild_id" int8 NOT NULL,
+	"source" varchar(32) NOT NULL,
+	"payload" jsonb NOT NULL,
+	"status" varchar(16) NOT NULL DEFAULT 'pending',
+	"run_id" int4 DEFAULT NULL,
+	"imported" int4 NOT NULL DEFAULT 0,
+	"skipped" int4 NOT NULL DEFAULT 0,
+	"failed" int4 NOT NULL DEFAULT 0,
+	"error" text DEFAULT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"completed_at" timestamptz DEFAULT NULL,
+	CHECK("status" IN ('pending', 'completed', 'failed')),
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS external_config_imports_guild_id ON external_config_imports("guild_id", "created_at" DESC);
+`
+}
+
+func (e *ExternalConfigImportsTable) Get(ctx context.Context, id int64) (ExternalConfigImport, bool, error) {
+	query := `
+SELECT "id", "guild_id", "source", "status", "run_id", "imported", "skipped", "failed", "error", "created_at", "completed_at"
+FROM external_config_imports
+WHERE "id" = $1;`
+
+	var configImport ExternalConfigImport
+	if err := e.QueryRow(ctx, query, id).Scan(configImport.fieldPtrs()...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ExternalConfigImport{}, false, nil
+		} else {
+			return ExternalConfigImport{}, false, err
+		}
+	}
+
+	return configImport, true, nil
+}
+
+func (e *ExternalConfigImportsTable) GetByGuild(ctx context.Context, guildId uint64) ([]ExternalConfigImport, error) {
+	query := `
+SELECT "id", "guild_id", "source", "status", "run_id", "imported", "skipped", "failed", "error", "created_at", "completed_at"
+FROM external_config_imports
+WHERE "guild_id" = $1
+ORDER BY "created_at" DESC;`
+
+	rows, err := e.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var imports []ExternalConfigImport
+	for rows.Next() {
+		var configImport ExternalConfigImport
+		if err := rows.Scan(configImport.fieldPtrs()...); err != nil {
+			return nil, err
+		}
+
+		imports = append(imports, configImport)
+	}
+
+	return imports, nil
+}
+
+func (i *ExternalConfigImport) fieldPtrs() []interface{} {
+	return []interface{}{
+		&i.Id,
+		&i.GuildId,
+		&i.Source,
+		&i.Status,
+		&i.RunId,
+		&i.Imported,
+		&i.Skipped,
+		&i.Failed,
+		&i.Error,
+		&i.CreatedAt,
+		&i.CompletedAt,
+	}
+}
+
+// externalConfigImporter holds the state of a single ImportExternalConfig call.
+type externalConfigImporter struct {
+	d             *Database
+	guildId       uint64
+	ownerId       uint64
+	source        string
+	gracePeriod   time.Duration
+	includeVoting bool
+	runId         int
+	mapping       map[string]map[int]int
+	result        ExternalConfigImport
+}
+
+// ImportExternalConfig stages the payload, which must be an ExternalConfig, and converts it into teams, forms and
+// panels. Each entity is imported in its own transaction, so an invalid entity does not prevent the rest from being
+// imported; the outcome of each is logged to import_logs under a CONFIG run. Entities already imported by a previous
+// run from the same source, according to import_mapping, are skipped. Forms and panels that would exceed the guild's
+// limits, as checked by CheckLimit with ownerId, gracePeriod and includeVoting, fail to import. Imported panels have no
+// message ID, and must be sent by the caller. Returns the staged import, with its counts and status.
+func (d *Database) ImportExternalConfig(ctx context.Context, guildId, ownerId uint64, source string, payload []byte, gracePeriod time.Duration, includeVoting bool) (ExternalConfigImport, error) {
+	importer := &externalConfigImporter{
+		d:             d,
+		guildId:       guildId,
+		ownerId:       ownerId,
+		source:        source,
+		gracePeriod:   gracePeriod,
+		includeVoting: includeVoting,
+		result: ExternalConfigImport{
+			GuildId: guildId,
+			Source:  source,
+			Status:  ExternalConfigImportStatusPending,
+		},
+	}
+
+	stageQuery := `
+INSERT INTO external_config_imports("guild_id", "source", "payload")
+VALUES($1, $2, $3)
+RETURNING "id", "created_at";`
+
+	if err := d.pool.QueryRow(ctx, stageQuery, guildId, source, payload).Scan(&importer.result.Id, &importer.result.CreatedAt); err != nil {
+		return ExternalConfigImport{}, err
+	}
+
+	var config ExternalConfig
+	if err := json.Unmarshal(payload, &config); err != nil {
+		message := err.Error()
+		importer.result.Status = ExternalConfigImportStatusFailed
+		importer.result.Error = &message
+
+		if err := importer.finish(ctx); err != nil {
+			return ExternalConfigImport{}, err
+		}
+
+		return importer.result, nil
+	}
+
+	runId, err := d.ImportLogs.CreateRun(ctx, guildId, externalConfigRunType)
+	if err != nil {
+		return ExternalConfigImport{}, err
+	}
+
+	importer.runId = runId
+	importer.result.RunId = &runId
+
+	importer.mapping, err = d.ImportMappingTable.GetMappingForSource(ctx, guildId, source)
+	if err != nil {
+		return ExternalConfigImport{}, err
+	}
+
+	for _, team := range config.Teams {
+		if err := importer.importEntity(ctx, "team", team.Id, func(tx pgx.Tx) (int, error) {
+			return importer.importTeam(ctx, tx, team)
+		}); err != nil {
+			return ExternalConfigImport{}, err
+		}
+	}
+
+	for _, form := range config.Forms {
+		if err := importer.importEntity(ctx, "form", form.Id, func(tx pgx.Tx) (int, error) {
+			return importer.importForm(ctx, tx, form)
+		}); err != nil {
+			return ExternalConfigImport{}, err
+		}
+	}
+
+	for _, panel := range config.Panels {
+		if err := importer.importEntity(ctx, "panel", panel.Id, func(tx pgx.Tx) (int, error) {
+			return importer.importPanel(ctx, tx, panel)
+		}); err != nil {
+			return ExternalConfigImport{}, err
+		}
+	}
+
+	if err := d.ImportLogs.AddLog(ctx, guildId, runId, externalConfigRunType, importLogRunComplete, "", ""); err != nil {
+		return ExternalConfigImport{}, err
+	}
+
+	importer.result.Status = ExternalConfigImportStatusCompleted
+	if err := importer.finish(ctx); err != nil {
+		return ExternalConfigImport{}, err
+	}
+
+	return importer.result, nil
+}
+
+// importEntity imports a single entity in its own transaction, recording its mapping and logging the outcome. Errors
+// importing the entity are logged rather than returned; only errors writing the log are returned.
+func (i *externalConfigImporter) importEntity(ctx context.Context, area string, sourceId int, f func(tx pgx.Tx) (int, error)) error {
+	if targetId, ok := i.mapping[area][sourceId]; ok {
+		i.result.Skipped++
+		return i.log(ctx, importLogEntitySkipped, area, fmt.Sprintf("%s %d was already imported as %d", area, sourceId, targetId))
+	}
+
+	var targetId int
+	err := i.d.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		targetId, err = f(tx)
+		if err != nil {
+			return err
+		}
+
+		return setImportMappingTx(ctx, tx, i.guildId, area, sourceId, targetId, i.source)
+	})
+
+	if err != nil {
+		i.result.Failed++
+		return i.log(ctx, importLogEntityFailed, area, fmt.Sprintf("%s %d: %s", area, sourceId, err.Error()))
+	}
+
+	if _, ok := i.mapping[area]; !ok {
+		i.mapping[area] = make(map[int]int)
+	}
+
+	i.mapping[area][sourceId] = targetId
+	i.result.Imported++
+
+	return i.log(ctx, importLogEntityImported, area, fmt.Sprintf("%s %d imported as %d", area, sourceId, targetId))
+}
+
+// importTeam creates the team, or reuses an existing team with the same name, and adds the members.
+func (i *externalConfigImporter) importTeam(ctx context.Context, tx pgx.Tx, team ExternalTeam) (int, error) {
+	query := `
+INSERT INTO support_team("guild_id", "name")
+VALUES($1, $2)
+ON CONFLICT("guild_id", "name") DO UPDATE SET "name" = EXCLUDED."name"
+RETURNING "id";`
+
+	var teamId int
+	if err := tx.QueryRow(ctx, query, i.guildId, team.Name).Scan(&teamId); err != nil {
+		return 0, err
+	}
+
+	for _, userId := range team.MemberIds {
+		memberQuery := `INSERT INTO support_team_members("team_id", "user_id") VALUES($1, $2) ON CONFLICT DO NOTHING;`
+		if _, err := tx.Exec(ctx, memberQuery, teamId, userId); err != nil {
+			return 0, err
+		}
+	}
+
+	return teamId, nil
+}
+
+func (i *externalConfigImporter) importForm(ctx context.Context, tx pgx.Tx, form ExternalForm) (int, error) {
+	if err := i.checkLimit(ctx, tx, LimitResourceForms); err != nil {
+		return 0, err
+	}
+
+	query := `
+INSERT INTO forms("guild_id", "title", "custom_id")
+VALUES($1, $2, $3)
+RETURNING "form_id";`
+
+	customId := newImportCustomId()
+
+	var formId int
+	if err := tx.QueryRow(ctx, query, i.guildId, form.Title, customId).Scan(&formId); err != nil {
+		return 0, err
+	}
+
+	for position, input := range form.Inputs {
+		style := input.Style
+		if style == 0 {
+			style = 1
+		}
+
+		// Type 4 is a text input
+		inputId, err := i.d.FormInput.CreateTx(
+			ctx,
+			tx,
+			formId,
+			4,
+			newImportCustomId(),
+			position+1,
+			style,
+			input.Label,
+			input.Description,
+			input.Placeholder,
+			input.Required,
+			input.MinLength,
+			input.MaxLength,
+		)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := setImportMappingTx(ctx, tx, i.guildId, "form_input", input.Id, inputId, i.source); err != nil {
+			return 0, err
+		}
+	}
+
+	newForm := Form{Id: formId, GuildId: i.guildId, Title: form.Title, CustomId: customId}
+	if err := recordAudit(ctx, tx, i.guildId, AuditActionFormCreate, AuditResourceForm, auditResourceId(formId), newForm); err != nil {
+		return 0, err
+	}
+
+	return formId, nil
+}
+
+func (i *externalConfigImporter) importPanel(ctx context.Context, tx pgx.Tx, panel ExternalPanel) (int, error) {
+	if err := i.checkLimit(ctx, tx, LimitResourcePanels); err != nil {
+		return 0, err
+	}
+
+	var formId *int
+	if panel.FormId != nil {
+		mapped, ok := i.mapping["form"][*panel.FormId]
+		if !ok {
+			return 0, fmt.Errorf("form %d has not been imported", *panel.FormId)
+		}
+
+		formId = &mapped
+	}
+
+	teamIds := make([]int, len(panel.TeamIds))
+	for j, sourceId := range panel.TeamIds {
+		mapped, ok := i.mapping["team"][sourceId]
+		if !ok {
+			return 0, fmt.Errorf("team %d has not been imported", sourceId)
+		}
+
+		teamIds[j] = mapped
+	}
+
+	buttonStyle := panel.ButtonStyle
+	if buttonStyle == 0 {
+		buttonStyle = 1
+	}
+
+	panelId, err := i.d.Panel.CreateWithTx(ctx, tx, Panel{
+		ChannelId:       panel.ChannelId,
+		GuildId:         i.guildId,
+		Title:           panel.Title,
+		Content:         panel.Content,
+		Colour:          panel.Colour,
+		TargetCategory:  NullSnowflakeFromPtr(panel.CategoryId),
+		EmojiName:       panel.EmojiName,
+		EmojiId:         panel.EmojiId,
+		WithDefaultTeam: len(teamIds) == 0,
+		CustomId:        newImportCustomId(),
+		ButtonStyle:     buttonStyle,
+		ButtonLabel:     panel.ButtonLabel,
+		FormId:          formId,
+		NamingScheme:    panel.NamingScheme,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(teamIds) > 0 {
+		if err := i.d.PanelTeams.ReplaceWithTx(ctx, tx, panelId, teamIds); err != nil {
+			return 0, err
+		}
+	}
+
+	return panelId, nil
+}
+
+func (i *externalConfigImporter) checkLimit(ctx context.Context, tx pgx.Tx, resource LimitResource) error {
+	return i.d.CheckLimitTx(ctx, tx, i.guildId, i.ownerId, resource, i.gracePeriod, i.includeVoting)
+}
+
+func (i *externalConfigImporter) log(ctx context.Context, logType, entityType, message string) error {
+	if len(message) > importLogMessageMaxLength {
+		message = message[:importLogMessageMaxLength]
+	}
+
+	return i.d.ImportLogs.AddLog(ctx, i.guildId, i.runId, externalConfigRunType, logType, entityType, message)
+}
+
+func (i *externalConfigImporter) finish(ctx context.Context) error {
+	query := `
+UPDATE external_config_imports
+SET "status" = $2, "run_id" = $3, "imported" = $4, "skipped" = $5, "failed" = $6, "error" = $7, "completed_at" = NOW()
+WHERE "id" = $1
+RETURNING "completed_at";`
+
+	return i.d.pool.QueryRow(ctx, query,
+		i.result.Id,
+		i.result.Status,
+		i.result.RunId,
+		i.result.Imported,
+		i.result.Skipped,
+		i.result.Failed,
+		i.result.Error,
+	).Scan(&i.result.CompletedAt)
+}
+
+func setImportMappingTx(ctx context.Context, tx pgx.Tx, guildId uint64, area string, sourceId, targetId int, source string) (err error) {
+	_, err = tx.Exec(ctx, importMappingSet, guildId, area, sourceId, targetId, source)
+	return
+}
+
+func newImportCustomId() string {
+	return strings.ReplaceAll(uuid.NewString(), "-", "")
+}
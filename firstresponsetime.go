@@ -100,8 +100,97 @@ func (f *FirstResponseTime) GetAverageAllTimeUser(ctx context.Context, guildId,
 	return
 }
 
+// Deprecated: use RecordIfAbsent, which reports whether the response time was recorded.
 func (f *FirstResponseTime) Set(ctx context.Context, guildId, userId uint64, ticketId int, responseTime time.Duration) (err error) {
 	query := `INSERT INTO first_response_time("guild_id", "ticket_id", "user_id", "response_time") VALUES($1, $2, $3, $4) ON CONFLICT("guild_id", "ticket_id") DO NOTHING;`
 	_, err = f.Exec(ctx, query, guildId, ticketId, userId, responseTime)
 	return
 }
+
+// RecordIfAbsent records the ticket's first response time, unless one has already been recorded. If two staff members
+// respond simultaneously, only one call wins; won is true for that call only.
+func (f *FirstResponseTime) RecordIfAbsent(ctx context.Context, guildId uint64, ticketId int, userId uint64, responseTime time.Duration) (won bool, err error) {
+	query := `
+INSERT INTO first_response_time("guild_id", "ticket_id", "user_id", "response_time")
+VALUES($1, $2, $3, $4)
+ON CONFLICT("guild_id", "ticket_id") DO NOTHING;`
+
+	res, err := f.Exec(ctx, query, guildId, ticketId, userId, responseTime)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// GetMissing returns the IDs of up to limit of the guild's closed tickets that have a transcript but no first response
+// time, newest first, for backfilling with BackfillFromMessages.
+func (f *FirstResponseTime) GetMissing(ctx context.Context, guildId uint64, limit int) ([]int, error) {
+	query := `
+SELECT tickets.id
+FROM tickets
+WHERE tickets.guild_id = $1 AND NOT tickets.open AND tickets.has_transcript AND NOT EXISTS(
+	SELECT 1
+	FROM first_response_time
+	WHERE first_response_time.guild_id = tickets.guild_id AND first_response_time.ticket_id = tickets.id
+)
+ORDER BY tickets.id DESC
+LIMIT $2;`
+
+	rows, err := f.Query(ctx, query, guildId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ticketIds []int
+	for rows.Next() {
+		var ticketId int
+		if err := rows.Scan(&ticketId); err != nil {
+			return nil, err
+		}
+
+		ticketIds = append(ticketIds, ticketId)
+	}
+
+	return ticketIds, nil
+}
+
+// ArchivedStaffMessage is a message sent by a staff member, read from a ticket's archived transcript.
+type ArchivedStaffMessage struct {
+	UserId uint64
+	SentAt time.Time
+}
+
+// BackfillFromMessages records the ticket's first response time from the staff messages in its transcript, if none has
+// been recorded. The earliest message sent after the ticket was opened by someone other than the ticket opener is
+// used. Returns whether a response time was recorded.
+func (f *FirstResponseTime) BackfillFromMessages(ctx context.Context, guildId uint64, ticketId int, messages []ArchivedStaffMessage) (bool, error) {
+	if len(messages) == 0 {
+		return false, nil
+	}
+
+	userIds := make([]uint64, len(messages))
+	sentAt := make([]time.Time, len(messages))
+	for i, message := range messages {
+		userIds[i] = message.UserId
+		sentAt[i] = message.SentAt
+	}
+
+	query := `
+INSERT INTO first_response_time("guild_id", "ticket_id", "user_id", "response_time")
+SELECT tickets.guild_id, tickets.id, messages.user_id, messages.sent_at - tickets.open_time
+FROM tickets
+CROSS JOIN unnest($3::int8[], $4::timestamptz[]) AS messages(user_id, sent_at)
+WHERE tickets.guild_id = $1 AND tickets.id = $2 AND messages.sent_at >= tickets.open_time AND messages.user_id <> tickets.user_id
+ORDER BY messages.sent_at ASC
+LIMIT 1
+ON CONFLICT("guild_id", "ticket_id") DO NOTHING;`
+
+	res, err := f.Exec(ctx, query, guildId, ticketId, userIds, sentAt)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
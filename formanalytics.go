@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+type FormAnalytics struct {
+	FormId int `json:"form_id"`
+	// Number of tickets that submitted the form
+	Submissions int `json:"submissions"`
+	// Average time between the ticket closing and the form being submitted. Nil if there are no submissions.
+	AverageSubmissionTime *time.Duration `json:"average_submission_time"`
+	// Input ID -> answer -> count, for select inputs only
+	AnswerDistribution map[int]map[string]int `json:"answer_distribution"`
+	// Drafts that expired before being submitted, and have not yet been pruned
+	AbandonedDrafts int `json:"abandoned_drafts"`
+	// Drafts that have not yet expired
+	InProgressDrafts int `json:"in_progress_drafts"`
+}
+
+// GetFormAnalytics aggregates the exit survey responses submitted to the form within the last period, and the form's
+// drafts last updated within the period. Responses submitted before submission times were recorded are not counted.
+func (d *Database) GetFormAnalytics(ctx context.Context, formId int, period time.Duration) (FormAnalytics, error) {
+	summaryQuery := `
+WITH submissions AS (
+	SELECT "guild_id", "ticket_id", MIN("submitted_at") AS "submitted_at"
+	FROM exit_survey_responses
+	WHERE "form_id" = $1 AND "submitted_at" > NOW() - $2::interval
+	GROUP BY "guild_id", "ticket_id"
+)
+SELECT
+	(SELECT COUNT(*) FROM submissions),
+	(
+		SELECT AVG(EXTRACT(EPOCH FROM (submissions."submitted_at" - tickets."close_time")))
+		FROM submissions
+		INNER JOIN tickets ON tickets."guild_id" = submissions."guild_id" AND tickets."id" = submissions."ticket_id"
+		WHERE tickets."close_time" IS NOT NULL AND submissions."submitted_at" >= tickets."close_time"
+	),
+	(
+		SELECT COUNT(*) FROM form_drafts
+		WHERE "form_id" = $1 AND "updated_at" > NOW() - $2::interval AND "expires_at" <= NOW()
+	),
+	(
+		SELECT COUNT(*) FROM form_drafts
+		WHERE "form_id" = $1 AND "updated_at" > NOW() - $2::interval AND "expires_at" > NOW()
+	);`
+
+	analytics := FormAnalytics{
+		FormId:             formId,
+		AnswerDistribution: make(map[int]map[string]int),
+	}
+
+	var averageSeconds *float64
+	if err := d.pool.QueryRow(ctx, summaryQuery, formId, period).Scan(
+		&analytics.Submissions,
+		&averageSeconds,
+		&analytics.AbandonedDrafts,
+		&analytics.InProgressDrafts,
+	); err != nil {
+		return FormAnalytics{}, err
+	}
+
+	if averageSeconds != nil {
+		average := time.Duration(*averageSeconds * float64(time.Second))
+		analytics.AverageSubmissionTime = &average
+	}
+
+	// Type 3 is a string select menu
+	distributionQuery := `
+SELECT exit_survey_responses."question_id", exit_survey_responses."response", COUNT(*)
+FROM exit_survey_responses
+INNER JOIN form_input ON form_input."id" = exit_survey_responses."question_id"
+WHERE
+	exit_survey_responses."form_id" = $1
+	AND exit_survey_responses."submitted_at" > NOW() - $2::interval
+	AND exit_survey_responses."response" IS NOT NULL
+	AND form_input."type" = 3
+GROUP BY exit_survey_responses."question_id", exit_survey_responses."response";`
+
+	rows, err := d.pool.Query(ctx, distributionQuery, formId, period)
+	if err != nil {
+		return FormAnalytics{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var inputId, count int
+		var answer string
+		if err := rows.Scan(&inputId, &answer, &count); err != nil {
+			return FormAnalytics{}, err
+		}
+
+		if _, ok := analytics.AnswerDistribution[inputId]; !ok {
+			analytics.AnswerDistribution[inputId] = make(map[string]int)
+		}
+
+		analytics.AnswerDistribution[inputId][answer] = count
+	}
+
+	return analytics, nil
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type FormDraft struct {
+	GuildId   uint64            `json:"guild_id,string"`
+	UserId    uint64            `json:"user_id,string"`
+	FormId    int               `json:"form_id"`
+	Answers   map[string]string `json:"answers"` // input custom_id -> value
+	UpdatedAt time.Time         `json:"updated_at"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+type FormDraftsTable struct {
+	*pgxpool.Pool
+}
+
+func newFormDraftsTable(db *pgxpool.Pool) *FormDraftsTable {
+	return &FormDraftsTable{
+		db,
+	}
+}
+
+func (f FormDraftsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS form_drafts(
+	"guild_id" int8 NOT NULL,
+	"user_id" int8 NOT NULL,
+	"form_id" int NOT NULL,
+	"answers" JSONB NOT NULL,
+	"updated_at" timestamptz NOT NULL DEFAULT NOW(),
+	"expires_at" timestamptz NOT NULL,
+	FOREIGN KEY("form_id") REFERENCES forms("form_id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "user_id", "form_id")
+);
+CREATE INDEX IF NOT EXISTS form_drafts_expires_at ON form_drafts("expires_at");
+`
+}
+
+// Get returns the draft only if it has not yet expired.
+func (f *FormDraftsTable) Get(ctx context.Context, guildId, userId uint64, formId int) (FormDraft, bool, error) {
+	query := `
+SELECT "guild_id", "user_id", "form_id", "answers", "updated_at", "expires_at"
+FROM form_drafts
+WHERE "guild_id" = $1 AND "user_id" = $2 AND "form_id" = $3 AND "expires_at" > NOW();`
+
+	var draft FormDraft
+	var answersRaw string
+	if err := f.QueryRow(ctx, query, guildId, userId, formId).Scan(
+		&draft.GuildId,
+		&draft.UserId,
+		&draft.FormId,
+		&answersRaw,
+		&draft.UpdatedAt,
+		&draft.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return FormDraft{}, false, nil
+		} else {
+			return FormDraft{}, false, err
+		}
+	}
+
+	if err := json.Unmarshal([]byte(answersRaw), &draft.Answers); err != nil {
+		return FormDraft{}, false, err
+	}
+
+	return draft, true, nil
+}
+
+func (f *FormDraftsTable) Save(ctx context.Context, guildId, userId uint64, formId int, answers map[string]string, expiresAt time.Time) error {
+	query := `
+INSERT INTO form_drafts("guild_id", "user_id", "form_id", "answers", "updated_at", "expires_at")
+VALUES($1, $2, $3, $4, NOW(), $5)
+ON CONFLICT("guild_id", "user_id", "form_id") DO UPDATE
+SET "answers" = EXCLUDED."answers", "updated_at" = NOW(), "expires_at" = EXCLUDED."expires_at";`
+
+	if answers == nil {
+		answers = make(map[string]string)
+	}
+
+	answersRaw, err := json.Marshal(answers)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Exec(ctx, query, guildId, userId, formId, string(answersRaw), expiresAt)
+	return err
+}
+
+func (f *FormDraftsTable) Delete(ctx context.Context, guildId, userId uint64, formId int) (err error) {
+	query := `DELETE FROM form_drafts WHERE "guild_id" = $1 AND "user_id" = $2 AND "form_id" = $3;`
+	_, err = f.Exec(ctx, query, guildId, userId, formId)
+	return
+}
+
+// DeleteExpired removes all expired drafts, returning the number of drafts removed.
+func (f *FormDraftsTable) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `DELETE FROM form_drafts WHERE "expires_at" <= NOW();`
+
+	res, err := f.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
@@ -164,31 +164,15 @@ func (f *FormInputOptionTable) CreateTx(ctx context.Context, tx pgx.Tx, formInpu
 }
 
 func (f *FormInputOptionTable) Delete(ctx context.Context, id int) (e error) {
-	q := `WITH deleted_positions AS (
-		DELETE FROM form_input_option
-		WHERE id = $1
-		RETURNING form_input_id, position
-	)
-	UPDATE form_input_option
-	SET position = position - 1
-	WHERE form_input_id = (SELECT form_input_id FROM deleted_positions)
-	AND position > (SELECT position FROM deleted_positions);`
-
-	_, err := f.Exec(ctx, q, id)
-	return err
+	return deleteAndShiftPositions(ctx, f.Pool, "form_input_option", "form_input_id", "id", id)
 }
 
 func (f *FormInputOptionTable) DeleteTx(ctx context.Context, tx pgx.Tx, id int) (e error) {
-	q := `WITH deleted_positions AS (
-		DELETE FROM form_input_option
-		WHERE id = $1
-		RETURNING form_input_id, position
-	)
-	UPDATE form_input_option
-	SET position = position - 1
-	WHERE form_input_id = (SELECT form_input_id FROM deleted_positions)
-	AND position > (SELECT position FROM deleted_positions);`
-
-	_, err := tx.Exec(ctx, q, id)
-	return err
+	return deleteAndShiftPositions(ctx, tx, "form_input_option", "form_input_id", "id", id)
+}
+
+// ReorderTx sets the positions of the input's options to match the order of optionIds, which must contain every option
+// of the input.
+func (f *FormInputOptionTable) ReorderTx(ctx context.Context, tx pgx.Tx, formInputId int, optionIds []int) error {
+	return reorderPositions(ctx, tx, "form_input_option", "form_input_id", "id", formInputId, optionIds)
 }
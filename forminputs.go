@@ -3,7 +3,6 @@ package database
 import (
 	"context"
 	"errors"
-	"fmt"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
@@ -264,19 +263,18 @@ func (f *FormInputTable) UpdateTx(ctx context.Context, tx pgx.Tx, input FormInpu
 	return
 }
 
-// TODO: Remove this function. It is unused.
+// Swap exchanges the positions of two inputs of the same form.
 func (f *FormInputTable) Swap(ctx context.Context, inputId, otherId int) error {
-	query := `
-	UPDATE form_input
-	SET position = CASE
-	WHEN id = $1 THEN (SELECT position FROM form_input WHERE id = $2)
-	ELSE (SELECT position FROM form_input WHERE id = $1)
-	END
-	WHERE id in ($1,$2);
-	`
+	return f.reorderInputs(ctx, inputId, func(ids []int, index int) bool {
+		for otherIndex, id := range ids {
+			if id == otherId {
+				ids[index], ids[otherIndex] = ids[otherIndex], ids[index]
+				return true
+			}
+		}
 
-	_, err := f.Exec(ctx, query, inputId, otherId)
-	return err
+		return false
+	})
 }
 
 type InputSwapDirection int
@@ -286,68 +284,95 @@ const (
 	SwapDirectionUp
 )
 
-func (d InputSwapDirection) operator() string {
-	switch d {
-	case SwapDirectionUp:
-		return "<"
-	case SwapDirectionDown:
-		return ">"
+// SwapDirection exchanges the position of the input with the input before it (SwapDirectionUp) or after it
+// (SwapDirectionDown). Nothing is changed if there is no such input.
+func (f *FormInputTable) SwapDirection(ctx context.Context, inputId, formId int, direction InputSwapDirection) error {
+	return f.reorderInputsInForm(ctx, formId, inputId, func(ids []int, index int) bool {
+		otherIndex := index + 1
+		if direction == SwapDirectionUp {
+			otherIndex = index - 1
+		}
+
+		if otherIndex < 0 || otherIndex >= len(ids) {
+			return false
+		}
+
+		ids[index], ids[otherIndex] = ids[otherIndex], ids[index]
+		return true
+	})
+}
+
+// reorderInputs calls reorderInputsInForm with the form the input belongs to.
+func (f *FormInputTable) reorderInputs(ctx context.Context, inputId int, reorder func(ids []int, index int) bool) error {
+	var formId int
+	if err := f.QueryRow(ctx, `SELECT "form_id" FROM form_input WHERE "id" = $1;`, inputId).Scan(&formId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+
+		return err
 	}
 
-	return ""
+	return f.reorderInputsInForm(ctx, formId, inputId, reorder)
 }
 
-// TODO: Remove this function. It is unused.
-func (f *FormInputTable) SwapDirection(ctx context.Context, inputId, formId int, direction InputSwapDirection) error {
-	query := fmt.Sprintf(`
-	WITH next AS (
-	SELECT id, position
-	FROM form_input
-	WHERE form_id = $2 AND position %s (SELECT position FROM form_input WHERE id = $1)
-	ORDER BY position DESC
-	LIMIT 1
-	)
-	UPDATE form_input
-	SET position = CASE
-	WHEN form_input.id = $1 THEN next.position
-	ELSE (SELECT position FROM form_input WHERE form_input.id =$1)
-	END
-	FROM next
-	WHERE form_input.id in ($1, next.id);
-	`, direction.operator())
-
-	_, err := f.Exec(ctx, query, inputId, formId)
-	return err
+// reorderInputsInForm calls reorder with the IDs of the form's inputs in order, and the index of the input, and saves
+// the new order with reorderPositions if it returns true. Nothing is changed if the input is not part of the form.
+func (f *FormInputTable) reorderInputsInForm(ctx context.Context, formId, inputId int, reorder func(ids []int, index int) bool) error {
+	tx, err := f.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `SELECT "id" FROM form_input WHERE "form_id" = $1 ORDER BY "position" ASC FOR UPDATE;`, formId)
+	if err != nil {
+		return err
+	}
+
+	var ids []int
+	index := -1
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+
+		if id == inputId {
+			index = len(ids)
+		}
+
+		ids = append(ids, id)
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if index == -1 || !reorder(ids, index) {
+		return nil
+	}
+
+	if err := reorderPositions(ctx, tx, "form_input", "form_id", "id", formId, ids); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (f *FormInputTable) Delete(ctx context.Context, formInputId, formId int) (err error) {
-	query := `
-	WITH deleted_position AS (
-	DELETE FROM form_input
-	WHERE "id" = $1 AND "form_id" = $2
-	RETURNING "position"
-	)
-	UPDATE form_input
-	SET position = position-1
-	WHERE form_id = $2 AND position>(SELECT position FROM deleted_position);
-	`
-
-	_, err = f.Exec(ctx, query, formInputId, formId)
-	return
+	return deleteAndShiftPositionsInScope(ctx, f.Pool, "form_input", "form_id", "id", formId, formInputId)
 }
 
 func (f *FormInputTable) DeleteTx(ctx context.Context, tx pgx.Tx, formInputId, formId int) (err error) {
-	query := `
-	WITH deleted_position AS (
-	DELETE FROM form_input
-	WHERE "id" = $1 AND "form_id" = $2
-	RETURNING "position"
-	)
-	UPDATE form_input
-	SET position = position-1
-	WHERE form_id = $2 AND position>(SELECT position FROM deleted_position)
-`
+	return deleteAndShiftPositionsInScope(ctx, tx, "form_input", "form_id", "id", formId, formInputId)
+}
 
-	_, err = tx.Exec(ctx, query, formInputId, formId)
-	return
+// ReorderTx sets the positions of the form's inputs to match the order of inputIds, which must contain every input of
+// the form.
+func (f *FormInputTable) ReorderTx(ctx context.Context, tx pgx.Tx, formId int, inputIds []int) error {
+	return reorderPositions(ctx, tx, "form_input", "form_id", "id", formId, inputIds)
 }
@@ -2,15 +2,18 @@ package database
 
 import (
 	"context"
+	"time"
+
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 type Form struct {
-	Id       int    `json:"form_id"`
-	GuildId  uint64 `json:"guild_id,string"`
-	Title    string `json:"title"`
-	CustomId string `json:"custom_id"`
+	Id        int        `json:"form_id"`
+	GuildId   uint64     `json:"guild_id,string"`
+	Title     string     `json:"title"`
+	CustomId  string     `json:"custom_id"`
+	DeletedAt *time.Time `json:"deleted_at"` // Set if the form has been soft deleted
 }
 
 type FormsTable struct {
@@ -30,16 +33,19 @@ CREATE TABLE IF NOT EXISTS forms(
 	"guild_id" int8 NOT NULL,
 	"title" VARCHAR(255) NOT NULL,
     "custom_id" VARCHAR(100) UNIQUE NOT NULL,
+	"deleted_at" timestamptz DEFAULT NULL,
 	PRIMARY KEY("form_id")
 );
 CREATE INDEX IF NOT EXISTS forms_guild_id ON forms("guild_id");
+ALTER TABLE forms ADD COLUMN IF NOT EXISTS "deleted_at" timestamptz DEFAULT NULL;
 `
 }
 
+// Get returns the form even if it has been soft deleted, so that panels still referencing it continue to work.
 func (f *FormsTable) Get(ctx context.Context, formId int) (form Form, ok bool, e error) {
-	query := `SELECT "form_id", "guild_id", "title", "custom_id" FROM forms WHERE "form_id" = $1;`
+	query := `SELECT "form_id", "guild_id", "title", "custom_id", "deleted_at" FROM forms WHERE "form_id" = $1;`
 
-	err := f.QueryRow(ctx, query, formId).Scan(&form.Id, &form.GuildId, &form.Title, &form.CustomId)
+	err := f.QueryRow(ctx, query, formId).Scan(&form.Id, &form.GuildId, &form.Title, &form.CustomId, &form.DeletedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return Form{}, false, nil
@@ -51,17 +57,40 @@ func (f *FormsTable) Get(ctx context.Context, formId int) (form Form, ok bool, e
 	return form, true, nil
 }
 
+// GetForms returns the guild's forms, excluding those that have been soft deleted.
 func (f *FormsTable) GetForms(ctx context.Context, guildId uint64) (forms []Form, e error) {
-	query := `SELECT "form_id", "guild_id", "title", "custom_id" FROM forms WHERE "guild_id" = $1;`
+	query := `SELECT "form_id", "guild_id", "title", "custom_id", "deleted_at" FROM forms WHERE "guild_id" = $1 AND "deleted_at" IS NULL;`
+
+	rows, err := f.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var form Form
+		if err := rows.Scan(&form.Id, &form.GuildId, &form.Title, &form.CustomId, &form.DeletedAt); err != nil {
+			return nil, err
+		}
+
+		forms = append(forms, form)
+	}
+
+	return
+}
+
+func (f *FormsTable) GetDeletedForms(ctx context.Context, guildId uint64) (forms []Form, e error) {
+	query := `SELECT "form_id", "guild_id", "title", "custom_id", "deleted_at" FROM forms WHERE "guild_id" = $1 AND "deleted_at" IS NOT NULL;`
 
 	rows, err := f.Query(ctx, query, guildId)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var form Form
-		if err := rows.Scan(&form.Id, &form.GuildId, &form.Title, &form.CustomId); err != nil {
+		if err := rows.Scan(&form.Id, &form.GuildId, &form.Title, &form.CustomId, &form.DeletedAt); err != nil {
 			return nil, err
 		}
 
@@ -79,7 +108,16 @@ RETURNING "form_id";
 `
 
 	var id int
-	if err := f.QueryRow(ctx, query, guildId, title, customId).Scan(&id); err != nil {
+	err := withAuditTx(ctx, f.Pool, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, guildId, title, customId).Scan(&id); err != nil {
+			return err
+		}
+
+		form := Form{Id: id, GuildId: guildId, Title: title, CustomId: customId}
+		return recordAudit(ctx, tx, guildId, AuditActionFormCreate, AuditResourceForm, auditResourceId(id), form)
+	})
+
+	if err != nil {
 		return 0, err
 	}
 
@@ -87,13 +125,90 @@ RETURNING "form_id";
 }
 
 func (f *FormsTable) UpdateTitle(ctx context.Context, formId int, title string) (err error) {
-	query := `UPDATE forms SET "title" = $1 WHERE "form_id" = $2;`
-	_, err = f.Exec(ctx, query, title, formId)
-	return
+	query := `UPDATE forms SET "title" = $1 WHERE "form_id" = $2 RETURNING "guild_id";`
+	data := map[string]string{"title": title}
+	return execAudited(ctx, f.Pool, AuditActionFormUpdate, AuditResourceForm, auditResourceId(formId), data, query, title, formId)
 }
 
 func (f *FormsTable) Delete(ctx context.Context, formId int) (err error) {
-	query := `DELETE FROM forms WHERE "form_id" = $1;`
-	_, err = f.Exec(ctx, query, formId)
-	return
+	query := `DELETE FROM forms WHERE "form_id" = $1 RETURNING "guild_id";`
+	return execAudited(ctx, f.Pool, AuditActionFormDelete, AuditResourceForm, auditResourceId(formId), nil, query, formId)
+}
+
+// SoftDelete marks the form as deleted, hiding it from GetForms while keeping its inputs, options and API configs so
+// that it can be restored.
+func (f *FormsTable) SoftDelete(ctx context.Context, formId int) (err error) {
+	query := `UPDATE forms SET "deleted_at" = NOW() WHERE "form_id" = $1 AND "deleted_at" IS NULL RETURNING "guild_id";`
+	data := map[string]bool{"soft_deleted": true}
+	return execAudited(ctx, f.Pool, AuditActionFormDelete, AuditResourceForm, auditResourceId(formId), data, query, formId)
+}
+
+// Restore undoes SoftDelete, recording the restoration as an update that clears deleted_at.
+func (f *FormsTable) Restore(ctx context.Context, formId int) (err error) {
+	query := `UPDATE forms SET "deleted_at" = NULL WHERE "form_id" = $1 AND "deleted_at" IS NOT NULL RETURNING "guild_id";`
+	data := map[string]*time.Time{"deleted_at": nil}
+	return execAudited(ctx, f.Pool, AuditActionFormUpdate, AuditResourceForm, auditResourceId(formId), data, query, formId)
+}
+
+// GetPanelsReferencingDeletedForms returns the guild's panels whose form or exit survey form has been soft deleted.
+func (f *FormsTable) GetPanelsReferencingDeletedForms(ctx context.Context, guildId uint64) ([]Panel, error) {
+	query := `
+SELECT
+	panels.panel_id,
+	panels.message_id,
+	panels.channel_id,
+	panels.guild_id,
+	panels.title,
+	panels.content,
+	panels.colour,
+	panels.target_category,
+	panels.emoji_name,
+	panels.emoji_id,
+	panels.welcome_message,
+	panels.default_team,
+	panels.custom_id,
+	panels.image_url,
+	panels.thumbnail_url,
+	panels.button_style,
+	panels.button_label,
+	panels.form_id,
+	panels.naming_scheme,
+	panels.force_disabled,
+	panels.disabled,
+	panels.exit_survey_form_id,
+	panels.pending_category,
+	panels.delete_mentions,
+	panels.transcript_channel_id,
+	panels.use_threads,
+	panels.ticket_notification_channel,
+	panels.cooldown_seconds,
+	panels.ticket_limit,
+	panels.hide_close_button,
+	panels.hide_close_with_reason_button,
+	panels.hide_claim_button
+FROM panels
+WHERE panels.guild_id = $1 AND EXISTS(
+	SELECT 1
+	FROM forms
+	WHERE forms.form_id IN (panels.form_id, panels.exit_survey_form_id) AND forms.deleted_at IS NOT NULL
+)
+ORDER BY panels.panel_id ASC;`
+
+	rows, err := f.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var panels []Panel
+	for rows.Next() {
+		var panel Panel
+		if err := rows.Scan(panel.fieldPtrs()...); err != nil {
+			return nil, err
+		}
+
+		panels = append(panels, panel)
+	}
+
+	return panels, nil
 }
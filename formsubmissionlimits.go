@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type FormSubmissionLimits struct {
+	FormId           int `json:"form_id"`
+	MaxPerUserPerDay int `json:"max_per_user_per_day"`
+}
+
+type FormSubmissionLimitsTable struct {
+	*pgxpool.Pool
+}
+
+func newFormSubmissionLimitsTable(db *pgxpool.Pool) *FormSubmissionLimitsTable {
+	return &FormSubmissionLimitsTable{
+		db,
+	}
+}
+
+func (f FormSubmissionLimitsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS form_submission_limits(
+	"form_id" int NOT NULL,
+	"max_per_user_per_day" int4 NOT NULL,
+	FOREIGN KEY("form_id") REFERENCES forms("form_id") ON DELETE CASCADE,
+	CHECK("max_per_user_per_day" >= 0),
+	PRIMARY KEY("form_id")
+);
+
+CREATE TABLE IF NOT EXISTS form_submission_counts(
+	"form_id" int NOT NULL,
+	"user_id" int8 NOT NULL,
+	"day" date NOT NULL,
+	"count" int4 NOT NULL,
+	FOREIGN KEY("form_id") REFERENCES forms("form_id") ON DELETE CASCADE,
+	PRIMARY KEY("form_id", "user_id", "day")
+);
+CREATE INDEX IF NOT EXISTS form_submission_counts_day ON form_submission_counts("day");
+`
+}
+
+func (f *FormSubmissionLimitsTable) GetLimits(ctx context.Context, formId int) (FormSubmissionLimits, bool, error) {
+	query := `SELECT "form_id", "max_per_user_per_day" FROM form_submission_limits WHERE "form_id" = $1;`
+
+	var limits FormSubmissionLimits
+	if err := f.QueryRow(ctx, query, formId).Scan(&limits.FormId, &limits.MaxPerUserPerDay); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return FormSubmissionLimits{}, false, nil
+		} else {
+			return FormSubmissionLimits{}, false, err
+		}
+	}
+
+	return limits, true, nil
+}
+
+func (f *FormSubmissionLimitsTable) SetLimits(ctx context.Context, limits FormSubmissionLimits) (err error) {
+	query := `
+INSERT INTO form_submission_limits("form_id", "max_per_user_per_day")
+VALUES($1, $2)
+ON CONFLICT("form_id") DO UPDATE SET "max_per_user_per_day" = EXCLUDED."max_per_user_per_day";`
+
+	_, err = f.Exec(ctx, query, limits.FormId, limits.MaxPerUserPerDay)
+	return
+}
+
+func (f *FormSubmissionLimitsTable) DeleteLimits(ctx context.Context, formId int) (err error) {
+	query := `DELETE FROM form_submission_limits WHERE "form_id" = $1;`
+	_, err = f.Exec(ctx, query, formId)
+	return
+}
+
+// RecordSubmission atomically counts a submission of the form by the user towards today's (UTC) limit. If the user has
+// already reached the limit, the submission is not counted and exceeded is true. Submissions to forms without a limit
+// are never counted.
+func (f *FormSubmissionLimitsTable) RecordSubmission(ctx context.Context, formId int, userId uint64) (exceeded bool, err error) {
+	query := `
+WITH limits AS (
+	SELECT "max_per_user_per_day"
+	FROM form_submission_limits
+	WHERE "form_id" = $1
+), recorded AS (
+	INSERT INTO form_submission_counts("form_id", "user_id", "day", "count")
+	SELECT $1, $2, (NOW() AT TIME ZONE 'UTC')::date, 1
+	FROM limits
+	WHERE limits."max_per_user_per_day" > 0
+	ON CONFLICT("form_id", "user_id", "day") DO UPDATE
+	SET "count" = form_submission_counts."count" + 1
+	WHERE form_submission_counts."count" < (SELECT "max_per_user_per_day" FROM limits)
+	RETURNING 1
+)
+SELECT EXISTS(SELECT 1 FROM limits), EXISTS(SELECT 1 FROM recorded);`
+
+	var limited, recorded bool
+	if err := f.QueryRow(ctx, query, formId, userId).Scan(&limited, &recorded); err != nil {
+		return false, err
+	}
+
+	return limited && !recorded, nil
+}
+
+// PruneSubmissionCounts deletes the counters for days before today, which no longer affect any limits.
+func (f *FormSubmissionLimitsTable) PruneSubmissionCounts(ctx context.Context) (err error) {
+	query := `DELETE FROM form_submission_counts WHERE "day" < (NOW() AT TIME ZONE 'UTC')::date;`
+	_, err = f.Exec(ctx, query)
+	return
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+
+	"github.com/TicketsBot-cloud/common/model"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// DashboardGuild is a guild in a user's dashboard server list.
+type DashboardGuild struct {
+	GuildId         uint64                 `json:"guild_id,string"`
+	Name            string                 `json:"name"`
+	Icon            *string                `json:"icon"`
+	Owner           bool                   `json:"owner"`
+	UserPermissions uint64                 `json:"user_permissions,string"`
+	IsAdmin         bool                   `json:"is_admin"`
+	IsSupport       bool                   `json:"is_support"` // True for admins too
+	PremiumTier     *model.EntitlementTier `json:"premium_tier"`
+	WhitelabelBotId *uint64                `json:"whitelabel_bot_id,string"`
+}
+
+// GuildDashboardCacheTable is a denormalised copy of user_guilds joined with permissions, premium and whitelabel data,
+// so that the dashboard server list can be served from a single indexed query. Rows are maintained by triggers on
+// user_guilds, permissions and whitelabel_guilds. The premium tier must be written through with SetPremiumTier whenever
+// it is computed, as it depends on the grace period and the guild owner.
+type GuildDashboardCacheTable struct {
+	*pgxpool.Pool
+}
+
+var (
+	//go:embed sql/guild_dashboard_cache/schema.sql
+	guildDashboardCacheSchema string
+
+	//go:embed sql/guild_dashboard_cache/get.sql
+	guildDashboardCacheGet string
+
+	//go:embed sql/guild_dashboard_cache/set_premium_tier.sql
+	guildDashboardCacheSetPremiumTier string
+
+	//go:embed sql/guild_dashboard_cache/rebuild.sql
+	guildDashboardCacheRebuild string
+)
+
+func newGuildDashboardCacheTable(db *pgxpool.Pool) *GuildDashboardCacheTable {
+	return &GuildDashboardCacheTable{
+		db,
+	}
+}
+
+func (GuildDashboardCacheTable) Schema() string {
+	return guildDashboardCacheSchema
+}
+
+// GetDashboardGuilds returns the guilds in the user's server list, ordered by name.
+func (g *GuildDashboardCacheTable) GetDashboardGuilds(ctx context.Context, userId uint64) ([]DashboardGuild, error) {
+	rows, err := g.Query(ctx, guildDashboardCacheGet, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var guilds []DashboardGuild
+	for rows.Next() {
+		var guild DashboardGuild
+		if err := rows.Scan(
+			&guild.GuildId,
+			&guild.Name,
+			&guild.Icon,
+			&guild.Owner,
+			&guild.UserPermissions,
+			&guild.IsAdmin,
+			&guild.IsSupport,
+			&guild.PremiumTier,
+			&guild.WhitelabelBotId,
+		); err != nil {
+			return nil, err
+		}
+
+		guilds = append(guilds, guild)
+	}
+
+	return guilds, nil
+}
+
+// SetPremiumTier updates the guild's premium tier in every user's server list. A nil tier means the guild does not
+// have premium.
+func (g *GuildDashboardCacheTable) SetPremiumTier(ctx context.Context, guildId uint64, tier *model.EntitlementTier) (err error) {
+	_, err = g.Exec(ctx, guildDashboardCacheSetPremiumTier, guildId, tier)
+	return
+}
+
+// Rebuild recomputes the user's rows from the source tables, e.g. for rows created before the cache was introduced.
+// Premium tiers are copied from other users' rows for the same guild where possible, and are otherwise left unset
+// until SetPremiumTier is next called.
+func (g *GuildDashboardCacheTable) Rebuild(ctx context.Context, userId uint64) (err error) {
+	_, err = g.Exec(ctx, guildDashboardCacheRebuild, userId)
+	return
+}
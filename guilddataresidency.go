@@ -0,0 +1,273 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type GuildDataResidency struct {
+	GuildId uint64 `json:"guild_id,string"`
+	Region  string `json:"region"`
+	Pinned  bool   `json:"pinned"` // If true, the guild's data must not be moved to another region
+}
+
+const (
+	// guildDataResidencyCacheTtl is how long ForGuild caches a guild's region. Changes made through Set and Delete are
+	// seen immediately by this process, but other processes may route to the previous region until the entry expires.
+	guildDataResidencyCacheTtl = time.Minute
+	// guildDataResidencyCacheSize is the number of entries at which expired entries are evicted from the cache.
+	guildDataResidencyCacheSize = 100_000
+)
+
+type GuildDataResidencyTable struct {
+	*pgxpool.Pool
+
+	cacheLock sync.RWMutex
+	cache     map[uint64]guildDataResidencyCacheEntry
+}
+
+type guildDataResidencyCacheEntry struct {
+	region    string // Empty if the guild has no residency
+	expiresAt time.Time
+}
+
+func newGuildDataResidencyTable(db *pgxpool.Pool) *GuildDataResidencyTable {
+	return &GuildDataResidencyTable{
+		Pool:  db,
+		cache: make(map[uint64]guildDataResidencyCacheEntry),
+	}
+}
+
+func (g *GuildDataResidencyTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS guild_data_residency(
+	"guild_id" int8 NOT NULL,
+	"region" varchar(32) NOT NULL,
+	"pinned" bool NOT NULL DEFAULT false,
+	PRIMARY KEY("guild_id")
+);
+CREATE INDEX IF NOT EXISTS guild_data_residency_region ON guild_data_residency("region");
+`
+}
+
+func (g *GuildDataResidencyTable) Get(ctx context.Context, guildId uint64) (GuildDataResidency, bool, error) {
+	query := `SELECT "guild_id", "region", "pinned" FROM guild_data_residency WHERE "guild_id" = $1;`
+
+	var residency GuildDataResidency
+	if err := g.QueryRow(ctx, query, guildId).Scan(&residency.GuildId, &residency.Region, &residency.Pinned); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return GuildDataResidency{}, false, nil
+		} else {
+			return GuildDataResidency{}, false, err
+		}
+	}
+
+	return residency, true, nil
+}
+
+func (g *GuildDataResidencyTable) Set(ctx context.Context, residency GuildDataResidency) (err error) {
+	query := `
+INSERT INTO guild_data_residency("guild_id", "region", "pinned")
+VALUES($1, $2, $3)
+ON CONFLICT("guild_id") DO UPDATE SET "region" = EXCLUDED."region", "pinned" = EXCLUDED."pinned";`
+
+	if _, err = g.Exec(ctx, query, residency.GuildId, residency.Region, residency.Pinned); err != nil {
+		return
+	}
+
+	g.invalidate(residency.GuildId)
+	return
+}
+
+func (g *GuildDataResidencyTable) Delete(ctx context.Context, guildId uint64) (err error) {
+	query := `DELETE FROM guild_data_residency WHERE "guild_id" = $1;`
+	if _, err = g.Exec(ctx, query, guildId); err != nil {
+		return
+	}
+
+	g.invalidate(guildId)
+	return
+}
+
+// getRegion returns the guild's region, or an empty string if it has no residency. Results are cached for
+// guildDataResidencyCacheTtl.
+func (g *GuildDataResidencyTable) getRegion(ctx context.Context, guildId uint64) (string, error) {
+	g.cacheLock.RLock()
+	entry, ok := g.cache[guildId]
+	g.cacheLock.RUnlock()
+
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.region, nil
+	}
+
+	residency, _, err := g.Get(ctx, guildId)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	g.cacheLock.Lock()
+	defer g.cacheLock.Unlock()
+
+	if len(g.cache) >= guildDataResidencyCacheSize {
+		for cachedGuildId, cached := range g.cache {
+			if !now.Before(cached.expiresAt) {
+				delete(g.cache, cachedGuildId)
+			}
+		}
+
+		if len(g.cache) >= guildDataResidencyCacheSize {
+			g.cache = make(map[uint64]guildDataResidencyCacheEntry)
+		}
+	}
+
+	g.cache[guildId] = guildDataResidencyCacheEntry{
+		region:    residency.Region,
+		expiresAt: now.Add(guildDataResidencyCacheTtl),
+	}
+
+	return residency.Region, nil
+}
+
+func (g *GuildDataResidencyTable) invalidate(guildId uint64) {
+	g.cacheLock.Lock()
+	delete(g.cache, guildId)
+	g.cacheLock.Unlock()
+}
+
+// RegionalPool is a connection pool to the database holding the data of guilds resident in Region.
+type RegionalPool struct {
+	Region string
+	Pool   *pgxpool.Pool
+}
+
+// ForGuild returns the Database that the guild's guild-scoped data should be read from and written to, based on the
+// guild's residency. Guilds without a residency, or when no regional pools were passed to NewDatabase, use d itself.
+// Residency is always stored in the primary database, and is cached for guildDataResidencyCacheTtl. Global tables,
+// e.g. bot staff, SKUs and entitlements, are only stored in the primary database, so the returned Database uses the
+// primary's instances of them. Methods reading both global and guild-scoped tables, e.g. CheckLimit and
+// ExecutePurgeGuildData, resolve the guild's Database themselves, so can be called on either. Tables in regional databases must be created by calling CreateTables on the Database returned
+// by ForGuild, which only creates the guild-scoped tables.
+func (d *Database) ForGuild(ctx context.Context, guildId uint64) (*Database, error) {
+	if len(d.regions) == 0 {
+		return d, nil
+	}
+
+	region, err := d.GuildDataResidency.getRegion(ctx, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	if region == "" {
+		return d, nil
+	}
+
+	regional, ok := d.regions[region]
+	if !ok {
+		return nil, fmt.Errorf("guild %d is resident in unknown region %s", guildId, region)
+	}
+
+	return regional, nil
+}
+
+// newRegionalDatabase creates a Database storing guild-scoped data in pool, which shares primary's instances of the
+// global tables, so that they are always read from and written to the primary database.
+func newRegionalDatabase(primary *Database, pool *pgxpool.Pool) *Database {
+	regional := NewDatabase(pool)
+	regional.primary = primary
+
+	// Tables that do not belong to a single guild, or that are referenced by foreign keys from such tables
+	regional.BlacklistNetworks = primary.BlacklistNetworks
+	regional.BotStaff = primary.BotStaff
+	regional.BotStaffRoles = primary.BotStaffRoles
+	regional.CustomIntegrations = primary.CustomIntegrations
+	regional.CustomIntegrationGuilds = primary.CustomIntegrationGuilds
+	regional.CustomIntegrationGuildCounts = primary.CustomIntegrationGuildCounts
+	regional.CustomIntegrationHeaders = primary.CustomIntegrationHeaders
+	regional.CustomIntegrationPlaceholders = primary.CustomIntegrationPlaceholders
+	regional.CustomIntegrationSecrets = primary.CustomIntegrationSecrets
+	regional.CustomIntegrationSecretValues = primary.CustomIntegrationSecretValues
+	regional.DashboardUsers = primary.DashboardUsers
+	regional.DataRequests = primary.DataRequests
+	regional.DiscordEntitlements = primary.DiscordEntitlements
+	regional.DiscordStoreSkus = primary.DiscordStoreSkus
+	regional.Entitlements = primary.Entitlements
+	regional.EntitlementSyncLog = primary.EntitlementSyncLog
+	regional.Experiment = primary.Experiment
+	regional.GdprLogs = primary.GdprLogs
+	regional.GlobalBlacklist = primary.GlobalBlacklist
+	regional.GuildDashboardCache = primary.GuildDashboardCache
+	regional.GuildDataResidency = primary.GuildDataResidency
+	regional.IdempotencyKeys = primary.IdempotencyKeys
+	regional.Jobs = primary.Jobs
+	regional.LegacyEntitlementMigrations = primary.LegacyEntitlementMigrations
+	regional.LegacyPremiumEntitlementGuilds = primary.LegacyPremiumEntitlementGuilds
+	regional.LegacyPremiumEntitlements = primary.LegacyPremiumEntitlements
+	regional.MaintenanceFlags = primary.MaintenanceFlags
+	regional.MultiServerSkus = primary.MultiServerSkus
+	regional.PatreonEntitlements = primary.PatreonEntitlements
+	regional.PremiumEvents = primary.PremiumEvents
+	regional.PremiumGuilds = primary.PremiumGuilds
+	regional.PremiumKeys = primary.PremiumKeys
+	regional.PremiumPrices = primary.PremiumPrices
+	regional.PremiumSeats = primary.PremiumSeats
+	regional.PromoCodes = primary.PromoCodes
+	regional.PurgedGuildSnapshots = primary.PurgedGuildSnapshots
+	regional.Referrals = primary.Referrals
+	regional.ServerBlacklist = primary.ServerBlacklist
+	regional.StatusNotices = primary.StatusNotices
+	regional.SubscriptionSkus = primary.SubscriptionSkus
+	regional.TierLimits = primary.TierLimits
+	regional.UsedKeys = primary.UsedKeys
+	regional.UserGuilds = primary.UserGuilds
+	regional.VoteCredits = primary.VoteCredits
+	regional.Votes = primary.Votes
+	regional.Whitelabel = primary.Whitelabel
+	regional.WhitelabelBranding = primary.WhitelabelBranding
+	regional.WhitelabelErrors = primary.WhitelabelErrors
+	regional.WhitelabelGuilds = primary.WhitelabelGuilds
+	regional.WhitelabelStatuses = primary.WhitelabelStatuses
+	regional.WhitelabelUsers = primary.WhitelabelUsers
+
+	// The guild_dashboard_cache triggers on permissions only exist in the primary database
+	regional.Permissions.primary = primary.Permissions
+
+	return regional
+}
+
+// primaryDatabase returns the primary Database, which is d itself unless d is a regional database.
+func (d *Database) primaryDatabase() *Database {
+	if d.primary == nil {
+		return d
+	}
+
+	return d.primary
+}
+
+// withoutPrimaryTables removes the tables shared with the primary database, if d is a regional database.
+func (d *Database) withoutPrimaryTables(tables []Table) []Table {
+	if d.primary == nil {
+		return tables
+	}
+
+	shared := make(map[Table]bool)
+	for _, table := range d.primary.Tables() {
+		shared[table] = true
+	}
+
+	var filtered []Table
+	for _, table := range tables {
+		if !shared[table] {
+			filtered = append(filtered, table)
+		}
+	}
+
+	return filtered
+}
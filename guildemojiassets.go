@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type GuildEmojiAsset struct {
+	GuildId      uint64    `json:"guild_id,string"`
+	EmojiId      uint64    `json:"emoji_id,string"`
+	Name         string    `json:"name"`
+	Animated     bool      `json:"animated"`
+	UploadedBy   *uint64   `json:"uploaded_by,string"`
+	LastVerified time.Time `json:"last_verified"`
+	Missing      bool      `json:"missing"`
+}
+
+type GuildEmojiAssetsTable struct {
+	*pgxpool.Pool
+}
+
+func newGuildEmojiAssetsTable(db *pgxpool.Pool) *GuildEmojiAssetsTable {
+	return &GuildEmojiAssetsTable{
+		db,
+	}
+}
+
+func (g GuildEmojiAssetsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS guild_emoji_assets(
+	"guild_id" int8 NOT NULL,
+	"emoji_id" int8 NOT NULL,
+	"name" varchar(32) NOT NULL,
+	"animated" bool NOT NULL DEFAULT false,
+	"uploaded_by" int8 DEFAULT NULL,
+	"last_verified" timestamptz NOT NULL DEFAULT NOW(),
+	"missing" bool NOT NULL DEFAULT false,
+	PRIMARY KEY("guild_id", "emoji_id")
+);
+CREATE INDEX IF NOT EXISTS guild_emoji_assets_guild_id_missing ON guild_emoji_assets("guild_id") WHERE "missing";
+`
+}
+
+func (g *GuildEmojiAssetsTable) Get(ctx context.Context, guildId, emojiId uint64) (GuildEmojiAsset, bool, error) {
+	query := `
+SELECT "guild_id", "emoji_id", "name", "animated", "uploaded_by", "last_verified", "missing"
+FROM guild_emoji_assets
+WHERE "guild_id" = $1 AND "emoji_id" = $2;`
+
+	var asset GuildEmojiAsset
+	if err := g.QueryRow(ctx, query, guildId, emojiId).Scan(
+		&asset.GuildId,
+		&asset.EmojiId,
+		&asset.Name,
+		&asset.Animated,
+		&asset.UploadedBy,
+		&asset.LastVerified,
+		&asset.Missing,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return GuildEmojiAsset{}, false, nil
+		} else {
+			return GuildEmojiAsset{}, false, err
+		}
+	}
+
+	return asset, true, nil
+}
+
+// Upsert records the emoji as present and verified now. The uploader is only overwritten if a new one is provided.
+func (g *GuildEmojiAssetsTable) Upsert(ctx context.Context, asset GuildEmojiAsset) (err error) {
+	query := `
+INSERT INTO guild_emoji_assets("guild_id", "emoji_id", "name", "animated", "uploaded_by", "last_verified", "missing")
+VALUES($1, $2, $3, $4, $5, NOW(), false)
+ON CONFLICT("guild_id", "emoji_id") DO UPDATE
+SET "name" = EXCLUDED."name",
+	"animated" = EXCLUDED."animated",
+	"uploaded_by" = COALESCE(EXCLUDED."uploaded_by", guild_emoji_assets."uploaded_by"),
+	"last_verified" = EXCLUDED."last_verified",
+	"missing" = false;`
+
+	_, err = g.Exec(ctx, query, asset.GuildId, asset.EmojiId, asset.Name, asset.Animated, asset.UploadedBy)
+	return
+}
+
+// MarkMissing flags the emoji as no longer existing in the guild, e.g. after it was deleted on Discord.
+func (g *GuildEmojiAssetsTable) MarkMissing(ctx context.Context, guildId, emojiId uint64) (err error) {
+	query := `
+UPDATE guild_emoji_assets
+SET "missing" = true, "last_verified" = NOW()
+WHERE "guild_id" = $1 AND "emoji_id" = $2;`
+
+	_, err = g.Exec(ctx, query, guildId, emojiId)
+	return
+}
+
+func (g *GuildEmojiAssetsTable) Delete(ctx context.Context, guildId, emojiId uint64) (err error) {
+	query := `DELETE FROM guild_emoji_assets WHERE "guild_id" = $1 AND "emoji_id" = $2;`
+	_, err = g.Exec(ctx, query, guildId, emojiId)
+	return
+}
+
+// GetPanelsWithMissingEmojis returns the guild's panels whose button emoji has been marked as missing.
+func (g *GuildEmojiAssetsTable) GetPanelsWithMissingEmojis(ctx context.Context, guildId uint64) ([]Panel, error) {
+	query := `
+SELECT
+	panels.panel_id,
+	panels.message_id,
+	panels.channel_id,
+	panels.guild_id,
+	panels.title,
+	panels.content,
+	panels.colour,
+	panels.target_category,
+	panels.emoji_name,
+	panels.emoji_id,
+	panels.welcome_message,
+	panels.default_team,
+	panels.custom_id,
+	panels.image_url,
+	panels.thumbnail_url,
+	panels.button_style,
+	panels.button_label,
+	panels.form_id,
+	panels.naming_scheme,
+	panels.force_disabled,
+	panels.disabled,
+	panels.exit_survey_form_id,
+	panels.pending_category,
+	panels.delete_mentions,
+	panels.transcript_channel_id,
+	panels.use_threads,
+	panels.ticket_notification_channel,
+	panels.cooldown_seconds,
+	panels.ticket_limit,
+	panels.hide_close_button,
+	panels.hide_close_with_reason_button,
+	panels.hide_claim_button
+FROM panels
+INNER JOIN guild_emoji_assets
+	ON guild_emoji_assets.guild_id = panels.guild_id AND guild_emoji_assets.emoji_id = panels.emoji_id
+WHERE panels.guild_id = $1 AND guild_emoji_assets.missing
+ORDER BY panels.panel_id ASC;`
+
+	rows, err := g.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var panels []Panel
+	for rows.Next() {
+		var panel Panel
+		if err := rows.Scan(panel.fieldPtrs()...); err != nil {
+			return nil, err
+		}
+
+		panels = append(panels, panel)
+	}
+
+	return panels, nil
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// GuildFreeze temporarily stops a guild from using the bot without blacklisting it, e.g. while abuse is investigated.
+// The guild's panels should also be disabled with PanelTable.SetForceDisabledAll.
+type GuildFreeze struct {
+	GuildId   uint64     `json:"guild_id,string"`
+	Reason    *string    `json:"reason"`
+	ActorId   uint64     `json:"actor_id,string"` // The bot staff member who froze the guild
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"` // Null if the freeze must be lifted manually
+}
+
+type GuildFreezesTable struct {
+	*pgxpool.Pool
+}
+
+func newGuildFreezesTable(db *pgxpool.Pool) *GuildFreezesTable {
+	return &GuildFreezesTable{
+		db,
+	}
+}
+
+func (g GuildFreezesTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS guild_freezes(
+	"guild_id" int8 NOT NULL,
+	"reason" text DEFAULT NULL,
+	"actor_id" int8 NOT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"expires_at" timestamptz DEFAULT NULL,
+	PRIMARY KEY("guild_id")
+);
+CREATE INDEX IF NOT EXISTS guild_freezes_expires_at ON guild_freezes("expires_at");
+`
+}
+
+// IsFrozen returns whether the guild currently has a freeze that has not expired, and the reason for it.
+func (g *GuildFreezesTable) IsFrozen(ctx context.Context, guildId uint64) (bool, *string, error) {
+	query := `
+SELECT "reason"
+FROM guild_freezes
+WHERE "guild_id" = $1 AND ("expires_at" IS NULL OR "expires_at" > NOW());`
+
+	var reason *string
+	if err := g.QueryRow(ctx, query, guildId).Scan(&reason); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil, nil
+		} else {
+			return false, nil, err
+		}
+	}
+
+	return true, reason, nil
+}
+
+// Get returns the guild's freeze, including if it has expired but has not yet been lifted.
+func (g *GuildFreezesTable) Get(ctx context.Context, guildId uint64) (GuildFreeze, bool, error) {
+	query := `
+SELECT "guild_id", "reason", "actor_id", "created_at", "expires_at"
+FROM guild_freezes
+WHERE "guild_id" = $1;`
+
+	var freeze GuildFreeze
+	if err := g.QueryRow(ctx, query, guildId).Scan(
+		&freeze.GuildId,
+		&freeze.Reason,
+		&freeze.ActorId,
+		&freeze.CreatedAt,
+		&freeze.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return GuildFreeze{}, false, nil
+		} else {
+			return GuildFreeze{}, false, err
+		}
+	}
+
+	return freeze, true, nil
+}
+
+// GetExpired returns the IDs of guilds whose freeze has expired, so that their panels can be re-enabled before the
+// freeze is deleted.
+func (g *GuildFreezesTable) GetExpired(ctx context.Context) ([]uint64, error) {
+	query := `SELECT "guild_id" FROM guild_freezes WHERE "expires_at" <= NOW();`
+
+	rows, err := g.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var guildIds []uint64
+	for rows.Next() {
+		var guildId uint64
+		if err := rows.Scan(&guildId); err != nil {
+			return nil, err
+		}
+
+		guildIds = append(guildIds, guildId)
+	}
+
+	return guildIds, nil
+}
+
+// Set freezes the guild, replacing any existing freeze.
+func (g *GuildFreezesTable) Set(ctx context.Context, guildId uint64, reason *string, actorId uint64, expiresAt *time.Time) (err error) {
+	query := `
+INSERT INTO guild_freezes("guild_id", "reason", "actor_id", "created_at", "expires_at")
+VALUES($1, $2, $3, NOW(), $4)
+ON CONFLICT("guild_id") DO UPDATE
+SET "reason" = EXCLUDED."reason", "actor_id" = EXCLUDED."actor_id", "created_at" = EXCLUDED."created_at", "expires_at" = EXCLUDED."expires_at";`
+
+	_, err = g.Exec(ctx, query, guildId, reason, actorId, expiresAt)
+	return
+}
+
+func (g *GuildFreezesTable) Delete(ctx context.Context, guildId uint64) (err error) {
+	query := `DELETE FROM guild_freezes WHERE "guild_id" = $1;`
+	_, err = g.Exec(ctx, query, guildId)
+	return
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// GuildLocaleSettings are the guild's regional settings, shared by features that need to interpret local times, such as
+// support hours and stats reports.
+type GuildLocaleSettings struct {
+	GuildId   uint64       `json:"guild_id,string"`
+	Timezone  string       `json:"timezone"` // IANA timezone identifier (e.g., "America/New_York")
+	Locale    *string      `json:"locale"`
+	WeekStart time.Weekday `json:"week_start"`
+}
+
+type GuildLocaleSettingsTable struct {
+	*pgxpool.Pool
+}
+
+func newGuildLocaleSettingsTable(db *pgxpool.Pool) *GuildLocaleSettingsTable {
+	return &GuildLocaleSettingsTable{
+		db,
+	}
+}
+
+func (g GuildLocaleSettingsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS guild_locale_settings(
+	"guild_id" int8 NOT NULL,
+	"timezone" varchar(50) NOT NULL DEFAULT 'UTC',
+	"locale" varchar(8) DEFAULT NULL,
+	"week_start" int2 NOT NULL DEFAULT 1,
+	CHECK("week_start" >= 0 AND "week_start" <= 6),
+	PRIMARY KEY("guild_id")
+);
+`
+}
+
+// Get returns the guild's settings. If the guild has none, the defaults of UTC and weeks starting on Monday are
+// returned, with ok false.
+func (g *GuildLocaleSettingsTable) Get(ctx context.Context, guildId uint64) (settings GuildLocaleSettings, ok bool, e error) {
+	query := `SELECT "guild_id", "timezone", "locale", "week_start" FROM guild_locale_settings WHERE "guild_id" = $1;`
+
+	if err := g.QueryRow(ctx, query, guildId).Scan(
+		&settings.GuildId,
+		&settings.Timezone,
+		&settings.Locale,
+		&settings.WeekStart,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return GuildLocaleSettings{
+				GuildId:   guildId,
+				Timezone:  "UTC",
+				WeekStart: time.Monday,
+			}, false, nil
+		} else {
+			return GuildLocaleSettings{}, false, err
+		}
+	}
+
+	return settings, true, nil
+}
+
+func (g *GuildLocaleSettingsTable) Upsert(ctx context.Context, settings GuildLocaleSettings) (err error) {
+	query := `
+INSERT INTO guild_locale_settings("guild_id", "timezone", "locale", "week_start")
+VALUES($1, $2, $3, $4)
+ON CONFLICT("guild_id") DO UPDATE
+SET "timezone" = EXCLUDED."timezone", "locale" = EXCLUDED."locale", "week_start" = EXCLUDED."week_start";`
+
+	_, err = g.Exec(ctx, query, settings.GuildId, settings.Timezone, settings.Locale, int(settings.WeekStart))
+	return
+}
+
+func (g *GuildLocaleSettingsTable) Delete(ctx context.Context, guildId uint64) (err error) {
+	query := `DELETE FROM guild_locale_settings WHERE "guild_id" = $1;`
+	_, err = g.Exec(ctx, query, guildId)
+	return
+}
@@ -4,14 +4,111 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/jackc/pgx/v4"
 	"go.uber.org/zap"
 )
 
-// PurgeGuildData deletes all data associated with a guild from all tables.
-func (d *Database) PurgeGuildData(ctx context.Context, guildId uint64, logger *zap.Logger) error {
+// purgeGuildIdTables are the tables with a direct guild_id column deleted from by a purge. Rows of their child tables
+// are deleted via ON DELETE CASCADE foreign key constraints.
+var purgeGuildIdTables = []string{
+	// Ticket-related child tables (must be deleted before tickets)
+	"archive_message_attachments",
+	"archive_message_reactions",
+	"archive_messages",
+	"auto_close_exclude",
+	"category_update_queue",
+	"close_reason",
+	"close_export_queue",
+	"close_request",
+	"exit_survey_responses",
+	"first_response_time",
+	"participant",
+	"pending_rating_prompts",
+	"service_ratings",
+	"ticket_claims",
+	"ticket_field_values",
+	"ticket_last_message",
+	"ticket_members",
+	"ticket_snoozes",
+
+	// Tickets table and its counter
+	"tickets",
+	"guild_ticket_counters",
+
+	// Panels table
+	"panels",
+	"multi_panels",
+
+	// Support team related
+	"support_team",
+
+	// Form-related
+	"forms",
+
+	// Embed-related
+	"embeds",
+
+	// Custom integration related
+	"custom_integration_secret_values",
+	"custom_integration_guilds",
+
+	// Other guild-specific tables
+	"active_language",
+	"archive_channel",
+	"auto_close",
+	"blacklist",
+	"blacklist_network_members",
+	"channel_category",
+	"claim_settings",
+	"close_confirmation",
+	"close_export_configs",
+	"custom_colours",
+	"external_config_imports",
+	"feedback_enabled",
+	"guild_locale_settings",
+	"guild_metadata",
+	"import_logs",
+	"import_mapping",
+	"jobs",
+	"legacy_entitlement_migrations",
+	"legacy_premium_entitlement_guilds",
+	"naming_scheme",
+	"on_call",
+	"pending_destructive_operations",
+	"permissions",
+	"premium_guilds",
+	"premium_seat_assignments",
+	"rating_prompt_schedule",
+	"role_blacklist",
+	"role_permissions",
+	"settings",
+	"staff_availability",
+	"staff_override",
+	"tags",
+	"ticket_field_definitions",
+	"ticket_limit",
+	"ticket_permissions",
+	"ticket_summary_usage",
+	"users_can_close",
+	"user_guilds",
+	"webhooks",
+	"welcome_messages",
+	"whitelabel_guilds",
+}
+
+// purgeGuildData deletes all data associated with a guild from all tables. d must be the primary Database: all tables
+// are purged from the primary database, and tables that the guild's regional database creates are purged there too, if
+// the guild is resident in another region. beforePurge is called with the primary transaction before anything
+// is deleted, e.g. to consume a confirmation token. The regional transaction is committed first, so that if the primary
+// transaction fails, the token is left intact and the purge, which is idempotent, can be retried.
+func (d *Database) purgeGuildData(ctx context.Context, guildId uint64, logger *zap.Logger, beforePurge func(tx pgx.Tx) error) error {
 	logger.Info("Starting guild data purge", zap.Uint64("guild_id", guildId))
 
-	// Start a transaction for atomicity
+	guildDb, err := d.ForGuild(ctx, guildId)
+	if err != nil {
+		return fmt.Errorf("failed to resolve guild data residency: %w", err)
+	}
+
 	tx, err := d.BeginTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -19,107 +116,85 @@ func (d *Database) PurgeGuildData(ctx context.Context, guildId uint64, logger *z
 
 	defer tx.Rollback(ctx)
 
-	// Tables with direct guild_id column
-	// will be automatically deleted via ON DELETE CASCADE foreign key constraints
-	directGuildIdTables := []string{
-		// Ticket-related child tables (must be deleted before tickets)
-		"archive_messages",
-		"auto_close_exclude",
-		"category_update_queue",
-		"close_reason",
-		"close_request",
-		"exit_survey_responses",
-		"first_response_time",
-		"participant",
-		"service_ratings",
-		"ticket_claims",
-		"ticket_last_message",
-		"ticket_members",
-
-		// Tickets table and its counter
-		"tickets",
-		"guild_ticket_counters",
-
-		// Panels table
-		"panels",
-		"multi_panels",
-
-		// Support team related
-		"support_team",
-
-		// Form-related
-		"forms",
-
-		// Embed-related
-		"embeds",
-
-		// Custom integration related
-		"custom_integration_secret_values",
-		"custom_integration_guilds",
-
-		// Other guild-specific tables
-		"active_language",
-		"archive_channel",
-		"auto_close",
-		"blacklist",
-		"channel_category",
-		"claim_settings",
-		"close_confirmation",
-		"custom_colours",
-		"feedback_enabled",
-		"guild_metadata",
-		"import_logs",
-		"import_mapping",
-		"legacy_premium_entitlement_guilds",
-		"naming_scheme",
-		"on_call",
-		"permissions",
-		"premium_guilds",
-		"role_blacklist",
-		"role_permissions",
-		"settings",
-		"staff_override",
-		"tags",
-		"ticket_limit",
-		"ticket_permissions",
-		"users_can_close",
-		"user_guilds",
-		"webhooks",
-		"welcome_messages",
-		"whitelabel_guilds",
+	if err := beforePurge(tx); err != nil {
+		return err
 	}
 
-	// Delete from tables with direct guild_id column
-	// Child tables are automatically deleted via CASCADE
-	for _, table := range directGuildIdTables {
-		query := fmt.Sprintf(`DELETE FROM %s WHERE guild_id = $1`, table)
-		result, err := tx.Exec(ctx, query, guildId)
+	guildTx := tx
+	regional := guildDb != d
+	regionalTables := make(map[string]bool)
+	if regional {
+		guildTx, err = guildDb.BeginTx(ctx)
 		if err != nil {
-			logger.Error(
-				"Failed to delete from table",
-				zap.String("table", table),
-				zap.Uint64("guild_id", guildId),
-				zap.Error(err),
-			)
-			return fmt.Errorf("failed to delete from %s: %w", table, err)
+			return fmt.Errorf("failed to begin regional transaction: %w", err)
+		}
+
+		defer guildTx.Rollback(ctx)
+
+		tableNames, _ := guildDb.schemaObjectNames()
+		for _, tableName := range tableNames {
+			regionalTables[tableName] = true
+		}
+	}
+
+	// Record aggregate counts before anything is deleted, so that historical reporting remains accurate
+	snapshotId, err := d.PurgedGuildSnapshots.CreateTx(ctx, tx, guildTx, guildId)
+	if err != nil {
+		logger.Error("Failed to create purged guild snapshot", zap.Uint64("guild_id", guildId), zap.Error(err))
+		return fmt.Errorf("failed to create purged guild snapshot: %w", err)
+	}
+
+	logger.Info("Created purged guild snapshot", zap.Uint64("guild_id", guildId), zap.Int64("snapshot_id", snapshotId))
+
+	// Child tables are automatically deleted via CASCADE. Tables in the regional database are purged from the primary
+	// database too, as some writes, e.g. to permissions, are mirrored to it.
+	for _, table := range purgeGuildIdTables {
+		if err := purgeGuildTable(ctx, tx, table, guildId, logger); err != nil {
+			return err
 		}
 
-		rowsAffected := result.RowsAffected()
-		if rowsAffected > 0 {
-			logger.Info(
-				"Deleted rows from table",
-				zap.String("table", table),
-				zap.Uint64("guild_id", guildId),
-				zap.Int64("rows_deleted", rowsAffected),
-			)
+		if regionalTables[table] {
+			if err := purgeGuildTable(ctx, guildTx, table, guildId, logger); err != nil {
+				return err
+			}
+		}
+	}
+
+	if regional {
+		if err := guildTx.Commit(ctx); err != nil {
+			return fmt.Errorf("failed to commit regional transaction: %w", err)
 		}
 	}
 
-	// Commit the transaction
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	logger.Info("Successfully completed guild data purge", zap.Uint64("guild_id", guildId))
+	return nil
+}
+
+func purgeGuildTable(ctx context.Context, tx pgx.Tx, table string, guildId uint64, logger *zap.Logger) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE guild_id = $1`, table)
+	result, err := tx.Exec(ctx, query, guildId)
+	if err != nil {
+		logger.Error(
+			"Failed to delete from table",
+			zap.String("table", table),
+			zap.Uint64("guild_id", guildId),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to delete from %s: %w", table, err)
+	}
+
+	rowsAffected := result.RowsAffected()
+	if rowsAffected > 0 {
+		logger.Info(
+			"Deleted rows from table",
+			zap.String("table", table),
+			zap.Uint64("guild_id", guildId),
+			zap.Int64("rows_deleted", rowsAffected),
+		)
+	}
+
 	return nil
 }
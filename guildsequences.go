@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type GuildSequenceName string
+
+const (
+	GuildSequenceFormResponses GuildSequenceName = "form_responses"
+	GuildSequenceReports       GuildSequenceName = "reports"
+	GuildSequenceInvoices      GuildSequenceName = "invoices"
+)
+
+type GuildSequencesTable struct {
+	*pgxpool.Pool
+}
+
+func newGuildSequencesTable(db *pgxpool.Pool) *GuildSequencesTable {
+	return &GuildSequencesTable{
+		db,
+	}
+}
+
+func (g GuildSequencesTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS guild_sequences(
+	"guild_id" int8 NOT NULL,
+	"sequence_name" varchar(32) NOT NULL,
+	"last_value" int8 NOT NULL,
+	PRIMARY KEY("guild_id", "sequence_name")
+);
+`
+}
+
+// Next atomically increments the guild's sequence and returns the new value. Sequences start at 1.
+func (g *GuildSequencesTable) Next(ctx context.Context, guildId uint64, sequenceName GuildSequenceName) (value int64, err error) {
+	err = g.QueryRow(ctx, guildSequencesNextQuery, guildId, sequenceName).Scan(&value)
+	return
+}
+
+// NextWithTx is like Next, but the increment is rolled back with the transaction, so values are not skipped if the
+// caller's write fails.
+func (g *GuildSequencesTable) NextWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, sequenceName GuildSequenceName) (value int64, err error) {
+	err = tx.QueryRow(ctx, guildSequencesNextQuery, guildId, sequenceName).Scan(&value)
+	return
+}
+
+// Current returns the last value handed out by the sequence, or 0 if it has never been used.
+func (g *GuildSequencesTable) Current(ctx context.Context, guildId uint64, sequenceName GuildSequenceName) (value int64, err error) {
+	query := `SELECT "last_value" FROM guild_sequences WHERE "guild_id" = $1 AND "sequence_name" = $2;`
+	if err := g.QueryRow(ctx, query, guildId, sequenceName).Scan(&value); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+
+	return value, nil
+}
+
+const guildSequencesNextQuery = `
+INSERT INTO guild_sequences("guild_id", "sequence_name", "last_value")
+VALUES($1, $2, 1)
+ON CONFLICT("guild_id", "sequence_name") DO UPDATE SET "last_value" = guild_sequences."last_value" + 1
+RETURNING "last_value";`
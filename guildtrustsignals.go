@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type TrustSignalType string
+
+const (
+	TrustSignalTicketSpam       TrustSignalType = "ticket_spam"
+	TrustSignalTokenAbuse       TrustSignalType = "token_abuse"
+	TrustSignalOwnerBlacklisted TrustSignalType = "owner_blacklisted"
+	TrustSignalManual           TrustSignalType = "manual"
+)
+
+// TrustSignalHalfLife is the time after which a signal contributes half of its original weight to a guild's score
+const TrustSignalHalfLife = time.Hour * 24 * 30
+
+type GuildTrustSignal struct {
+	Id         int64           `json:"id"`
+	GuildId    uint64          `json:"guild_id,string"`
+	SignalType TrustSignalType `json:"signal_type"`
+	Weight     float64         `json:"weight"`
+	Reason     *string         `json:"reason"`
+	CreatedBy  *uint64         `json:"created_by,string"` // Null if raised automatically
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type GuildRiskScore struct {
+	GuildId uint64  `json:"guild_id,string"`
+	Score   float64 `json:"score"`
+}
+
+type GuildTrustSignalsTable struct {
+	*pgxpool.Pool
+}
+
+func newGuildTrustSignalsTable(db *pgxpool.Pool) *GuildTrustSignalsTable {
+	return &GuildTrustSignalsTable{
+		db,
+	}
+}
+
+func (g GuildTrustSignalsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS guild_trust_signals(
+	"id" BIGSERIAL NOT NULL,
+	"guild_id" int8 NOT NULL,
+	"signal_type" varchar(32) NOT NULL,
+	"weight" float8 NOT NULL,
+	"reason" varchar(255) DEFAULT NULL,
+	"created_by" int8 DEFAULT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS guild_trust_signals_guild_id ON guild_trust_signals("guild_id");
+`
+}
+
+func (g *GuildTrustSignalsTable) AddSignal(ctx context.Context, guildId uint64, signalType TrustSignalType, weight float64, reason *string, createdBy *uint64) (err error) {
+	query := `
+INSERT INTO guild_trust_signals("guild_id", "signal_type", "weight", "reason", "created_by")
+VALUES($1, $2, $3, $4, $5);`
+
+	_, err = g.Exec(ctx, query, guildId, signalType, weight, reason, createdBy)
+	return
+}
+
+func (g *GuildTrustSignalsTable) GetSignals(ctx context.Context, guildId uint64) ([]GuildTrustSignal, error) {
+	query := `
+SELECT "id", "guild_id", "signal_type", "weight", "reason", "created_by", "created_at"
+FROM guild_trust_signals
+WHERE "guild_id" = $1
+ORDER BY "created_at" DESC;`
+
+	rows, err := g.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var signals []GuildTrustSignal
+	for rows.Next() {
+		var signal GuildTrustSignal
+		if err := rows.Scan(
+			&signal.Id,
+			&signal.GuildId,
+			&signal.SignalType,
+			&signal.Weight,
+			&signal.Reason,
+			&signal.CreatedBy,
+			&signal.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		signals = append(signals, signal)
+	}
+
+	return signals, nil
+}
+
+// GetScore returns the guild's risk score: the sum of its signal weights, with each signal decaying according to
+// TrustSignalHalfLife. A higher score means a less trusted guild. Guilds with no signals have a score of 0.
+func (g *GuildTrustSignalsTable) GetScore(ctx context.Context, guildId uint64) (score float64, err error) {
+	query := `
+SELECT COALESCE(SUM("weight" * POWER(0.5, EXTRACT(EPOCH FROM NOW() - "created_at") / $2)), 0)
+FROM guild_trust_signals
+WHERE "guild_id" = $1;`
+
+	err = g.QueryRow(ctx, query, guildId, TrustSignalHalfLife.Seconds()).Scan(&score)
+	return
+}
+
+// ListHighRisk returns the guilds with a score of at least threshold, highest first.
+func (g *GuildTrustSignalsTable) ListHighRisk(ctx context.Context, threshold float64) ([]GuildRiskScore, error) {
+	query := `
+SELECT "guild_id", SUM("weight" * POWER(0.5, EXTRACT(EPOCH FROM NOW() - "created_at") / $2)) AS score
+FROM guild_trust_signals
+GROUP BY "guild_id"
+HAVING SUM("weight" * POWER(0.5, EXTRACT(EPOCH FROM NOW() - "created_at") / $2)) >= $1
+ORDER BY score DESC;`
+
+	rows, err := g.Query(ctx, query, threshold, TrustSignalHalfLife.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scores []GuildRiskScore
+	for rows.Next() {
+		var score GuildRiskScore
+		if err := rows.Scan(&score.GuildId, &score.Score); err != nil {
+			return nil, err
+		}
+
+		scores = append(scores, score)
+	}
+
+	return scores, nil
+}
+
+func (g *GuildTrustSignalsTable) DeleteSignal(ctx context.Context, id int64) (err error) {
+	query := `DELETE FROM guild_trust_signals WHERE "id" = $1;`
+	_, err = g.Exec(ctx, query, id)
+	return
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// ErrIdempotencyKeyInProgress is returned by WithIdempotency if another call holding the same key has not finished yet.
+var ErrIdempotencyKeyInProgress = errors.New("idempotency key is in use by an operation in progress")
+
+type IdempotencyKeysTable struct {
+	*pgxpool.Pool
+}
+
+func newIdempotencyKeysTable(db *pgxpool.Pool) *IdempotencyKeysTable {
+	return &IdempotencyKeysTable{
+		db,
+	}
+}
+
+func (i IdempotencyKeysTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS idempotency_keys(
+	"key" varchar(255) NOT NULL,
+	"result" jsonb DEFAULT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"expires_at" timestamptz NOT NULL,
+	PRIMARY KEY("key")
+);
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at ON idempotency_keys("expires_at");
+`
+}
+
+// Claim reserves the key until ttl has elapsed. Returns false if the key is already held by an unexpired claim.
+func (i *IdempotencyKeysTable) Claim(ctx context.Context, key string, ttl time.Duration) (claimed bool, err error) {
+	query := `
+WITH expired AS (
+	DELETE FROM idempotency_keys
+	WHERE "key" = $1 AND "expires_at" <= NOW()
+)
+INSERT INTO idempotency_keys("key", "result", "created_at", "expires_at")
+VALUES($1, NULL, NOW(), NOW() + $2::interval)
+ON CONFLICT("key") DO NOTHING;`
+
+	res, err := i.Exec(ctx, query, key, ttl)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// GetResult returns the stored result for the key. If the key is held but the operation has not completed yet, result
+// is nil and ok is true.
+func (i *IdempotencyKeysTable) GetResult(ctx context.Context, key string) (result []byte, ok bool, err error) {
+	query := `SELECT "result" FROM idempotency_keys WHERE "key" = $1 AND "expires_at" > NOW();`
+	if err := i.QueryRow(ctx, query, key).Scan(&result); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, nil
+		} else {
+			return nil, false, err
+		}
+	}
+
+	return result, true, nil
+}
+
+func (i *IdempotencyKeysTable) SetResult(ctx context.Context, key string, result []byte) (err error) {
+	query := `UPDATE idempotency_keys SET "result" = $2 WHERE "key" = $1;`
+	_, err = i.Exec(ctx, query, key, result)
+	return
+}
+
+// Release deletes the key, allowing the operation to be retried.
+func (i *IdempotencyKeysTable) Release(ctx context.Context, key string) (err error) {
+	query := `DELETE FROM idempotency_keys WHERE "key" = $1;`
+	_, err = i.Exec(ctx, query, key)
+	return
+}
+
+func (i *IdempotencyKeysTable) DeleteExpired(ctx context.Context) (err error) {
+	query := `DELETE FROM idempotency_keys WHERE "expires_at" <= NOW();`
+	_, err = i.Exec(ctx, query)
+	return
+}
+
+// WithIdempotency runs fn at most once per key within ttl. The first call's result is stored as JSON and returned to
+// any later calls with the same key, without running fn again. If fn returns an error, the key is released so that
+// the operation can be retried. Returns ErrIdempotencyKeyInProgress if a call with the same key is still running.
+func WithIdempotency[T any](ctx context.Context, keys *IdempotencyKeysTable, key string, ttl time.Duration, fn func() (T, error)) (T, error) {
+	var zero T
+
+	claimed, err := keys.Claim(ctx, key, ttl)
+	if err != nil {
+		return zero, err
+	}
+
+	if !claimed {
+		stored, ok, err := keys.GetResult(ctx, key)
+		if err != nil {
+			return zero, err
+		}
+
+		// The key may have expired or been released between the claim and the read
+		if !ok {
+			return WithIdempotency(ctx, keys, key, ttl, fn)
+		}
+
+		if stored == nil {
+			return zero, ErrIdempotencyKeyInProgress
+		}
+
+		var result T
+		if err := json.Unmarshal(stored, &result); err != nil {
+			return zero, err
+		}
+
+		return result, nil
+	}
+
+	result, err := fn()
+	if err != nil {
+		if releaseErr := keys.Release(ctx, key); releaseErr != nil {
+			return zero, errors.Join(err, releaseErr)
+		}
+
+		return zero, err
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return zero, err
+	}
+
+	if err := keys.SetResult(ctx, key, encoded); err != nil {
+		return zero, err
+	}
+
+	return result, nil
+}
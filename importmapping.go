@@ -17,6 +17,7 @@ type ImportMapping struct {
 	Area     string `json:"area"`
 	SourceId int    `json:"source_id"`
 	TargetId int    `json:"target_id"`
+	Source   string `json:"source"`
 }
 
 var (
@@ -37,10 +38,17 @@ func (s ImportMappingTable) Schema() string {
 	return importMappingSchema
 }
 
+// GetMapping returns the guild's mappings from imports of TicketsBot's own exports, keyed by area and then source ID.
 func (s *ImportMappingTable) GetMapping(ctx context.Context, guildId uint64) (map[string]map[int]int, error) {
-	query := `SELECT * FROM import_mapping WHERE "guild_id" = $1;`
+	return s.GetMappingForSource(ctx, guildId, "")
+}
+
+// GetMappingForSource returns the guild's mappings from imports from the source, as passed to ImportExternalConfig,
+// keyed by area and then source ID.
+func (s *ImportMappingTable) GetMappingForSource(ctx context.Context, guildId uint64, source string) (map[string]map[int]int, error) {
+	query := `SELECT "guild_id", "area", "source_id", "target_id", "source" FROM import_mapping WHERE "guild_id" = $1 AND "source" = $2;`
 
-	rows, err := s.Query(ctx, query, guildId)
+	rows, err := s.Query(ctx, query, guildId, source)
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +57,7 @@ func (s *ImportMappingTable) GetMapping(ctx context.Context, guildId uint64) (ma
 
 	for rows.Next() {
 		var mappingEntry ImportMapping
-		if err := rows.Scan(&mappingEntry.GuildId, &mappingEntry.Area, &mappingEntry.SourceId, &mappingEntry.TargetId); err != nil {
+		if err := rows.Scan(&mappingEntry.GuildId, &mappingEntry.Area, &mappingEntry.SourceId, &mappingEntry.TargetId, &mappingEntry.Source); err != nil {
 			return nil, err
 		}
 
@@ -64,7 +72,7 @@ func (s *ImportMappingTable) GetMapping(ctx context.Context, guildId uint64) (ma
 }
 
 func (s *ImportMappingTable) Set(ctx context.Context, guildId uint64, area string, sourceId, targetId int) error {
-	_, err := s.Exec(ctx, importMappingSet, guildId, area, sourceId, targetId)
+	_, err := s.Exec(ctx, importMappingSet, guildId, area, sourceId, targetId, "")
 	return err
 }
 
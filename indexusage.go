@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"regexp"
+	"time"
+)
+
+// sequentialScanMinRows is the number of live rows a table must have before sequential scans of it are reported, as
+// sequential scans of small tables are usually cheaper than index scans.
+const sequentialScanMinRows = 10000
+
+var (
+	createTableRegex = regexp.MustCompile(`(?i)CREATE\s+TABLE\s+IF\s+NOT\s+EXISTS\s+"?(\w+)"?`)
+	createIndexRegex = regexp.MustCompile(`(?i)CREATE\s+(?:UNIQUE\s+)?INDEX\s+IF\s+NOT\s+EXISTS\s+"?(\w+)"?`)
+)
+
+type IndexUsageReport struct {
+	// When the statistics were last reset. Nil if they have never been reset, in which case they cover the entire
+	// lifetime of the database.
+	StatsResetAt         *time.Time            `json:"stats_reset_at"`
+	UnusedIndexes        []UnusedIndex         `json:"unused_indexes"`
+	SequentialScanTables []SequentialScanTable `json:"sequential_scan_tables"`
+}
+
+type UnusedIndex struct {
+	Table     string `json:"table"`
+	Index     string `json:"index"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+type SequentialScanTable struct {
+	Table              string `json:"table"`
+	SequentialScans    int64  `json:"sequential_scans"`
+	SequentialRowsRead int64  `json:"sequential_rows_read"`
+	IndexScans         int64  `json:"index_scans"`
+	LiveRows           int64  `json:"live_rows"`
+}
+
+// AnalyzeIndexUsage reports indexes created by this package that have never been scanned, and tables created by this
+// package that are scanned sequentially more often than by index. Indexes backing unique or primary key constraints
+// are never reported as unused, as they are required regardless of usage. Statistics are only meaningful once the
+// database has been serving traffic for a while after the last reset.
+func (d *Database) AnalyzeIndexUsage(ctx context.Context) (IndexUsageReport, error) {
+	tableNames, indexNames := d.schemaObjectNames()
+
+	var report IndexUsageReport
+
+	statsResetQuery := `SELECT stats_reset FROM pg_stat_database WHERE datname = current_database();`
+	if err := d.pool.QueryRow(ctx, statsResetQuery).Scan(&report.StatsResetAt); err != nil {
+		return IndexUsageReport{}, err
+	}
+
+	unusedQuery := `
+SELECT pg_stat_user_indexes.relname, pg_stat_user_indexes.indexrelname, pg_relation_size(pg_stat_user_indexes.indexrelid)
+FROM pg_stat_user_indexes
+INNER JOIN pg_index
+	ON pg_index.indexrelid = pg_stat_user_indexes.indexrelid
+WHERE pg_stat_user_indexes.schemaname = current_schema()
+	AND pg_stat_user_indexes.relname = ANY($1::text[])
+	AND pg_stat_user_indexes.indexrelname = ANY($2::text[])
+	AND pg_stat_user_indexes.idx_scan = 0
+	AND NOT pg_index.indisunique
+ORDER BY pg_relation_size(pg_stat_user_indexes.indexrelid) DESC;`
+
+	rows, err := d.pool.Query(ctx, unusedQuery, tableNames, indexNames)
+	if err != nil {
+		return IndexUsageReport{}, err
+	}
+
+	for rows.Next() {
+		var index UnusedIndex
+		if err := rows.Scan(&index.Table, &index.Index, &index.SizeBytes); err != nil {
+			rows.Close()
+			return IndexUsageReport{}, err
+		}
+
+		report.UnusedIndexes = append(report.UnusedIndexes, index)
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return IndexUsageReport{}, err
+	}
+
+	seqScanQuery := `
+SELECT relname, seq_scan, seq_tup_read, COALESCE(idx_scan, 0), n_live_tup
+FROM pg_stat_user_tables
+WHERE schemaname = current_schema()
+	AND relname = ANY($1::text[])
+	AND n_live_tup >= $2
+	AND seq_scan > COALESCE(idx_scan, 0)
+ORDER BY seq_tup_read DESC;`
+
+	rows, err = d.pool.Query(ctx, seqScanQuery, tableNames, sequentialScanMinRows)
+	if err != nil {
+		return IndexUsageReport{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table SequentialScanTable
+		if err := rows.Scan(
+			&table.Table,
+			&table.SequentialScans,
+			&table.SequentialRowsRead,
+			&table.IndexScans,
+			&table.LiveRows,
+		); err != nil {
+			return IndexUsageReport{}, err
+		}
+
+		report.SequentialScanTables = append(report.SequentialScanTables, table)
+	}
+
+	if err := rows.Err(); err != nil {
+		return IndexUsageReport{}, err
+	}
+
+	return report, nil
+}
+
+// schemaObjectNames returns the names of the tables and indexes created by the schemas of this package's tables.
+func (d *Database) schemaObjectNames() (tableNames, indexNames []string) {
+	for _, table := range d.Tables() {
+		schema := table.Schema()
+
+		for _, match := range createTableRegex.FindAllStringSubmatch(schema, -1) {
+			tableNames = append(tableNames, match[1])
+		}
+
+		for _, match := range createIndexRegex.FindAllStringSubmatch(schema, -1) {
+			indexNames = append(indexNames, match[1])
+		}
+	}
+
+	return
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// InteractionCustomId is the state behind a custom_id attached to a button or select menu. The custom_id itself is
+// generated by the database, so it never collides with a previously issued one.
+type InteractionCustomId struct {
+	CustomId  string     `json:"custom_id"`
+	Type      string     `json:"type"`
+	GuildId   uint64     `json:"guild_id,string"`
+	PanelId   *int       `json:"panel_id"`
+	FormId    *int       `json:"form_id"`
+	Payload   []byte     `json:"payload"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"` // Null if the custom_id never expires
+}
+
+type InteractionCustomIdsTable struct {
+	*pgxpool.Pool
+}
+
+func newInteractionCustomIdsTable(db *pgxpool.Pool) *InteractionCustomIdsTable {
+	return &InteractionCustomIdsTable{
+		db,
+	}
+}
+
+func (i InteractionCustomIdsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS interaction_custom_ids(
+	"custom_id" varchar(100) NOT NULL DEFAULT gen_random_uuid()::text,
+	"type" varchar(32) NOT NULL,
+	"guild_id" int8 NOT NULL,
+	"panel_id" int DEFAULT NULL,
+	"form_id" int DEFAULT NULL,
+	"payload" jsonb DEFAULT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"expires_at" timestamptz DEFAULT NULL,
+	FOREIGN KEY("panel_id") REFERENCES panels("panel_id") ON DELETE CASCADE,
+	FOREIGN KEY("form_id") REFERENCES forms("form_id") ON DELETE CASCADE,
+	PRIMARY KEY("custom_id")
+);
+CREATE INDEX IF NOT EXISTS interaction_custom_ids_guild_id ON interaction_custom_ids("guild_id");
+CREATE INDEX IF NOT EXISTS interaction_custom_ids_expires_at ON interaction_custom_ids("expires_at") WHERE "expires_at" IS NOT NULL;
+`
+}
+
+// Create stores the state and returns the newly generated custom_id. The CustomId and CreatedAt fields are ignored.
+func (i *InteractionCustomIdsTable) Create(ctx context.Context, data InteractionCustomId) (customId string, err error) {
+	query := `
+INSERT INTO interaction_custom_ids("type", "guild_id", "panel_id", "form_id", "payload", "created_at", "expires_at")
+VALUES($1, $2, $3, $4, $5, NOW(), $6)
+RETURNING "custom_id";`
+
+	err = i.QueryRow(ctx, query, data.Type, data.GuildId, data.PanelId, data.FormId, data.Payload, data.ExpiresAt).Scan(&customId)
+	return
+}
+
+// Resolve returns the state behind the custom_id. Expired custom_ids are treated as not existing.
+func (i *InteractionCustomIdsTable) Resolve(ctx context.Context, customId string) (InteractionCustomId, bool, error) {
+	query := `
+SELECT "custom_id", "type", "guild_id", "panel_id", "form_id", "payload", "created_at", "expires_at"
+FROM interaction_custom_ids
+WHERE "custom_id" = $1 AND ("expires_at" IS NULL OR "expires_at" > NOW());`
+
+	var data InteractionCustomId
+	if err := i.QueryRow(ctx, query, customId).Scan(
+		&data.CustomId,
+		&data.Type,
+		&data.GuildId,
+		&data.PanelId,
+		&data.FormId,
+		&data.Payload,
+		&data.CreatedAt,
+		&data.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return InteractionCustomId{}, false, nil
+		} else {
+			return InteractionCustomId{}, false, err
+		}
+	}
+
+	return data, true, nil
+}
+
+// Expire makes the custom_id unresolvable immediately. The row is removed by the next call to DeleteExpired.
+func (i *InteractionCustomIdsTable) Expire(ctx context.Context, customId string) (err error) {
+	query := `UPDATE interaction_custom_ids SET "expires_at" = NOW() WHERE "custom_id" = $1;`
+	_, err = i.Exec(ctx, query, customId)
+	return
+}
+
+func (i *InteractionCustomIdsTable) DeleteExpired(ctx context.Context) (err error) {
+	query := `DELETE FROM interaction_custom_ids WHERE "expires_at" <= NOW();`
+	_, err = i.Exec(ctx, query)
+	return
+}
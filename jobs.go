@@ -0,0 +1,213 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	// jobLeaseDuration is how long a claimed job is hidden from other workers. If the worker does not call Complete or
+	// Fail within the lease, e.g. because it crashed, the job is claimed again.
+	jobLeaseDuration = 5 * time.Minute
+	jobBackoffBase   = 30 * time.Second
+	jobBackoffMax    = 6 * time.Hour
+
+	defaultJobMaxAttempts = 5
+)
+
+// ErrJobLeaseLost is returned by JobQueue.Complete and JobQueue.Fail if the job is no longer running under the claim,
+// e.g. because its lease expired and it was claimed by another worker.
+var ErrJobLeaseLost = errors.New("job lease has been lost")
+
+// JobKind identifies the feature a job belongs to, e.g. "ticket_followup". Each feature claims only its own kind.
+type JobKind string
+
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "pending"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusCompleted JobStatus = "completed"
+	JobStatusFailed    JobStatus = "failed" // All attempts have been used
+)
+
+type Job struct {
+	Id          int64      `json:"id"`
+	Kind        JobKind    `json:"kind"`
+	GuildId     *uint64    `json:"guild_id,string"`
+	Payload     []byte     `json:"payload"`
+	Status      JobStatus  `json:"status"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	AvailableAt time.Time  `json:"available_at"`
+	LastError   *string    `json:"last_error"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// JobQueue is a generic queue for background work that must be processed exactly once by one of many workers, so that
+// features do not need to implement claiming and retries on their own tables.
+type JobQueue struct {
+	*pgxpool.Pool
+}
+
+func newJobQueue(db *pgxpool.Pool) *JobQueue {
+	return &JobQueue{
+		db,
+	}
+}
+
+func (j JobQueue) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS jobs(
+	"id" BIGSERIAL NOT NULL,
+	"kind" varchar(64) NOT NULL,
+	"guild_id" int8 DEFAULT NULL,
+	"payload" jsonb DEFAULT NULL,
+	"status" varchar(16) NOT NULL DEFAULT 'pending',
+	"attempts" int4 NOT NULL DEFAULT 0,
+	"max_attempts" int4 NOT NULL,
+	"available_at" timestamptz NOT NULL DEFAULT NOW(),
+	"last_error" text DEFAULT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"completed_at" timestamptz DEFAULT NULL,
+	CHECK("status" IN ('pending', 'running', 'completed', 'failed')),
+	CHECK("max_attempts" > 0),
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS jobs_kind_available_at ON jobs("kind", "available_at") WHERE "status" IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS jobs_guild_id ON jobs("guild_id");
+`
+}
+
+// Enqueue adds a job that becomes available to workers at runAt. The payload must be valid JSON, or nil. If
+// maxAttempts is not positive, the job is attempted up to 5 times.
+func (j *JobQueue) Enqueue(ctx context.Context, kind JobKind, guildId *uint64, payload []byte, runAt time.Time, maxAttempts int) (id int64, err error) {
+	if maxAttempts <= 0 {
+		maxAttempts = defaultJobMaxAttempts
+	}
+
+	query := `
+INSERT INTO jobs("kind", "guild_id", "payload", "max_attempts", "available_at")
+VALUES($1, $2, $3, $4, $5)
+RETURNING "id";`
+
+	err = j.QueryRow(ctx, query, kind, guildId, payload, maxAttempts, runAt).Scan(&id)
+	return
+}
+
+// ClaimBatch returns up to n jobs of the kind that are due, marking them as running and incrementing their attempts.
+// Rows locked by another worker are skipped. Jobs whose lease has expired without Complete or Fail being called are
+// claimed again, or marked as failed if they have used all of their attempts. The returned jobs' Attempts identify the
+// claim, and must be passed to Complete or Fail.
+func (j *JobQueue) ClaimBatch(ctx context.Context, kind JobKind, n int) ([]Job, error) {
+	query := `
+WITH exhausted AS (
+	UPDATE jobs
+	SET "status" = 'failed', "completed_at" = NOW(), "last_error" = COALESCE("last_error", 'lease expired on the final attempt')
+	WHERE "kind" = $1 AND "status" = 'running' AND "available_at" <= NOW() AND "attempts" >= "max_attempts"
+)
+UPDATE jobs
+SET "status" = 'running', "attempts" = "attempts" + 1, "available_at" = NOW() + $3::interval
+WHERE "id" IN (
+	SELECT "id"
+	FROM jobs
+	WHERE "kind" = $1 AND "status" IN ('pending', 'running') AND "available_at" <= NOW() AND "attempts" < "max_attempts"
+	ORDER BY "available_at" ASC
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING "id", "kind", "guild_id", "payload", "status", "attempts", "max_attempts", "available_at", "last_error", "created_at", "completed_at";`
+
+	rows, err := j.Query(ctx, query, kind, n, jobLeaseDuration)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		var job Job
+		if err := rows.Scan(
+			&job.Id,
+			&job.Kind,
+			&job.GuildId,
+			&job.Payload,
+			&job.Status,
+			&job.Attempts,
+			&job.MaxAttempts,
+			&job.AvailableAt,
+			&job.LastError,
+			&job.CreatedAt,
+			&job.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// Complete marks the job as completed. attempt must be the job's Attempts as returned by ClaimBatch; if the job has
+// since been claimed again, or has finished, ErrJobLeaseLost is returned.
+func (j *JobQueue) Complete(ctx context.Context, id int64, attempt int) error {
+	query := `
+UPDATE jobs
+SET "status" = 'completed', "completed_at" = NOW(), "last_error" = NULL
+WHERE "id" = $1 AND "status" = 'running' AND "attempts" = $2;`
+
+	res, err := j.Exec(ctx, query, id, attempt)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrJobLeaseLost
+	}
+
+	return nil
+}
+
+// Fail records the error and makes the job available again after an exponential backoff of 30 seconds doubling with
+// each attempt, up to 6 hours. If the job has used all of its attempts, it is marked as failed instead. attempt must be
+// the job's Attempts as returned by ClaimBatch; if the job has since been claimed again, or has finished,
+// ErrJobLeaseLost is returned. Returns whether the job will be retried.
+func (j *JobQueue) Fail(ctx context.Context, id int64, attempt int, errorMessage string) (retrying bool, err error) {
+	query := `
+UPDATE jobs
+SET
+	"status" = CASE WHEN "attempts" < "max_attempts" THEN 'pending' ELSE 'failed' END,
+	"available_at" = NOW() + LEAST($3::interval * power(2, GREATEST("attempts" - 1, 0)), $4::interval),
+	"last_error" = $2,
+	"completed_at" = CASE WHEN "attempts" < "max_attempts" THEN NULL ELSE NOW() END
+WHERE "id" = $1 AND "status" = 'running' AND "attempts" = $5
+RETURNING "status" = 'pending';`
+
+	if err := j.QueryRow(ctx, query, id, errorMessage, jobBackoffBase, jobBackoffMax, attempt).Scan(&retrying); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, ErrJobLeaseLost
+		}
+
+		return false, err
+	}
+
+	return retrying, nil
+}
+
+// Prune deletes completed and failed jobs that finished before olderThan.
+func (j *JobQueue) Prune(ctx context.Context, olderThan time.Time) (deleted int64, err error) {
+	query := `DELETE FROM jobs WHERE "status" IN ('completed', 'failed') AND "completed_at" < $1;`
+
+	res, err := j.Exec(ctx, query, olderThan)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
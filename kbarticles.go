@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type KbArticle struct {
+	Id        int       `json:"id"`
+	GuildId   uint64    `json:"guild_id,string"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type KbArticlesTable struct {
+	*pgxpool.Pool
+}
+
+func newKbArticlesTable(db *pgxpool.Pool) *KbArticlesTable {
+	return &KbArticlesTable{
+		db,
+	}
+}
+
+func (k KbArticlesTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS kb_articles(
+	"id" SERIAL NOT NULL UNIQUE,
+	"guild_id" int8 NOT NULL,
+	"title" varchar(255) NOT NULL,
+	"body" text NOT NULL,
+	"tags" text[] NOT NULL DEFAULT '{}',
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"updated_at" timestamptz NOT NULL DEFAULT NOW(),
+	"search" tsvector GENERATED ALWAYS AS (
+		setweight(to_tsvector('english', "title"), 'A') || setweight(to_tsvector('english', "body"), 'B')
+	) STORED,
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS kb_articles_guild_id ON kb_articles("guild_id");
+CREATE INDEX IF NOT EXISTS kb_articles_search ON kb_articles USING GIN("search");
+CREATE INDEX IF NOT EXISTS kb_articles_tags ON kb_articles USING GIN("tags");
+`
+}
+
+func (k *KbArticlesTable) Get(ctx context.Context, guildId uint64, id int) (KbArticle, bool, error) {
+	query := `
+SELECT "id", "guild_id", "title", "body", "tags", "created_at", "updated_at"
+FROM kb_articles
+WHERE "guild_id" = $1 AND "id" = $2;`
+
+	var article KbArticle
+	if err := k.QueryRow(ctx, query, guildId, id).Scan(
+		&article.Id,
+		&article.GuildId,
+		&article.Title,
+		&article.Body,
+		&article.Tags,
+		&article.CreatedAt,
+		&article.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return KbArticle{}, false, nil
+		} else {
+			return KbArticle{}, false, err
+		}
+	}
+
+	return article, true, nil
+}
+
+func (k *KbArticlesTable) GetByGuild(ctx context.Context, guildId uint64) ([]KbArticle, error) {
+	query := `
+SELECT "id", "guild_id", "title", "body", "tags", "created_at", "updated_at"
+FROM kb_articles
+WHERE "guild_id" = $1
+ORDER BY "id" ASC;`
+
+	rows, err := k.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanKbArticles(rows)
+}
+
+// SearchArticles returns the articles that best match the query, most relevant first. The query is parsed using
+// websearch syntax, and articles with a tag exactly matching the query are also included.
+func (k *KbArticlesTable) SearchArticles(ctx context.Context, guildId uint64, query string, limit int) ([]KbArticle, error) {
+	sql := `
+SELECT "id", "guild_id", "title", "body", "tags", "created_at", "updated_at"
+FROM kb_articles
+WHERE "guild_id" = $1 AND ("search" @@ websearch_to_tsquery('english', $2) OR LOWER($2) = ANY("tags"))
+ORDER BY ts_rank("search", websearch_to_tsquery('english', $2)) DESC, "id" ASC
+LIMIT $3;`
+
+	rows, err := k.Query(ctx, sql, guildId, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanKbArticles(rows)
+}
+
+func (k *KbArticlesTable) Create(ctx context.Context, guildId uint64, title, body string, tags []string) (id int, err error) {
+	query := `
+INSERT INTO kb_articles("guild_id", "title", "body", "tags")
+VALUES($1, $2, $3, $4)
+RETURNING "id";`
+
+	err = k.QueryRow(ctx, query, guildId, title, body, normaliseKbTags(tags)).Scan(&id)
+	return
+}
+
+func (k *KbArticlesTable) Update(ctx context.Context, guildId uint64, id int, title, body string, tags []string) (err error) {
+	query := `
+UPDATE kb_articles
+SET "title" = $3, "body" = $4, "tags" = $5, "updated_at" = NOW()
+WHERE "guild_id" = $1 AND "id" = $2;`
+
+	_, err = k.Exec(ctx, query, guildId, id, title, body, normaliseKbTags(tags))
+	return
+}
+
+func (k *KbArticlesTable) Delete(ctx context.Context, guildId uint64, id int) (err error) {
+	query := `DELETE FROM kb_articles WHERE "guild_id" = $1 AND "id" = $2;`
+	_, err = k.Exec(ctx, query, guildId, id)
+	return
+}
+
+// Tags are stored lowercase so that they can be matched case-insensitively
+func normaliseKbTags(tags []string) []string {
+	normalised := make([]string, len(tags))
+	for i, tag := range tags {
+		normalised[i] = strings.ToLower(strings.TrimSpace(tag))
+	}
+
+	return normalised
+}
+
+func scanKbArticles(rows pgx.Rows) ([]KbArticle, error) {
+	var articles []KbArticle
+	for rows.Next() {
+		var article KbArticle
+		if err := rows.Scan(
+			&article.Id,
+			&article.GuildId,
+			&article.Title,
+			&article.Body,
+			&article.Tags,
+			&article.CreatedAt,
+			&article.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		articles = append(articles, article)
+	}
+
+	return articles, nil
+}
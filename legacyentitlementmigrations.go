@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const defaultLegacyEntitlementMigrationBatchSize = 500
+
+type LegacyEntitlementUnmappableReason string
+
+const (
+	LegacyEntitlementUnmappableExpired                LegacyEntitlementUnmappableReason = "expired"
+	LegacyEntitlementUnmappableNotSubscriptionSku     LegacyEntitlementUnmappableReason = "not_subscription_sku"
+	LegacyEntitlementUnmappableConflictingEntitlement LegacyEntitlementUnmappableReason = "conflicting_entitlement"
+)
+
+// UnmappableLegacyGuild is a legacy guild assignment that MigrateLegacyEntitlements skips, and which must be resolved
+// manually.
+type UnmappableLegacyGuild struct {
+	UserId        uint64                            `json:"user_id,string"`
+	GuildId       uint64                            `json:"guild_id,string"`
+	EntitlementId uuid.UUID                         `json:"entitlement_id"`
+	SkuId         uuid.UUID                         `json:"sku_id"`
+	Reason        LegacyEntitlementUnmappableReason `json:"reason"`
+}
+
+type LegacyEntitlementMigrationReport struct {
+	Pending    int                     `json:"pending"` // Assignments that would be migrated
+	Unmappable []UnmappableLegacyGuild `json:"unmappable"`
+}
+
+// LegacyEntitlementMigrations records the legacy_premium_entitlement_guilds rows that have been converted to guild
+// entitlements, so that MigrateLegacyEntitlements can be resumed and rerun safely.
+type LegacyEntitlementMigrations struct {
+	*pgxpool.Pool
+}
+
+var (
+	legacyEntitlementMigrationsSql            = loadSqlTable("legacy_entitlement_migrations")
+	legacyEntitlementMigrationsSchema         = legacyEntitlementMigrationsSql.schema()
+	legacyEntitlementMigrationsGet            = legacyEntitlementMigrationsSql.query("get")
+	legacyEntitlementMigrationsMigrateBatch   = legacyEntitlementMigrationsSql.query("migrate_batch")
+	legacyEntitlementMigrationsCountPending   = legacyEntitlementMigrationsSql.query("count_pending")
+	legacyEntitlementMigrationsListUnmappable = legacyEntitlementMigrationsSql.query("list_unmappable")
+)
+
+func newLegacyEntitlementMigrations(db *pgxpool.Pool) *LegacyEntitlementMigrations {
+	return &LegacyEntitlementMigrations{
+		db,
+	}
+}
+
+func (LegacyEntitlementMigrations) Schema() string {
+	return legacyEntitlementMigrationsSchema
+}
+
+// Get returns the entitlement that the user's legacy assignment to the guild was converted to, and when.
+func (m *LegacyEntitlementMigrations) Get(ctx context.Context, userId, guildId uint64) (uuid.UUID, time.Time, bool, error) {
+	var entitlementId uuid.UUID
+	var migratedAt time.Time
+	if err := m.QueryRow(ctx, legacyEntitlementMigrationsGet, userId, guildId).Scan(&entitlementId, &migratedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.UUID{}, time.Time{}, false, nil
+		} else {
+			return uuid.UUID{}, time.Time{}, false, err
+		}
+	}
+
+	return entitlementId, migratedAt, true, nil
+}
+
+// MigrateLegacyEntitlements converts the legacy_premium_entitlement_guilds assignments to guild entitlements: the
+// entitlement each assignment points to is updated to belong to the guild, with the SKU and expiry of the user's legacy
+// entitlement and the patreon source, and the assignment is recorded in legacy_entitlement_migrations. Each batch is
+// converted in a single statement, so an interrupted migration can be resumed by calling it again, and assignments
+// that have already been migrated are skipped. Assignments that cannot be mapped automatically are skipped, and can be
+// listed with DryRunLegacyEntitlementMigration. The legacy rows are retained until the legacy sync is retired. Returns
+// the number of assignments migrated.
+func (d *Database) MigrateLegacyEntitlements(ctx context.Context, batchSize int) (migrated int, err error) {
+	if batchSize <= 0 {
+		batchSize = defaultLegacyEntitlementMigrationBatchSize
+	}
+
+	for {
+		res, err := d.pool.Exec(ctx, legacyEntitlementMigrationsMigrateBatch, batchSize)
+		if err != nil {
+			return migrated, err
+		}
+
+		count := int(res.RowsAffected())
+		migrated += count
+
+		if count < batchSize {
+			return migrated, nil
+		}
+	}
+}
+
+// DryRunLegacyEntitlementMigration returns the number of assignments that MigrateLegacyEntitlements would convert, and
+// the assignments that it would skip because they cannot be mapped automatically, without making any changes.
+func (d *Database) DryRunLegacyEntitlementMigration(ctx context.Context) (LegacyEntitlementMigrationReport, error) {
+	var report LegacyEntitlementMigrationReport
+	if err := d.pool.QueryRow(ctx, legacyEntitlementMigrationsCountPending).Scan(&report.Pending); err != nil {
+		return LegacyEntitlementMigrationReport{}, err
+	}
+
+	rows, err := d.pool.Query(ctx, legacyEntitlementMigrationsListUnmappable)
+	if err != nil {
+		return LegacyEntitlementMigrationReport{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var guild UnmappableLegacyGuild
+		if err := rows.Scan(&guild.UserId, &guild.GuildId, &guild.EntitlementId, &guild.SkuId, &guild.Reason); err != nil {
+			return LegacyEntitlementMigrationReport{}, err
+		}
+
+		report.Unmappable = append(report.Unmappable, guild)
+	}
+
+	if err := rows.Err(); err != nil {
+		return LegacyEntitlementMigrationReport{}, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+type TableMaintenanceStats struct {
+	Table         string     `json:"table"`
+	LiveRows      int64      `json:"live_rows"`
+	DeadRows      int64      `json:"dead_rows"`
+	DeadRatio     float64    `json:"dead_ratio"`      // Dead rows as a fraction of all rows, 0 if the table is empty
+	SizeBytes     int64      `json:"size_bytes"`      // Including indexes and TOAST
+	LastVacuumAt  *time.Time `json:"last_vacuum_at"`  // Most recent manual or automatic vacuum
+	LastAnalyzeAt *time.Time `json:"last_analyze_at"` // Most recent manual or automatic analyze
+}
+
+// MaintenanceStats returns dead row statistics for each table created by this package, most bloated first, so that
+// operators can decide which tables to Vacuum.
+func (d *Database) MaintenanceStats(ctx context.Context) ([]TableMaintenanceStats, error) {
+	tableNames, _ := d.schemaObjectNames()
+
+	query := `
+SELECT
+	relname,
+	n_live_tup,
+	n_dead_tup,
+	CASE WHEN n_live_tup + n_dead_tup = 0 THEN 0 ELSE n_dead_tup::float8 / (n_live_tup + n_dead_tup) END AS dead_ratio,
+	pg_total_relation_size(relid),
+	GREATEST(last_vacuum, last_autovacuum),
+	GREATEST(last_analyze, last_autoanalyze)
+FROM pg_stat_user_tables
+WHERE schemaname = current_schema() AND relname = ANY($1::text[])
+ORDER BY dead_ratio DESC, n_dead_tup DESC;`
+
+	rows, err := d.pool.Query(ctx, query, tableNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []TableMaintenanceStats
+	for rows.Next() {
+		var table TableMaintenanceStats
+		if err := rows.Scan(
+			&table.Table,
+			&table.LiveRows,
+			&table.DeadRows,
+			&table.DeadRatio,
+			&table.SizeBytes,
+			&table.LastVacuumAt,
+			&table.LastAnalyzeAt,
+		); err != nil {
+			return nil, err
+		}
+
+		stats = append(stats, table)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// Vacuum runs VACUUM, and ANALYZE if analyze is true, on each of the given tables in turn. Only tables created by this
+// package may be vacuumed. VACUUM cannot run inside a transaction, so tables vacuumed before an error are not rolled
+// back.
+func (d *Database) Vacuum(ctx context.Context, tables []string, analyze bool) error {
+	tableNames, _ := d.schemaObjectNames()
+
+	for _, table := range tables {
+		if !slices.Contains(tableNames, table) {
+			return fmt.Errorf("table %s is not managed by this package", table)
+		}
+	}
+
+	for _, table := range tables {
+		query := "VACUUM "
+		if analyze {
+			query += "(ANALYZE) "
+		}
+
+		query += pgx.Identifier{table}.Sanitize()
+
+		if _, err := d.pool.Exec(ctx, query); err != nil {
+			return fmt.Errorf("failed to vacuum %s: %w", table, err)
+		}
+	}
+
+	return nil
+}
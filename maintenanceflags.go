@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type MaintenanceFeature string
+
+const (
+	MaintenanceFeatureTicketCreation MaintenanceFeature = "ticket_creation"
+	MaintenanceFeatureTranscripts    MaintenanceFeature = "transcripts"
+	MaintenanceFeatureDashboard      MaintenanceFeature = "dashboard"
+	MaintenanceFeatureIntegrations   MaintenanceFeature = "integrations"
+)
+
+type MaintenanceFlag struct {
+	GuildId   *uint64            `json:"guild_id,string"` // Null for flags that apply to all guilds
+	Feature   MaintenanceFeature `json:"feature"`
+	Enabled   bool               `json:"enabled"`
+	Message   *string            `json:"message"`
+	SetBy     uint64             `json:"set_by,string"`
+	ExpiresAt *time.Time         `json:"expires_at"` // Null if the flag must be disabled manually
+}
+
+type MaintenanceFlagsTable struct {
+	*pgxpool.Pool
+}
+
+func newMaintenanceFlagsTable(db *pgxpool.Pool) *MaintenanceFlagsTable {
+	return &MaintenanceFlagsTable{
+		db,
+	}
+}
+
+func (m MaintenanceFlagsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS maintenance_flags(
+	"guild_id" int8 DEFAULT NULL,
+	"feature" varchar(32) NOT NULL,
+	"enabled" bool NOT NULL DEFAULT true,
+	"message" varchar(255) DEFAULT NULL,
+	"set_by" int8 NOT NULL,
+	"expires_at" timestamptz DEFAULT NULL,
+	UNIQUE NULLS NOT DISTINCT ("guild_id", "feature")
+);
+CREATE INDEX IF NOT EXISTS maintenance_flags_active ON maintenance_flags("guild_id") WHERE "enabled";
+`
+}
+
+// Get returns the flag for the feature in the given scope. Pass a nil guild ID for the global flag.
+func (m *MaintenanceFlagsTable) Get(ctx context.Context, guildId *uint64, feature MaintenanceFeature) (MaintenanceFlag, bool, error) {
+	query := `
+SELECT "guild_id", "feature", "enabled", "message", "set_by", "expires_at"
+FROM maintenance_flags
+WHERE "guild_id" IS NOT DISTINCT FROM $1 AND "feature" = $2;`
+
+	var flag MaintenanceFlag
+	if err := m.QueryRow(ctx, query, guildId, feature).Scan(flag.fieldPtrs()...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return MaintenanceFlag{}, false, nil
+		} else {
+			return MaintenanceFlag{}, false, err
+		}
+	}
+
+	return flag, true, nil
+}
+
+func (m *MaintenanceFlagsTable) Set(ctx context.Context, flag MaintenanceFlag) (err error) {
+	query := `
+INSERT INTO maintenance_flags("guild_id", "feature", "enabled", "message", "set_by", "expires_at")
+VALUES($1, $2, $3, $4, $5, $6)
+ON CONFLICT("guild_id", "feature") DO UPDATE
+SET "enabled" = EXCLUDED."enabled", "message" = EXCLUDED."message", "set_by" = EXCLUDED."set_by", "expires_at" = EXCLUDED."expires_at";`
+
+	_, err = m.Exec(ctx, query, flag.GuildId, flag.Feature, flag.Enabled, flag.Message, flag.SetBy, flag.ExpiresAt)
+	return
+}
+
+func (m *MaintenanceFlagsTable) Delete(ctx context.Context, guildId *uint64, feature MaintenanceFeature) (err error) {
+	query := `DELETE FROM maintenance_flags WHERE "guild_id" IS NOT DISTINCT FROM $1 AND "feature" = $2;`
+	_, err = m.Exec(ctx, query, guildId, feature)
+	return
+}
+
+// GetActiveFlags returns the enabled, unexpired flags that apply to the guild, including global flags. If a feature is
+// flagged both globally and for the guild, only the guild's flag is returned.
+func (m *MaintenanceFlagsTable) GetActiveFlags(ctx context.Context, guildId uint64) ([]MaintenanceFlag, error) {
+	query := `
+SELECT DISTINCT ON ("feature") "guild_id", "feature", "enabled", "message", "set_by", "expires_at"
+FROM maintenance_flags
+WHERE ("guild_id" = $1 OR "guild_id" IS NULL) AND "enabled" AND ("expires_at" IS NULL OR "expires_at" > NOW())
+ORDER BY "feature", "guild_id" NULLS LAST;`
+
+	rows, err := m.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flags []MaintenanceFlag
+	for rows.Next() {
+		var flag MaintenanceFlag
+		if err := rows.Scan(flag.fieldPtrs()...); err != nil {
+			return nil, err
+		}
+
+		flags = append(flags, flag)
+	}
+
+	return flags, nil
+}
+
+func (f *MaintenanceFlag) fieldPtrs() []interface{} {
+	return []interface{}{
+		&f.GuildId,
+		&f.Feature,
+		&f.Enabled,
+		&f.Message,
+		&f.SetBy,
+		&f.ExpiresAt,
+	}
+}
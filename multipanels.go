@@ -9,7 +9,7 @@ import (
 
 type MultiPanel struct {
 	Id                    int                    `json:"id"`
-	MessageId             uint64                 `json:"message_id,string"`
+	MessageId             NullSnowflake          `json:"message_id"`
 	ChannelId             uint64                 `json:"channel_id,string"`
 	GuildId               uint64                 `json:"guild_id,string"`
 	SelectMenu            bool                   `json:"select_menu"`
@@ -31,7 +31,7 @@ func (MultiPanelTable) Schema() string {
 	return `
 CREATE TABLE IF NOT EXISTS multi_panels(
 	"id" SERIAL NOT NULL,
-	"message_id" int8 NOT NULL,
+	"message_id" int8,
 	"channel_id" int8 NOT NULL,
 	"guild_id" int8 NOT NULL,
 	"select_menu" bool DEFAULT 'f',
@@ -40,7 +40,9 @@ CREATE TABLE IF NOT EXISTS multi_panels(
 	PRIMARY KEY("id")
 );
 CREATE INDEX IF NOT EXISTS multi_panels_guild_id ON multi_panels("guild_id");
-CREATE INDEX IF NOT EXISTS multi_panels_message_id ON multi_panels("message_id");`
+CREATE INDEX IF NOT EXISTS multi_panels_message_id ON multi_panels("message_id");
+CREATE INDEX IF NOT EXISTS multi_panels_embed ON multi_panels USING GIN("embed" jsonb_path_ops);` +
+		nullableSnowflakeMigration("multi_panels", "message_id")
 }
 
 func (p *MultiPanelTable) Get(ctx context.Context, id int) (MultiPanel, bool, error) {
@@ -146,6 +148,49 @@ WHERE "guild_id" = $1;
 	return panels, nil
 }
 
+// FindWithEmbedField returns the multi-panels, across all guilds, whose embed has the top-level key set to value, e.g.
+// FindWithEmbedField(ctx, "color", 0) for embeds with a black colour. The lookup uses the GIN index on embed, so it
+// does not require a full scan.
+func (p *MultiPanelTable) FindWithEmbedField(ctx context.Context, key string, value interface{}) ([]MultiPanel, error) {
+	query := `
+SELECT "id", "message_id", "channel_id", "guild_id", "select_menu", "select_menu_placeholder", "embed"
+FROM multi_panels
+WHERE "embed" @> $1::jsonb;
+`
+
+	containment, err := json.Marshal(map[string]interface{}{key: value})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.Query(ctx, query, string(containment))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var panels []MultiPanel
+	for rows.Next() {
+		var panel MultiPanel
+		var embedRaw *string
+		if err := rows.Scan(
+			&panel.Id, &panel.MessageId, &panel.ChannelId, &panel.GuildId, &panel.SelectMenu, &panel.SelectMenuPlaceholder, &embedRaw,
+		); err != nil {
+			return nil, err
+		}
+
+		if embedRaw != nil {
+			if err := json.Unmarshal([]byte(*embedRaw), &panel.Embed); err != nil {
+				return nil, err
+			}
+		}
+
+		panels = append(panels, panel)
+	}
+
+	return panels, nil
+}
+
 func (p *MultiPanelTable) Create(ctx context.Context, panel MultiPanel) (int, error) {
 	query := `
 INSERT INTO
@@ -213,7 +258,7 @@ SET "message_id" = $1
 WHERE "id" = $2;
 `
 
-	_, err = p.Exec(ctx, query, messageId, multiPanelId)
+	_, err = p.Exec(ctx, query, NewNullSnowflake(messageId), multiPanelId)
 	return
 }
 
@@ -29,7 +29,7 @@ func (p MultiPanelTargets) Schema() string {
 CREATE TABLE IF NOT EXISTS multi_panel_targets(
 	"multi_panel_id" int4 NOT NULL,
 	"panel_id" int NOT NULL,
-	"position" int NOT NULL DEFAULT 0,
+	"position" int NOT NULL,
 	"custom_label" VARCHAR(80),
 	"description" VARCHAR(100),
 	"custom_emoji_name" VARCHAR(32),
@@ -37,9 +37,23 @@ CREATE TABLE IF NOT EXISTS multi_panel_targets(
 	FOREIGN KEY("multi_panel_id") REFERENCES multi_panels("id") ON DELETE CASCADE,
 	FOREIGN KEY ("panel_id") REFERENCES panels("panel_id") ON DELETE CASCADE,
 	PRIMARY KEY("multi_panel_id", "panel_id"),
-	UNIQUE("multi_panel_id", "position")
+	UNIQUE("multi_panel_id", "position") DEFERRABLE INITIALLY IMMEDIATE
 );
 CREATE INDEX IF NOT EXISTS multi_panel_targets_multi_panel_id ON multi_panel_targets("multi_panel_id");
+ALTER TABLE multi_panel_targets ALTER COLUMN "position" DROP DEFAULT;
+DO $$
+BEGIN
+	IF EXISTS(
+		SELECT 1
+		FROM pg_constraint
+		WHERE conrelid = 'multi_panel_targets'::regclass
+			AND conname = 'multi_panel_targets_multi_panel_id_position_key'
+			AND NOT condeferrable
+	) THEN
+		ALTER TABLE multi_panel_targets DROP CONSTRAINT multi_panel_targets_multi_panel_id_position_key;
+		ALTER TABLE multi_panel_targets ADD CONSTRAINT multi_panel_targets_multi_panel_id_position_key UNIQUE("multi_panel_id", "position") DEFERRABLE INITIALLY IMMEDIATE;
+	END IF;
+END $$;
 `
 }
 
@@ -169,6 +183,8 @@ WHERE multi_panel_targets.panel_id = $1;
 	return multiPanels, nil
 }
 
+// Insert adds the panel to the multi-panel, or updates it if it is already a target. Positions start from 1, as set by
+// Reorder.
 func (p *MultiPanelTargets) Insert(ctx context.Context, multiPanelId, panelId, position int, customLabel, description, customEmojiName *string, customEmojiId *uint64) (err error) {
 	query := `
 INSERT INTO multi_panel_targets("multi_panel_id", "panel_id", "position", "custom_label", "description", "custom_emoji_name", "custom_emoji_id")
@@ -205,3 +221,20 @@ WHERE
 	_, err = p.Exec(ctx, query, multiPanelId, panelId)
 	return
 }
+
+// Reorder sets the positions of the multi-panel's targets to match the order of panelIds, which must contain every
+// target of the multi-panel.
+func (p *MultiPanelTargets) Reorder(ctx context.Context, multiPanelId int, panelIds []int) error {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := reorderPositions(ctx, tx, "multi_panel_targets", "multi_panel_id", "panel_id", multiPanelId, panelIds); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// OrphanedTicket is an open ticket whose channel was found to no longer exist. Tickets are held here for review rather
+// than being closed straight away, as the channel may only be missing due to a Discord outage.
+type OrphanedTicket struct {
+	GuildId    uint64    `json:"guild_id,string"`
+	TicketId   int       `json:"ticket_id"`
+	ChannelId  *uint64   `json:"channel_id,string"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+type OrphanedTicketsTable struct {
+	*pgxpool.Pool
+}
+
+func newOrphanedTicketsTable(db *pgxpool.Pool) *OrphanedTicketsTable {
+	return &OrphanedTicketsTable{
+		db,
+	}
+}
+
+func (o OrphanedTicketsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS orphaned_tickets(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"channel_id" int8 DEFAULT NULL,
+	"detected_at" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "ticket_id")
+);
+`
+}
+
+func (o *OrphanedTicketsTable) GetByGuild(ctx context.Context, guildId uint64) ([]OrphanedTicket, error) {
+	query := `
+SELECT "guild_id", "ticket_id", "channel_id", "detected_at"
+FROM orphaned_tickets
+WHERE "guild_id" = $1
+ORDER BY "detected_at" ASC;`
+
+	rows, err := o.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tickets []OrphanedTicket
+	for rows.Next() {
+		var ticket OrphanedTicket
+		if err := rows.Scan(&ticket.GuildId, &ticket.TicketId, &ticket.ChannelId, &ticket.DetectedAt); err != nil {
+			return nil, err
+		}
+
+		tickets = append(tickets, ticket)
+	}
+
+	return tickets, nil
+}
+
+// MarkOrphaned records the tickets for review, along with the channel they had at the time. Only open tickets are
+// recorded, and tickets that are already recorded keep their original detection time.
+func (o *OrphanedTicketsTable) MarkOrphaned(ctx context.Context, guildId uint64, ticketIds []int) (err error) {
+	query := `
+INSERT INTO orphaned_tickets("guild_id", "ticket_id", "channel_id", "detected_at")
+SELECT "guild_id", "id", "channel_id", NOW()
+FROM tickets
+WHERE "guild_id" = $1 AND "id" = ANY($2) AND "open" = true
+ON CONFLICT("guild_id", "ticket_id") DO NOTHING;`
+
+	array := &pgtype.Int4Array{}
+	if err := array.Set(ticketIds); err != nil {
+		return err
+	}
+
+	_, err = o.Exec(ctx, query, guildId, array)
+	return
+}
+
+// Delete removes the ticket from review, e.g. once it has been closed or its channel has been found.
+func (o *OrphanedTicketsTable) Delete(ctx context.Context, guildId uint64, ticketId int) (err error) {
+	query := `DELETE FROM orphaned_tickets WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+	_, err = o.Exec(ctx, query, guildId, ticketId)
+	return
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+)
+
+// GetOutOfHoursPanels returns the IDs of the guild's panels that are currently outside their support hours and whose
+// out of hours behaviour is to block creation, i.e. the panels whose buttons should be rendered as disabled. Panels
+// without any support hours configured are always active, and panels without settings use the default behaviour of
+// blocking creation. As with IsActiveNow, invalid timezones fall back to UTC.
+func (d *Database) GetOutOfHoursPanels(ctx context.Context, guildId uint64) ([]int, error) {
+	query := `
+WITH panel_hours AS (
+	SELECT
+		panel_support_hours.panel_id,
+		panel_support_hours.day_of_week,
+		panel_support_hours.start_time,
+		panel_support_hours.end_time,
+		panel_support_hours.enabled,
+		NOW() AT TIME ZONE COALESCE(pg_timezone_names.name, 'UTC') AS local_now
+	FROM panel_support_hours
+	INNER JOIN panels
+	ON panels.panel_id = panel_support_hours.panel_id
+	LEFT JOIN pg_timezone_names
+	ON pg_timezone_names.name = panel_support_hours.timezone
+	WHERE panels.guild_id = $1
+)
+SELECT panel_hours.panel_id
+FROM panel_hours
+LEFT JOIN panel_support_hours_settings
+ON panel_support_hours_settings.panel_id = panel_hours.panel_id
+WHERE COALESCE(panel_support_hours_settings.out_of_hours_behaviour, $2) = $2
+GROUP BY panel_hours.panel_id
+HAVING NOT bool_or(
+	panel_hours.enabled
+	AND panel_hours.day_of_week = EXTRACT(DOW FROM panel_hours.local_now)
+	AND panel_hours.local_now::TIME BETWEEN panel_hours.start_time AND panel_hours.end_time
+);`
+
+	rows, err := d.pool.Query(ctx, query, guildId, OutOfHoursBehaviourBlockCreation)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var panelIds []int
+	for rows.Next() {
+		var panelId int
+		if err := rows.Scan(&panelId); err != nil {
+			return nil, err
+		}
+
+		panelIds = append(panelIds, panelId)
+	}
+
+	return panelIds, nil
+}
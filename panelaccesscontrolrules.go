@@ -46,6 +46,9 @@ var (
 	//go:embed sql/panel_access_control_rules/get_all_for_guild.sql
 	panelAccessControlRulesGetAllForGuild string
 
+	//go:embed sql/panel_access_control_rules/get_role_ids_by_guild.sql
+	panelAccessControlRulesGetRoleIdsByGuild string
+
 	//go:embed sql/panel_access_control_rules/get_first_matched.sql
 	panelAccessControlRulesGetFirstMatched string
 
@@ -101,6 +104,29 @@ func (p *PanelAccessControlRules) GetAllForGuild(ctx context.Context, guildId ui
 	return rules, nil
 }
 
+// GetAllByGuild returns a map[panel_id][]role_id, with the role IDs of each panel's rules in order of position
+func (p *PanelAccessControlRules) GetAllByGuild(ctx context.Context, guildId uint64) (map[int][]uint64, error) {
+	rows, err := p.Query(ctx, panelAccessControlRulesGetRoleIdsByGuild, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	roles := make(map[int][]uint64)
+	for rows.Next() {
+		var panelId int
+		var roleId uint64
+		if err := rows.Scan(&panelId, &roleId); err != nil {
+			return nil, err
+		}
+
+		roles[panelId] = append(roles[panelId], roleId)
+	}
+
+	return roles, rows.Err()
+}
+
 func (p *PanelAccessControlRules) GetFirstMatched(ctx context.Context, panelId int, userRoles []uint64) (uint64, AccessControlAction, error) {
 	idArray := &pgtype.Int8Array{}
 	if err := idArray.Set(userRoles); err != nil {
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+var ErrPanelNotFound = errors.New("panel not found")
+
+// PanelDependencies are the rows that would be affected by deleting a panel. The foreign keys already cascade or set
+// the references to null, so none of these block the deletion at the database level, but each silently changes
+// behaviour for users.
+type PanelDependencies struct {
+	PanelId int `json:"panel_id"`
+	// Multi-panels that have the panel as a target, which would lose the button
+	MultiPanelIds []int `json:"multi_panel_ids"`
+	// Open tickets opened from the panel, which would lose their panel's settings, e.g. teams and transcript destinations
+	OpenTicketIds []int `json:"open_ticket_ids"`
+	// Resend jobs that have not yet resent the panel
+	PendingResendJobIds []uuid.UUID `json:"pending_resend_job_ids"`
+	// Ticket requests waiting in the panel's queue
+	QueuedTicketRequestIds []int64 `json:"queued_ticket_request_ids"`
+	// Whether the panel is the guild's context menu panel
+	IsContextMenuPanel bool `json:"is_context_menu_panel"`
+}
+
+func (p PanelDependencies) HasDependencies() bool {
+	return len(p.MultiPanelIds) > 0 ||
+		len(p.OpenTicketIds) > 0 ||
+		len(p.PendingResendJobIds) > 0 ||
+		len(p.QueuedTicketRequestIds) > 0 ||
+		p.IsContextMenuPanel
+}
+
+// GetPanelDependencies returns the rows that reference the panel, so that callers can warn users or block deletion.
+// Returns ErrPanelNotFound if the panel does not exist.
+func (d *Database) GetPanelDependencies(ctx context.Context, panelId int) (PanelDependencies, error) {
+	var dependencies PanelDependencies
+	err := d.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		dependencies, _, err = getPanelDependencies(ctx, tx, panelId, false)
+		return err
+	})
+
+	return dependencies, err
+}
+
+// forceDeletePanelTx deletes the panel and explicitly detaches everything that references it: the panel is removed from
+// multi-panels, open tickets are left without a panel, pending resend jobs and queued ticket requests are deleted, and
+// the guild's context menu panel is unset. Multi-panels left without any targets are not deleted. Returns the
+// dependencies that were removed and the panel's guild ID, or ErrPanelNotFound if the panel does not exist. It is only
+// reachable through ExecuteForceDeletePanel and SystemForceDeletePanel, so that every forced deletion is audited.
+func forceDeletePanelTx(ctx context.Context, tx pgx.Tx, panelId int) (PanelDependencies, uint64, error) {
+	dependencies, guildId, err := getPanelDependencies(ctx, tx, panelId, true)
+	if err != nil {
+		return PanelDependencies{}, 0, err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM multi_panel_targets WHERE "panel_id" = $1;`, panelId); err != nil {
+		return PanelDependencies{}, 0, err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE tickets SET "panel_id" = NULL WHERE "guild_id" = $1 AND "panel_id" = $2;`, guildId, panelId); err != nil {
+		return PanelDependencies{}, 0, err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM panel_resend_jobs WHERE "panel_id" = $1;`, panelId); err != nil {
+		return PanelDependencies{}, 0, err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM queued_ticket_requests WHERE "panel_id" = $1;`, panelId); err != nil {
+		return PanelDependencies{}, 0, err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE settings SET "context_menu_panel" = NULL WHERE "guild_id" = $1 AND "context_menu_panel" = $2;`, guildId, panelId); err != nil {
+		return PanelDependencies{}, 0, err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM panels WHERE "panel_id" = $1;`, panelId); err != nil {
+		return PanelDependencies{}, 0, err
+	}
+
+	if err := recordAudit(ctx, tx, guildId, AuditActionPanelDelete, AuditResourcePanel, auditResourceId(panelId), nil); err != nil {
+		return PanelDependencies{}, 0, err
+	}
+
+	return dependencies, guildId, nil
+}
+
+// getPanelDependencies returns the panel's dependencies and guild ID. If lock is true, the panel row is locked for the
+// rest of the transaction.
+func getPanelDependencies(ctx context.Context, tx pgx.Tx, panelId int, lock bool) (PanelDependencies, uint64, error) {
+	panelQuery := `SELECT "guild_id" FROM panels WHERE "panel_id" = $1;`
+	if lock {
+		panelQuery = `SELECT "guild_id" FROM panels WHERE "panel_id" = $1 FOR UPDATE;`
+	}
+
+	var guildId uint64
+	if err := tx.QueryRow(ctx, panelQuery, panelId).Scan(&guildId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PanelDependencies{}, 0, ErrPanelNotFound
+		}
+
+		return PanelDependencies{}, 0, err
+	}
+
+	query := `
+SELECT
+	COALESCE((
+		SELECT array_agg(multi_panel_targets.multi_panel_id ORDER BY multi_panel_targets.multi_panel_id)
+		FROM multi_panel_targets
+		WHERE multi_panel_targets.panel_id = $1
+	), '{}'),
+	COALESCE((
+		SELECT array_agg(tickets.id ORDER BY tickets.id)
+		FROM tickets
+		WHERE tickets.guild_id = $2 AND tickets.panel_id = $1 AND tickets.open
+	), '{}'),
+	COALESCE((
+		SELECT array_agg(DISTINCT panel_resend_jobs.job_id::text)
+		FROM panel_resend_jobs
+		WHERE panel_resend_jobs.panel_id = $1 AND panel_resend_jobs.status = 'pending'
+	), '{}'),
+	COALESCE((
+		SELECT array_agg(queued_ticket_requests.id ORDER BY queued_ticket_requests.id)
+		FROM queued_ticket_requests
+		WHERE queued_ticket_requests.panel_id = $1 AND NOT queued_ticket_requests.processed
+	), '{}'),
+	EXISTS(SELECT 1 FROM settings WHERE settings.guild_id = $2 AND settings.context_menu_panel = $1);`
+
+	dependencies := PanelDependencies{
+		PanelId: panelId,
+	}
+
+	var jobIds []string
+	if err := tx.QueryRow(ctx, query, panelId, guildId).Scan(
+		&dependencies.MultiPanelIds,
+		&dependencies.OpenTicketIds,
+		&jobIds,
+		&dependencies.QueuedTicketRequestIds,
+		&dependencies.IsContextMenuPanel,
+	); err != nil {
+		return PanelDependencies{}, 0, err
+	}
+
+	for _, jobId := range jobIds {
+		parsed, err := uuid.Parse(jobId)
+		if err != nil {
+			return PanelDependencies{}, 0, err
+		}
+
+		dependencies.PendingResendJobIds = append(dependencies.PendingResendJobIds, parsed)
+	}
+
+	return dependencies, guildId, nil
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PanelLocalization overrides a panel's text for users with the given locale. Nil fields fall back to the panel's own
+// values.
+type PanelLocalization struct {
+	PanelId     int     `json:"panel_id"`
+	Locale      string  `json:"locale"`
+	Title       *string `json:"title"`
+	ButtonLabel *string `json:"button_label"`
+	Placeholder *string `json:"placeholder"`
+}
+
+type PanelLocalizationsTable struct {
+	*pgxpool.Pool
+}
+
+func newPanelLocalizationsTable(db *pgxpool.Pool) *PanelLocalizationsTable {
+	return &PanelLocalizationsTable{
+		db,
+	}
+}
+
+func (p PanelLocalizationsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS panel_localizations(
+	"panel_id" int NOT NULL,
+	"locale" varchar(8) NOT NULL,
+	"title" varchar(255) DEFAULT NULL,
+	"button_label" varchar(80) DEFAULT NULL,
+	"placeholder" varchar(150) DEFAULT NULL,
+	FOREIGN KEY("panel_id") REFERENCES panels("panel_id") ON DELETE CASCADE ON UPDATE CASCADE,
+	PRIMARY KEY("panel_id", "locale")
+);
+`
+}
+
+func (p *PanelLocalizationsTable) GetAllForPanel(ctx context.Context, panelId int) ([]PanelLocalization, error) {
+	query := `
+SELECT "panel_id", "locale", "title", "button_label", "placeholder"
+FROM panel_localizations
+WHERE "panel_id" = $1
+ORDER BY "locale" ASC;`
+
+	rows, err := p.Query(ctx, query, panelId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var localizations []PanelLocalization
+	for rows.Next() {
+		var localization PanelLocalization
+		if err := rows.Scan(
+			&localization.PanelId,
+			&localization.Locale,
+			&localization.Title,
+			&localization.ButtonLabel,
+			&localization.Placeholder,
+		); err != nil {
+			return nil, err
+		}
+
+		localizations = append(localizations, localization)
+	}
+
+	return localizations, nil
+}
+
+func (p *PanelLocalizationsTable) Upsert(ctx context.Context, localization PanelLocalization) (err error) {
+	query := `
+INSERT INTO panel_localizations("panel_id", "locale", "title", "button_label", "placeholder")
+VALUES($1, $2, $3, $4, $5)
+ON CONFLICT("panel_id", "locale") DO UPDATE
+SET "title" = EXCLUDED."title", "button_label" = EXCLUDED."button_label", "placeholder" = EXCLUDED."placeholder";`
+
+	_, err = p.Exec(ctx, query, localization.PanelId, localization.Locale, localization.Title, localization.ButtonLabel, localization.Placeholder)
+	return
+}
+
+func (p *PanelLocalizationsTable) Delete(ctx context.Context, panelId int, locale string) (err error) {
+	query := `DELETE FROM panel_localizations WHERE "panel_id" = $1 AND "locale" = $2;`
+	_, err = p.Exec(ctx, query, panelId, locale)
+	return
+}
+
+// getPanelLocalizationsByGuild returns panel ID -> localizations for every panel in the guild.
+func getPanelLocalizationsByGuild(ctx context.Context, pool *pgxpool.Pool, guildId uint64) (map[int][]PanelLocalization, error) {
+	query := `
+SELECT panel_localizations.panel_id, panel_localizations.locale, panel_localizations.title, panel_localizations.button_label, panel_localizations.placeholder
+FROM panel_localizations
+INNER JOIN panels
+ON panel_localizations.panel_id = panels.panel_id
+WHERE panels.guild_id = $1
+ORDER BY panel_localizations.locale ASC;`
+
+	rows, err := pool.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	localizations := make(map[int][]PanelLocalization)
+	for rows.Next() {
+		var localization PanelLocalization
+		if err := rows.Scan(
+			&localization.PanelId,
+			&localization.Locale,
+			&localization.Title,
+			&localization.ButtonLabel,
+			&localization.Placeholder,
+		); err != nil {
+			return nil, err
+		}
+
+		localizations[localization.PanelId] = append(localizations[localization.PanelId], localization)
+	}
+
+	return localizations, nil
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// PanelMentions is everything that is pinged when a ticket is opened from a panel.
+type PanelMentions struct {
+	PanelId     int      `json:"panel_id"`
+	RoleIds     []uint64 `json:"role_ids"`
+	MentionUser bool     `json:"mention_user"`
+	MentionHere bool     `json:"mention_here"`
+	// DefaultTeam is whether the default team is assigned to the panel, in which case DefaultTeamOnCallRole is the
+	// guild's on-call role, if it has one
+	DefaultTeam           bool          `json:"default_team"`
+	DefaultTeamOnCallRole *uint64       `json:"default_team_on_call_role_id"`
+	Teams                 []SupportTeam `json:"teams"`
+}
+
+// ResolvePanelMentions returns the panel's mentions, combining its role, user and here mentions, and its teams along
+// with their on-call roles. Returns false if the panel does not exist.
+func (d *Database) ResolvePanelMentions(ctx context.Context, panelId int) (PanelMentions, bool, error) {
+	query := `
+SELECT
+	panels.default_team,
+	CASE WHEN panels.default_team THEN guild_metadata.on_call_role END,
+	COALESCE(panel_user_mentions.should_mention_user, false),
+	COALESCE(panel_here_mentions.should_mention_here, false),
+	COALESCE((
+		SELECT json_agg(panel_role_mentions.role_id ORDER BY panel_role_mentions.role_id)
+		FROM panel_role_mentions
+		WHERE panel_role_mentions.panel_id = panels.panel_id
+	), '[]'::json),
+	COALESCE((
+		SELECT json_agg(json_build_object(
+			'id', support_team.id,
+			'guild_id', support_team.guild_id,
+			'name', support_team.name,
+			'on_call_role_id', support_team.on_call_role_id
+		) ORDER BY support_team.id)
+		FROM panel_teams
+		INNER JOIN support_team
+		ON support_team.id = panel_teams.team_id
+		WHERE panel_teams.panel_id = panels.panel_id
+	), '[]'::json)
+FROM panels
+LEFT JOIN panel_user_mentions
+ON panel_user_mentions.panel_id = panels.panel_id
+LEFT JOIN panel_here_mentions
+ON panel_here_mentions.panel_id = panels.panel_id
+LEFT JOIN guild_metadata
+ON guild_metadata.guild_id = panels.guild_id
+WHERE panels.panel_id = $1;`
+
+	mentions := PanelMentions{
+		PanelId: panelId,
+	}
+
+	var roleIdsRaw, teamsRaw string
+	if err := d.pool.QueryRow(ctx, query, panelId).Scan(
+		&mentions.DefaultTeam,
+		&mentions.DefaultTeamOnCallRole,
+		&mentions.MentionUser,
+		&mentions.MentionHere,
+		&roleIdsRaw,
+		&teamsRaw,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PanelMentions{}, false, nil
+		} else {
+			return PanelMentions{}, false, err
+		}
+	}
+
+	if err := json.Unmarshal([]byte(roleIdsRaw), &mentions.RoleIds); err != nil {
+		return PanelMentions{}, false, err
+	}
+
+	if err := json.Unmarshal([]byte(teamsRaw), &mentions.Teams); err != nil {
+		return PanelMentions{}, false, err
+	}
+
+	return mentions, true, nil
+}
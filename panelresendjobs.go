@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type PanelResendStatus string
+
+const (
+	PanelResendStatusPending   PanelResendStatus = "pending"
+	PanelResendStatusSucceeded PanelResendStatus = "succeeded"
+	PanelResendStatusFailed    PanelResendStatus = "failed"
+)
+
+// PanelResendJobEntry is the state of a single panel within a job that resends all of a guild's panel messages.
+type PanelResendJobEntry struct {
+	JobId        uuid.UUID         `json:"job_id"`
+	GuildId      uint64            `json:"guild_id,string"`
+	PanelId      int               `json:"panel_id"`
+	Status       PanelResendStatus `json:"status"`
+	NewMessageId *uint64           `json:"new_message_id,string"`
+	Error        *string           `json:"error"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+type PanelResendJobSummary struct {
+	JobId     uuid.UUID             `json:"job_id"`
+	Pending   int                   `json:"pending"`
+	Succeeded int                   `json:"succeeded"`
+	Failed    []PanelResendJobEntry `json:"failed"`
+}
+
+type PanelResendJobsTable struct {
+	*pgxpool.Pool
+}
+
+func newPanelResendJobsTable(db *pgxpool.Pool) *PanelResendJobsTable {
+	return &PanelResendJobsTable{
+		db,
+	}
+}
+
+func (p PanelResendJobsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS panel_resend_jobs(
+	"job_id" uuid NOT NULL,
+	"guild_id" int8 NOT NULL,
+	"panel_id" int NOT NULL,
+	"status" varchar(16) NOT NULL DEFAULT 'pending',
+	"new_message_id" int8 DEFAULT NULL,
+	"error" text DEFAULT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"updated_at" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("panel_id") REFERENCES panels("panel_id") ON DELETE CASCADE,
+	CHECK("status" IN ('pending', 'succeeded', 'failed')),
+	PRIMARY KEY("job_id", "panel_id")
+);
+CREATE INDEX IF NOT EXISTS panel_resend_jobs_guild_id ON panel_resend_jobs("guild_id");
+CREATE INDEX IF NOT EXISTS panel_resend_jobs_created_at ON panel_resend_jobs("created_at");
+`
+}
+
+// CreateJob creates a job to resend the given panels, with each panel pending, and returns the job's ID.
+func (p *PanelResendJobsTable) CreateJob(ctx context.Context, guildId uint64, panelIds []int) (uuid.UUID, error) {
+	query := `
+INSERT INTO panel_resend_jobs("job_id", "guild_id", "panel_id", "status")
+SELECT $1, $2, panel_id, 'pending'
+FROM unnest($3::int4[]) AS panel_id;`
+
+	array := &pgtype.Int4Array{}
+	if err := array.Set(panelIds); err != nil {
+		return uuid.Nil, err
+	}
+
+	jobId := uuid.New()
+	if _, err := p.Exec(ctx, query, jobId, guildId, array); err != nil {
+		return uuid.Nil, err
+	}
+
+	return jobId, nil
+}
+
+func (p *PanelResendJobsTable) MarkSucceeded(ctx context.Context, jobId uuid.UUID, panelId int, newMessageId uint64) (err error) {
+	query := `
+UPDATE panel_resend_jobs
+SET "status" = 'succeeded', "new_message_id" = $3, "error" = NULL, "updated_at" = NOW()
+WHERE "job_id" = $1 AND "panel_id" = $2;`
+
+	_, err = p.Exec(ctx, query, jobId, panelId, newMessageId)
+	return
+}
+
+func (p *PanelResendJobsTable) MarkFailed(ctx context.Context, jobId uuid.UUID, panelId int, errorMessage string) (err error) {
+	query := `
+UPDATE panel_resend_jobs
+SET "status" = 'failed', "error" = $3, "updated_at" = NOW()
+WHERE "job_id" = $1 AND "panel_id" = $2;`
+
+	_, err = p.Exec(ctx, query, jobId, panelId, errorMessage)
+	return
+}
+
+// ResetFailed sets the job's failed panels back to pending so that they can be retried, and returns their IDs.
+func (p *PanelResendJobsTable) ResetFailed(ctx context.Context, jobId uuid.UUID) ([]int, error) {
+	query := `
+UPDATE panel_resend_jobs
+SET "status" = 'pending', "error" = NULL, "updated_at" = NOW()
+WHERE "job_id" = $1 AND "status" = 'failed'
+RETURNING "panel_id";`
+
+	rows, err := p.Query(ctx, query, jobId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var panelIds []int
+	for rows.Next() {
+		var panelId int
+		if err := rows.Scan(&panelId); err != nil {
+			return nil, err
+		}
+
+		panelIds = append(panelIds, panelId)
+	}
+
+	return panelIds, nil
+}
+
+// GetSummary returns the number of pending and succeeded panels in the job, and the details of the failed panels.
+func (p *PanelResendJobsTable) GetSummary(ctx context.Context, jobId uuid.UUID) (PanelResendJobSummary, error) {
+	query := `
+SELECT "job_id", "guild_id", "panel_id", "status", "new_message_id", "error", "updated_at"
+FROM panel_resend_jobs
+WHERE "job_id" = $1
+ORDER BY "panel_id" ASC;`
+
+	rows, err := p.Query(ctx, query, jobId)
+	if err != nil {
+		return PanelResendJobSummary{}, err
+	}
+	defer rows.Close()
+
+	summary := PanelResendJobSummary{
+		JobId: jobId,
+	}
+
+	for rows.Next() {
+		var entry PanelResendJobEntry
+		if err := rows.Scan(
+			&entry.JobId,
+			&entry.GuildId,
+			&entry.PanelId,
+			&entry.Status,
+			&entry.NewMessageId,
+			&entry.Error,
+			&entry.UpdatedAt,
+		); err != nil {
+			return PanelResendJobSummary{}, err
+		}
+
+		switch entry.Status {
+		case PanelResendStatusPending:
+			summary.Pending++
+		case PanelResendStatusSucceeded:
+			summary.Succeeded++
+		case PanelResendStatusFailed:
+			summary.Failed = append(summary.Failed, entry)
+		}
+	}
+
+	return summary, nil
+}
+
+// DeleteOlderThan removes jobs created before the given time.
+func (p *PanelResendJobsTable) DeleteOlderThan(ctx context.Context, before time.Time) (err error) {
+	query := `DELETE FROM panel_resend_jobs WHERE "created_at" < $1;`
+	_, err = p.Exec(ctx, query, before)
+	return
+}
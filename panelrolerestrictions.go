@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type PanelRoleRestrictionType string
+
+const (
+	PanelRoleRestrictionAllow PanelRoleRestrictionType = "allow"
+	PanelRoleRestrictionDeny  PanelRoleRestrictionType = "deny"
+)
+
+type PanelRoleRestriction struct {
+	PanelId int                      `json:"panel_id"`
+	RoleId  uint64                   `json:"role_id,string"`
+	Type    PanelRoleRestrictionType `json:"type"`
+}
+
+// PanelRoleRestrictionsTable controls which members may use a panel at all. If a panel has any allowed roles, only
+// members with at least one of them may use it, and members with any denied role may never use it. Unlike
+// PanelAccessControlRules, these are not evaluated in order.
+type PanelRoleRestrictionsTable struct {
+	*pgxpool.Pool
+}
+
+func newPanelRoleRestrictionsTable(db *pgxpool.Pool) *PanelRoleRestrictionsTable {
+	return &PanelRoleRestrictionsTable{
+		db,
+	}
+}
+
+func (p PanelRoleRestrictionsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS panel_role_restrictions(
+	"panel_id" int NOT NULL,
+	"role_id" int8 NOT NULL,
+	"type" varchar(8) NOT NULL,
+	FOREIGN KEY("panel_id") REFERENCES panels("panel_id") ON DELETE CASCADE ON UPDATE CASCADE,
+	CHECK("type" IN ('allow', 'deny')),
+	PRIMARY KEY("panel_id", "role_id")
+);
+`
+}
+
+func (p *PanelRoleRestrictionsTable) GetByPanel(ctx context.Context, panelId int) ([]PanelRoleRestriction, error) {
+	query := `SELECT "panel_id", "role_id", "type" FROM panel_role_restrictions WHERE "panel_id" = $1;`
+
+	rows, err := p.Query(ctx, query, panelId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var restrictions []PanelRoleRestriction
+	for rows.Next() {
+		var restriction PanelRoleRestriction
+		if err := rows.Scan(&restriction.PanelId, &restriction.RoleId, &restriction.Type); err != nil {
+			return nil, err
+		}
+
+		restrictions = append(restrictions, restriction)
+	}
+
+	return restrictions, nil
+}
+
+// Set adds the role to the panel's allow or deny list, moving it from the other list if it is already present.
+func (p *PanelRoleRestrictionsTable) Set(ctx context.Context, restriction PanelRoleRestriction) (err error) {
+	query := `
+INSERT INTO panel_role_restrictions("panel_id", "role_id", "type")
+VALUES($1, $2, $3)
+ON CONFLICT("panel_id", "role_id") DO UPDATE SET "type" = EXCLUDED."type";`
+
+	_, err = p.Exec(ctx, query, restriction.PanelId, restriction.RoleId, restriction.Type)
+	return
+}
+
+func (p *PanelRoleRestrictionsTable) Delete(ctx context.Context, panelId int, roleId uint64) (err error) {
+	query := `DELETE FROM panel_role_restrictions WHERE "panel_id" = $1 AND "role_id" = $2;`
+	_, err = p.Exec(ctx, query, panelId, roleId)
+	return
+}
+
+func (p *PanelRoleRestrictionsTable) DeleteAll(ctx context.Context, panelId int) (err error) {
+	query := `DELETE FROM panel_role_restrictions WHERE "panel_id" = $1;`
+	_, err = p.Exec(ctx, query, panelId)
+	return
+}
+
+// CanUserSeePanel returns whether a member with the given roles may use the panel.
+func (p *PanelRoleRestrictionsTable) CanUserSeePanel(ctx context.Context, panelId int, roleIds []uint64) (allowed bool, err error) {
+	query := `
+SELECT
+	NOT EXISTS(
+		SELECT 1 FROM panel_role_restrictions
+		WHERE "panel_id" = $1 AND "type" = 'deny' AND "role_id" = ANY($2)
+	)
+	AND (
+		NOT EXISTS(
+			SELECT 1 FROM panel_role_restrictions
+			WHERE "panel_id" = $1 AND "type" = 'allow'
+		)
+		OR EXISTS(
+			SELECT 1 FROM panel_role_restrictions
+			WHERE "panel_id" = $1 AND "type" = 'allow' AND "role_id" = ANY($2)
+		)
+	);`
+
+	array := &pgtype.Int8Array{}
+	if err := array.Set(roleIds); err != nil {
+		return false, err
+	}
+
+	err = p.QueryRow(ctx, query, panelId, array).Scan(&allowed)
+	return
+}
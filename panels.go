@@ -2,6 +2,9 @@ package database
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
 
 	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
@@ -9,43 +12,45 @@ import (
 )
 
 type Panel struct {
-	PanelId                        int     `json:"panel_id"`
-	MessageId                      uint64  `json:"message_id,string"`
-	ChannelId                      uint64  `json:"channel_id,string"`
-	GuildId                        uint64  `json:"guild_id,string"`
-	Title                          string  `json:"title"`
-	Content                        string  `json:"content"`
-	Colour                         int32   `json:"colour"`
-	TargetCategory                 uint64  `json:"category_id,string"`
-	EmojiName                      *string `json:"emoji_name"`
-	EmojiId                        *uint64 `json:"emoji_id,string"`
-	WelcomeMessageEmbed            *int    `json:"welcome_message_embed"`
-	WithDefaultTeam                bool    `json:"default_team"`
-	CustomId                       string  `json:"custom_id"`
-	ImageUrl                       *string `json:"image_url,omitempty"`
-	ThumbnailUrl                   *string `json:"thumbnail_url,omitempty"`
-	ButtonStyle                    int     `json:"button_style"`
-	ButtonLabel                    string  `json:"button_label"`
-	FormId                         *int    `json:"form_id"`
-	NamingScheme                   *string `json:"naming_scheme"`
-	ForceDisabled                  bool    `json:"force_disabled"`
-	Disabled                       bool    `json:"disabled"`
-	ExitSurveyFormId               *int    `json:"exit_survey_form_id"`
-	PendingCategory                *uint64 `json:"pending_category,string"`
-	DeleteMentions                 bool    `json:"delete_mentions"`
-	TranscriptChannelId            *uint64 `json:"transcript_channel_id,string,omitempty"`
-	UseThreads                     bool    `json:"use_threads"`
-	TicketNotificationChannel      *uint64 `json:"ticket_notification_channel,string,omitempty"`
-	CooldownSeconds                int     `json:"cooldown_seconds"`
-	TicketLimit                    *uint8  `json:"ticket_limit,omitempty"`
-	HideCloseButton                bool    `json:"hide_close_button"`
-	HideCloseWithReasonButton      bool    `json:"hide_close_with_reason_button"`
-	HideClaimButton                bool    `json:"hide_claim_button"`
+	PanelId                   int           `json:"panel_id"`
+	MessageId                 NullSnowflake `json:"message_id"`
+	ChannelId                 uint64        `json:"channel_id,string"`
+	GuildId                   uint64        `json:"guild_id,string"`
+	Title                     string        `json:"title"`
+	Content                   string        `json:"content"`
+	Colour                    int32         `json:"colour"`
+	TargetCategory            NullSnowflake `json:"category_id"`
+	EmojiName                 *string       `json:"emoji_name"`
+	EmojiId                   *uint64       `json:"emoji_id,string"`
+	WelcomeMessageEmbed       *int          `json:"welcome_message_embed"`
+	WithDefaultTeam           bool          `json:"default_team"`
+	CustomId                  string        `json:"custom_id"`
+	ImageUrl                  *string       `json:"image_url,omitempty"`
+	ThumbnailUrl              *string       `json:"thumbnail_url,omitempty"`
+	ButtonStyle               int           `json:"button_style"`
+	ButtonLabel               string        `json:"button_label"`
+	FormId                    *int          `json:"form_id"`
+	NamingScheme              *string       `json:"naming_scheme"`
+	ForceDisabled             bool          `json:"force_disabled"`
+	Disabled                  bool          `json:"disabled"`
+	ExitSurveyFormId          *int          `json:"exit_survey_form_id"`
+	PendingCategory           *uint64       `json:"pending_category,string"`
+	DeleteMentions            bool          `json:"delete_mentions"`
+	TranscriptChannelId       *uint64       `json:"transcript_channel_id,string,omitempty"`
+	UseThreads                bool          `json:"use_threads"`
+	TicketNotificationChannel *uint64       `json:"ticket_notification_channel,string,omitempty"`
+	CooldownSeconds           int           `json:"cooldown_seconds"`
+	TicketLimit               *uint8        `json:"ticket_limit,omitempty"`
+	HideCloseButton           bool          `json:"hide_close_button"`
+	HideCloseWithReasonButton bool          `json:"hide_close_with_reason_button"`
+	HideClaimButton           bool          `json:"hide_claim_button"`
 }
 
 type PanelWithWelcomeMessage struct {
 	Panel
-	WelcomeMessage *CustomEmbed
+	WelcomeMessage           *CustomEmbed
+	VerificationRequirements *PanelVerificationRequirements
+	Localizations            []PanelLocalization
 }
 
 type PanelTable struct {
@@ -63,13 +68,13 @@ func (p PanelTable) Schema() string {
 	return `
 CREATE TABLE IF NOT EXISTS panels(
 	"panel_id" SERIAL NOT NULL UNIQUE,
-	"message_id" int8 NOT NULL UNIQUE,
+	"message_id" int8 UNIQUE,
 	"channel_id" int8 NOT NULL,
 	"guild_id" int8 NOT NULL,
 	"title" varchar(255) NOT NULL,
 	"content" text NOT NULL,
 	"colour" int4 NOT NULL,
-	"target_category" int8 NOT NULL,
+	"target_category" int8 DEFAULT NULL,
 	"emoji_name" varchar(32) DEFAULT NULL,
 	"emoji_id" int8 DEFAULT NULL,
 	"welcome_message" int NULL,
@@ -103,10 +108,56 @@ CREATE INDEX IF NOT EXISTS panels_guild_id ON panels("guild_id");
 CREATE INDEX IF NOT EXISTS panels_message_id ON panels("message_id");
 CREATE INDEX IF NOT EXISTS panels_form_id ON panels("form_id");
 CREATE INDEX IF NOT EXISTS panels_guild_id_form_id ON panels("guild_id", "form_id");
-CREATE INDEX IF NOT EXISTS panels_custom_id ON panels("custom_id");`
+CREATE INDEX IF NOT EXISTS panels_custom_id ON panels("custom_id");` +
+		nullableSnowflakeMigration("panels", "message_id") +
+		nullableSnowflakeMigration("panels", "target_category") +
+		checkConstraintMigration("panels", "panels_colour_range", `"colour" >= 0 AND "colour" <= 16777215`, `"colour" = "colour" & 16777215`) +
+		checkConstraintMigration("panels", "panels_button_style_range", `"button_style" >= 1 AND "button_style" <= 4`, `"button_style" = 1`) +
+		checkConstraintMigration("panels", "panels_button_label_or_emoji", `"button_label" <> '' OR "emoji_name" IS NOT NULL`, `"button_label" = 'Open a ticket!'`) +
+		checkConstraintMigration("panels", "panels_emoji_id_requires_name", `"emoji_id" IS NULL OR "emoji_name" IS NOT NULL`, `"emoji_id" = NULL`) +
+		checkConstraintMigration("panels", "panels_cooldown_non_negative", `"cooldown_seconds" >= 0`, `"cooldown_seconds" = 0`) + `
+DO $$
+BEGIN
+	IF to_regclass('panels_guild_id_custom_id_unique') IS NULL THEN
+		IF EXISTS(SELECT 1 FROM panels GROUP BY "guild_id", "custom_id" HAVING COUNT(*) > 1) THEN
+			RAISE WARNING 'panels_guild_id_custom_id_unique was not created as some panels share a custom ID, run PanelTable.DedupePanelCustomIds';
+		ELSE
+			` + createPanelCustomIdIndexQuery + `
+		END IF;
+	END IF;
+END $$;`
 }
 
-func (p *PanelTable) Get(ctx context.Context, messageId uint64) (panel Panel, e error) {
+// checkConstraintMigration returns a statement adding a CHECK constraint to the table, if it does not already exist.
+// Rows violating the constraint are first fixed by applying the fix assignment to them, and the constraint is then
+// validated. It only does anything until the constraint has been validated, so the table is not scanned every time the
+// schema is applied.
+func checkConstraintMigration(table, name, check, fix string) string {
+	return fmt.Sprintf(`
+DO $$
+BEGIN
+	IF NOT EXISTS(SELECT 1 FROM pg_constraint WHERE conrelid = '%[1]s'::regclass AND conname = '%[2]s') THEN
+		ALTER TABLE %[1]s ADD CONSTRAINT %[2]s CHECK(%[3]s) NOT VALID;
+	END IF;
+
+	IF NOT (SELECT convalidated FROM pg_constraint WHERE conrelid = '%[1]s'::regclass AND conname = '%[2]s') THEN
+		UPDATE %[1]s SET %[4]s WHERE NOT (%[3]s);
+		ALTER TABLE %[1]s VALIDATE CONSTRAINT %[2]s;
+	END IF;
+END $$;`, table, name, check, fix)
+}
+
+// createPanelCustomIdIndexQuery creates the unique index on custom IDs. It is only created by Schema if no panels share
+// a custom ID, so that existing buttons are never renamed automatically; otherwise, DedupePanelCustomIds must be run.
+const createPanelCustomIdIndexQuery = `CREATE UNIQUE INDEX IF NOT EXISTS panels_guild_id_custom_id_unique ON panels("guild_id", "custom_id");`
+
+// Deprecated: use GetByMessageId, which distinguishes a missing panel from an error.
+func (p *PanelTable) Get(ctx context.Context, messageId uint64) (Panel, error) {
+	panel, _, err := p.GetByMessageId(ctx, messageId)
+	return panel, err
+}
+
+func (p *PanelTable) GetByMessageId(ctx context.Context, messageId uint64) (panel Panel, ok bool, e error) {
 	query := `
 SELECT
 	panel_id,
@@ -145,15 +196,25 @@ FROM panels
 WHERE "message_id" = $1;
 `
 
-	if err := p.QueryRow(ctx, query, messageId).
-		Scan(panel.fieldPtrs()...); err != nil && err != pgx.ErrNoRows {
+	err := p.QueryRow(ctx, query, messageId).Scan(panel.fieldPtrs()...)
+	switch {
+	case err == nil:
+		ok = true
+	case errors.Is(err, pgx.ErrNoRows):
+	default:
 		e = err
 	}
 
 	return
 }
 
-func (p *PanelTable) GetById(ctx context.Context, panelId int) (panel Panel, e error) {
+// Deprecated: use GetByPanelId, which distinguishes a missing panel from an error.
+func (p *PanelTable) GetById(ctx context.Context, panelId int) (Panel, error) {
+	panel, _, err := p.GetByPanelId(ctx, panelId)
+	return panel, err
+}
+
+func (p *PanelTable) GetByPanelId(ctx context.Context, panelId int) (panel Panel, ok bool, e error) {
 	query := `
 SELECT
 	panel_id,
@@ -192,8 +253,12 @@ FROM panels
 WHERE "panel_id" = $1;
 `
 
-	if err := p.QueryRow(ctx, query, panelId).
-		Scan(panel.fieldPtrs()...); err != nil && err != pgx.ErrNoRows {
+	err := p.QueryRow(ctx, query, panelId).Scan(panel.fieldPtrs()...)
+	switch {
+	case err == nil:
+		ok = true
+	case errors.Is(err, pgx.ErrNoRows):
+	default:
 		e = err
 	}
 
@@ -248,10 +313,15 @@ SELECT
 	embeds.thumbnail_url,
 	embeds.footer_text,
 	embeds.footer_icon_url,
-	embeds.timestamp
+	embeds.timestamp,
+	panel_verification_requirements.required,
+	panel_verification_requirements.min_account_age_seconds,
+	panel_verification_requirements.min_join_age_seconds
 FROM panels
 LEFT JOIN embeds
 ON panels.welcome_message = embeds.id
+LEFT JOIN panel_verification_requirements
+ON panels.panel_id = panel_verification_requirements.panel_id
 WHERE panels.guild_id = $1
 AND panels.panel_id = $2;`
 
@@ -269,6 +339,9 @@ AND panels.panel_id = $2;`
 		var embedId *int
 		var embedGuildId *uint64
 		var embedColour *uint32
+		var verificationRequired *bool
+
+		var verification PanelVerificationRequirements
 
 		err := rows.Scan(append(pan.fieldPtrs(),
 			&embedId,
@@ -285,6 +358,9 @@ AND panels.panel_id = $2;`
 			&embed.FooterText,
 			&embed.FooterIconUrl,
 			&embed.Timestamp,
+			&verificationRequired,
+			&verification.MinAccountAgeSeconds,
+			&verification.MinJoinAgeSeconds,
 		)...)
 
 		if err != nil {
@@ -300,12 +376,34 @@ AND panels.panel_id = $2;`
 			embedPtr = &embed
 		}
 
+		var verificationPtr *PanelVerificationRequirements
+		if verificationRequired != nil {
+			verification.PanelId = pan.PanelId
+			verification.Required = *verificationRequired
+
+			verificationPtr = &verification
+		}
+
 		panel = &PanelWithWelcomeMessage{
-			Panel:          pan,
-			WelcomeMessage: embedPtr,
+			Panel:                    pan,
+			WelcomeMessage:           embedPtr,
+			VerificationRequirements: verificationPtr,
 		}
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if panel != nil {
+		localizations, err := newPanelLocalizationsTable(p.Pool).GetAllForPanel(ctx, panel.PanelId)
+		if err != nil {
+			return nil, err
+		}
+
+		panel.Localizations = localizations
+	}
+
 	return
 }
 
@@ -348,10 +446,11 @@ FROM panels
 WHERE "guild_id" = $1 AND "custom_id" = $2;
 `
 
-	switch err := p.QueryRow(ctx, query, guildId, customId).Scan(panel.fieldPtrs()...); err {
-	case nil:
+	err := p.QueryRow(ctx, query, guildId, customId).Scan(panel.fieldPtrs()...)
+	switch {
+	case err == nil:
 		ok = true
-	case pgx.ErrNoRows:
+	case errors.Is(err, pgx.ErrNoRows):
 	default:
 		e = err
 	}
@@ -398,10 +497,11 @@ FROM panels
 WHERE "guild_id" = $1 AND "form_id" = $2;
 `
 
-	switch err := p.QueryRow(ctx, query, guildId, formId).Scan(panel.fieldPtrs()...); err {
-	case nil:
+	err := p.QueryRow(ctx, query, guildId, formId).Scan(panel.fieldPtrs()...)
+	switch {
+	case err == nil:
 		ok = true
-	case pgx.ErrNoRows:
+	case errors.Is(err, pgx.ErrNoRows):
 	default:
 		e = err
 	}
@@ -450,10 +550,11 @@ ON forms.form_id = panels.form_id
 WHERE forms.guild_id = $1 AND forms.form_id = $2;
 `
 
-	switch err := p.QueryRow(ctx, query, guildId, customId).Scan(panel.fieldPtrs()...); err {
-	case nil:
+	err := p.QueryRow(ctx, query, guildId, customId).Scan(panel.fieldPtrs()...)
+	switch {
+	case err == nil:
 		ok = true
-	case pgx.ErrNoRows:
+	case errors.Is(err, pgx.ErrNoRows):
 	default:
 		e = err
 	}
@@ -566,10 +667,15 @@ SELECT
 	embeds.thumbnail_url,
 	embeds.footer_text,
 	embeds.footer_icon_url,
-	embeds.timestamp
+	embeds.timestamp,
+	panel_verification_requirements.required,
+	panel_verification_requirements.min_account_age_seconds,
+	panel_verification_requirements.min_join_age_seconds
 FROM panels
 LEFT JOIN embeds
 ON panels.welcome_message = embeds.id
+LEFT JOIN panel_verification_requirements
+ON panels.panel_id = panel_verification_requirements.panel_id
 WHERE panels.guild_id = $1
 ORDER BY panels.panel_id ASC;`
 
@@ -587,6 +693,9 @@ ORDER BY panels.panel_id ASC;`
 		var embedId *int
 		var embedGuildId *uint64
 		var embedColour *uint32
+		var verificationRequired *bool
+
+		var verification PanelVerificationRequirements
 
 		err := rows.Scan(append(panel.fieldPtrs(),
 			&embedId,
@@ -603,6 +712,9 @@ ORDER BY panels.panel_id ASC;`
 			&embed.FooterText,
 			&embed.FooterIconUrl,
 			&embed.Timestamp,
+			&verificationRequired,
+			&verification.MinAccountAgeSeconds,
+			&verification.MinJoinAgeSeconds,
 		)...)
 
 		if err != nil {
@@ -618,12 +730,34 @@ ORDER BY panels.panel_id ASC;`
 			embedPtr = &embed
 		}
 
+		var verificationPtr *PanelVerificationRequirements
+		if verificationRequired != nil {
+			verification.PanelId = panel.PanelId
+			verification.Required = *verificationRequired
+
+			verificationPtr = &verification
+		}
+
 		panels = append(panels, PanelWithWelcomeMessage{
-			Panel:          panel,
-			WelcomeMessage: embedPtr,
+			Panel:                    panel,
+			WelcomeMessage:           embedPtr,
+			VerificationRequirements: verificationPtr,
 		})
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	localizations, err := getPanelLocalizationsByGuild(ctx, p.Pool, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range panels {
+		panels[i].Localizations = localizations[panels[i].PanelId]
+	}
+
 	return
 }
 
@@ -642,10 +776,29 @@ func (p *PanelTable) Create(ctx context.Context, panel Panel) (int, error) {
 
 	defer tx.Rollback(ctx)
 
-	return p.CreateWithTx(ctx, tx, panel)
+	panelId, err := p.CreateWithTx(ctx, tx, panel)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return panelId, nil
 }
 
+// CreateWithTx returns ValidationErrors without touching the database if the panel fails ValidatePanel.
 func (p *PanelTable) CreateWithTx(ctx context.Context, tx pgx.Tx, panel Panel) (panelId int, err error) {
+	return p.insertWithTx(ctx, tx, panel, `ON CONFLICT("message_id") DO NOTHING`)
+}
+
+// insertWithTx inserts the panel with the given ON CONFLICT clause, returning pgx.ErrNoRows if nothing was inserted.
+func (p *PanelTable) insertWithTx(ctx context.Context, tx pgx.Tx, panel Panel, onConflict string) (panelId int, err error) {
+	if errs := ValidatePanel(panel); len(errs) > 0 {
+		return 0, ValidationErrors(errs)
+	}
+
 	query := `
 INSERT INTO panels(
 	"message_id",
@@ -681,7 +834,7 @@ INSERT INTO panels(
 	"hide_claim_button"
 )
 VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31)
-ON CONFLICT("message_id") DO NOTHING
+` + onConflict + `
 RETURNING "panel_id";`
 
 	err = tx.QueryRow(ctx, query,
@@ -717,8 +870,142 @@ RETURNING "panel_id";`
 		panel.HideCloseWithReasonButton,
 		panel.HideClaimButton,
 	).Scan(&panelId)
+	if err != nil {
+		return 0, err
+	}
 
-	return
+	panel.PanelId = panelId
+	if err := recordAudit(ctx, tx, panel.GuildId, AuditActionPanelCreate, AuditResourcePanel, auditResourceId(panelId), panel); err != nil {
+		return 0, err
+	}
+
+	return panelId, nil
+}
+
+// CreateOrGetByCustomId creates the panel, unless the guild already has a panel with the same custom ID, in which case
+// the existing panel's ID is returned and nothing is changed. Used by the importer to avoid creating duplicate panels
+// when an import is retried. Concurrent calls for the same custom ID are resolved by the unique index on custom IDs,
+// so only one panel is created.
+func (p *PanelTable) CreateOrGetByCustomId(ctx context.Context, panel Panel) (panelId int, created bool, err error) {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	panelId, err = p.insertWithTx(ctx, tx, panel, `ON CONFLICT DO NOTHING`)
+	if err == nil {
+		if err := tx.Commit(ctx); err != nil {
+			return 0, false, err
+		}
+
+		return panelId, true, nil
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, err
+	}
+
+	query := `SELECT "panel_id" FROM panels WHERE "guild_id" = $1 AND "custom_id" = $2;`
+	if err := tx.QueryRow(ctx, query, panel.GuildId, panel.CustomId).Scan(&panelId); err != nil {
+		return 0, false, err
+	}
+
+	return panelId, false, nil
+}
+
+// DedupePanelCustomIds renames the custom IDs of panels that share a custom ID with an older panel in the same guild,
+// keeping the oldest panel's custom ID unchanged, and then creates the unique index on custom IDs. Buttons already sent
+// for the renamed panels will open tickets from the oldest panel until the renamed panels are resent, so this is never
+// run automatically: Schema only creates the index if there are no duplicates. Renamed panels are suffixed with their
+// panel ID, and a counter if that is also taken. Returns the number of panels renamed.
+func (p *PanelTable) DedupePanelCustomIds(ctx context.Context) (renamed int64, err error) {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	// Block writes until the index has been created, so that no new duplicates can be created in the meantime
+	if _, err := tx.Exec(ctx, `LOCK TABLE panels IN SHARE ROW EXCLUSIVE MODE;`); err != nil {
+		return 0, err
+	}
+
+	query := `
+SELECT "panel_id", "guild_id", "custom_id"
+FROM (
+	SELECT "panel_id", "guild_id", "custom_id", ROW_NUMBER() OVER (PARTITION BY "guild_id", "custom_id" ORDER BY "panel_id" ASC) AS "rank"
+	FROM panels
+) AS ranked
+WHERE "rank" > 1
+ORDER BY "panel_id" ASC;`
+
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	type duplicate struct {
+		panelId  int
+		guildId  uint64
+		customId string
+	}
+
+	var duplicates []duplicate
+	for rows.Next() {
+		var d duplicate
+		if err := rows.Scan(&d.panelId, &d.guildId, &d.customId); err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		duplicates = append(duplicates, d)
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	renameQuery := `
+UPDATE panels
+SET "custom_id" = $2
+WHERE "panel_id" = $1 AND NOT EXISTS(SELECT 1 FROM panels WHERE "guild_id" = $3 AND "custom_id" = $2);`
+
+	for _, d := range duplicates {
+		for attempt := 1; ; attempt++ {
+			suffix := "-" + strconv.Itoa(d.panelId)
+			if attempt > 1 {
+				suffix += "-" + strconv.Itoa(attempt)
+			}
+
+			// custom_id is a varchar(100), which is measured in characters
+			prefix := []rune(d.customId)
+			if maxLength := panelCustomIdMaxLength - len(suffix); len(prefix) > maxLength {
+				prefix = prefix[:maxLength]
+			}
+
+			res, err := tx.Exec(ctx, renameQuery, d.panelId, string(prefix)+suffix, d.guildId)
+			if err != nil {
+				return 0, err
+			}
+
+			if res.RowsAffected() > 0 {
+				renamed++
+				break
+			}
+		}
+	}
+
+	if _, err := tx.Exec(ctx, createPanelCustomIdIndexQuery); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return renamed, nil
 }
 
 func (p *PanelTable) Update(ctx context.Context, panel Panel) (err error) {
@@ -736,7 +1023,12 @@ func (p *PanelTable) Update(ctx context.Context, panel Panel) (err error) {
 	return tx.Commit(ctx)
 }
 
+// UpdateWithTx returns ValidationErrors without touching the database if the panel fails ValidatePanel.
 func (p *PanelTable) UpdateWithTx(ctx context.Context, tx pgx.Tx, panel Panel) error {
+	if errs := ValidatePanel(panel); len(errs) > 0 {
+		return ValidationErrors(errs)
+	}
+
 	query := `
 UPDATE panels
 	SET "message_id" = $2,
@@ -771,9 +1063,11 @@ UPDATE panels
 		"hide_claim_button" = $31
 	WHERE
 		"panel_id" = $1
+	RETURNING "guild_id"
 ;`
 
-	_, err := tx.Exec(ctx, query,
+	var guildId uint64
+	err := tx.QueryRow(ctx, query,
 		panel.PanelId,
 		panel.MessageId,
 		panel.ChannelId,
@@ -805,9 +1099,16 @@ UPDATE panels
 		panel.HideCloseButton,
 		panel.HideCloseWithReasonButton,
 		panel.HideClaimButton,
-	)
+	).Scan(&guildId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
 
-	return err
+		return err
+	}
+
+	return recordAudit(ctx, tx, guildId, AuditActionPanelUpdate, AuditResourcePanel, auditResourceId(panel.PanelId), panel)
 }
 
 func (p *PanelTable) UpdateMessageId(ctx context.Context, panelId int, messageId uint64) (err error) {
@@ -817,7 +1118,7 @@ SET "message_id" = $1
 WHERE "panel_id" = $2;
 `
 
-	_, err = p.Exec(ctx, query, messageId, panelId)
+	_, err = p.Exec(ctx, query, NewNullSnowflake(messageId), panelId)
 	return
 }
 
@@ -832,6 +1133,20 @@ WHERE "guild_id" = $1;
 	return
 }
 
+// SetForceDisabledAll force disables or re-enables all of the guild's panels, e.g. when the guild is frozen. Panels
+// disabled by DisableSome are also re-enabled, so DisableSome should be called again after unfreezing a guild that is
+// over the free limit.
+func (p *PanelTable) SetForceDisabledAll(ctx context.Context, guildId uint64, disabled bool) (err error) {
+	query := `
+UPDATE panels
+SET "force_disabled" = $2
+WHERE "guild_id" = $1;
+`
+
+	_, err = p.Exec(ctx, query, guildId, disabled)
+	return
+}
+
 func (p *PanelTable) DisableSome(ctx context.Context, guildId uint64, freeLimit int) error {
 	txOpts := pgx.TxOptions{
 		IsoLevel:       pgx.Serializable,
@@ -889,9 +1204,8 @@ func (p *PanelTable) DisableSome(ctx context.Context, guildId uint64, freeLimit
 }
 
 func (p *PanelTable) Delete(ctx context.Context, panelId int) (err error) {
-	query := `DELETE FROM panels WHERE "panel_id"=$1;`
-	_, err = p.Exec(ctx, query, panelId)
-	return
+	query := `DELETE FROM panels WHERE "panel_id"=$1 RETURNING "guild_id";`
+	return execAudited(ctx, p.Pool, AuditActionPanelDelete, AuditResourcePanel, auditResourceId(panelId), nil, query, panelId)
 }
 
 func (p *Panel) fieldPtrs() []interface{} {
@@ -15,7 +15,7 @@ type PanelSupportHours struct {
 	StartTime time.Time `json:"start_time"`
 	EndTime   time.Time `json:"end_time"`
 	Enabled   bool      `json:"enabled"`
-	Timezone  string    `json:"timezone"` // IANA timezone identifier (e.g., "America/New_York")
+	Timezone  string    `json:"timezone"` // IANA timezone identifier (e.g., "America/New_York"). Defaults to the guild's timezone
 }
 
 type PanelSupportHoursTable struct {
@@ -85,7 +85,8 @@ ORDER BY "day_of_week" ASC;`
 	return supportHours, nil
 }
 
-// Upsert creates or updates support hours for a specific panel and day
+// Upsert creates or updates support hours for a specific panel and day. If no timezone is provided, the guild's
+// timezone from guild_locale_settings is used
 func (p *PanelSupportHoursTable) Upsert(ctx context.Context, supportHours PanelSupportHours) (int, error) {
 	query := `
 INSERT INTO panel_support_hours (
@@ -95,7 +96,11 @@ INSERT INTO panel_support_hours (
     "end_time",
     "enabled",
     "timezone"
-) VALUES ($1, $2, $3, $4, $5, $6)
+) VALUES ($1, $2, $3, $4, $5, COALESCE(
+    NULLIF($6, ''),
+    (SELECT gls."timezone" FROM guild_locale_settings gls INNER JOIN panels p ON p.guild_id = gls.guild_id WHERE p.panel_id = $1),
+    'UTC'
+))
 ON CONFLICT ("panel_id", "day_of_week")
 DO UPDATE SET
     "start_time" = EXCLUDED."start_time",
@@ -145,7 +150,11 @@ INSERT INTO panel_support_hours (
     "end_time",
     "enabled",
     "timezone"
-) VALUES ($1, $2, $3, $4, $5, $6)
+) VALUES ($1, $2, $3, $4, $5, COALESCE(
+    NULLIF($6, ''),
+    (SELECT gls."timezone" FROM guild_locale_settings gls INNER JOIN panels p ON p.guild_id = gls.guild_id WHERE p.panel_id = $1),
+    'UTC'
+))
 ON CONFLICT ("panel_id", "day_of_week")
 DO UPDATE SET
     "start_time" = EXCLUDED."start_time",
@@ -292,3 +301,47 @@ SELECT EXISTS(
 	err := p.QueryRow(ctx, query, panelId).Scan(&hasHours)
 	return hasHours, err
 }
+
+// BusinessMinutesBetween returns the number of whole minutes between from and to that fall within the panel's support
+// hours, for pausing timers such as SLAs outside of support hours. Panels without support hours configured are always
+// active, so all minutes are counted. Times are compared in the panel's local timezone, falling back to UTC if it is
+// invalid.
+func (p *PanelSupportHoursTable) BusinessMinutesBetween(ctx context.Context, panelId int, from, to time.Time) (int, error) {
+	query := `
+WITH tz AS (
+	SELECT COALESCE((
+		SELECT pg_timezone_names.name
+		FROM panel_support_hours
+		INNER JOIN pg_timezone_names ON pg_timezone_names.name = panel_support_hours.timezone
+		WHERE panel_support_hours.panel_id = $1
+		LIMIT 1
+	), 'UTC') AS name
+), bounds AS (
+	SELECT
+		$2::timestamptz AT TIME ZONE tz.name AS local_from,
+		$3::timestamptz AT TIME ZONE tz.name AS local_to
+	FROM tz
+), days AS (
+	SELECT generate_series(date_trunc('day', local_from), date_trunc('day', local_to), INTERVAL '1 day')::date AS day
+	FROM bounds
+)
+SELECT
+	CASE WHEN NOT EXISTS(SELECT 1 FROM panel_support_hours WHERE "panel_id" = $1)
+		THEN FLOOR(GREATEST(EXTRACT(EPOCH FROM $3::timestamptz - $2::timestamptz), 0) / 60)
+		ELSE FLOOR(COALESCE(SUM(EXTRACT(EPOCH FROM GREATEST(
+			LEAST(days.day + psh.end_time, bounds.local_to) - GREATEST(days.day + psh.start_time, bounds.local_from),
+			INTERVAL '0'
+		))), 0) / 60)
+	END
+FROM bounds
+CROSS JOIN days
+INNER JOIN panel_support_hours psh
+ON psh.panel_id = $1 AND psh.enabled = true AND psh.day_of_week = EXTRACT(DOW FROM days.day);`
+
+	var minutes float64
+	if err := p.QueryRow(ctx, query, panelId, from, to).Scan(&minutes); err != nil {
+		return 0, err
+	}
+
+	return int(minutes), nil
+}
@@ -2,7 +2,9 @@ package database
 
 import (
 	"context"
+	"errors"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -11,6 +13,7 @@ type OutOfHoursBehaviour string
 const (
 	OutOfHoursBehaviourBlockCreation    OutOfHoursBehaviour = "block_creation"
 	OutOfHoursBehaviourAllowWithWarning OutOfHoursBehaviour = "allow_with_warning"
+	OutOfHoursBehaviourQueue            OutOfHoursBehaviour = "queue" // Requests are stored in queued_ticket_requests
 )
 
 type PanelSupportHoursSettings struct {
@@ -58,7 +61,7 @@ WHERE "panel_id" = $1;`
 	)
 
 	if err != nil {
-		if err.Error() == "no rows in result set" {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return PanelSupportHoursSettings{}, false, nil
 		}
 		return PanelSupportHoursSettings{}, false, err
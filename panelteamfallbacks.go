@@ -0,0 +1,203 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PanelTeamFallback is an entry in a panel's ordered list of teams to mention when the teams before it cannot be
+// resolved (e.g. they have no members). A nil TeamId refers to the default team.
+type PanelTeamFallback struct {
+	Position int  `json:"position"`
+	TeamId   *int `json:"team_id"`
+}
+
+type PanelTeamFallbacksTable struct {
+	*pgxpool.Pool
+}
+
+func newPanelTeamFallbacksTable(db *pgxpool.Pool) *PanelTeamFallbacksTable {
+	return &PanelTeamFallbacksTable{
+		db,
+	}
+}
+
+func (p PanelTeamFallbacksTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS panel_team_fallbacks(
+	"panel_id" int NOT NULL,
+	"position" int NOT NULL,
+	"team_id" int DEFAULT NULL,
+	FOREIGN KEY("panel_id") REFERENCES panels("panel_id") ON DELETE CASCADE ON UPDATE CASCADE,
+	FOREIGN KEY("team_id") REFERENCES support_team("id") ON DELETE CASCADE ON UPDATE CASCADE,
+	UNIQUE NULLS NOT DISTINCT ("panel_id", "team_id"),
+	PRIMARY KEY("panel_id", "position")
+);
+`
+}
+
+// GetFallbacks returns the panel's fallbacks, in the order they should be tried. Nothing is returned if the panel
+// does not belong to the guild.
+func (p *PanelTeamFallbacksTable) GetFallbacks(ctx context.Context, guildId uint64, panelId int) ([]PanelTeamFallback, error) {
+	query := `
+SELECT panel_team_fallbacks."position", panel_team_fallbacks."team_id"
+FROM panel_team_fallbacks
+INNER JOIN panels ON panels."panel_id" = panel_team_fallbacks."panel_id"
+WHERE panel_team_fallbacks."panel_id" = $1 AND panels."guild_id" = $2
+ORDER BY panel_team_fallbacks."position" ASC;`
+
+	rows, err := p.Query(ctx, query, panelId, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fallbacks []PanelTeamFallback
+	for rows.Next() {
+		var fallback PanelTeamFallback
+		if err := rows.Scan(&fallback.Position, &fallback.TeamId); err != nil {
+			return nil, err
+		}
+
+		fallbacks = append(fallbacks, fallback)
+	}
+
+	return fallbacks, nil
+}
+
+// Add appends the team to the end of the panel's fallbacks. A nil team ID adds the default team. Returns
+// ErrPanelNotFound if the panel does not belong to the guild.
+func (p *PanelTeamFallbacksTable) Add(ctx context.Context, guildId uint64, panelId int, teamId *int) error {
+	return p.withLockedPanel(ctx, guildId, panelId, func(tx pgx.Tx) error {
+		query := `
+INSERT INTO panel_team_fallbacks("panel_id", "position", "team_id")
+SELECT $1, COALESCE(MAX("position") + 1, 0), $2
+FROM panel_team_fallbacks
+WHERE "panel_id" = $1
+ON CONFLICT("panel_id", "team_id") DO NOTHING;`
+
+		_, err := tx.Exec(ctx, query, panelId, teamId)
+		return err
+	})
+}
+
+// Delete removes the team from the panel's fallbacks, moving the teams after it up to close the gap. Returns
+// ErrPanelNotFound if the panel does not belong to the guild.
+func (p *PanelTeamFallbacksTable) Delete(ctx context.Context, guildId uint64, panelId int, teamId *int) error {
+	return p.withLockedPanel(ctx, guildId, panelId, func(tx pgx.Tx) error {
+		query := `DELETE FROM panel_team_fallbacks WHERE "panel_id" = $1 AND "team_id" IS NOT DISTINCT FROM $2;`
+		if _, err := tx.Exec(ctx, query, panelId, teamId); err != nil {
+			return err
+		}
+
+		return compactPanelTeamFallbacks(ctx, tx, panelId)
+	})
+}
+
+// DeleteAll removes all of the panel's fallbacks. Returns ErrPanelNotFound if the panel does not belong to the guild.
+func (p *PanelTeamFallbacksTable) DeleteAll(ctx context.Context, guildId uint64, panelId int) error {
+	return p.withLockedPanel(ctx, guildId, panelId, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `DELETE FROM panel_team_fallbacks WHERE "panel_id" = $1;`, panelId)
+		return err
+	})
+}
+
+// Replace sets the panel's fallbacks to teamIds, in order. A nil team ID refers to the default team. Returns
+// ErrPanelNotFound if the panel does not belong to the guild.
+func (p *PanelTeamFallbacksTable) Replace(ctx context.Context, guildId uint64, panelId int, teamIds []*int) error {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := p.ReplaceWithTx(ctx, tx, guildId, panelId, teamIds); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (p *PanelTeamFallbacksTable) ReplaceWithTx(ctx context.Context, tx pgx.Tx, guildId uint64, panelId int, teamIds []*int) error {
+	if err := lockPanelForFallbacks(ctx, tx, guildId, panelId); err != nil {
+		return err
+	}
+
+	// Remove existing fallbacks from panel
+	if _, err := tx.Exec(ctx, `DELETE FROM panel_team_fallbacks WHERE "panel_id" = $1;`, panelId); err != nil {
+		return err
+	}
+
+	// Add each provided team in order. Duplicate teams are skipped, so only advance the position when a row is written.
+	position := 0
+	for _, teamId := range teamIds {
+		query := `INSERT INTO panel_team_fallbacks("panel_id", "position", "team_id") VALUES($1, $2, $3) ON CONFLICT("panel_id", "team_id") DO NOTHING;`
+		res, err := tx.Exec(ctx, query, panelId, position, teamId)
+		if err != nil {
+			return err
+		}
+
+		position += int(res.RowsAffected())
+	}
+
+	return nil
+}
+
+func (p *PanelTeamFallbacksTable) withLockedPanel(ctx context.Context, guildId uint64, panelId int, f func(tx pgx.Tx) error) error {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := lockPanelForFallbacks(ctx, tx, guildId, panelId); err != nil {
+		return err
+	}
+
+	if err := f(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// lockPanelForFallbacks locks the panel's row, so that concurrent changes to its fallbacks are serialised and positions
+// are not assigned twice. Returns ErrPanelNotFound if the panel does not belong to the guild.
+func lockPanelForFallbacks(ctx context.Context, tx pgx.Tx, guildId uint64, panelId int) error {
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT true FROM panels WHERE "panel_id" = $1 AND "guild_id" = $2 FOR UPDATE;`, panelId, guildId).Scan(&exists); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPanelNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// compactPanelTeamFallbacks renumbers the panel's fallbacks from 0 without gaps, keeping their order. The primary key
+// is not deferrable, so the positions are first moved to negative values that cannot collide with the new ones.
+func compactPanelTeamFallbacks(ctx context.Context, tx pgx.Tx, panelId int) error {
+	if _, err := tx.Exec(ctx, `UPDATE panel_team_fallbacks SET "position" = -"position" - 1 WHERE "panel_id" = $1;`, panelId); err != nil {
+		return err
+	}
+
+	query := `
+UPDATE panel_team_fallbacks
+SET "position" = ordered.new_position
+FROM (
+	SELECT "position", ROW_NUMBER() OVER (ORDER BY "position" DESC) - 1 AS new_position
+	FROM panel_team_fallbacks
+	WHERE "panel_id" = $1
+) AS ordered
+WHERE panel_team_fallbacks."panel_id" = $1 AND panel_team_fallbacks."position" = ordered."position";`
+
+	_, err := tx.Exec(ctx, query, panelId)
+	return err
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type TranscriptDestinationType string
+
+const (
+	TranscriptDestinationChannel  TranscriptDestinationType = "channel"
+	TranscriptDestinationWebhook  TranscriptDestinationType = "webhook"
+	TranscriptDestinationExternal TranscriptDestinationType = "external"
+)
+
+// TranscriptDestination is where a ticket's transcript is sent on close. Exactly one of ChannelId, WebhookUrl and
+// StorageUri is set, depending on Type.
+type TranscriptDestination struct {
+	Type       TranscriptDestinationType `json:"type"`
+	ChannelId  *uint64                   `json:"channel_id,string,omitempty"`
+	WebhookUrl *string                   `json:"webhook_url,omitempty"`
+	StorageUri *string                   `json:"storage_uri,omitempty"`
+}
+
+type PanelTranscriptDestination struct {
+	PanelId int `json:"panel_id"`
+	TranscriptDestination
+}
+
+type PanelTranscriptDestinationsTable struct {
+	*pgxpool.Pool
+}
+
+func newPanelTranscriptDestinationsTable(db *pgxpool.Pool) *PanelTranscriptDestinationsTable {
+	return &PanelTranscriptDestinationsTable{
+		db,
+	}
+}
+
+func (p PanelTranscriptDestinationsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS panel_transcript_destinations(
+	"panel_id" int NOT NULL,
+	"type" varchar(16) NOT NULL,
+	"channel_id" int8 DEFAULT NULL,
+	"webhook_url" varchar(255) DEFAULT NULL,
+	"storage_uri" varchar(255) DEFAULT NULL,
+	FOREIGN KEY("panel_id") REFERENCES panels("panel_id") ON DELETE CASCADE ON UPDATE CASCADE,
+	CHECK(
+		("type" = 'channel' AND "channel_id" IS NOT NULL AND "webhook_url" IS NULL AND "storage_uri" IS NULL) OR
+		("type" = 'webhook' AND "channel_id" IS NULL AND "webhook_url" IS NOT NULL AND "storage_uri" IS NULL) OR
+		("type" = 'external' AND "channel_id" IS NULL AND "webhook_url" IS NULL AND "storage_uri" IS NOT NULL)
+	),
+	PRIMARY KEY("panel_id")
+);
+`
+}
+
+func (p *PanelTranscriptDestinationsTable) Get(ctx context.Context, panelId int) (PanelTranscriptDestination, bool, error) {
+	query := `
+SELECT "panel_id", "type", "channel_id", "webhook_url", "storage_uri"
+FROM panel_transcript_destinations
+WHERE "panel_id" = $1;`
+
+	var destination PanelTranscriptDestination
+	if err := p.QueryRow(ctx, query, panelId).Scan(
+		&destination.PanelId,
+		&destination.Type,
+		&destination.ChannelId,
+		&destination.WebhookUrl,
+		&destination.StorageUri,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PanelTranscriptDestination{}, false, nil
+		} else {
+			return PanelTranscriptDestination{}, false, err
+		}
+	}
+
+	return destination, true, nil
+}
+
+func (p *PanelTranscriptDestinationsTable) Set(ctx context.Context, destination PanelTranscriptDestination) (err error) {
+	query := `
+INSERT INTO panel_transcript_destinations("panel_id", "type", "channel_id", "webhook_url", "storage_uri")
+VALUES($1, $2, $3, $4, $5)
+ON CONFLICT("panel_id") DO UPDATE
+SET "type" = EXCLUDED."type", "channel_id" = EXCLUDED."channel_id", "webhook_url" = EXCLUDED."webhook_url", "storage_uri" = EXCLUDED."storage_uri";`
+
+	_, err = p.Exec(ctx, query, destination.PanelId, destination.Type, destination.ChannelId, destination.WebhookUrl, destination.StorageUri)
+	return
+}
+
+func (p *PanelTranscriptDestinationsTable) Delete(ctx context.Context, panelId int) (err error) {
+	query := `DELETE FROM panel_transcript_destinations WHERE "panel_id" = $1;`
+	_, err = p.Exec(ctx, query, panelId)
+	return
+}
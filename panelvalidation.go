@@ -0,0 +1,123 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	panelTitleMaxLength        = 255
+	panelContentMaxLength      = 4096
+	panelButtonLabelMaxLength  = 80
+	panelCustomIdMaxLength     = 100
+	panelNamingSchemeMaxLength = 100
+	panelColourMax             = 0xFFFFFF
+)
+
+// NamingSchemePlaceholders are the placeholders that may be used in a panel's naming scheme.
+var NamingSchemePlaceholders = []string{"%id%", "%id_padded%", "%username%", "%nickname%", "%claimed%"}
+
+var (
+	customEmojiNameRegex         = regexp.MustCompile(`^\w{2,32}$`)
+	namingSchemePlaceholderRegex = regexp.MustCompile(`%[a-z_]+%`)
+)
+
+// ValidationError describes why a field of a resource is invalid.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors is returned by methods that validate their input before writing it, e.g. PanelTable.Create.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// ValidatePanel checks the panel against the limits imposed by Discord, so that invalid panels are rejected before
+// they are stored rather than when they are sent. Returns nil if the panel is valid.
+func ValidatePanel(panel Panel) []ValidationError {
+	var errs []ValidationError
+
+	if utf8.RuneCountInString(panel.Title) > panelTitleMaxLength {
+		errs = append(errs, ValidationError{"title", fmt.Sprintf("must be at most %d characters", panelTitleMaxLength)})
+	}
+
+	if utf8.RuneCountInString(panel.Content) > panelContentMaxLength {
+		errs = append(errs, ValidationError{"content", fmt.Sprintf("must be at most %d characters", panelContentMaxLength)})
+	}
+
+	if panel.Colour < 0 || panel.Colour > panelColourMax {
+		errs = append(errs, ValidationError{"colour", "must be between 0 and 0xFFFFFF"})
+	}
+
+	if utf8.RuneCountInString(panel.ButtonLabel) > panelButtonLabelMaxLength {
+		errs = append(errs, ValidationError{"button_label", fmt.Sprintf("must be at most %d characters", panelButtonLabelMaxLength)})
+	}
+
+	if panel.ButtonLabel == "" && panel.EmojiName == nil {
+		errs = append(errs, ValidationError{"button_label", "must be set if the button has no emoji"})
+	}
+
+	// Link buttons (style 5) cannot open tickets
+	if panel.ButtonStyle < 1 || panel.ButtonStyle > 4 {
+		errs = append(errs, ValidationError{"button_style", "must be between 1 and 4"})
+	}
+
+	// Custom emojis have both a name and an ID, while unicode emojis have only a name, which is the emoji itself
+	if panel.EmojiId != nil {
+		if *panel.EmojiId == 0 {
+			errs = append(errs, ValidationError{"emoji_id", "must be a valid ID"})
+		}
+
+		if panel.EmojiName == nil || !customEmojiNameRegex.MatchString(*panel.EmojiName) {
+			errs = append(errs, ValidationError{"emoji_name", "must be the custom emoji's name"})
+		}
+	} else if panel.EmojiName != nil && customEmojiNameRegex.MatchString(*panel.EmojiName) {
+		errs = append(errs, ValidationError{"emoji_id", "must be set for custom emojis"})
+	}
+
+	if len(panel.CustomId) > panelCustomIdMaxLength {
+		errs = append(errs, ValidationError{"custom_id", fmt.Sprintf("must be at most %d characters", panelCustomIdMaxLength)})
+	}
+
+	if panel.NamingScheme != nil {
+		if utf8.RuneCountInString(*panel.NamingScheme) > panelNamingSchemeMaxLength {
+			errs = append(errs, ValidationError{"naming_scheme", fmt.Sprintf("must be at most %d characters", panelNamingSchemeMaxLength)})
+		}
+
+		for _, placeholder := range namingSchemePlaceholderRegex.FindAllString(*panel.NamingScheme, -1) {
+			if !isNamingSchemePlaceholder(placeholder) {
+				errs = append(errs, ValidationError{"naming_scheme", fmt.Sprintf("unknown placeholder %s", placeholder)})
+			}
+		}
+	}
+
+	if panel.CooldownSeconds < 0 {
+		errs = append(errs, ValidationError{"cooldown_seconds", "must not be negative"})
+	}
+
+	return errs
+}
+
+func isNamingSchemePlaceholder(placeholder string) bool {
+	for _, valid := range NamingSchemePlaceholders {
+		if placeholder == valid {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type PanelVerificationRequirements struct {
+	PanelId              int  `json:"panel_id"`
+	Required             bool `json:"required"`
+	MinAccountAgeSeconds *int `json:"min_account_age_seconds"`
+	MinJoinAgeSeconds    *int `json:"min_join_age_seconds"`
+}
+
+type PanelVerificationRequirementsTable struct {
+	*pgxpool.Pool
+}
+
+func newPanelVerificationRequirementsTable(db *pgxpool.Pool) *PanelVerificationRequirementsTable {
+	return &PanelVerificationRequirementsTable{
+		db,
+	}
+}
+
+func (p PanelVerificationRequirementsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS panel_verification_requirements(
+	"panel_id" int NOT NULL,
+	"required" bool NOT NULL DEFAULT false,
+	"min_account_age_seconds" int DEFAULT NULL,
+	"min_join_age_seconds" int DEFAULT NULL,
+	FOREIGN KEY("panel_id") REFERENCES panels("panel_id") ON DELETE CASCADE ON UPDATE CASCADE,
+	CHECK("min_account_age_seconds" IS NULL OR "min_account_age_seconds" >= 0),
+	CHECK("min_join_age_seconds" IS NULL OR "min_join_age_seconds" >= 0),
+	PRIMARY KEY("panel_id")
+);
+`
+}
+
+func (p *PanelVerificationRequirementsTable) Get(ctx context.Context, panelId int) (PanelVerificationRequirements, bool, error) {
+	query := `
+SELECT "panel_id", "required", "min_account_age_seconds", "min_join_age_seconds"
+FROM panel_verification_requirements
+WHERE "panel_id" = $1;`
+
+	var requirements PanelVerificationRequirements
+	if err := p.QueryRow(ctx, query, panelId).Scan(
+		&requirements.PanelId,
+		&requirements.Required,
+		&requirements.MinAccountAgeSeconds,
+		&requirements.MinJoinAgeSeconds,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PanelVerificationRequirements{}, false, nil
+		} else {
+			return PanelVerificationRequirements{}, false, err
+		}
+	}
+
+	return requirements, true, nil
+}
+
+func (p *PanelVerificationRequirementsTable) Set(ctx context.Context, requirements PanelVerificationRequirements) error {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err := p.SetWithTx(ctx, tx, requirements); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (p *PanelVerificationRequirementsTable) SetWithTx(ctx context.Context, tx pgx.Tx, requirements PanelVerificationRequirements) (err error) {
+	query := `
+INSERT INTO panel_verification_requirements("panel_id", "required", "min_account_age_seconds", "min_join_age_seconds")
+VALUES($1, $2, $3, $4)
+ON CONFLICT("panel_id") DO UPDATE
+SET "required" = EXCLUDED."required", "min_account_age_seconds" = EXCLUDED."min_account_age_seconds", "min_join_age_seconds" = EXCLUDED."min_join_age_seconds";`
+
+	_, err = tx.Exec(ctx, query, requirements.PanelId, requirements.Required, requirements.MinAccountAgeSeconds, requirements.MinJoinAgeSeconds)
+	return
+}
+
+func (p *PanelVerificationRequirementsTable) Delete(ctx context.Context, panelId int) (err error) {
+	query := `DELETE FROM panel_verification_requirements WHERE "panel_id" = $1;`
+	_, err = p.Exec(ctx, query, panelId)
+	return
+}
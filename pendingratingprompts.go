@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// ratingPromptLeaseDuration is how long a claimed prompt is hidden from other workers. If MarkSent is not called within
+// the lease, e.g. because the worker crashed, the prompt is claimed again.
+const ratingPromptLeaseDuration = 5 * time.Minute
+
+type PendingRatingPrompt struct {
+	GuildId       uint64    `json:"guild_id,string"`
+	TicketId      int       `json:"ticket_id"`
+	UserId        uint64    `json:"user_id,string"`
+	DueAt         time.Time `json:"due_at"`
+	PromptsSent   int       `json:"prompts_sent"` // 0 for the first prompt, 1 for the first reminder, etc.
+	ReminderCount int       `json:"reminder_count"`
+}
+
+// IsReminder returns whether the prompt is a reminder, rather than the first prompt for the ticket.
+func (p PendingRatingPrompt) IsReminder() bool {
+	return p.PromptsSent > 0
+}
+
+// PendingRatingPrompts is the queue of rating DMs waiting to be sent for closed tickets, according to the guild's
+// RatingPromptSchedule.
+type PendingRatingPrompts struct {
+	*pgxpool.Pool
+}
+
+var (
+	pendingRatingPromptsSql      = loadSqlTable("pending_rating_prompts")
+	pendingRatingPromptsSchema   = pendingRatingPromptsSql.schema()
+	pendingRatingPromptsEnqueue  = pendingRatingPromptsSql.query("enqueue")
+	pendingRatingPromptsClaimDue = pendingRatingPromptsSql.query("claim_due")
+	pendingRatingPromptsMarkSent = pendingRatingPromptsSql.query("mark_sent")
+	pendingRatingPromptsDelete   = pendingRatingPromptsSql.query("delete")
+)
+
+func newPendingRatingPrompts(db *pgxpool.Pool) *PendingRatingPrompts {
+	return &PendingRatingPrompts{
+		db,
+	}
+}
+
+func (PendingRatingPrompts) Schema() string {
+	return pendingRatingPromptsSchema
+}
+
+// Enqueue queues the rating prompt for a closed ticket, due after the guild's scheduled delay. Returns false if the
+// guild has no RatingPromptSchedule, in which case the prompt should be sent immediately, or if the ticket has already
+// been queued.
+func (p *PendingRatingPrompts) Enqueue(ctx context.Context, guildId uint64, ticketId int, userId uint64) (bool, error) {
+	var enqueued bool
+	if err := p.QueryRow(ctx, pendingRatingPromptsEnqueue, guildId, ticketId, userId).Scan(&enqueued); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		} else {
+			return false, err
+		}
+	}
+
+	return enqueued, nil
+}
+
+// ClaimDue returns up to limit prompts that are due, oldest first. Rows locked by another worker are skipped. MarkSent
+// must be called once each prompt has been sent; otherwise it is claimed again once the lease expires.
+func (p *PendingRatingPrompts) ClaimDue(ctx context.Context, limit int) ([]PendingRatingPrompt, error) {
+	rows, err := p.Query(ctx, pendingRatingPromptsClaimDue, limit, ratingPromptLeaseDuration)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prompts []PendingRatingPrompt
+	for rows.Next() {
+		var prompt PendingRatingPrompt
+		var promptsSent, reminderCount int16
+		if err := rows.Scan(
+			&prompt.GuildId,
+			&prompt.TicketId,
+			&prompt.UserId,
+			&prompt.DueAt,
+			&promptsSent,
+			&reminderCount,
+		); err != nil {
+			return nil, err
+		}
+
+		prompt.PromptsSent = int(promptsSent)
+		prompt.ReminderCount = int(reminderCount)
+		prompts = append(prompts, prompt)
+	}
+
+	return prompts, nil
+}
+
+// MarkSent records that the prompt for the ticket has been sent. If the guild's schedule allows another reminder, the
+// prompt is requeued after the delay, and true is returned; otherwise it is removed from the queue.
+func (p *PendingRatingPrompts) MarkSent(ctx context.Context, guildId uint64, ticketId int) (bool, error) {
+	var remindScheduled bool
+	if err := p.QueryRow(ctx, pendingRatingPromptsMarkSent, guildId, ticketId).Scan(&remindScheduled); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		} else {
+			return false, err
+		}
+	}
+
+	return remindScheduled, nil
+}
+
+// Delete removes the ticket's prompt from the queue, e.g. once the user has submitted a rating, so that no further
+// reminders are sent.
+func (p *PendingRatingPrompts) Delete(ctx context.Context, guildId uint64, ticketId int) (err error) {
+	_, err = p.Exec(ctx, pendingRatingPromptsDelete, guildId, ticketId)
+	return
+}
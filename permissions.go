@@ -8,31 +8,40 @@ import (
 
 type Permissions struct {
 	*pgxpool.Pool
+
+	// primary is the primary database's table, if this table is in a regional database. Writes are mirrored to it, as
+	// the guild_dashboard_cache triggers can only be created alongside user_guilds in the primary database.
+	primary *Permissions
 }
 
+var (
+	permissionsSql            = loadSqlTable("permissions")
+	permissionsSchema         = permissionsSql.schema()
+	permissionsIsSupport      = permissionsSql.query("is_support")
+	permissionsIsAdmin        = permissionsSql.query("is_admin")
+	permissionsGetAdmins      = permissionsSql.query("get_admins")
+	permissionsGetSupport     = permissionsSql.query("get_support")
+	permissionsGetSupportOnly = permissionsSql.query("get_support_only")
+	permissionsAddAdmin       = permissionsSql.query("add_admin")
+	permissionsAddSupport     = permissionsSql.query("add_support")
+	permissionsRemoveAdmin    = permissionsSql.query("remove_admin")
+	permissionsRemoveSupport  = permissionsSql.query("remove_support")
+)
+
 func newPermissions(db *pgxpool.Pool) *Permissions {
 	return &Permissions{
-		db,
+		Pool: db,
 	}
 }
 
 func (p Permissions) Schema() string {
-	return `
-CREATE TABLE IF NOT EXISTS permissions(
-	"guild_id" int8 NOT NULL,
-	"user_id" int8 NOT NULL,
-	"support" bool NOT NULL,
-	"admin" bool NOT NULL,
-	PRIMARY KEY("guild_id", "user_id")
-);
-CREATE INDEX IF NOT EXISTS permissions_guild_id ON permissions("guild_id");
-`
+	return permissionsSchema
 }
 
 func (p *Permissions) IsSupport(ctx context.Context, guildId, userId uint64) (support bool, e error) {
 	var admin bool
 
-	if err := p.QueryRow(ctx, `SELECT "support", "admin" from permissions WHERE "guild_id" = $1 AND "user_id" = $2;`, guildId, userId).Scan(&support, &admin); err != nil && err != pgx.ErrNoRows {
+	if err := p.QueryRow(ctx, permissionsIsSupport, guildId, userId).Scan(&support, &admin); err != nil && err != pgx.ErrNoRows {
 		e = err
 	}
 
@@ -44,7 +53,7 @@ func (p *Permissions) IsSupport(ctx context.Context, guildId, userId uint64) (su
 }
 
 func (p *Permissions) IsAdmin(ctx context.Context, guildId, userId uint64) (admin bool, e error) {
-	if err := p.QueryRow(ctx, `SELECT "admin" from permissions WHERE "guild_id" = $1 AND "user_id" = $2;`, guildId, userId).Scan(&admin); err != nil && err != pgx.ErrNoRows {
+	if err := p.QueryRow(ctx, permissionsIsAdmin, guildId, userId).Scan(&admin); err != nil && err != pgx.ErrNoRows {
 		e = err
 	}
 
@@ -52,7 +61,7 @@ func (p *Permissions) IsAdmin(ctx context.Context, guildId, userId uint64) (admi
 }
 
 func (p *Permissions) GetAdmins(ctx context.Context, guildId uint64) (admins []uint64, e error) {
-	rows, err := p.Query(ctx, `SELECT "user_id" from permissions WHERE "guild_id" = $1 AND "admin" = true;`, guildId)
+	rows, err := p.Query(ctx, permissionsGetAdmins, guildId)
 	defer rows.Close()
 	if err != nil && err != pgx.ErrNoRows {
 		e = err
@@ -72,7 +81,7 @@ func (p *Permissions) GetAdmins(ctx context.Context, guildId uint64) (admins []u
 }
 
 func (p *Permissions) GetSupport(ctx context.Context, guildId uint64) (support []uint64, e error) {
-	rows, err := p.Query(ctx, `SELECT "user_id" from permissions WHERE "guild_id" = $1 AND ("admin" = true OR "support" = true);`, guildId)
+	rows, err := p.Query(ctx, permissionsGetSupport, guildId)
 	defer rows.Close()
 	if err != nil && err != pgx.ErrNoRows {
 		e = err
@@ -92,8 +101,7 @@ func (p *Permissions) GetSupport(ctx context.Context, guildId uint64) (support [
 }
 
 func (p *Permissions) GetSupportOnly(ctx context.Context, guildId uint64) (support []uint64, e error) {
-	query := `SELECT "user_id" from permissions WHERE "guild_id" = $1 AND "admin" = false AND "support" = true;`
-	rows, err := p.Query(ctx, query, guildId)
+	rows, err := p.Query(ctx, permissionsGetSupportOnly, guildId)
 	defer rows.Close()
 	if err != nil && err != pgx.ErrNoRows {
 		e = err
@@ -112,26 +120,34 @@ func (p *Permissions) GetSupportOnly(ctx context.Context, guildId uint64) (suppo
 	return
 }
 
-func (p *Permissions) AddAdmin(ctx context.Context, guildId, userId uint64) (err error) {
-	query := `INSERT INTO permissions("guild_id", "user_id", "support", "admin") VALUES($1, $2, true, true) ON CONFLICT("guild_id", "user_id") DO UPDATE SET "admin" = true, "support" = true;`
-	_, err = p.Exec(ctx, query, guildId, userId)
-	return
+func (p *Permissions) AddAdmin(ctx context.Context, guildId, userId uint64) error {
+	return p.write(ctx, permissionsAddAdmin, guildId, userId)
 }
 
-func (p *Permissions) AddSupport(ctx context.Context, guildId, userId uint64) (err error) {
-	query := `INSERT INTO permissions("guild_id", "user_id", "support", "admin") VALUES($1, $2, true, false) ON CONFLICT("guild_id", "user_id") DO UPDATE SET "admin" = false, "support" = true;`
-	_, err = p.Exec(ctx, query, guildId, userId)
-	return
+func (p *Permissions) AddSupport(ctx context.Context, guildId, userId uint64) error {
+	return p.write(ctx, permissionsAddSupport, guildId, userId)
 }
 
-func (p *Permissions) RemoveAdmin(ctx context.Context, guildId, userId uint64) (err error) {
-	query := `UPDATE permissions SET "admin" = false WHERE "guild_id" = $1 AND "user_id" = $2;`
-	_, err = p.Exec(ctx, query, guildId, userId)
-	return
+func (p *Permissions) RemoveAdmin(ctx context.Context, guildId, userId uint64) error {
+	return p.write(ctx, permissionsRemoveAdmin, guildId, userId)
 }
 
-func (p *Permissions) RemoveSupport(ctx context.Context, guildId, userId uint64) (err error) {
-	query := `UPDATE permissions SET "admin" = false, "support" = false WHERE "guild_id" = $1 AND "user_id" = $2;`
-	_, err = p.Exec(ctx, query, guildId, userId)
-	return
+func (p *Permissions) RemoveSupport(ctx context.Context, guildId, userId uint64) error {
+	return p.write(ctx, permissionsRemoveSupport, guildId, userId)
+}
+
+// write runs the query, then mirrors it to the primary database if this table is in a regional database, so that the
+// guild_dashboard_cache triggers see the change.
+func (p *Permissions) write(ctx context.Context, query string, guildId, userId uint64) error {
+	if _, err := p.Exec(ctx, query, guildId, userId); err != nil {
+		return err
+	}
+
+	if p.primary != nil {
+		if _, err := p.primary.Exec(ctx, query, guildId, userId); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
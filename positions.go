@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+// ErrPositionsMismatch is returned when reordering rows if the provided IDs are not exactly the rows in the scope.
+var ErrPositionsMismatch = errors.New("ordered ids do not match the rows in scope")
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+}
+
+// reorderPositions sets the "position" column of the rows in the scope (e.g. the inputs of a form) to match the order
+// of orderedIds, starting from 1. orderedIds must contain every row in the scope exactly once, else
+// ErrPositionsMismatch is returned before anything is written. The rows in the scope are locked for the rest of the
+// transaction. The table's UNIQUE(scope, position) constraint must be DEFERRABLE and have the default name,
+// <table>_<scope>_position_key, as positions are swapped in a single statement. Only that constraint is deferred, and
+// it is restored to its initial mode afterwards.
+func reorderPositions(ctx context.Context, tx pgx.Tx, table, scopeColumn, idColumn string, scopeId int, orderedIds []int) error {
+	lockQuery := fmt.Sprintf(`SELECT "%s" FROM %s WHERE "%s" = $1 FOR UPDATE;`, idColumn, table, scopeColumn)
+	rows, err := tx.Query(ctx, lockQuery, scopeId)
+	if err != nil {
+		return err
+	}
+
+	inScope := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+
+		inScope[id] = true
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Catches missing and duplicate IDs, and IDs from outside the scope
+	if len(orderedIds) != len(inScope) {
+		return ErrPositionsMismatch
+	}
+
+	seen := make(map[int]bool, len(orderedIds))
+	for _, id := range orderedIds {
+		if !inScope[id] || seen[id] {
+			return ErrPositionsMismatch
+		}
+
+		seen[id] = true
+	}
+
+	constraint := fmt.Sprintf("%s_%s_position_key", table, scopeColumn)
+
+	var deferrable, initiallyDeferred bool
+	if err := tx.QueryRow(ctx,
+		`SELECT "condeferrable", "condeferred" FROM pg_constraint WHERE "conrelid" = $1::regclass AND "conname" = $2;`,
+		table, constraint,
+	).Scan(&deferrable, &initiallyDeferred); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("position constraint %s does not exist", constraint)
+		}
+
+		return err
+	}
+
+	if !deferrable {
+		return fmt.Errorf("position constraint %s is not deferrable", constraint)
+	}
+
+	constraintIdentifier := pgx.Identifier{constraint}.Sanitize()
+	if !initiallyDeferred {
+		if _, err := tx.Exec(ctx, "SET CONSTRAINTS "+constraintIdentifier+" DEFERRED;"); err != nil {
+			return err
+		}
+	}
+
+	array := &pgtype.Int4Array{}
+	if err := array.Set(orderedIds); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`
+UPDATE %[1]s
+SET "position" = ordered.position
+FROM unnest($2::int4[]) WITH ORDINALITY AS ordered(id, position)
+WHERE %[1]s."%[2]s" = $1 AND %[1]s."%[3]s" = ordered.id;`, table, scopeColumn, idColumn)
+
+	res, err := tx.Exec(ctx, query, scopeId, array)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() != int64(len(orderedIds)) {
+		return ErrPositionsMismatch
+	}
+
+	// Checks the constraint now, and stops it being deferred for the rest of the caller's transaction
+	if !initiallyDeferred {
+		if _, err := tx.Exec(ctx, "SET CONSTRAINTS "+constraintIdentifier+" IMMEDIATE;"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteAndShiftPositions deletes the row, and moves the rows after it in the same scope up by one position to close
+// the gap.
+func deleteAndShiftPositions(ctx context.Context, db execer, table, scopeColumn, idColumn string, id int) error {
+	_, err := db.Exec(ctx, deleteAndShiftPositionsQuery(table, scopeColumn, idColumn, ""), id)
+	return err
+}
+
+// deleteAndShiftPositionsInScope is the same as deleteAndShiftPositions, but only deletes the row if it belongs to
+// the given scope.
+func deleteAndShiftPositionsInScope(ctx context.Context, db execer, table, scopeColumn, idColumn string, scopeId, id int) error {
+	condition := fmt.Sprintf(` AND "%s" = $2`, scopeColumn)
+	_, err := db.Exec(ctx, deleteAndShiftPositionsQuery(table, scopeColumn, idColumn, condition), id, scopeId)
+	return err
+}
+
+func deleteAndShiftPositionsQuery(table, scopeColumn, idColumn, condition string) string {
+	return fmt.Sprintf(`
+WITH deleted AS (
+	DELETE FROM %[1]s
+	WHERE "%[3]s" = $1%[4]s
+	RETURNING "%[2]s" AS scope_id, "position"
+)
+UPDATE %[1]s
+SET "position" = %[1]s."position" - 1
+FROM deleted
+WHERE %[1]s."%[2]s" = deleted.scope_id AND %[1]s."position" > deleted."position";`, table, scopeColumn, idColumn, condition)
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// positionsTestTx begins a transaction containing a position-based table, seeded with rows 1-3 in scope 1 and rows 4-5
+// in scope 2, in order of ID. The table is created inside the transaction, so is removed when it is rolled back at the
+// end of the test. The tests are skipped unless DATABASE_URI is set.
+func positionsTestTx(t *testing.T) (context.Context, pgx.Tx) {
+	t.Helper()
+
+	uri := os.Getenv("DATABASE_URI")
+	if uri == "" {
+		t.Skip("DATABASE_URI is not set")
+	}
+
+	ctx := context.Background()
+
+	conn, err := pgx.Connect(ctx, uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close(ctx) })
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tx.Rollback(ctx) })
+
+	schema := `
+CREATE TABLE positions_test(
+	"id" int4 NOT NULL,
+	"scope_id" int4 NOT NULL,
+	"position" int4 NOT NULL,
+	UNIQUE("scope_id", "position") DEFERRABLE INITIALLY IMMEDIATE,
+	PRIMARY KEY("id")
+);
+INSERT INTO positions_test("id", "scope_id", "position")
+VALUES (1, 1, 1), (2, 1, 2), (3, 1, 3), (4, 2, 1), (5, 2, 2);`
+
+	if _, err := tx.Exec(ctx, schema); err != nil {
+		t.Fatal(err)
+	}
+
+	return ctx, tx
+}
+
+// positionsTestOrder returns the IDs of the rows in the scope, ordered by position.
+func positionsTestOrder(t *testing.T, ctx context.Context, tx pgx.Tx, scopeId int) ([]int, []int) {
+	t.Helper()
+
+	rows, err := tx.Query(ctx, `SELECT "id", "position" FROM positions_test WHERE "scope_id" = $1 ORDER BY "position";`, scopeId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var ids, positions []int
+	for rows.Next() {
+		var id, position int
+		if err := rows.Scan(&id, &position); err != nil {
+			t.Fatal(err)
+		}
+
+		ids = append(ids, id)
+		positions = append(positions, position)
+	}
+
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return ids, positions
+}
+
+func TestReorderPositions(t *testing.T) {
+	ctx, tx := positionsTestTx(t)
+
+	if err := reorderPositions(ctx, tx, "positions_test", "scope_id", "id", 1, []int{3, 1, 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The constraint is restored to immediate mode, so a conflicting position is rejected straight away
+	if _, err := tx.Exec(ctx, `SAVEPOINT conflict;`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE positions_test SET "position" = 1 WHERE "id" = 1;`); err == nil {
+		t.Fatal("expected unique violation after reordering")
+	}
+
+	if _, err := tx.Exec(ctx, `ROLLBACK TO SAVEPOINT conflict;`); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, positions := positionsTestOrder(t, ctx, tx, 1)
+	if !reflect.DeepEqual(ids, []int{3, 1, 2}) || !reflect.DeepEqual(positions, []int{1, 2, 3}) {
+		t.Fatalf("unexpected order: ids %v, positions %v", ids, positions)
+	}
+
+	// Other scopes are left untouched
+	ids, positions = positionsTestOrder(t, ctx, tx, 2)
+	if !reflect.DeepEqual(ids, []int{4, 5}) || !reflect.DeepEqual(positions, []int{1, 2}) {
+		t.Fatalf("unexpected order in other scope: ids %v, positions %v", ids, positions)
+	}
+}
+
+func TestReorderPositionsMismatch(t *testing.T) {
+	cases := map[string][]int{
+		"missing":       {1, 2},
+		"extra":         {1, 2, 3, 3},
+		"duplicate":     {1, 1, 2},
+		"outside scope": {1, 2, 4},
+		"unknown":       {1, 2, 6},
+	}
+
+	for name, orderedIds := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx, tx := positionsTestTx(t)
+
+			err := reorderPositions(ctx, tx, "positions_test", "scope_id", "id", 1, orderedIds)
+			if !errors.Is(err, ErrPositionsMismatch) {
+				t.Fatalf("expected ErrPositionsMismatch, got %v", err)
+			}
+
+			// Nothing is written if the IDs do not match
+			ids, positions := positionsTestOrder(t, ctx, tx, 1)
+			if !reflect.DeepEqual(ids, []int{1, 2, 3}) || !reflect.DeepEqual(positions, []int{1, 2, 3}) {
+				t.Fatalf("positions changed: ids %v, positions %v", ids, positions)
+			}
+		})
+	}
+}
+
+func TestDeleteAndShiftPositions(t *testing.T) {
+	ctx, tx := positionsTestTx(t)
+
+	if err := deleteAndShiftPositions(ctx, tx, "positions_test", "scope_id", "id", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, positions := positionsTestOrder(t, ctx, tx, 1)
+	if !reflect.DeepEqual(ids, []int{1, 3}) || !reflect.DeepEqual(positions, []int{1, 2}) {
+		t.Fatalf("unexpected order: ids %v, positions %v", ids, positions)
+	}
+
+	ids, positions = positionsTestOrder(t, ctx, tx, 2)
+	if !reflect.DeepEqual(ids, []int{4, 5}) || !reflect.DeepEqual(positions, []int{1, 2}) {
+		t.Fatalf("unexpected order in other scope: ids %v, positions %v", ids, positions)
+	}
+}
+
+func TestDeleteAndShiftPositionsInScope(t *testing.T) {
+	ctx, tx := positionsTestTx(t)
+
+	// The row belongs to scope 2, so nothing is deleted
+	if err := deleteAndShiftPositionsInScope(ctx, tx, "positions_test", "scope_id", "id", 1, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, _ := positionsTestOrder(t, ctx, tx, 2)
+	if !reflect.DeepEqual(ids, []int{4, 5}) {
+		t.Fatalf("row outside the scope was deleted: ids %v", ids)
+	}
+
+	if err := deleteAndShiftPositionsInScope(ctx, tx, "positions_test", "scope_id", "id", 1, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, positions := positionsTestOrder(t, ctx, tx, 1)
+	if !reflect.DeepEqual(ids, []int{2, 3}) || !reflect.DeepEqual(positions, []int{1, 2}) {
+		t.Fatalf("unexpected order: ids %v, positions %v", ids, positions)
+	}
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"time"
+
+	"github.com/TicketsBot-cloud/common/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type PremiumEventType string
+
+const (
+	PremiumEventStarted   PremiumEventType = "started"
+	PremiumEventRenewed   PremiumEventType = "renewed"
+	PremiumEventCancelled PremiumEventType = "cancelled"
+	PremiumEventExpired   PremiumEventType = "expired"
+)
+
+type PremiumEvent struct {
+	GuildId *uint64
+	UserId  *uint64
+	Event   PremiumEventType
+	Source  model.EntitlementSource
+	SkuId   uuid.UUID
+}
+
+type PremiumMonthlyCounts struct {
+	Month     time.Time `json:"month"`
+	Started   int       `json:"started"`
+	Renewed   int       `json:"renewed"`
+	Cancelled int       `json:"cancelled"`
+	Expired   int       `json:"expired"`
+}
+
+type PremiumEvents struct {
+	*pgxpool.Pool
+}
+
+var (
+	//go:embed sql/premium_events/schema.sql
+	premiumEventsSchema string
+
+	//go:embed sql/premium_events/insert.sql
+	premiumEventsInsert string
+
+	//go:embed sql/premium_events/monthly_counts.sql
+	premiumEventsMonthlyCounts string
+
+	//go:embed sql/premium_events/churn_rate.sql
+	premiumEventsChurnRate string
+)
+
+func newPremiumEvents(db *pgxpool.Pool) *PremiumEvents {
+	return &PremiumEvents{
+		db,
+	}
+}
+
+// Schema also installs triggers on the entitlements table, which record events automatically.
+func (PremiumEvents) Schema() string {
+	return premiumEventsSchema
+}
+
+// Insert records an event manually, for premium sources that are not stored in the entitlements table.
+func (p *PremiumEvents) Insert(ctx context.Context, event PremiumEvent) error {
+	_, err := p.Exec(ctx, premiumEventsInsert, event.GuildId, event.UserId, event.Event, event.Source, event.SkuId)
+	return err
+}
+
+// GetMonthlyCounts returns the number of each event type per calendar month (UTC), between from (inclusive) and to
+// (exclusive). Months with no events are omitted.
+func (p *PremiumEvents) GetMonthlyCounts(ctx context.Context, from, to time.Time) ([]PremiumMonthlyCounts, error) {
+	rows, err := p.Query(ctx, premiumEventsMonthlyCounts, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []PremiumMonthlyCounts
+	for rows.Next() {
+		var monthCounts PremiumMonthlyCounts
+		if err := rows.Scan(
+			&monthCounts.Month,
+			&monthCounts.Started,
+			&monthCounts.Renewed,
+			&monthCounts.Cancelled,
+			&monthCounts.Expired,
+		); err != nil {
+			return nil, err
+		}
+
+		counts = append(counts, monthCounts)
+	}
+
+	return counts, nil
+}
+
+// GetChurnRate returns the fraction of subscriptions active at the start of the calendar month (UTC) containing
+// month that were cancelled or expired during that month. Returns 0 if there were no active subscriptions.
+func (p *PremiumEvents) GetChurnRate(ctx context.Context, month time.Time) (float64, error) {
+	var activeAtStart, churned int
+	if err := p.QueryRow(ctx, premiumEventsChurnRate, month).Scan(&activeAtStart, &churned); err != nil {
+		return 0, err
+	}
+
+	if activeAtStart <= 0 {
+		return 0, nil
+	}
+
+	return float64(churned) / float64(activeAtStart), nil
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type PremiumPrice struct {
+	SkuId       uuid.UUID
+	Currency    string // ISO 4217 code, e.g. "USD"
+	AmountMinor int64  // Amount in the currency's minor unit, e.g. cents
+	Active      bool
+}
+
+type PremiumPrices struct {
+	*pgxpool.Pool
+}
+
+var (
+	//go:embed sql/premium_prices/schema.sql
+	premiumPricesSchema string
+
+	//go:embed sql/premium_prices/get.sql
+	premiumPricesGet string
+
+	//go:embed sql/premium_prices/get_active.sql
+	premiumPricesGetActive string
+
+	//go:embed sql/premium_prices/set.sql
+	premiumPricesSet string
+
+	//go:embed sql/premium_prices/delete.sql
+	premiumPricesDelete string
+)
+
+func newPremiumPrices(db *pgxpool.Pool) *PremiumPrices {
+	return &PremiumPrices{
+		db,
+	}
+}
+
+func (PremiumPrices) Schema() string {
+	return premiumPricesSchema
+}
+
+func (p *PremiumPrices) Get(ctx context.Context, skuId uuid.UUID, currency string) (PremiumPrice, bool, error) {
+	price, err := scanPremiumPrice(p.QueryRow(ctx, premiumPricesGet, skuId, currency))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PremiumPrice{}, false, nil
+		}
+
+		return PremiumPrice{}, false, err
+	}
+
+	return price, true, nil
+}
+
+// GetActivePrices returns the SKU's active prices, one per currency.
+func (p *PremiumPrices) GetActivePrices(ctx context.Context, skuId uuid.UUID) ([]PremiumPrice, error) {
+	rows, err := p.Query(ctx, premiumPricesGetActive, skuId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []PremiumPrice
+	for rows.Next() {
+		price, err := scanPremiumPrice(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		prices = append(prices, price)
+	}
+
+	return prices, nil
+}
+
+func (p *PremiumPrices) Set(ctx context.Context, price PremiumPrice) error {
+	_, err := p.Exec(ctx, premiumPricesSet, price.SkuId, price.Currency, price.AmountMinor, price.Active)
+	return err
+}
+
+func (p *PremiumPrices) Delete(ctx context.Context, skuId uuid.UUID, currency string) error {
+	_, err := p.Exec(ctx, premiumPricesDelete, skuId, currency)
+	return err
+}
+
+func scanPremiumPrice(row pgx.Row) (price PremiumPrice, err error) {
+	err = row.Scan(
+		&price.SkuId,
+		&price.Currency,
+		&price.AmountMinor,
+		&price.Active,
+	)
+	return
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var (
+	ErrPremiumSeatsNotFound    = errors.New("entitlement does not have premium seats")
+	ErrPremiumSeatLimitReached = errors.New("all of the entitlement's premium seats are assigned")
+)
+
+// premiumSeatsAuditData is recorded as the new data of AuditActionPremiumSetActiveGuilds entries.
+type premiumSeatsAuditData struct {
+	GuildIds []uint64 `json:"guild_ids"`
+}
+
+// PremiumSeats stores the number of servers that a multi-server entitlement grants premium to, and the servers chosen
+// by the purchaser. Assigned servers receive the entitlement's tier as though it were a guild entitlement.
+type PremiumSeats struct {
+	*pgxpool.Pool
+}
+
+var (
+	premiumSeatsSql               = loadSqlTable("premium_seats")
+	premiumSeatsSchema            = premiumSeatsSql.schema()
+	premiumSeatsSetMaxSeats       = premiumSeatsSql.query("set_max_seats")
+	premiumSeatsGetMaxSeats       = premiumSeatsSql.query("get_max_seats")
+	premiumSeatsLock              = premiumSeatsSql.query("lock")
+	premiumSeatsAssign            = premiumSeatsSql.query("assign")
+	premiumSeatsUnassign          = premiumSeatsSql.query("unassign")
+	premiumSeatsGetAssignedGuilds = premiumSeatsSql.query("get_assigned_guilds")
+)
+
+func newPremiumSeats(db *pgxpool.Pool) *PremiumSeats {
+	return &PremiumSeats{
+		db,
+	}
+}
+
+func (PremiumSeats) Schema() string {
+	return premiumSeatsSchema
+}
+
+// SetMaxSeats sets the number of servers the entitlement can be assigned to, e.g. from the SKU's
+// multi_server_skus.servers_permitted. Lowering the limit does not unassign servers already assigned.
+func (p *PremiumSeats) SetMaxSeats(ctx context.Context, entitlementId uuid.UUID, maxSeats int) (err error) {
+	_, err = p.Exec(ctx, premiumSeatsSetMaxSeats, entitlementId, maxSeats)
+	return
+}
+
+func (p *PremiumSeats) GetMaxSeats(ctx context.Context, entitlementId uuid.UUID) (int, bool, error) {
+	var maxSeats int
+	if err := p.QueryRow(ctx, premiumSeatsGetMaxSeats, entitlementId).Scan(&maxSeats); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
+		} else {
+			return 0, false, err
+		}
+	}
+
+	return maxSeats, true, nil
+}
+
+// AssignSeat grants the entitlement's premium to the guild. The entitlement's seats are locked for the duration of the
+// transaction, so concurrent assignments cannot exceed the limit. Returns ErrPremiumSeatsNotFound if SetMaxSeats has
+// not been called for the entitlement, or ErrPremiumSeatLimitReached if all seats are assigned. Assigning a guild that
+// is already assigned is a no-op.
+func (p *PremiumSeats) AssignSeat(ctx context.Context, entitlementId uuid.UUID, guildId uint64) error {
+	return withAuditTx(ctx, p.Pool, func(tx pgx.Tx) error {
+		maxSeats, assigned, alreadyAssigned, err := lockPremiumSeats(ctx, tx, entitlementId, guildId)
+		if err != nil {
+			return err
+		}
+
+		if alreadyAssigned {
+			return nil
+		}
+
+		if assigned >= maxSeats {
+			return ErrPremiumSeatLimitReached
+		}
+
+		if _, err := tx.Exec(ctx, premiumSeatsAssign, entitlementId, guildId); err != nil {
+			return err
+		}
+
+		return recordPremiumSeatsAudit(ctx, tx, entitlementId, guildId)
+	})
+}
+
+// UnassignSeat removes the entitlement's premium from the guild, freeing the seat. Returns ErrPremiumSeatsNotFound if
+// SetMaxSeats has not been called for the entitlement.
+func (p *PremiumSeats) UnassignSeat(ctx context.Context, entitlementId uuid.UUID, guildId uint64) error {
+	return withAuditTx(ctx, p.Pool, func(tx pgx.Tx) error {
+		_, _, alreadyAssigned, err := lockPremiumSeats(ctx, tx, entitlementId, guildId)
+		if err != nil {
+			return err
+		}
+
+		if !alreadyAssigned {
+			return nil
+		}
+
+		if _, err := tx.Exec(ctx, premiumSeatsUnassign, entitlementId, guildId); err != nil {
+			return err
+		}
+
+		return recordPremiumSeatsAudit(ctx, tx, entitlementId, guildId)
+	})
+}
+
+// GetAssignedGuilds returns the guilds the entitlement is assigned to, in the order they were assigned.
+func (p *PremiumSeats) GetAssignedGuilds(ctx context.Context, entitlementId uuid.UUID) ([]uint64, error) {
+	return scanPremiumSeatGuilds(p.Query(ctx, premiumSeatsGetAssignedGuilds, entitlementId))
+}
+
+func lockPremiumSeats(ctx context.Context, tx pgx.Tx, entitlementId uuid.UUID, guildId uint64) (maxSeats, assigned int, alreadyAssigned bool, err error) {
+	if err = tx.QueryRow(ctx, premiumSeatsLock, entitlementId, guildId).Scan(&maxSeats, &assigned, &alreadyAssigned); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = ErrPremiumSeatsNotFound
+		}
+	}
+
+	return
+}
+
+// recordPremiumSeatsAudit records the entitlement's assigned guilds after a change, against the guild that was
+// assigned or unassigned.
+func recordPremiumSeatsAudit(ctx context.Context, tx pgx.Tx, entitlementId uuid.UUID, guildId uint64) error {
+	guildIds, err := scanPremiumSeatGuilds(tx.Query(ctx, premiumSeatsGetAssignedGuilds, entitlementId))
+	if err != nil {
+		return err
+	}
+
+	data := premiumSeatsAuditData{
+		GuildIds: guildIds,
+	}
+
+	return recordAudit(ctx, tx, guildId, AuditActionPremiumSetActiveGuilds, AuditResourcePremium, entitlementId.String(), data)
+}
+
+func scanPremiumSeatGuilds(rows pgx.Rows, err error) ([]uint64, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	guildIds := make([]uint64, 0)
+	for rows.Next() {
+		var guildId uint64
+		if err := rows.Scan(&guildId); err != nil {
+			return nil, err
+		}
+
+		guildIds = append(guildIds, guildId)
+	}
+
+	return guildIds, rows.Err()
+}
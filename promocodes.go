@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type PromoCode struct {
+	Code           string
+	SkuId          uuid.UUID
+	PercentOff     *int16 // Either PercentOff or FreeDays is set
+	FreeDays       *int
+	MaxRedemptions *int // Null if unlimited
+	ExpiresAt      *time.Time
+	CreatedAt      time.Time
+}
+
+type PromoCodes struct {
+	*pgxpool.Pool
+}
+
+var (
+	ErrPromoCodeNotFound        = errors.New("promo code not found")
+	ErrPromoCodeExpired         = errors.New("promo code has expired")
+	ErrPromoCodeExhausted       = errors.New("promo code has reached its redemption limit")
+	ErrPromoCodeAlreadyRedeemed = errors.New("promo code has already been redeemed for this guild")
+)
+
+var (
+	//go:embed sql/promo_codes/schema.sql
+	promoCodesSchema string
+
+	//go:embed sql/promo_codes/get.sql
+	promoCodesGet string
+
+	//go:embed sql/promo_codes/get_for_update.sql
+	promoCodesGetForUpdate string
+
+	//go:embed sql/promo_codes/create.sql
+	promoCodesCreate string
+
+	//go:embed sql/promo_codes/delete.sql
+	promoCodesDelete string
+
+	//go:embed sql/promo_codes/count_redemptions.sql
+	promoCodesCountRedemptions string
+
+	//go:embed sql/promo_codes/insert_redemption.sql
+	promoCodesInsertRedemption string
+)
+
+func newPromoCodes(db *pgxpool.Pool) *PromoCodes {
+	return &PromoCodes{
+		db,
+	}
+}
+
+func (PromoCodes) Schema() string {
+	return promoCodesSchema
+}
+
+func (p *PromoCodes) Get(ctx context.Context, code string) (PromoCode, bool, error) {
+	promoCode, err := scanPromoCode(p.QueryRow(ctx, promoCodesGet, code))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PromoCode{}, false, nil
+		}
+
+		return PromoCode{}, false, err
+	}
+
+	return promoCode, true, nil
+}
+
+func (p *PromoCodes) Create(ctx context.Context, promoCode PromoCode) error {
+	_, err := p.Exec(ctx, promoCodesCreate,
+		promoCode.Code,
+		promoCode.SkuId,
+		promoCode.PercentOff,
+		promoCode.FreeDays,
+		promoCode.MaxRedemptions,
+		promoCode.ExpiresAt,
+	)
+	return err
+}
+
+func (p *PromoCodes) Delete(ctx context.Context, code string) error {
+	_, err := p.Exec(ctx, promoCodesDelete, code)
+	return err
+}
+
+func (p *PromoCodes) GetRedemptionCount(ctx context.Context, code string) (count int, err error) {
+	err = p.QueryRow(ctx, promoCodesCountRedemptions, code).Scan(&count)
+	return
+}
+
+// Redeem records a redemption of the code for the guild, returning the code so that the caller can apply it. The code
+// row is locked for the duration of the transaction, so the redemption limit cannot be exceeded by concurrent calls.
+// Returns one of the ErrPromoCode* errors if the code cannot be redeemed.
+func (p *PromoCodes) Redeem(ctx context.Context, code string, userId, guildId uint64) (PromoCode, error) {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return PromoCode{}, err
+	}
+
+	defer tx.Rollback(ctx)
+
+	promoCode, err := scanPromoCode(tx.QueryRow(ctx, promoCodesGetForUpdate, code))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PromoCode{}, ErrPromoCodeNotFound
+		}
+
+		return PromoCode{}, err
+	}
+
+	if promoCode.ExpiresAt != nil && promoCode.ExpiresAt.Before(time.Now()) {
+		return PromoCode{}, ErrPromoCodeExpired
+	}
+
+	if promoCode.MaxRedemptions != nil {
+		var count int
+		if err := tx.QueryRow(ctx, promoCodesCountRedemptions, code).Scan(&count); err != nil {
+			return PromoCode{}, err
+		}
+
+		if count >= *promoCode.MaxRedemptions {
+			return PromoCode{}, ErrPromoCodeExhausted
+		}
+	}
+
+	res, err := tx.Exec(ctx, promoCodesInsertRedemption, code, userId, guildId)
+	if err != nil {
+		return PromoCode{}, err
+	}
+
+	if res.RowsAffected() == 0 {
+		return PromoCode{}, ErrPromoCodeAlreadyRedeemed
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return PromoCode{}, err
+	}
+
+	return promoCode, nil
+}
+
+func scanPromoCode(row pgx.Row) (promoCode PromoCode, err error) {
+	err = row.Scan(
+		&promoCode.Code,
+		&promoCode.SkuId,
+		&promoCode.PercentOff,
+		&promoCode.FreeDays,
+		&promoCode.MaxRedemptions,
+		&promoCode.ExpiresAt,
+		&promoCode.CreatedAt,
+	)
+	return
+}
@@ -0,0 +1,213 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PurgedGuildSnapshot holds aggregate counts for a guild at the time its data was purged. The guild ID is not stored,
+// so snapshots can be retained indefinitely for historical reporting.
+type PurgedGuildSnapshot struct {
+	Id           int64      `json:"id"`
+	PurgedAt     time.Time  `json:"purged_at"`
+	TicketsTotal int64      `json:"tickets_total"`
+	TicketsOpen  int64      `json:"tickets_open"`
+	Panels       int64      `json:"panels"`
+	MultiPanels  int64      `json:"multi_panels"`
+	SupportTeams int64      `json:"support_teams"`
+	HadPremium   bool       `json:"had_premium"`
+	FirstTicket  *time.Time `json:"first_ticket"` // Truncated to the month
+}
+
+// PurgedGuildTotals sums the snapshots of guilds purged within a period.
+type PurgedGuildTotals struct {
+	Guilds        int64 `json:"guilds"`
+	PremiumGuilds int64 `json:"premium_guilds"`
+	TicketsTotal  int64 `json:"tickets_total"`
+	Panels        int64 `json:"panels"`
+}
+
+// PurgedGuildMonthlyTotals are the totals of guilds purged within a calendar month.
+type PurgedGuildMonthlyTotals struct {
+	Month time.Time `json:"month"`
+	PurgedGuildTotals
+}
+
+type PurgedGuildSnapshotsTable struct {
+	*pgxpool.Pool
+}
+
+func newPurgedGuildSnapshotsTable(db *pgxpool.Pool) *PurgedGuildSnapshotsTable {
+	return &PurgedGuildSnapshotsTable{
+		db,
+	}
+}
+
+func (p PurgedGuildSnapshotsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS purged_guild_snapshots(
+	"id" BIGSERIAL NOT NULL,
+	"purged_at" timestamptz NOT NULL DEFAULT NOW(),
+	"tickets_total" int8 NOT NULL,
+	"tickets_open" int8 NOT NULL,
+	"panels" int8 NOT NULL,
+	"multi_panels" int8 NOT NULL,
+	"support_teams" int8 NOT NULL,
+	"had_premium" bool NOT NULL,
+	"first_ticket" timestamptz DEFAULT NULL,
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS purged_guild_snapshots_purged_at ON purged_guild_snapshots("purged_at");
+`
+}
+
+// CreateTx records a snapshot of the guild's current data. It must be called within the purge transactions, before any
+// rows are deleted: tx is the primary database's transaction, in which the snapshot is recorded, and guildTx is the
+// transaction of the database holding the guild's guild-scoped data, which is tx itself unless the guild is resident
+// in another region. A guild is considered to have had premium if it had an unexpired guild entitlement, premium_guilds
+// row, or legacy entitlement assigned to it; premium inherited from the owner or an admin is not captured.
+func (p *PurgedGuildSnapshotsTable) CreateTx(ctx context.Context, tx, guildTx pgx.Tx, guildId uint64) (id int64, err error) {
+	countsQuery := `
+SELECT
+	(SELECT COUNT(*) FROM tickets WHERE "guild_id" = $1),
+	(SELECT COUNT(*) FROM tickets WHERE "guild_id" = $1 AND "open" = true),
+	(SELECT COUNT(*) FROM panels WHERE "guild_id" = $1),
+	(SELECT COUNT(*) FROM multi_panels WHERE "guild_id" = $1),
+	(SELECT COUNT(*) FROM support_team WHERE "guild_id" = $1),
+	(SELECT date_trunc('month', MIN("open_time")) FROM tickets WHERE "guild_id" = $1);`
+
+	var snapshot PurgedGuildSnapshot
+	if err = guildTx.QueryRow(ctx, countsQuery, guildId).Scan(
+		&snapshot.TicketsTotal,
+		&snapshot.TicketsOpen,
+		&snapshot.Panels,
+		&snapshot.MultiPanels,
+		&snapshot.SupportTeams,
+		&snapshot.FirstTicket,
+	); err != nil {
+		return
+	}
+
+	query := `
+INSERT INTO purged_guild_snapshots("tickets_total", "tickets_open", "panels", "multi_panels", "support_teams", "had_premium", "first_ticket")
+VALUES(
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	(
+		EXISTS(SELECT 1 FROM entitlements WHERE "guild_id" = $1 AND ("expires_at" IS NULL OR "expires_at" > NOW()))
+		OR EXISTS(SELECT 1 FROM premium_guilds WHERE "guild_id" = $1 AND "expiry" > NOW())
+		OR EXISTS(SELECT 1 FROM legacy_premium_entitlement_guilds WHERE "guild_id" = $1)
+	),
+	$7
+)
+RETURNING "id";`
+
+	err = tx.QueryRow(ctx, query,
+		guildId,
+		snapshot.TicketsTotal,
+		snapshot.TicketsOpen,
+		snapshot.Panels,
+		snapshot.MultiPanels,
+		snapshot.SupportTeams,
+		snapshot.FirstTicket,
+	).Scan(&id)
+	return
+}
+
+// List returns the snapshots of guilds purged in [from, to), oldest first.
+func (p *PurgedGuildSnapshotsTable) List(ctx context.Context, from, to time.Time) ([]PurgedGuildSnapshot, error) {
+	query := `
+SELECT "id", "purged_at", "tickets_total", "tickets_open", "panels", "multi_panels", "support_teams", "had_premium", "first_ticket"
+FROM purged_guild_snapshots
+WHERE "purged_at" >= $1 AND "purged_at" < $2
+ORDER BY "purged_at" ASC, "id" ASC;`
+
+	rows, err := p.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []PurgedGuildSnapshot
+	for rows.Next() {
+		var snapshot PurgedGuildSnapshot
+		if err := rows.Scan(
+			&snapshot.Id,
+			&snapshot.PurgedAt,
+			&snapshot.TicketsTotal,
+			&snapshot.TicketsOpen,
+			&snapshot.Panels,
+			&snapshot.MultiPanels,
+			&snapshot.SupportTeams,
+			&snapshot.HadPremium,
+			&snapshot.FirstTicket,
+		); err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, nil
+}
+
+// GetTotals returns the totals of guilds purged in [from, to).
+func (p *PurgedGuildSnapshotsTable) GetTotals(ctx context.Context, from, to time.Time) (totals PurgedGuildTotals, err error) {
+	query := `
+SELECT
+	COUNT(*),
+	COUNT(*) FILTER (WHERE "had_premium"),
+	COALESCE(SUM("tickets_total"), 0)::int8,
+	COALESCE(SUM("panels"), 0)::int8
+FROM purged_guild_snapshots
+WHERE "purged_at" >= $1 AND "purged_at" < $2;`
+
+	err = p.QueryRow(ctx, query, from, to).Scan(&totals.Guilds, &totals.PremiumGuilds, &totals.TicketsTotal, &totals.Panels)
+	return
+}
+
+// GetMonthlyTotals returns the totals of guilds purged in [from, to), grouped by the month of the purge in UTC. Months
+// in which no guilds were purged are omitted.
+func (p *PurgedGuildSnapshotsTable) GetMonthlyTotals(ctx context.Context, from, to time.Time) ([]PurgedGuildMonthlyTotals, error) {
+	query := `
+SELECT
+	date_trunc('month', "purged_at" AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS "month",
+	COUNT(*),
+	COUNT(*) FILTER (WHERE "had_premium"),
+	SUM("tickets_total")::int8,
+	SUM("panels")::int8
+FROM purged_guild_snapshots
+WHERE "purged_at" >= $1 AND "purged_at" < $2
+GROUP BY "month"
+ORDER BY "month" ASC;`
+
+	rows, err := p.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var months []PurgedGuildMonthlyTotals
+	for rows.Next() {
+		var month PurgedGuildMonthlyTotals
+		if err := rows.Scan(
+			&month.Month,
+			&month.Guilds,
+			&month.PremiumGuilds,
+			&month.TicketsTotal,
+			&month.Panels,
+		); err != nil {
+			return nil, err
+		}
+
+		months = append(months, month)
+	}
+
+	return months, nil
+}
@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// ErrQueuedTicketRequestClaimLost is returned by QueuedTicketRequestsTable.Complete if the request's claim has expired
+// and it has since been claimed again, or it has already been completed.
+var ErrQueuedTicketRequestClaimLost = errors.New("queued ticket request claim has been lost")
+
+// QueuedTicketRequest is a request to open a ticket that was made outside of the panel's support hours, when the
+// panel's out of hours behaviour is OutOfHoursBehaviourQueue. Requests are opened in the order they were queued once
+// support hours resume.
+type QueuedTicketRequest struct {
+	Id           int64      `json:"id"`
+	GuildId      uint64     `json:"guild_id,string"`
+	PanelId      int        `json:"panel_id"`
+	UserId       uint64     `json:"user_id,string"`
+	FormAnswers  []byte     `json:"form_answers"`
+	QueuedAt     time.Time  `json:"queued_at"`
+	Processed    bool       `json:"processed"`
+	ClaimedUntil *time.Time `json:"claimed_until"`
+	Attempts     int        `json:"attempts"`
+}
+
+type QueuedTicketRequestsTable struct {
+	*pgxpool.Pool
+}
+
+func newQueuedTicketRequestsTable(db *pgxpool.Pool) *QueuedTicketRequestsTable {
+	return &QueuedTicketRequestsTable{
+		db,
+	}
+}
+
+func (q QueuedTicketRequestsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS queued_ticket_requests(
+	"id" BIGSERIAL NOT NULL,
+	"guild_id" int8 NOT NULL,
+	"panel_id" int NOT NULL,
+	"user_id" int8 NOT NULL,
+	"form_answers" jsonb DEFAULT NULL,
+	"queued_at" timestamptz NOT NULL DEFAULT NOW(),
+	"processed" bool NOT NULL DEFAULT false,
+	FOREIGN KEY("panel_id") REFERENCES panels("panel_id") ON DELETE CASCADE,
+	PRIMARY KEY("id")
+);
+ALTER TABLE queued_ticket_requests ADD COLUMN IF NOT EXISTS "claimed_until" timestamptz DEFAULT NULL;
+ALTER TABLE queued_ticket_requests ADD COLUMN IF NOT EXISTS "attempts" int4 NOT NULL DEFAULT 0;
+CREATE UNIQUE INDEX IF NOT EXISTS queued_ticket_requests_pending_user ON queued_ticket_requests("panel_id", "user_id") WHERE NOT "processed";
+CREATE INDEX IF NOT EXISTS queued_ticket_requests_pending ON queued_ticket_requests("panel_id", "id") WHERE NOT "processed";
+`
+}
+
+// Enqueue adds the user to the back of the panel's queue. If the user already has a pending request for the panel,
+// nothing is changed and queued is false.
+func (q *QueuedTicketRequestsTable) Enqueue(ctx context.Context, guildId uint64, panelId int, userId uint64, formAnswers []byte) (queued bool, err error) {
+	query := `
+INSERT INTO queued_ticket_requests("guild_id", "panel_id", "user_id", "form_answers", "queued_at", "processed")
+VALUES($1, $2, $3, $4, NOW(), false)
+ON CONFLICT("panel_id", "user_id") WHERE NOT "processed" DO NOTHING;`
+
+	res, err := q.Exec(ctx, query, guildId, panelId, userId, formAnswers)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// ClaimNext claims the request at the front of the panel's queue for claimDuration and returns it. Requests locked or
+// claimed by another worker are skipped, so multiple workers may drain the same queue concurrently. If a worker does not
+// call Complete before the claim expires (e.g. because it crashed), the request can be claimed again. The returned
+// request's Attempts identifies the claim, and must be passed to Complete.
+func (q *QueuedTicketRequestsTable) ClaimNext(ctx context.Context, panelId int, claimDuration time.Duration) (QueuedTicketRequest, bool, error) {
+	query := `
+UPDATE queued_ticket_requests
+SET "claimed_until" = NOW() + $2::interval, "attempts" = "attempts" + 1
+WHERE "id" = (
+	SELECT "id"
+	FROM queued_ticket_requests
+	WHERE "panel_id" = $1 AND NOT "processed" AND ("claimed_until" IS NULL OR "claimed_until" <= NOW())
+	ORDER BY "id" ASC
+	LIMIT 1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING "id", "guild_id", "panel_id", "user_id", "form_answers", "queued_at", "processed", "claimed_until", "attempts";`
+
+	var request QueuedTicketRequest
+	if err := q.QueryRow(ctx, query, panelId, claimDuration).Scan(
+		&request.Id,
+		&request.GuildId,
+		&request.PanelId,
+		&request.UserId,
+		&request.FormAnswers,
+		&request.QueuedAt,
+		&request.Processed,
+		&request.ClaimedUntil,
+		&request.Attempts,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return QueuedTicketRequest{}, false, nil
+		} else {
+			return QueuedTicketRequest{}, false, err
+		}
+	}
+
+	return request, true, nil
+}
+
+// Complete marks a claimed request as processed. attempt must be the request's Attempts as returned by ClaimNext; if
+// the request has since been claimed again, or has already been completed, ErrQueuedTicketRequestClaimLost is returned.
+func (q *QueuedTicketRequestsTable) Complete(ctx context.Context, id int64, attempt int) error {
+	query := `
+UPDATE queued_ticket_requests
+SET "processed" = true, "claimed_until" = NULL
+WHERE "id" = $1 AND NOT "processed" AND "attempts" = $2;`
+
+	res, err := q.Exec(ctx, query, id, attempt)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrQueuedTicketRequestClaimLost
+	}
+
+	return nil
+}
+
+// GetPosition returns the user's 1-indexed position in the panel's queue. Returns false if the user has no pending
+// request for the panel.
+func (q *QueuedTicketRequestsTable) GetPosition(ctx context.Context, panelId int, userId uint64) (position int, ok bool, e error) {
+	query := `
+SELECT COUNT(*)
+FROM queued_ticket_requests AS ahead
+INNER JOIN queued_ticket_requests AS own
+ON own."panel_id" = ahead."panel_id" AND NOT own."processed"
+WHERE ahead."panel_id" = $1 AND own."user_id" = $2 AND NOT ahead."processed" AND ahead."id" <= own."id";`
+
+	if err := q.QueryRow(ctx, query, panelId, userId).Scan(&position); err != nil {
+		return 0, false, err
+	}
+
+	return position, position > 0, nil
+}
+
+func (q *QueuedTicketRequestsTable) GetQueueLength(ctx context.Context, panelId int) (count int, err error) {
+	query := `SELECT COUNT(*) FROM queued_ticket_requests WHERE "panel_id" = $1 AND NOT "processed";`
+	err = q.QueryRow(ctx, query, panelId).Scan(&count)
+	return
+}
+
+// Cancel removes the user's pending request from the panel's queue. Requests that are currently claimed by a worker are
+// left in place, as their ticket may already be being opened.
+func (q *QueuedTicketRequestsTable) Cancel(ctx context.Context, panelId int, userId uint64) (err error) {
+	query := `
+DELETE FROM queued_ticket_requests
+WHERE "panel_id" = $1 AND "user_id" = $2 AND NOT "processed" AND ("claimed_until" IS NULL OR "claimed_until" <= NOW());`
+	_, err = q.Exec(ctx, query, panelId, userId)
+	return
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// RatingPromptSchedule controls when the rating DM is sent after a ticket is closed. Guilds without a schedule are
+// prompted at close time.
+type RatingPromptSchedule struct {
+	GuildId       uint64        `json:"guild_id,string"`
+	Delay         time.Duration `json:"delay"`          // Between the close and the prompt, and between each reminder
+	ReminderCount int           `json:"reminder_count"` // Reminders sent after the first prompt if no rating is given
+}
+
+type RatingPromptScheduleTable struct {
+	*pgxpool.Pool
+}
+
+var (
+	ratingPromptScheduleSql    = loadSqlTable("rating_prompt_schedule")
+	ratingPromptScheduleSchema = ratingPromptScheduleSql.schema()
+	ratingPromptScheduleGet    = ratingPromptScheduleSql.query("get")
+	ratingPromptScheduleSet    = ratingPromptScheduleSql.query("set")
+	ratingPromptScheduleDelete = ratingPromptScheduleSql.query("delete")
+)
+
+func newRatingPromptScheduleTable(db *pgxpool.Pool) *RatingPromptScheduleTable {
+	return &RatingPromptScheduleTable{
+		db,
+	}
+}
+
+func (RatingPromptScheduleTable) Schema() string {
+	return ratingPromptScheduleSchema
+}
+
+func (r *RatingPromptScheduleTable) Get(ctx context.Context, guildId uint64) (RatingPromptSchedule, bool, error) {
+	schedule := RatingPromptSchedule{
+		GuildId: guildId,
+	}
+
+	var delaySeconds int32
+	var reminderCount int16
+	if err := r.QueryRow(ctx, ratingPromptScheduleGet, guildId).Scan(&delaySeconds, &reminderCount); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return RatingPromptSchedule{}, false, nil
+		} else {
+			return RatingPromptSchedule{}, false, err
+		}
+	}
+
+	schedule.Delay = time.Duration(delaySeconds) * time.Second
+	schedule.ReminderCount = int(reminderCount)
+
+	return schedule, true, nil
+}
+
+// Set creates or replaces the guild's schedule. Prompts that have already been queued keep the schedule they were
+// queued with. The delay is truncated to whole seconds.
+func (r *RatingPromptScheduleTable) Set(ctx context.Context, schedule RatingPromptSchedule) (err error) {
+	_, err = r.Exec(ctx, ratingPromptScheduleSet, schedule.GuildId, int32(schedule.Delay/time.Second), int16(schedule.ReminderCount))
+	return
+}
+
+func (r *RatingPromptScheduleTable) Delete(ctx context.Context, guildId uint64) (err error) {
+	_, err = r.Exec(ctx, ratingPromptScheduleDelete, guildId)
+	return
+}
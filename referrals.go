@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type Referral struct {
+	RefereeGuildId  uint64    `json:"referee_guild_id,string"`
+	ReferrerUserId  uint64    `json:"referrer_user_id,string"`
+	ReferrerGuildId *uint64   `json:"referrer_guild_id,string"`
+	CreatedAt       time.Time `json:"created_at"`
+	RewardGranted   bool      `json:"reward_granted"`
+}
+
+type ReferralStats struct {
+	Total          int `json:"total"`
+	RewardsGranted int `json:"rewards_granted"`
+}
+
+type ReferralsTable struct {
+	*pgxpool.Pool
+}
+
+func newReferralsTable(db *pgxpool.Pool) *ReferralsTable {
+	return &ReferralsTable{
+		db,
+	}
+}
+
+func (r ReferralsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS referrals(
+	"referee_guild_id" int8 NOT NULL,
+	"referrer_user_id" int8 NOT NULL,
+	"referrer_guild_id" int8 DEFAULT NULL,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	"reward_granted" bool NOT NULL DEFAULT false,
+	CHECK("referrer_guild_id" IS NULL OR "referrer_guild_id" <> "referee_guild_id"),
+	PRIMARY KEY("referee_guild_id")
+);
+CREATE INDEX IF NOT EXISTS referrals_referrer_user_id ON referrals("referrer_user_id");
+`
+}
+
+// RecordReferral records that the referee guild was referred by the user. A guild can only be referred once: if it
+// has already been referred, the existing referral is kept and false is returned.
+func (r *ReferralsTable) RecordReferral(ctx context.Context, referrerUserId uint64, referrerGuildId *uint64, refereeGuildId uint64) (recorded bool, err error) {
+	query := `
+INSERT INTO referrals("referee_guild_id", "referrer_user_id", "referrer_guild_id")
+VALUES($1, $2, $3)
+ON CONFLICT("referee_guild_id") DO NOTHING;`
+
+	res, err := r.Exec(ctx, query, refereeGuildId, referrerUserId, referrerGuildId)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+func (r *ReferralsTable) GetByReferee(ctx context.Context, refereeGuildId uint64) (Referral, bool, error) {
+	query := `
+SELECT "referee_guild_id", "referrer_user_id", "referrer_guild_id", "created_at", "reward_granted"
+FROM referrals
+WHERE "referee_guild_id" = $1;`
+
+	var referral Referral
+	if err := r.QueryRow(ctx, query, refereeGuildId).Scan(
+		&referral.RefereeGuildId,
+		&referral.ReferrerUserId,
+		&referral.ReferrerGuildId,
+		&referral.CreatedAt,
+		&referral.RewardGranted,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Referral{}, false, nil
+		} else {
+			return Referral{}, false, err
+		}
+	}
+
+	return referral, true, nil
+}
+
+func (r *ReferralsTable) GetReferralStats(ctx context.Context, userId uint64) (stats ReferralStats, err error) {
+	query := `
+SELECT COUNT(*), COUNT(*) FILTER (WHERE "reward_granted")
+FROM referrals
+WHERE "referrer_user_id" = $1;`
+
+	err = r.QueryRow(ctx, query, userId).Scan(&stats.Total, &stats.RewardsGranted)
+	return
+}
+
+// MarkRewardGranted returns false if the reward had already been granted, so that it is never granted twice.
+func (r *ReferralsTable) MarkRewardGranted(ctx context.Context, refereeGuildId uint64) (bool, error) {
+	query := `
+UPDATE referrals
+SET "reward_granted" = true
+WHERE "referee_guild_id" = $1 AND NOT "reward_granted";`
+
+	res, err := r.Exec(ctx, query, refereeGuildId)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
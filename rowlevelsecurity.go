@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	// guildScopeSetting is the setting read by row level security policies to determine the guild being served.
+	guildScopeSetting = "app.guild_id"
+	guildScopePolicy  = "guild_scope"
+)
+
+// rlsExcludedTables have a guild_id column, but are read across guilds by user, e.g. to list a user's servers, so must
+// remain readable without a guild scope.
+var rlsExcludedTables = []string{
+	"guild_dashboard_cache",
+	"user_guilds",
+}
+
+// EnableRLS enables row level security on every table created by this package that has a guild_id column, with a
+// policy that only allows access to rows of the guild set by WithGuildScope. Tables scoped to a guild only through a
+// foreign key, e.g. panel_teams, are not covered. Policies do not apply to the table owner or superusers, so they only
+// restrict a separate, less privileged role, e.g. the dashboard's. Safe to call repeatedly. Returns the names of the
+// tables that policies were created on.
+func (d *Database) EnableRLS(ctx context.Context) ([]string, error) {
+	tables, err := d.guildScopedTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.WithTx(ctx, func(tx pgx.Tx) error {
+		for _, table := range tables {
+			identifier := pgx.Identifier{table}.Sanitize()
+			policy := pgx.Identifier{guildScopePolicy}.Sanitize()
+
+			queries := []string{
+				fmt.Sprintf(`ALTER TABLE %s ENABLE ROW LEVEL SECURITY;`, identifier),
+				fmt.Sprintf(`DROP POLICY IF EXISTS %s ON %s;`, policy, identifier),
+				fmt.Sprintf(
+					`CREATE POLICY %s ON %s USING ("guild_id" = NULLIF(current_setting('%s', true), '')::int8);`,
+					policy, identifier, guildScopeSetting,
+				),
+			}
+
+			for _, query := range queries {
+				if _, err := tx.Exec(ctx, query); err != nil {
+					return fmt.Errorf("failed to enable row level security on %s: %w", table, err)
+				}
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tables, nil
+}
+
+// DisableRLS removes the policies created by EnableRLS, and disables row level security on the tables.
+func (d *Database) DisableRLS(ctx context.Context) error {
+	tables, err := d.guildScopedTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	return d.WithTx(ctx, func(tx pgx.Tx) error {
+		for _, table := range tables {
+			identifier := pgx.Identifier{table}.Sanitize()
+
+			queries := []string{
+				fmt.Sprintf(`DROP POLICY IF EXISTS %s ON %s;`, pgx.Identifier{guildScopePolicy}.Sanitize(), identifier),
+				fmt.Sprintf(`ALTER TABLE %s DISABLE ROW LEVEL SECURITY;`, identifier),
+			}
+
+			for _, query := range queries {
+				if _, err := tx.Exec(ctx, query); err != nil {
+					return fmt.Errorf("failed to disable row level security on %s: %w", table, err)
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// WithGuildScope runs f in a transaction that can only access rows of the guild when row level security is enabled.
+// The scope is set with SET LOCAL semantics, so does not leak to other users of the connection after the transaction.
+func (d *Database) WithGuildScope(ctx context.Context, guildId uint64, f func(tx pgx.Tx) error) error {
+	return d.WithTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT set_config($1, $2, true);`, guildScopeSetting, strconv.FormatUint(guildId, 10)); err != nil {
+			return err
+		}
+
+		return f(tx)
+	})
+}
+
+// guildScopedTables returns the tables created by this package that have a guild_id column, other than those in
+// rlsExcludedTables.
+func (d *Database) guildScopedTables(ctx context.Context) ([]string, error) {
+	tableNames, _ := d.schemaObjectNames()
+
+	query := `
+SELECT "table_name"
+FROM information_schema.columns
+WHERE "table_schema" = current_schema() AND "column_name" = 'guild_id' AND "table_name" = ANY($1::text[])
+ORDER BY "table_name" ASC;`
+
+	rows, err := d.pool.Query(ctx, query, tableNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, err
+		}
+
+		if !slices.Contains(rlsExcludedTables, table) {
+			tables = append(tables, table)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tables, nil
+}
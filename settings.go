@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"strconv"
 )
 
 // TODO: Migrate all settings to this table
@@ -165,10 +166,10 @@ DO UPDATE SET
 	"anonymise_dashboard_responses" = $13,
 	"hide_close_button" = $14,
 	"hide_close_with_reason_button" = $15
-;
+RETURNING "guild_id";
 `
 
-	_, err = s.Exec(ctx, query,
+	return execAudited(ctx, s.Pool, AuditActionSettingsUpdate, AuditResourceSettings, strconv.FormatUint(guildId, 10), settings, query,
 		guildId,
 		settings.HideClaimButton,
 		settings.DisableOpenCommand,
@@ -185,8 +186,6 @@ DO UPDATE SET
 		settings.HideCloseButton,
 		settings.HideCloseWithReasonButton,
 	)
-
-	return
 }
 
 func (s *SettingsTable) SetHideClaimButton(ctx context.Context, guildId uint64, hideClaimButton bool) (err error) {
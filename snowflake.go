@@ -0,0 +1,140 @@
+package database
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+)
+
+// Snowflake is a Discord ID stored in an int8 column.
+type Snowflake uint64
+
+func (s Snowflake) Value() (driver.Value, error) {
+	return int64(s), nil
+}
+
+func (s *Snowflake) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case int64:
+		*s = Snowflake(v)
+	case nil:
+		return fmt.Errorf("cannot scan NULL into Snowflake")
+	default:
+		return fmt.Errorf("cannot scan %T into Snowflake", src)
+	}
+
+	return nil
+}
+
+func (s Snowflake) String() string {
+	return strconv.FormatUint(uint64(s), 10)
+}
+
+// nullableSnowflakeMigration returns a statement converting an int8 column that used 0 to mean "unset" to a nullable
+// column storing NULL instead. It only does anything while the column is still NOT NULL, so the backfill does not scan
+// the table every time the schema is applied.
+func nullableSnowflakeMigration(table, column string) string {
+	return fmt.Sprintf(`
+DO $$
+BEGIN
+	IF EXISTS(
+		SELECT 1
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = '%[1]s' AND column_name = '%[2]s' AND is_nullable = 'NO'
+	) THEN
+		ALTER TABLE %[1]s ALTER COLUMN "%[2]s" DROP NOT NULL;
+		UPDATE %[1]s SET "%[2]s" = NULL WHERE "%[2]s" = 0;
+	END IF;
+END $$;`, table, column)
+}
+
+// NullSnowflake is a Discord ID stored in a nullable int8 column. Columns that previously used 0 to mean "unset"
+// should use this type instead, so that the absence of a value is stored as NULL.
+type NullSnowflake struct {
+	Snowflake Snowflake
+	Valid     bool
+}
+
+// NewNullSnowflake treats 0 as unset, which matches how these IDs were represented before the columns were nullable.
+func NewNullSnowflake(id uint64) NullSnowflake {
+	return NullSnowflake{
+		Snowflake: Snowflake(id),
+		Valid:     id != 0,
+	}
+}
+
+func NullSnowflakeFromPtr(id *uint64) NullSnowflake {
+	if id == nil {
+		return NullSnowflake{}
+	}
+
+	return NewNullSnowflake(*id)
+}
+
+// Uint64 returns 0 if the value is unset.
+func (s NullSnowflake) Uint64() uint64 {
+	if !s.Valid {
+		return 0
+	}
+
+	return uint64(s.Snowflake)
+}
+
+func (s NullSnowflake) Ptr() *uint64 {
+	if !s.Valid {
+		return nil
+	}
+
+	return ptr(uint64(s.Snowflake))
+}
+
+func (s NullSnowflake) Value() (driver.Value, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+
+	return int64(s.Snowflake), nil
+}
+
+func (s *NullSnowflake) Scan(src interface{}) error {
+	if src == nil {
+		*s = NullSnowflake{}
+		return nil
+	}
+
+	if err := s.Snowflake.Scan(src); err != nil {
+		return err
+	}
+
+	s.Valid = true
+	return nil
+}
+
+func (s NullSnowflake) MarshalJSON() ([]byte, error) {
+	if !s.Valid {
+		return []byte("null"), nil
+	}
+
+	return []byte(strconv.Quote(s.Snowflake.String())), nil
+}
+
+// UnmarshalJSON accepts null, a quoted ID or a bare number, for compatibility with the previous uint64 fields.
+func (s *NullSnowflake) UnmarshalJSON(data []byte) error {
+	raw := string(data)
+	if raw == "null" {
+		*s = NullSnowflake{}
+		return nil
+	}
+
+	if unquoted, err := strconv.Unquote(raw); err == nil {
+		raw = unquoted
+	}
+
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	*s = NewNullSnowflake(id)
+	return nil
+}
@@ -0,0 +1,4 @@
+SELECT message_id, attachment_id, filename, content_type, size, storage_key, url, expires_at
+FROM archive_message_attachments
+WHERE guild_id = $1 AND ticket_id = $2
+ORDER BY message_id, attachment_id;
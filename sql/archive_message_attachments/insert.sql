@@ -0,0 +1,12 @@
+INSERT INTO archive_message_attachments (guild_id, ticket_id, message_id, attachment_id, filename, content_type, size, storage_key, url, expires_at)
+SELECT $1, $2, attachments.message_id, attachments.attachment_id, attachments.filename, attachments.content_type,
+       attachments.size, attachments.storage_key, attachments.url, attachments.expires_at
+FROM unnest($3::int8[], $4::int8[], $5::text[], $6::text[], $7::int8[], $8::text[], $9::text[], $10::timestamptz[])
+    AS attachments(message_id, attachment_id, filename, content_type, size, storage_key, url, expires_at)
+ON CONFLICT (guild_id, ticket_id, attachment_id) DO UPDATE SET
+    filename = excluded.filename,
+    content_type = excluded.content_type,
+    size = excluded.size,
+    storage_key = excluded.storage_key,
+    url = excluded.url,
+    expires_at = excluded.expires_at;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS archive_message_attachments (
+    guild_id int8 NOT NULL,
+    ticket_id int4 NOT NULL,
+    message_id int8 NOT NULL,
+    attachment_id int8 NOT NULL,
+    filename varchar(1024) NOT NULL,
+    content_type varchar(255),
+    size int8 NOT NULL,
+    storage_key text,
+    url text,
+    expires_at timestamptz,
+    FOREIGN KEY (guild_id, ticket_id) REFERENCES tickets(guild_id, id) ON DELETE CASCADE,
+    CHECK (storage_key IS NOT NULL OR url IS NOT NULL),
+    PRIMARY KEY (guild_id, ticket_id, attachment_id)
+);
+
+CREATE INDEX IF NOT EXISTS archive_message_attachments_expires_at ON archive_message_attachments (expires_at) WHERE storage_key IS NULL;
@@ -0,0 +1,7 @@
+SELECT archive_message_reactions.message_id, archive_message_reactions.emoji, archive_message_reactions.count
+FROM archive_message_reactions
+INNER JOIN archive_messages
+    ON archive_messages.guild_id = archive_message_reactions.guild_id
+    AND archive_messages.ticket_id = archive_message_reactions.ticket_id
+WHERE archive_message_reactions.guild_id = $1 AND archive_message_reactions.ticket_id = $2
+ORDER BY archive_message_reactions.message_id, archive_message_reactions.count DESC, archive_message_reactions.emoji;
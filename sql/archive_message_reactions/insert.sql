@@ -0,0 +1,5 @@
+INSERT INTO archive_message_reactions (guild_id, ticket_id, message_id, emoji, count)
+SELECT $1, $2, reactions.message_id, reactions.emoji, reactions.count
+FROM unnest($3::int8[], $4::text[], $5::int4[]) AS reactions(message_id, emoji, count)
+ON CONFLICT (guild_id, ticket_id, message_id, emoji) DO UPDATE SET
+    count = excluded.count;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS archive_message_reactions (
+    guild_id int8 NOT NULL,
+    ticket_id int4 NOT NULL,
+    message_id int8 NOT NULL,
+    emoji varchar(128) NOT NULL,
+    count int4 NOT NULL,
+    FOREIGN KEY (guild_id, ticket_id) REFERENCES tickets(guild_id, id) ON DELETE CASCADE,
+    CHECK (count > 0),
+    PRIMARY KEY (guild_id, ticket_id, message_id, emoji)
+);
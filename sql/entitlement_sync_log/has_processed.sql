@@ -0,0 +1,5 @@
+SELECT EXISTS(
+    SELECT 1
+    FROM entitlement_sync_log
+    WHERE provider = $1 AND external_id = $2 AND result IN ('success', 'ignored')
+);
@@ -0,0 +1,4 @@
+SELECT provider, external_id, payload_hash, result, error, attempts, processed_at
+FROM entitlement_sync_log
+WHERE result = 'failed' AND processed_at >= $1
+ORDER BY processed_at ASC;
@@ -0,0 +1,8 @@
+INSERT INTO entitlement_sync_log (provider, external_id, payload_hash, result, error, attempts, processed_at)
+VALUES ($1, $2, $3, $4, $5, 1, NOW())
+ON CONFLICT (provider, external_id) DO UPDATE
+    SET payload_hash = EXCLUDED.payload_hash,
+        result       = EXCLUDED.result,
+        error        = EXCLUDED.error,
+        attempts     = entitlement_sync_log.attempts + 1,
+        processed_at = EXCLUDED.processed_at;
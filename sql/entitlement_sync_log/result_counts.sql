@@ -0,0 +1,4 @@
+SELECT provider, result, COUNT(*)
+FROM entitlement_sync_log
+WHERE processed_at >= $1
+GROUP BY provider, result;
@@ -0,0 +1,15 @@
+CREATE TYPE entitlement_sync_result AS ENUM ('success', 'failed', 'ignored');
+
+CREATE TABLE IF NOT EXISTS entitlement_sync_log
+(
+    provider     VARCHAR(32)             NOT NULL,
+    external_id  VARCHAR(255)            NOT NULL,
+    payload_hash BYTEA                   NOT NULL,
+    result       entitlement_sync_result NOT NULL,
+    error        TEXT DEFAULT NULL,
+    attempts     INT4                    NOT NULL DEFAULT 1,
+    processed_at timestamptz             NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, external_id)
+);
+
+CREATE INDEX IF NOT EXISTS entitlement_sync_log_result_processed_at ON entitlement_sync_log (result, processed_at);
@@ -25,4 +25,17 @@ WHERE (
         entitlements.user_id = $2
             OR
         (entitlements.user_id = permissions.user_id AND permissions.admin = 't' AND permissions.guild_id = $1)
-    );
\ No newline at end of file
+    )
+
+UNION ALL
+
+SELECT entitlements.id, entitlements.user_id, entitlements.source, entitlements.expires_at, skus.id, skus.label, subscription_skus.tier, subscription_skus.priority
+FROM entitlements
+INNER JOIN premium_seat_assignments ON entitlements.id = premium_seat_assignments.entitlement_id
+INNER JOIN skus ON entitlements.sku_id = skus.id
+INNER JOIN subscription_skus ON skus.id = subscription_skus.sku_id
+WHERE (
+        entitlements.expires_at IS NULL OR
+        entitlements.expires_at > (NOW() - $3::interval)
+    ) AND
+    premium_seat_assignments.guild_id = $1;
\ No newline at end of file
@@ -11,4 +11,8 @@ CREATE TABLE IF NOT EXISTS exit_survey_responses(
 );
 
 CREATE INDEX IF NOT EXISTS exit_survey_responses_guild_id ON exit_survey_responses("guild_id");
-CREATE INDEX IF NOT EXISTS exit_survey_responses_form_id ON exit_survey_responses("form_id");
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS exit_survey_responses_form_id ON exit_survey_responses("form_id");
+
+ALTER TABLE exit_survey_responses ADD COLUMN IF NOT EXISTS "submitted_at" timestamptz DEFAULT NULL;
+ALTER TABLE exit_survey_responses ALTER COLUMN "submitted_at" SET DEFAULT NOW();
+CREATE INDEX IF NOT EXISTS exit_survey_responses_form_id_submitted_at ON exit_survey_responses("form_id", "submitted_at");
\ No newline at end of file
@@ -0,0 +1,4 @@
+SELECT guild_id, name, icon, owner, permissions, is_admin, is_support, premium_tier, whitelabel_bot_id
+FROM guild_dashboard_cache
+WHERE user_id = $1
+ORDER BY name ASC, guild_id ASC;
@@ -0,0 +1,6 @@
+SELECT refresh_guild_dashboard_cache(user_id, guild_id)
+FROM (
+    SELECT user_id, guild_id FROM user_guilds WHERE user_id = $1
+    UNION
+    SELECT user_id, guild_id FROM guild_dashboard_cache WHERE user_id = $1
+) AS members;
@@ -0,0 +1,108 @@
+CREATE TABLE IF NOT EXISTS guild_dashboard_cache
+(
+    user_id           int8          NOT NULL,
+    guild_id          int8          NOT NULL,
+    name              varchar(100)  NOT NULL,
+    icon              varchar(34),
+    owner             bool          NOT NULL,
+    permissions       int8          NOT NULL,
+    is_admin          bool          NOT NULL DEFAULT false,
+    is_support        bool          NOT NULL DEFAULT false,
+    premium_tier      premium_tier  DEFAULT NULL,
+    whitelabel_bot_id int8          DEFAULT NULL,
+    updated_at        timestamptz   NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, guild_id)
+);
+
+CREATE INDEX IF NOT EXISTS guild_dashboard_cache_guild_id ON guild_dashboard_cache (guild_id);
+
+-- Rebuild the row for a user and guild from user_guilds, permissions and whitelabel_guilds. The premium tier depends on
+-- the grace period and the guild owner, so is maintained by the application instead, and copied from the guild's other
+-- rows when a row is created.
+CREATE OR REPLACE FUNCTION refresh_guild_dashboard_cache(p_user_id int8, p_guild_id int8)
+RETURNS void AS $$
+BEGIN
+    DELETE FROM guild_dashboard_cache
+    WHERE user_id = p_user_id
+      AND guild_id = p_guild_id
+      AND NOT EXISTS (SELECT 1 FROM user_guilds WHERE user_id = p_user_id AND guild_id = p_guild_id);
+
+    INSERT INTO guild_dashboard_cache (user_id, guild_id, name, icon, owner, permissions, is_admin, is_support, premium_tier, whitelabel_bot_id, updated_at)
+    SELECT
+        user_guilds.user_id,
+        user_guilds.guild_id,
+        user_guilds.name,
+        user_guilds.icon,
+        user_guilds.owner,
+        user_guilds.permissions,
+        COALESCE(permissions.admin, false),
+        COALESCE(permissions.admin OR permissions.support, false),
+        (SELECT existing.premium_tier FROM guild_dashboard_cache AS existing WHERE existing.guild_id = p_guild_id LIMIT 1),
+        (SELECT MIN(whitelabel_guilds.bot_id) FROM whitelabel_guilds WHERE whitelabel_guilds.guild_id = p_guild_id),
+        NOW()
+    FROM user_guilds
+    LEFT JOIN permissions ON permissions.guild_id = user_guilds.guild_id AND permissions.user_id = user_guilds.user_id
+    WHERE user_guilds.user_id = p_user_id AND user_guilds.guild_id = p_guild_id
+    ON CONFLICT (user_id, guild_id) DO UPDATE
+    SET name              = EXCLUDED.name,
+        icon              = EXCLUDED.icon,
+        owner             = EXCLUDED.owner,
+        permissions       = EXCLUDED.permissions,
+        is_admin          = EXCLUDED.is_admin,
+        is_support        = EXCLUDED.is_support,
+        whitelabel_bot_id = EXCLUDED.whitelabel_bot_id,
+        updated_at        = EXCLUDED.updated_at;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION guild_dashboard_cache_member_changed()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM refresh_guild_dashboard_cache(OLD.user_id, OLD.guild_id);
+        RETURN OLD;
+    END IF;
+
+    PERFORM refresh_guild_dashboard_cache(NEW.user_id, NEW.guild_id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION guild_dashboard_cache_whitelabel_changed()
+RETURNS TRIGGER AS $$
+DECLARE
+    changed_guild_id int8;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed_guild_id := OLD.guild_id;
+    ELSE
+        changed_guild_id := NEW.guild_id;
+    END IF;
+
+    UPDATE guild_dashboard_cache
+    SET whitelabel_bot_id = (SELECT MIN(whitelabel_guilds.bot_id) FROM whitelabel_guilds WHERE whitelabel_guilds.guild_id = changed_guild_id),
+        updated_at        = NOW()
+    WHERE guild_id = changed_guild_id;
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER user_guilds_guild_dashboard_cache
+AFTER INSERT OR UPDATE OR DELETE ON user_guilds
+FOR EACH ROW
+EXECUTE FUNCTION guild_dashboard_cache_member_changed();
+
+CREATE OR REPLACE TRIGGER permissions_guild_dashboard_cache
+AFTER INSERT OR UPDATE OR DELETE ON permissions
+FOR EACH ROW
+EXECUTE FUNCTION guild_dashboard_cache_member_changed();
+
+CREATE OR REPLACE TRIGGER whitelabel_guilds_guild_dashboard_cache
+AFTER INSERT OR UPDATE OR DELETE ON whitelabel_guilds
+FOR EACH ROW
+EXECUTE FUNCTION guild_dashboard_cache_whitelabel_changed();
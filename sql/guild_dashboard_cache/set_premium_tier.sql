@@ -0,0 +1,3 @@
+UPDATE guild_dashboard_cache
+SET premium_tier = $2, updated_at = NOW()
+WHERE guild_id = $1;
@@ -1,4 +1,10 @@
-CREATE TYPE mapping_area AS ENUM ('ticket', 'form', 'form_input', 'panel');
+DO $$
+BEGIN
+    CREATE TYPE mapping_area AS ENUM ('ticket', 'form', 'form_input', 'panel');
+EXCEPTION
+    WHEN duplicate_object THEN NULL;
+END
+$$;
 
 CREATE TABLE IF NOT EXISTS import_mapping
 (
@@ -7,4 +13,9 @@ CREATE TABLE IF NOT EXISTS import_mapping
     source_id int4 NOT NULL,
     target_id int4 NOT NULL,
     UNIQUE NULLS NOT DISTINCT (guild_id, area, source_id, target_id)
-);
\ No newline at end of file
+);
+ALTER TYPE mapping_area ADD VALUE IF NOT EXISTS 'team';
+
+-- The bot that the mapped entity was imported from, as passed to ImportExternalConfig, so that IDs from different bots
+-- do not collide. Empty for imports of TicketsBot's own exports.
+ALTER TABLE import_mapping ADD COLUMN IF NOT EXISTS source varchar(32) NOT NULL DEFAULT '';
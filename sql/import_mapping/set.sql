@@ -1,3 +1,3 @@
-INSERT INTO import_mapping (guild_id, area, source_id, target_id)
-VALUES ($1, $2, $3, $4)
+INSERT INTO import_mapping (guild_id, area, source_id, target_id, source)
+VALUES ($1, $2, $3, $4, $5)
 ON CONFLICT DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,19 @@
+SELECT COUNT(*)
+FROM legacy_premium_entitlement_guilds AS legacy_guilds
+INNER JOIN legacy_premium_entitlements AS legacy ON legacy.user_id = legacy_guilds.user_id
+INNER JOIN subscription_skus ON subscription_skus.sku_id = legacy.sku_id
+WHERE legacy.expires_at > NOW() AND
+    NOT EXISTS (
+        SELECT 1
+        FROM legacy_entitlement_migrations AS migrations
+        WHERE migrations.user_id = legacy_guilds.user_id AND migrations.guild_id = legacy_guilds.guild_id
+    ) AND
+    NOT EXISTS (
+        SELECT 1
+        FROM entitlements
+        WHERE entitlements.guild_id = legacy_guilds.guild_id AND
+            entitlements.user_id = legacy_guilds.user_id AND
+            entitlements.sku_id = legacy.sku_id AND
+            entitlements.source = 'patreon' AND
+            entitlements.id != legacy_guilds.entitlement_id
+    );
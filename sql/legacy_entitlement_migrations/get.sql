@@ -0,0 +1,3 @@
+SELECT entitlement_id, migrated_at
+FROM legacy_entitlement_migrations
+WHERE user_id = $1 AND guild_id = $2;
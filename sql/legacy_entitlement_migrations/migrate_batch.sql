@@ -0,0 +1,38 @@
+WITH batch AS (
+    SELECT legacy_guilds.user_id, legacy_guilds.guild_id, legacy_guilds.entitlement_id, legacy.sku_id, legacy.expires_at
+    FROM legacy_premium_entitlement_guilds AS legacy_guilds
+    INNER JOIN legacy_premium_entitlements AS legacy ON legacy.user_id = legacy_guilds.user_id
+    INNER JOIN subscription_skus ON subscription_skus.sku_id = legacy.sku_id
+    WHERE legacy.expires_at > NOW() AND
+        NOT EXISTS (
+            SELECT 1
+            FROM legacy_entitlement_migrations AS migrations
+            WHERE migrations.user_id = legacy_guilds.user_id AND migrations.guild_id = legacy_guilds.guild_id
+        ) AND
+        NOT EXISTS (
+            SELECT 1
+            FROM entitlements
+            WHERE entitlements.guild_id = legacy_guilds.guild_id AND
+                entitlements.user_id = legacy_guilds.user_id AND
+                entitlements.sku_id = legacy.sku_id AND
+                entitlements.source = 'patreon' AND
+                entitlements.id != legacy_guilds.entitlement_id
+        )
+    ORDER BY legacy_guilds.user_id, legacy_guilds.guild_id
+    LIMIT $1
+    FOR UPDATE OF legacy_guilds SKIP LOCKED
+), converted AS (
+    UPDATE entitlements
+    SET guild_id   = batch.guild_id,
+        user_id    = batch.user_id,
+        sku_id     = batch.sku_id,
+        source     = 'patreon',
+        expires_at = batch.expires_at
+    FROM batch
+    WHERE entitlements.id = batch.entitlement_id
+    RETURNING batch.user_id, batch.guild_id, entitlements.id
+)
+INSERT INTO legacy_entitlement_migrations (user_id, guild_id, entitlement_id)
+SELECT user_id, guild_id, id
+FROM converted
+ON CONFLICT (user_id, guild_id) DO NOTHING;
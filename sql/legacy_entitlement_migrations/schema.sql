@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS legacy_entitlement_migrations
+(
+    user_id        int8        NOT NULL,
+    guild_id       int8        NOT NULL,
+    entitlement_id UUID        NOT NULL,
+    migrated_at    timestamptz NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, guild_id),
+    FOREIGN KEY (entitlement_id) REFERENCES entitlements (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS legacy_entitlement_migrations_guild_id ON legacy_entitlement_migrations (guild_id);
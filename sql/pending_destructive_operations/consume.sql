@@ -0,0 +1,7 @@
+DELETE FROM pending_destructive_operations
+WHERE token = $1
+    AND operation = $2
+    AND guild_id = $3
+    AND resource_id IS NOT DISTINCT FROM $4
+    AND expires_at > NOW()
+RETURNING token, operation, guild_id, resource_id, requested_by, created_at, expires_at;
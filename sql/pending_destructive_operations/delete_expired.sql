@@ -0,0 +1,2 @@
+DELETE FROM pending_destructive_operations
+WHERE expires_at <= NOW();
@@ -0,0 +1,3 @@
+SELECT token, operation, guild_id, resource_id, requested_by, created_at, expires_at
+FROM pending_destructive_operations
+WHERE token = $1 AND expires_at > NOW();
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS pending_destructive_operations
+(
+    token        uuid        NOT NULL DEFAULT gen_random_uuid(),
+    operation    varchar(32) NOT NULL,
+    guild_id     int8        NOT NULL,
+    resource_id  text        DEFAULT NULL,
+    requested_by int8        NOT NULL,
+    created_at   timestamptz NOT NULL DEFAULT NOW(),
+    expires_at   timestamptz NOT NULL,
+    PRIMARY KEY (token)
+);
+
+CREATE INDEX IF NOT EXISTS pending_destructive_operations_guild_id ON pending_destructive_operations (guild_id);
+CREATE INDEX IF NOT EXISTS pending_destructive_operations_expires_at ON pending_destructive_operations (expires_at);
@@ -0,0 +1,3 @@
+INSERT INTO pending_destructive_operations (operation, guild_id, resource_id, requested_by, expires_at)
+VALUES ($1, $2, $3, $4, NOW() + $5::interval)
+RETURNING token, created_at, expires_at;
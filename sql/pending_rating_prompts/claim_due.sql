@@ -0,0 +1,11 @@
+UPDATE pending_rating_prompts
+SET claimed_until = NOW() + $2::interval
+WHERE (guild_id, ticket_id) IN (
+    SELECT guild_id, ticket_id
+    FROM pending_rating_prompts
+    WHERE due_at <= NOW() AND (claimed_until IS NULL OR claimed_until <= NOW())
+    ORDER BY due_at ASC
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING guild_id, ticket_id, user_id, due_at, prompts_sent, reminder_count;
@@ -0,0 +1,2 @@
+DELETE FROM pending_rating_prompts
+WHERE guild_id = $1 AND ticket_id = $2;
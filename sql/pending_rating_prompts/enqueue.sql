@@ -0,0 +1,6 @@
+INSERT INTO pending_rating_prompts (guild_id, ticket_id, user_id, due_at, delay_seconds, reminder_count)
+SELECT $1, $2, $3, NOW() + make_interval(secs => delay_seconds), delay_seconds, reminder_count
+FROM rating_prompt_schedule
+WHERE guild_id = $1
+ON CONFLICT (guild_id, ticket_id) DO NOTHING
+RETURNING true;
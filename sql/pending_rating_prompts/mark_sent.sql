@@ -0,0 +1,10 @@
+WITH finished AS (
+    DELETE FROM pending_rating_prompts
+    WHERE guild_id = $1 AND ticket_id = $2 AND prompts_sent >= reminder_count
+)
+UPDATE pending_rating_prompts
+SET prompts_sent = prompts_sent + 1,
+    due_at = NOW() + make_interval(secs => delay_seconds),
+    claimed_until = NULL
+WHERE guild_id = $1 AND ticket_id = $2 AND prompts_sent < reminder_count
+RETURNING true;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS pending_rating_prompts
+(
+    guild_id       int8        NOT NULL,
+    ticket_id      int4        NOT NULL,
+    user_id        int8        NOT NULL,
+    due_at         timestamptz NOT NULL,
+    delay_seconds  int4        NOT NULL,
+    reminder_count int2        NOT NULL,
+    prompts_sent   int2        NOT NULL DEFAULT 0,
+    claimed_until  timestamptz DEFAULT NULL,
+    PRIMARY KEY (guild_id, ticket_id),
+    FOREIGN KEY (guild_id, ticket_id) REFERENCES tickets (guild_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS pending_rating_prompts_due_at ON pending_rating_prompts (due_at);
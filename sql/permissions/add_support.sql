@@ -0,0 +1,3 @@
+INSERT INTO permissions ("guild_id", "user_id", "support", "admin")
+VALUES ($1, $2, true, false)
+ON CONFLICT ("guild_id", "user_id") DO UPDATE SET "admin" = false, "support" = true;
@@ -0,0 +1,3 @@
+SELECT "user_id"
+FROM permissions
+WHERE "guild_id" = $1 AND "admin" = false AND "support" = true;
@@ -0,0 +1,3 @@
+SELECT "support", "admin"
+FROM permissions
+WHERE "guild_id" = $1 AND "user_id" = $2;
@@ -0,0 +1,3 @@
+UPDATE permissions
+SET "admin" = false
+WHERE "guild_id" = $1 AND "user_id" = $2;
@@ -0,0 +1,8 @@
+WITH bounds AS (
+    SELECT date_trunc('month', $1::timestamptz AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'                        AS month_start,
+           (date_trunc('month', $1::timestamptz AT TIME ZONE 'UTC') + INTERVAL '1 month') AT TIME ZONE 'UTC' AS month_end
+)
+SELECT COUNT(*) FILTER (WHERE event = 'started' AND created_at < bounds.month_start)
+           - COUNT(*) FILTER (WHERE event IN ('cancelled', 'expired') AND created_at < bounds.month_start) AS active_at_start,
+       COUNT(*) FILTER (WHERE event IN ('cancelled', 'expired') AND created_at >= bounds.month_start AND created_at < bounds.month_end) AS churned
+FROM premium_events, bounds;
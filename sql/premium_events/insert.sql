@@ -0,0 +1,2 @@
+INSERT INTO premium_events (guild_id, user_id, event, source, sku_id)
+VALUES ($1, $2, $3, $4, $5);
@@ -0,0 +1,9 @@
+SELECT date_trunc('month', created_at AT TIME ZONE 'UTC') AS month,
+       COUNT(*) FILTER (WHERE event = 'started')   AS started,
+       COUNT(*) FILTER (WHERE event = 'renewed')   AS renewed,
+       COUNT(*) FILTER (WHERE event = 'cancelled') AS cancelled,
+       COUNT(*) FILTER (WHERE event = 'expired')   AS expired
+FROM premium_events
+WHERE created_at >= $1 AND created_at < $2
+GROUP BY month
+ORDER BY month ASC;
@@ -0,0 +1,49 @@
+CREATE TYPE premium_event_type AS ENUM ('started', 'renewed', 'cancelled', 'expired');
+
+CREATE TABLE IF NOT EXISTS premium_events
+(
+    id         BIGSERIAL,
+    guild_id   int8 DEFAULT NULL,
+    user_id    int8 DEFAULT NULL,
+    event      premium_event_type NOT NULL,
+    source     premium_source     NOT NULL,
+    sku_id     UUID               NOT NULL,
+    created_at timestamptz        NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id),
+    FOREIGN KEY (sku_id) REFERENCES skus (id)
+);
+
+CREATE INDEX IF NOT EXISTS premium_events_created_at ON premium_events (created_at);
+
+-- Record entitlement lifecycle changes automatically, so that every code path that modifies entitlements is tracked
+CREATE OR REPLACE FUNCTION record_premium_event()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO premium_events (guild_id, user_id, event, source, sku_id)
+        VALUES (NEW.guild_id, NEW.user_id, 'started', NEW.source, NEW.sku_id);
+        RETURN NEW;
+    ELSIF TG_OP = 'UPDATE' THEN
+        IF NEW.expires_at IS DISTINCT FROM OLD.expires_at AND (OLD.expires_at IS NULL OR NEW.expires_at IS NULL OR NEW.expires_at > OLD.expires_at) THEN
+            INSERT INTO premium_events (guild_id, user_id, event, source, sku_id)
+            VALUES (NEW.guild_id, NEW.user_id, 'renewed', NEW.source, NEW.sku_id);
+        END IF;
+        RETURN NEW;
+    ELSE
+        INSERT INTO premium_events (guild_id, user_id, event, source, sku_id)
+        VALUES (
+            OLD.guild_id,
+            OLD.user_id,
+            CASE WHEN OLD.expires_at IS NOT NULL AND OLD.expires_at <= NOW() THEN 'expired' ELSE 'cancelled' END::premium_event_type,
+            OLD.source,
+            OLD.sku_id
+        );
+        RETURN OLD;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER entitlements_premium_events
+AFTER INSERT OR UPDATE OF expires_at OR DELETE ON entitlements
+FOR EACH ROW
+EXECUTE FUNCTION record_premium_event();
@@ -0,0 +1,2 @@
+DELETE FROM premium_prices
+WHERE sku_id = $1 AND currency = $2;
@@ -0,0 +1,4 @@
+SELECT sku_id, currency, amount_minor, active
+FROM premium_prices
+WHERE sku_id = $1 AND active = true
+ORDER BY currency;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS premium_prices
+(
+    sku_id       UUID    NOT NULL,
+    currency     CHAR(3) NOT NULL,
+    amount_minor int8    NOT NULL,
+    active       bool    NOT NULL DEFAULT true,
+    PRIMARY KEY (sku_id, currency),
+    FOREIGN KEY (sku_id) REFERENCES skus (id) ON DELETE CASCADE,
+    CHECK (currency ~ '^[A-Z]{3}$'),
+    CHECK (amount_minor >= 0)
+);
@@ -0,0 +1,5 @@
+INSERT INTO premium_prices (sku_id, currency, amount_minor, active)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (sku_id, currency) DO UPDATE
+    SET amount_minor = EXCLUDED.amount_minor,
+        active       = EXCLUDED.active;
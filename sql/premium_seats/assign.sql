@@ -0,0 +1,3 @@
+INSERT INTO premium_seat_assignments (entitlement_id, guild_id)
+VALUES ($1, $2)
+ON CONFLICT (entitlement_id, guild_id) DO NOTHING;
@@ -0,0 +1,4 @@
+SELECT guild_id
+FROM premium_seat_assignments
+WHERE entitlement_id = $1
+ORDER BY assigned_at, guild_id;
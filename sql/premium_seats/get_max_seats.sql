@@ -0,0 +1,3 @@
+SELECT max_seats
+FROM premium_seats
+WHERE entitlement_id = $1;
@@ -0,0 +1,6 @@
+SELECT max_seats,
+       (SELECT COUNT(*) FROM premium_seat_assignments WHERE entitlement_id = $1),
+       EXISTS(SELECT 1 FROM premium_seat_assignments WHERE entitlement_id = $1 AND guild_id = $2)
+FROM premium_seats
+WHERE entitlement_id = $1
+FOR UPDATE;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS premium_seats
+(
+    entitlement_id UUID NOT NULL,
+    max_seats      int4 NOT NULL,
+    PRIMARY KEY (entitlement_id),
+    FOREIGN KEY (entitlement_id) REFERENCES entitlements (id) ON DELETE CASCADE,
+    CHECK (max_seats >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS premium_seat_assignments
+(
+    entitlement_id UUID        NOT NULL,
+    guild_id       int8        NOT NULL,
+    assigned_at    timestamptz NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (entitlement_id, guild_id),
+    FOREIGN KEY (entitlement_id) REFERENCES premium_seats (entitlement_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS premium_seat_assignments_guild_id ON premium_seat_assignments (guild_id);
@@ -0,0 +1,3 @@
+INSERT INTO premium_seats (entitlement_id, max_seats)
+VALUES ($1, $2)
+ON CONFLICT (entitlement_id) DO UPDATE SET max_seats = EXCLUDED.max_seats;
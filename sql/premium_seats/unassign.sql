@@ -0,0 +1,2 @@
+DELETE FROM premium_seat_assignments
+WHERE entitlement_id = $1 AND guild_id = $2;
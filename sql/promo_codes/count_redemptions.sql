@@ -0,0 +1,3 @@
+SELECT COUNT(*)
+FROM promo_redemptions
+WHERE code = $1;
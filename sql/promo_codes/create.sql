@@ -0,0 +1,2 @@
+INSERT INTO promo_codes (code, sku_id, percent_off, free_days, max_redemptions, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6);
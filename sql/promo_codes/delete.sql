@@ -0,0 +1,2 @@
+DELETE FROM promo_codes
+WHERE code = $1;
@@ -0,0 +1,3 @@
+SELECT code, sku_id, percent_off, free_days, max_redemptions, expires_at, created_at
+FROM promo_codes
+WHERE code = $1;
@@ -0,0 +1,3 @@
+INSERT INTO promo_redemptions (code, user_id, guild_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (code, guild_id) DO NOTHING;
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS promo_codes
+(
+    code            VARCHAR(32) NOT NULL,
+    sku_id          UUID        NOT NULL,
+    percent_off     int2 DEFAULT NULL,
+    free_days       int4 DEFAULT NULL,
+    max_redemptions int4 DEFAULT NULL,
+    expires_at      timestamptz DEFAULT NULL,
+    created_at      timestamptz NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (code),
+    FOREIGN KEY (sku_id) REFERENCES skus (id),
+    CHECK ((percent_off IS NULL) <> (free_days IS NULL)),
+    CHECK (percent_off IS NULL OR (percent_off > 0 AND percent_off <= 100)),
+    CHECK (free_days IS NULL OR free_days > 0),
+    CHECK (max_redemptions IS NULL OR max_redemptions > 0)
+);
+
+CREATE TABLE IF NOT EXISTS promo_redemptions
+(
+    code        VARCHAR(32) NOT NULL,
+    user_id     int8        NOT NULL,
+    guild_id    int8        NOT NULL,
+    redeemed_at timestamptz NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (code, guild_id),
+    FOREIGN KEY (code) REFERENCES promo_codes (code) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS promo_redemptions_user_id ON promo_redemptions (user_id);
@@ -0,0 +1,2 @@
+DELETE FROM rating_prompt_schedule
+WHERE guild_id = $1;
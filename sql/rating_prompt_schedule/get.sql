@@ -0,0 +1,3 @@
+SELECT delay_seconds, reminder_count
+FROM rating_prompt_schedule
+WHERE guild_id = $1;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS rating_prompt_schedule
+(
+    guild_id       int8 NOT NULL,
+    delay_seconds  int4 NOT NULL,
+    reminder_count int2 NOT NULL DEFAULT 1,
+    PRIMARY KEY (guild_id),
+    CHECK (delay_seconds >= 0),
+    CHECK (reminder_count >= 0)
+);
@@ -0,0 +1,5 @@
+INSERT INTO rating_prompt_schedule (guild_id, delay_seconds, reminder_count)
+VALUES ($1, $2, $3)
+ON CONFLICT (guild_id) DO UPDATE SET
+    delay_seconds = EXCLUDED.delay_seconds,
+    reminder_count = EXCLUDED.reminder_count;
@@ -0,0 +1,2 @@
+DELETE FROM ticket_last_message
+WHERE "guild_id" = $1 AND "ticket_id" = $2;
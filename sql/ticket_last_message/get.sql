@@ -0,0 +1,3 @@
+SELECT "last_message_id", "last_message_time", "user_id", "user_is_staff"
+FROM ticket_last_message
+WHERE "guild_id" = $1 AND "ticket_id" = $2;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS ticket_last_message
+(
+    "guild_id"          int8 NOT NULL,
+    "ticket_id"         int4 NOT NULL,
+    "last_message_id"   int8,
+    "last_message_time" timestamptz,
+    "user_id"           int8,
+    "user_is_staff"     bool NOT NULL,
+    FOREIGN KEY ("guild_id", "ticket_id") REFERENCES tickets ("guild_id", "id"),
+    PRIMARY KEY ("guild_id", "ticket_id")
+);
@@ -0,0 +1,3 @@
+INSERT INTO ticket_members ("guild_id", "ticket_id", "user_id")
+VALUES ($1, $2, $3)
+ON CONFLICT ("guild_id", "ticket_id", "user_id") DO NOTHING;
@@ -0,0 +1,2 @@
+DELETE FROM ticket_members
+WHERE "guild_id" = $1 AND "ticket_id" = $2 AND "user_id" = $3;
@@ -0,0 +1,3 @@
+SELECT "user_id"
+FROM ticket_members
+WHERE "guild_id" = $1 AND "ticket_id" = $2;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS ticket_members
+(
+    "guild_id"  int8 NOT NULL,
+    "ticket_id" int4 NOT NULL,
+    "user_id"   int8 NOT NULL,
+    FOREIGN KEY ("guild_id", "ticket_id") REFERENCES tickets ("guild_id", "id"),
+    PRIMARY KEY ("guild_id", "ticket_id", "user_id")
+);
+
+CREATE INDEX IF NOT EXISTS ticket_members_guild_ticket ON ticket_members ("guild_id", "ticket_id");
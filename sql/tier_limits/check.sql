@@ -0,0 +1,13 @@
+WITH tiers AS (
+    SELECT 'free' AS tier
+
+    UNION
+
+    SELECT guild_tiers.tier::text
+    FROM (
+        /* guild_tiers */
+    ) AS guild_tiers
+)
+SELECT CASE WHEN bool_and(tier_limits.max_count IS NOT NULL) THEN MAX(tier_limits.max_count) END
+FROM tiers
+LEFT JOIN tier_limits ON tier_limits.tier = tiers.tier AND tier_limits.resource = $5;
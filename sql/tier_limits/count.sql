@@ -0,0 +1,6 @@
+SELECT
+    CASE $2::text
+        WHEN 'panels' THEN (SELECT COUNT(*) FROM panels WHERE guild_id = $1)
+        WHEN 'forms' THEN (SELECT COUNT(*) FROM forms WHERE guild_id = $1 AND deleted_at IS NULL)
+        WHEN 'teams' THEN (SELECT COUNT(*) FROM support_team WHERE guild_id = $1)
+    END;
@@ -0,0 +1,2 @@
+DELETE FROM tier_limits
+WHERE tier = $1 AND resource = $2;
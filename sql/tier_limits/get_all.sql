@@ -0,0 +1,3 @@
+SELECT tier, resource, max_count
+FROM tier_limits
+ORDER BY tier, resource;
@@ -0,0 +1,2 @@
+-- Serialises limit checks and creations of the resource for the guild until the end of the transaction
+SELECT pg_advisory_xact_lock(hashtextextended('tier_limits:' || $2::text || ':' || $1::text, 0));
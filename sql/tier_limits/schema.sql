@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS tier_limits
+(
+    tier      VARCHAR(16) NOT NULL,
+    resource  VARCHAR(32) NOT NULL,
+    max_count int4        NOT NULL,
+    PRIMARY KEY (tier, resource),
+    CHECK (tier IN ('free', 'premium', 'whitelabel')),
+    CHECK (max_count >= 0)
+);
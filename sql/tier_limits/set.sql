@@ -0,0 +1,4 @@
+INSERT INTO tier_limits (tier, resource, max_count)
+VALUES ($1, $2, $3)
+ON CONFLICT (tier, resource) DO UPDATE
+    SET max_count = EXCLUDED.max_count;
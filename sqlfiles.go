@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// sqlFS holds the statements of tables following the sql/<table>/ convention: the table's DDL is stored in schema.sql,
+// and each query in its own <name>.sql file, loaded into package variables with loadSqlTable.
+//
+//go:embed sql
+var sqlFS embed.FS
+
+const sqlSchemaFile = "schema.sql"
+
+// sqlTableFiles are the statements loaded from a table's directory. Files that cannot be read are recorded rather than
+// causing a panic during package initialisation, and are reported by ValidateSqlFiles.
+type sqlTableFiles struct {
+	table   string
+	loaded  map[string]bool
+	queries map[string]string // file name -> statement
+	errs    []error
+}
+
+// sqlTables contains every table registered with loadSqlTable. It is only written during package initialisation.
+var sqlTables []*sqlTableFiles
+
+func loadSqlTable(table string) *sqlTableFiles {
+	t := &sqlTableFiles{
+		table:   table,
+		loaded:  make(map[string]bool),
+		queries: make(map[string]string),
+	}
+
+	sqlTables = append(sqlTables, t)
+	return t
+}
+
+func (t *sqlTableFiles) schema() string {
+	return t.load(sqlSchemaFile)
+}
+
+func (t *sqlTableFiles) query(name string) string {
+	fileName := name + ".sql"
+
+	statement := t.load(fileName)
+	if statement != "" {
+		t.queries[fileName] = statement
+	}
+
+	return statement
+}
+
+func (t *sqlTableFiles) load(fileName string) string {
+	t.loaded[fileName] = true
+
+	bytes, err := sqlFS.ReadFile(path.Join("sql", t.table, fileName))
+	if err != nil {
+		t.errs = append(t.errs, fmt.Errorf("failed to load sql/%s/%s: %w", t.table, fileName, err))
+		return ""
+	}
+
+	if strings.TrimSpace(string(bytes)) == "" {
+		t.errs = append(t.errs, fmt.Errorf("sql/%s/%s is empty", t.table, fileName))
+		return ""
+	}
+
+	return string(bytes)
+}
+
+// ValidateSqlFiles checks that every file referenced by a table registered with loadSqlTable exists and is not empty,
+// and that every .sql file in the tables' directories is referenced, so that stale files are not left behind.
+func ValidateSqlFiles() error {
+	var errs []error
+	for _, t := range sqlTables {
+		errs = append(errs, t.errs...)
+
+		entries, err := sqlFS.ReadDir(path.Join("sql", t.table))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read sql/%s: %w", t.table, err))
+			continue
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() && path.Ext(entry.Name()) == ".sql" && !t.loaded[entry.Name()] {
+				errs = append(errs, fmt.Errorf("sql/%s/%s is not referenced", t.table, entry.Name()))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateSql runs ValidateSqlFiles, and then prepares every query loaded with loadSqlTable for the tables returned by
+// Tables, so that syntax errors and references to missing tables or columns are reported at startup rather than when
+// the query is first used. Queries of other tables, e.g. the global tables of a regional database, are not prepared,
+// as they do not exist in the database. The tables must have been created first. Schema files are not prepared, as
+// they contain multiple statements; they are instead validated by CreateTables.
+func (d *Database) ValidateSql(ctx context.Context) error {
+	return d.validateSql(ctx, d.pool)
+}
+
+func (d *Database) validateSql(ctx context.Context, pool *pgxpool.Pool) error {
+	if err := ValidateSqlFiles(); err != nil {
+		return err
+	}
+
+	tableNames, _ := d.schemaObjectNames()
+	owned := make(map[string]bool, len(tableNames))
+	for _, tableName := range tableNames {
+		owned[tableName] = true
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	var errs []error
+	for _, t := range sqlTables {
+		if !owned[t.table] {
+			continue
+		}
+
+		for fileName, statement := range t.queries {
+			// An unnamed statement is replaced by the next, so nothing needs to be deallocated
+			if _, err := conn.Conn().Prepare(ctx, "", statement); err != nil {
+				errs = append(errs, fmt.Errorf("failed to prepare sql/%s/%s: %w", t.table, fileName, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (d *Database) mustValidateSql(ctx context.Context, pool *pgxpool.Pool) {
+	if err := d.validateSql(ctx, pool); err != nil {
+		panic(err)
+	}
+}
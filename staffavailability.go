@@ -0,0 +1,181 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// StaffAvailability is the hours a staff member is available on a day of the week, in the guild's timezone.
+type StaffAvailability struct {
+	GuildId              uint64    `json:"guild_id,string"`
+	UserId               uint64    `json:"user_id,string"`
+	DayOfWeek            int       `json:"day_of_week"` // 0 = Sunday, 1 = Monday, ..., 6 = Saturday
+	StartTime            time.Time `json:"start_time"`
+	EndTime              time.Time `json:"end_time"`
+	MaxConcurrentTickets *int      `json:"max_concurrent_tickets"` // Null if there is no limit
+}
+
+type StaffAvailabilityTable struct {
+	*pgxpool.Pool
+}
+
+func newStaffAvailabilityTable(db *pgxpool.Pool) *StaffAvailabilityTable {
+	return &StaffAvailabilityTable{
+		db,
+	}
+}
+
+func (s StaffAvailabilityTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS staff_availability(
+	"guild_id" int8 NOT NULL,
+	"user_id" int8 NOT NULL,
+	"day_of_week" int2 NOT NULL,
+	"start_time" time NOT NULL,
+	"end_time" time NOT NULL,
+	"max_concurrent_tickets" int4 DEFAULT NULL,
+	CHECK("day_of_week" >= 0 AND "day_of_week" <= 6),
+	CHECK("start_time" < "end_time"),
+	CHECK("max_concurrent_tickets" IS NULL OR "max_concurrent_tickets" >= 0),
+	PRIMARY KEY("guild_id", "user_id", "day_of_week")
+);
+`
+}
+
+func (s *StaffAvailabilityTable) GetByUser(ctx context.Context, guildId, userId uint64) ([]StaffAvailability, error) {
+	query := `
+SELECT "guild_id", "user_id", "day_of_week", "start_time", "end_time", "max_concurrent_tickets"
+FROM staff_availability
+WHERE "guild_id" = $1 AND "user_id" = $2
+ORDER BY "day_of_week" ASC;`
+
+	rows, err := s.Query(ctx, query, guildId, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var availability []StaffAvailability
+	for rows.Next() {
+		var day StaffAvailability
+		if err := rows.Scan(
+			&day.GuildId,
+			&day.UserId,
+			&day.DayOfWeek,
+			&day.StartTime,
+			&day.EndTime,
+			&day.MaxConcurrentTickets,
+		); err != nil {
+			return nil, err
+		}
+
+		availability = append(availability, day)
+	}
+
+	return availability, nil
+}
+
+func (s *StaffAvailabilityTable) Set(ctx context.Context, availability StaffAvailability) (err error) {
+	query := `
+INSERT INTO staff_availability("guild_id", "user_id", "day_of_week", "start_time", "end_time", "max_concurrent_tickets")
+VALUES($1, $2, $3, $4, $5, $6)
+ON CONFLICT("guild_id", "user_id", "day_of_week") DO UPDATE
+SET "start_time" = EXCLUDED."start_time", "end_time" = EXCLUDED."end_time", "max_concurrent_tickets" = EXCLUDED."max_concurrent_tickets";`
+
+	_, err = s.Exec(ctx, query,
+		availability.GuildId,
+		availability.UserId,
+		availability.DayOfWeek,
+		availability.StartTime,
+		availability.EndTime,
+		availability.MaxConcurrentTickets,
+	)
+	return
+}
+
+func (s *StaffAvailabilityTable) Delete(ctx context.Context, guildId, userId uint64, dayOfWeek int) (err error) {
+	query := `DELETE FROM staff_availability WHERE "guild_id" = $1 AND "user_id" = $2 AND "day_of_week" = $3;`
+	_, err = s.Exec(ctx, query, guildId, userId, dayOfWeek)
+	return
+}
+
+func (s *StaffAvailabilityTable) DeleteAll(ctx context.Context, guildId, userId uint64) (err error) {
+	query := `DELETE FROM staff_availability WHERE "guild_id" = $1 AND "user_id" = $2;`
+	_, err = s.Exec(ctx, query, guildId, userId)
+	return
+}
+
+// GetAvailableStaff returns the members of the team that are available at the given time and have fewer open claimed
+// tickets than their limit, ordered by the number of open tickets they have claimed, least first. Snoozed tickets do
+// not count towards the limit. Members without any availability configured are always available, with no limit.
+func (s *StaffAvailabilityTable) GetAvailableStaff(ctx context.Context, guildId uint64, teamId int, at time.Time) ([]uint64, error) {
+	query := `
+WITH local_time AS (
+	SELECT $3::timestamptz AT TIME ZONE COALESCE(pg_timezone_names.name, 'UTC') AS "at"
+	FROM (SELECT 1) AS dummy
+	LEFT JOIN guild_locale_settings
+		ON guild_locale_settings.guild_id = $1
+	LEFT JOIN pg_timezone_names
+		ON pg_timezone_names.name = guild_locale_settings.timezone
+)
+SELECT support_team_members.user_id
+FROM support_team_members
+INNER JOIN support_team
+	ON support_team.id = support_team_members.team_id
+CROSS JOIN local_time
+LEFT JOIN staff_availability
+	ON staff_availability.guild_id = support_team.guild_id
+	AND staff_availability.user_id = support_team_members.user_id
+	AND staff_availability.day_of_week = EXTRACT(DOW FROM local_time.at)
+CROSS JOIN LATERAL (
+	SELECT COUNT(*) AS "count"
+	FROM ticket_claims
+	INNER JOIN tickets
+		ON tickets.guild_id = ticket_claims.guild_id AND tickets.id = ticket_claims.ticket_id
+	WHERE ticket_claims.guild_id = support_team.guild_id
+		AND ticket_claims.user_id = support_team_members.user_id
+		AND tickets.open
+		AND NOT EXISTS(
+			SELECT 1
+			FROM ticket_snoozes
+			WHERE ticket_snoozes.guild_id = tickets.guild_id AND ticket_snoozes.ticket_id = tickets.id AND ticket_snoozes.until > NOW()
+		)
+) AS open_claims
+WHERE support_team_members.team_id = $2
+	AND support_team.guild_id = $1
+	AND (
+		(
+			staff_availability.user_id IS NULL
+			AND NOT EXISTS(
+				SELECT 1 FROM staff_availability AS configured
+				WHERE configured.guild_id = support_team.guild_id AND configured.user_id = support_team_members.user_id
+			)
+		)
+		OR (
+			local_time.at::time >= staff_availability.start_time
+			AND local_time.at::time < staff_availability.end_time
+			AND (staff_availability.max_concurrent_tickets IS NULL OR open_claims.count < staff_availability.max_concurrent_tickets)
+		)
+	)
+ORDER BY open_claims.count ASC, support_team_members.user_id ASC;`
+
+	rows, err := s.Query(ctx, query, guildId, teamId, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIds []uint64
+	for rows.Next() {
+		var userId uint64
+		if err := rows.Scan(&userId); err != nil {
+			return nil, err
+		}
+
+		userIds = append(userIds, userId)
+	}
+
+	return userIds, nil
+}
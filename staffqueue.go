@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// staffQueueSlaWarningFraction is the fraction of the SLA that must have elapsed without a staff response before a
+// ticket is considered to be approaching the SLA.
+const staffQueueSlaWarningFraction = 0.75
+
+// StaffQueuePriority orders the entries of a staff member's queue. Lower values are more urgent.
+type StaffQueuePriority int
+
+const (
+	StaffQueuePrioritySlaBreached StaffQueuePriority = iota + 1
+	StaffQueuePriorityApproachingSla
+	StaffQueuePriorityAwaitingResponse // Claimed by the staff member, and the last message is not from staff
+	StaffQueuePriorityUnclaimed
+	StaffQueuePriorityClaimed // Claimed by the staff member, and the last message is from staff
+)
+
+type StaffQueueEntry struct {
+	TicketId        int        `json:"ticket_id"`
+	ChannelId       *uint64    `json:"channel_id,string"`
+	PanelId         *int       `json:"panel_id"`
+	OpenerId        uint64     `json:"opener_id,string"`
+	OpenTime        time.Time  `json:"open_time"`
+	ClaimedBy       *uint64    `json:"claimed_by,string"`
+	LastMessageTime *time.Time `json:"last_message_time"`
+	// When the ticket started waiting for a staff response. Nil if the last message is from staff.
+	AwaitingResponseSince *time.Time `json:"awaiting_response_since"`
+	// When the ticket breaches the guild's SLA. Nil if the ticket is not awaiting a response, or the guild has no
+	// no_response_for escalation rule.
+	SlaDeadline *time.Time         `json:"sla_deadline"`
+	Priority    StaffQueuePriority `json:"priority"`
+}
+
+// GetStaffQueue returns the open tickets the staff member should look at, most urgent first: tickets they have
+// claimed, unclaimed tickets opened from panels assigned to one of their teams, and tickets in their teams' panels that
+// are approaching the SLA regardless of who has claimed them. The SLA is the shortest enabled no_response_for
+// escalation rule of the guild. Team membership is resolved from support_team_members and the permissions table (for
+// panels using the default team) only, as team roles cannot be resolved without Discord. Snoozed tickets are excluded.
+func (d *Database) GetStaffQueue(ctx context.Context, guildId, userId uint64) ([]StaffQueueEntry, error) {
+	query := `
+WITH sla AS (
+	SELECT MIN(escalation_rules.no_response_seconds) AS "seconds"
+	FROM escalation_rules
+	WHERE escalation_rules.guild_id = $1 AND escalation_rules.enabled AND escalation_rules.condition = 'no_response_for'
+), in_default_team AS (
+	SELECT EXISTS(
+		SELECT 1
+		FROM permissions
+		WHERE permissions.guild_id = $1 AND permissions.user_id = $2 AND (permissions.support OR permissions.admin)
+	) AS "value"
+), queue AS (
+	SELECT
+		tickets.id,
+		tickets.channel_id,
+		tickets.panel_id,
+		tickets.user_id AS opener_id,
+		tickets.open_time,
+		ticket_claims.user_id AS claimed_by,
+		ticket_last_message.last_message_time,
+		CASE
+			WHEN ticket_last_message.user_is_staff THEN NULL
+			ELSE COALESCE(ticket_last_message.last_message_time, tickets.open_time)
+		END AS awaiting_since
+	FROM tickets
+	CROSS JOIN in_default_team
+	LEFT JOIN ticket_claims
+		ON ticket_claims.guild_id = tickets.guild_id AND ticket_claims.ticket_id = tickets.id
+	LEFT JOIN ticket_last_message
+		ON ticket_last_message.guild_id = tickets.guild_id AND ticket_last_message.ticket_id = tickets.id
+	LEFT JOIN panels
+		ON panels.panel_id = tickets.panel_id
+	WHERE tickets.guild_id = $1
+		AND tickets.open
+		AND NOT EXISTS(
+			SELECT 1
+			FROM ticket_snoozes
+			WHERE ticket_snoozes.guild_id = tickets.guild_id AND ticket_snoozes.ticket_id = tickets.id AND ticket_snoozes.until > NOW()
+		)
+		AND (
+			ticket_claims.user_id = $2
+			OR ((panels.panel_id IS NULL OR panels.default_team) AND in_default_team.value)
+			OR EXISTS(
+				SELECT 1
+				FROM panel_teams
+				INNER JOIN support_team_members
+					ON support_team_members.team_id = panel_teams.team_id
+				WHERE panel_teams.panel_id = tickets.panel_id AND support_team_members.user_id = $2
+			)
+		)
+), prioritised AS (
+	SELECT
+		queue.*,
+		queue.awaiting_since + sla.seconds * INTERVAL '1 second' AS sla_deadline,
+		CASE
+			WHEN queue.awaiting_since + sla.seconds * INTERVAL '1 second' <= NOW() THEN 1
+			WHEN queue.awaiting_since + sla.seconds * $3::float8 * INTERVAL '1 second' <= NOW() THEN 2
+			WHEN queue.claimed_by = $2 AND queue.awaiting_since IS NOT NULL THEN 3
+			WHEN queue.claimed_by IS NULL THEN 4
+			WHEN queue.claimed_by = $2 THEN 5
+		END AS priority
+	FROM queue
+	CROSS JOIN sla
+)
+SELECT
+	prioritised.id,
+	prioritised.channel_id,
+	prioritised.panel_id,
+	prioritised.opener_id,
+	prioritised.open_time,
+	prioritised.claimed_by,
+	prioritised.last_message_time,
+	prioritised.awaiting_since,
+	prioritised.sla_deadline,
+	prioritised.priority
+FROM prioritised
+WHERE prioritised.priority IS NOT NULL
+ORDER BY prioritised.priority ASC, prioritised.awaiting_since ASC NULLS LAST, prioritised.open_time ASC;`
+
+	rows, err := d.pool.Query(ctx, query, guildId, userId, staffQueueSlaWarningFraction)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var queue []StaffQueueEntry
+	for rows.Next() {
+		var entry StaffQueueEntry
+		if err := rows.Scan(
+			&entry.TicketId,
+			&entry.ChannelId,
+			&entry.PanelId,
+			&entry.OpenerId,
+			&entry.OpenTime,
+			&entry.ClaimedBy,
+			&entry.LastMessageTime,
+			&entry.AwaitingResponseSince,
+			&entry.SlaDeadline,
+			&entry.Priority,
+		); err != nil {
+			return nil, err
+		}
+
+		queue = append(queue, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return queue, nil
+}
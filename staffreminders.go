@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type StaffReminder struct {
+	Id        int64     `json:"id"`
+	GuildId   uint64    `json:"guild_id,string"`
+	TicketId  int       `json:"ticket_id"`
+	UserId    uint64    `json:"user_id,string"`
+	RemindAt  time.Time `json:"remind_at"`
+	Note      *string   `json:"note"`
+	Notified  bool      `json:"notified"`
+	Completed bool      `json:"completed"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type StaffRemindersTable struct {
+	*pgxpool.Pool
+}
+
+func newStaffRemindersTable(db *pgxpool.Pool) *StaffRemindersTable {
+	return &StaffRemindersTable{
+		db,
+	}
+}
+
+func (s StaffRemindersTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS staff_reminders(
+	"id" BIGSERIAL NOT NULL,
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"user_id" int8 NOT NULL,
+	"remind_at" timestamptz NOT NULL,
+	"note" varchar(255) DEFAULT NULL,
+	"notified" bool NOT NULL DEFAULT false,
+	"completed" bool NOT NULL DEFAULT false,
+	"created_at" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS staff_reminders_user_id_remind_at ON staff_reminders("user_id", "remind_at") WHERE NOT "completed";
+CREATE INDEX IF NOT EXISTS staff_reminders_remind_at ON staff_reminders("remind_at") WHERE NOT "completed" AND NOT "notified";
+`
+}
+
+func (s *StaffRemindersTable) Create(ctx context.Context, guildId uint64, ticketId int, userId uint64, remindAt time.Time, note *string) (id int64, err error) {
+	query := `
+INSERT INTO staff_reminders("guild_id", "ticket_id", "user_id", "remind_at", "note")
+VALUES($1, $2, $3, $4, $5)
+RETURNING "id";`
+
+	err = s.QueryRow(ctx, query, guildId, ticketId, userId, remindAt, note).Scan(&id)
+	return
+}
+
+// ListDue returns up to limit reminders whose time has passed and marks them as notified, so that each reminder is
+// only delivered once. Rows locked by another worker are skipped.
+func (s *StaffRemindersTable) ListDue(ctx context.Context, limit int) ([]StaffReminder, error) {
+	query := `
+UPDATE staff_reminders
+SET "notified" = true
+WHERE "id" IN (
+	SELECT "id"
+	FROM staff_reminders
+	WHERE "remind_at" <= NOW() AND NOT "completed" AND NOT "notified"
+	ORDER BY "remind_at" ASC
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING "id", "guild_id", "ticket_id", "user_id", "remind_at", "note", "notified", "completed", "created_at";`
+
+	rows, err := s.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanStaffReminders(rows)
+}
+
+// GetUpcomingForUser returns the user's reminders that have not been completed, soonest first.
+func (s *StaffRemindersTable) GetUpcomingForUser(ctx context.Context, userId uint64, limit int) ([]StaffReminder, error) {
+	query := `
+SELECT "id", "guild_id", "ticket_id", "user_id", "remind_at", "note", "notified", "completed", "created_at"
+FROM staff_reminders
+WHERE "user_id" = $1 AND NOT "completed"
+ORDER BY "remind_at" ASC
+LIMIT $2;`
+
+	rows, err := s.Query(ctx, query, userId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanStaffReminders(rows)
+}
+
+// Snooze moves the reminder to a later time, allowing it to be delivered again.
+func (s *StaffRemindersTable) Snooze(ctx context.Context, userId uint64, id int64, until time.Time) (err error) {
+	query := `
+UPDATE staff_reminders
+SET "remind_at" = $3, "notified" = false
+WHERE "user_id" = $1 AND "id" = $2 AND NOT "completed";`
+
+	_, err = s.Exec(ctx, query, userId, id, until)
+	return
+}
+
+func (s *StaffRemindersTable) Complete(ctx context.Context, userId uint64, id int64) (err error) {
+	query := `UPDATE staff_reminders SET "completed" = true WHERE "user_id" = $1 AND "id" = $2;`
+	_, err = s.Exec(ctx, query, userId, id)
+	return
+}
+
+func (s *StaffRemindersTable) Delete(ctx context.Context, userId uint64, id int64) (err error) {
+	query := `DELETE FROM staff_reminders WHERE "user_id" = $1 AND "id" = $2;`
+	_, err = s.Exec(ctx, query, userId, id)
+	return
+}
+
+func scanStaffReminders(rows pgx.Rows) ([]StaffReminder, error) {
+	var reminders []StaffReminder
+	for rows.Next() {
+		var reminder StaffReminder
+		if err := rows.Scan(
+			&reminder.Id,
+			&reminder.GuildId,
+			&reminder.TicketId,
+			&reminder.UserId,
+			&reminder.RemindAt,
+			&reminder.Note,
+			&reminder.Notified,
+			&reminder.Completed,
+			&reminder.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, nil
+}
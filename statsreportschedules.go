@@ -0,0 +1,177 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type StatsReportCadence string
+
+const (
+	StatsReportCadenceDaily   StatsReportCadence = "daily"
+	StatsReportCadenceWeekly  StatsReportCadence = "weekly"
+	StatsReportCadenceMonthly StatsReportCadence = "monthly"
+)
+
+// StatsReportSection is a bitmask of the sections to include in a report.
+type StatsReportSection int32
+
+const (
+	StatsReportSectionTicketCounts StatsReportSection = 1 << iota
+	StatsReportSectionResponseTimes
+	StatsReportSectionStaffLeaderboard
+	StatsReportSectionRatings
+	StatsReportSectionPanels
+)
+
+func (s StatsReportSection) Has(section StatsReportSection) bool {
+	return s&section == section
+}
+
+type StatsReportSchedule struct {
+	GuildId    uint64             `json:"guild_id,string"`
+	ChannelId  uint64             `json:"channel_id,string"`
+	Cadence    StatsReportCadence `json:"cadence"`
+	LastSentAt *time.Time         `json:"last_sent_at"`
+	Sections   StatsReportSection `json:"sections"`
+	// Timezone and WeekStart are read from the guild's locale settings, for computing report periods
+	Timezone  string       `json:"timezone"`
+	WeekStart time.Weekday `json:"week_start"`
+}
+
+type StatsReportSchedulesTable struct {
+	*pgxpool.Pool
+}
+
+func newStatsReportSchedulesTable(db *pgxpool.Pool) *StatsReportSchedulesTable {
+	return &StatsReportSchedulesTable{
+		db,
+	}
+}
+
+func (s StatsReportSchedulesTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS stats_report_schedules(
+	"guild_id" int8 NOT NULL,
+	"channel_id" int8 NOT NULL,
+	"cadence" varchar(16) NOT NULL,
+	"last_sent_at" timestamptz DEFAULT NULL,
+	"sections" int4 NOT NULL DEFAULT 0,
+	CHECK("cadence" IN ('daily', 'weekly', 'monthly')),
+	PRIMARY KEY("guild_id")
+);
+`
+}
+
+func (s *StatsReportSchedulesTable) Get(ctx context.Context, guildId uint64) (StatsReportSchedule, bool, error) {
+	query := `
+SELECT
+	stats_report_schedules.guild_id,
+	stats_report_schedules.channel_id,
+	stats_report_schedules.cadence,
+	stats_report_schedules.last_sent_at,
+	stats_report_schedules.sections,
+	COALESCE(guild_locale_settings.timezone, 'UTC'),
+	COALESCE(guild_locale_settings.week_start, 1)
+FROM stats_report_schedules
+LEFT JOIN guild_locale_settings
+ON guild_locale_settings.guild_id = stats_report_schedules.guild_id
+WHERE stats_report_schedules.guild_id = $1;`
+
+	var schedule StatsReportSchedule
+	if err := s.QueryRow(ctx, query, guildId).Scan(
+		&schedule.GuildId,
+		&schedule.ChannelId,
+		&schedule.Cadence,
+		&schedule.LastSentAt,
+		&schedule.Sections,
+		&schedule.Timezone,
+		&schedule.WeekStart,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return StatsReportSchedule{}, false, nil
+		} else {
+			return StatsReportSchedule{}, false, err
+		}
+	}
+
+	return schedule, true, nil
+}
+
+// Set creates or replaces the guild's schedule. The last sent time is preserved, so changing the configuration does
+// not cause a report to be sent early.
+func (s *StatsReportSchedulesTable) Set(ctx context.Context, schedule StatsReportSchedule) (err error) {
+	query := `
+INSERT INTO stats_report_schedules("guild_id", "channel_id", "cadence", "sections")
+VALUES($1, $2, $3, $4)
+ON CONFLICT("guild_id") DO UPDATE
+SET "channel_id" = EXCLUDED."channel_id", "cadence" = EXCLUDED."cadence", "sections" = EXCLUDED."sections";`
+
+	_, err = s.Exec(ctx, query, schedule.GuildId, schedule.ChannelId, schedule.Cadence, schedule.Sections)
+	return
+}
+
+func (s *StatsReportSchedulesTable) Delete(ctx context.Context, guildId uint64) (err error) {
+	query := `DELETE FROM stats_report_schedules WHERE "guild_id" = $1;`
+	_, err = s.Exec(ctx, query, guildId)
+	return
+}
+
+// GetDue returns the schedules that have never been sent, or whose cadence has elapsed since the last report as of now.
+func (s *StatsReportSchedulesTable) GetDue(ctx context.Context, now time.Time) ([]StatsReportSchedule, error) {
+	query := `
+SELECT
+	stats_report_schedules.guild_id,
+	stats_report_schedules.channel_id,
+	stats_report_schedules.cadence,
+	stats_report_schedules.last_sent_at,
+	stats_report_schedules.sections,
+	COALESCE(guild_locale_settings.timezone, 'UTC'),
+	COALESCE(guild_locale_settings.week_start, 1)
+FROM stats_report_schedules
+LEFT JOIN guild_locale_settings
+ON guild_locale_settings.guild_id = stats_report_schedules.guild_id
+WHERE stats_report_schedules.last_sent_at IS NULL OR stats_report_schedules.last_sent_at + (
+	CASE stats_report_schedules.cadence
+		WHEN 'daily' THEN INTERVAL '1 day'
+		WHEN 'weekly' THEN INTERVAL '1 week'
+		WHEN 'monthly' THEN INTERVAL '1 month'
+	END
+) <= $1;`
+
+	rows, err := s.Query(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []StatsReportSchedule
+	for rows.Next() {
+		var schedule StatsReportSchedule
+		if err := rows.Scan(
+			&schedule.GuildId,
+			&schedule.ChannelId,
+			&schedule.Cadence,
+			&schedule.LastSentAt,
+			&schedule.Sections,
+			&schedule.Timezone,
+			&schedule.WeekStart,
+		); err != nil {
+			return nil, err
+		}
+
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, nil
+}
+
+func (s *StatsReportSchedulesTable) MarkSent(ctx context.Context, guildId uint64, sentAt time.Time) (err error) {
+	query := `UPDATE stats_report_schedules SET "last_sent_at" = $2 WHERE "guild_id" = $1;`
+	_, err = s.Exec(ctx, query, guildId, sentAt)
+	return
+}
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type StatusNoticeSeverity string
+
+const (
+	StatusNoticeSeverityInfo     StatusNoticeSeverity = "info"
+	StatusNoticeSeverityWarning  StatusNoticeSeverity = "warning"
+	StatusNoticeSeverityCritical StatusNoticeSeverity = "critical"
+)
+
+// StatusNotice is a message about the state of the bot itself, e.g. an ongoing incident, shown to all guilds.
+type StatusNotice struct {
+	Id               int                  `json:"id"`
+	Severity         StatusNoticeSeverity `json:"severity"`
+	Message          string               `json:"message"`
+	StartsAt         time.Time            `json:"starts_at"`
+	EndsAt           *time.Time           `json:"ends_at"` // Null if the notice is shown until it is ended
+	AffectedFeatures []string             `json:"affected_features"`
+}
+
+type StatusNoticesTable struct {
+	*pgxpool.Pool
+}
+
+func newStatusNoticesTable(db *pgxpool.Pool) *StatusNoticesTable {
+	return &StatusNoticesTable{
+		db,
+	}
+}
+
+func (s StatusNoticesTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS status_notices(
+	"id" SERIAL NOT NULL UNIQUE,
+	"severity" varchar(16) NOT NULL,
+	"message" text NOT NULL,
+	"starts_at" timestamptz NOT NULL DEFAULT NOW(),
+	"ends_at" timestamptz DEFAULT NULL,
+	"affected_features" text[] NOT NULL DEFAULT '{}',
+	CHECK("severity" IN ('info', 'warning', 'critical')),
+	CHECK("ends_at" IS NULL OR "ends_at" > "starts_at"),
+	PRIMARY KEY("id")
+);
+`
+}
+
+func (s *StatusNoticesTable) Get(ctx context.Context, id int) (StatusNotice, bool, error) {
+	query := `
+SELECT "id", "severity", "message", "starts_at", "ends_at", "affected_features"
+FROM status_notices
+WHERE "id" = $1;`
+
+	var notice StatusNotice
+	if err := s.QueryRow(ctx, query, id).Scan(notice.fieldPtrs()...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return StatusNotice{}, false, nil
+		} else {
+			return StatusNotice{}, false, err
+		}
+	}
+
+	return notice, true, nil
+}
+
+// GetActiveNotices returns the notices that have started and not yet ended, most severe first.
+func (s *StatusNoticesTable) GetActiveNotices(ctx context.Context) ([]StatusNotice, error) {
+	query := `
+SELECT "id", "severity", "message", "starts_at", "ends_at", "affected_features"
+FROM status_notices
+WHERE "starts_at" <= NOW() AND ("ends_at" IS NULL OR "ends_at" > NOW())
+ORDER BY
+	CASE "severity" WHEN 'critical' THEN 0 WHEN 'warning' THEN 1 ELSE 2 END,
+	"starts_at" DESC;`
+
+	rows, err := s.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notices []StatusNotice
+	for rows.Next() {
+		var notice StatusNotice
+		if err := rows.Scan(notice.fieldPtrs()...); err != nil {
+			return nil, err
+		}
+
+		notices = append(notices, notice)
+	}
+
+	return notices, nil
+}
+
+func (s *StatusNoticesTable) Create(ctx context.Context, notice StatusNotice) (id int, err error) {
+	query := `
+INSERT INTO status_notices("severity", "message", "starts_at", "ends_at", "affected_features")
+VALUES($1, $2, $3, $4, $5)
+RETURNING "id";`
+
+	err = s.QueryRow(ctx, query, notice.Severity, notice.Message, notice.StartsAt, notice.EndsAt, notice.AffectedFeatures).Scan(&id)
+	return
+}
+
+func (s *StatusNoticesTable) Update(ctx context.Context, notice StatusNotice) (err error) {
+	query := `
+UPDATE status_notices
+SET "severity" = $2, "message" = $3, "starts_at" = $4, "ends_at" = $5, "affected_features" = $6
+WHERE "id" = $1;`
+
+	_, err = s.Exec(ctx, query, notice.Id, notice.Severity, notice.Message, notice.StartsAt, notice.EndsAt, notice.AffectedFeatures)
+	return
+}
+
+// End stops showing the notice from now on, if it has not already ended.
+func (s *StatusNoticesTable) End(ctx context.Context, id int) (err error) {
+	query := `UPDATE status_notices SET "ends_at" = NOW() WHERE "id" = $1 AND ("ends_at" IS NULL OR "ends_at" > NOW());`
+	_, err = s.Exec(ctx, query, id)
+	return
+}
+
+func (s *StatusNoticesTable) Delete(ctx context.Context, id int) (err error) {
+	query := `DELETE FROM status_notices WHERE "id" = $1;`
+	_, err = s.Exec(ctx, query, id)
+	return
+}
+
+func (n *StatusNotice) fieldPtrs() []interface{} {
+	return []interface{}{
+		&n.Id,
+		&n.Severity,
+		&n.Message,
+		&n.StartsAt,
+		&n.EndsAt,
+		&n.AffectedFeatures,
+	}
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type ThreadState struct {
+	GuildId     uint64    `json:"guild_id,string"`
+	TicketId    int       `json:"ticket_id"`
+	Archived    bool      `json:"archived"`
+	Locked      bool      `json:"locked"`
+	LastChecked time.Time `json:"last_checked"`
+}
+
+type ThreadStateTable struct {
+	*pgxpool.Pool
+}
+
+func newThreadStateTable(db *pgxpool.Pool) *ThreadStateTable {
+	return &ThreadStateTable{
+		db,
+	}
+}
+
+func (t ThreadStateTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS thread_state(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"archived" bool NOT NULL DEFAULT false,
+	"locked" bool NOT NULL DEFAULT false,
+	"last_checked" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "ticket_id")
+);
+CREATE INDEX IF NOT EXISTS thread_state_guild_id_archived ON thread_state("guild_id") WHERE "archived";
+`
+}
+
+func (t *ThreadStateTable) Get(ctx context.Context, guildId uint64, ticketId int) (ThreadState, bool, error) {
+	query := `
+SELECT "guild_id", "ticket_id", "archived", "locked", "last_checked"
+FROM thread_state
+WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+
+	var state ThreadState
+	if err := t.QueryRow(ctx, query, guildId, ticketId).Scan(
+		&state.GuildId,
+		&state.TicketId,
+		&state.Archived,
+		&state.Locked,
+		&state.LastChecked,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ThreadState{}, false, nil
+		} else {
+			return ThreadState{}, false, err
+		}
+	}
+
+	return state, true, nil
+}
+
+// Upsert records the thread's state as observed now.
+func (t *ThreadStateTable) Upsert(ctx context.Context, guildId uint64, ticketId int, archived, locked bool) (err error) {
+	query := `
+INSERT INTO thread_state("guild_id", "ticket_id", "archived", "locked", "last_checked")
+VALUES($1, $2, $3, $4, NOW())
+ON CONFLICT("guild_id", "ticket_id") DO UPDATE
+SET "archived" = EXCLUDED."archived", "locked" = EXCLUDED."locked", "last_checked" = EXCLUDED."last_checked";`
+
+	_, err = t.Exec(ctx, query, guildId, ticketId, archived, locked)
+	return
+}
+
+// GetArchivedOpenTickets returns the tickets that are still open, but whose thread was last seen archived.
+func (t *ThreadStateTable) GetArchivedOpenTickets(ctx context.Context, guildId uint64) ([]Ticket, error) {
+	query := `
+SELECT tickets.id, tickets.guild_id, tickets.channel_id, tickets.user_id, tickets.open, tickets.open_time, tickets.welcome_message_id, tickets.panel_id, tickets.has_transcript, tickets.close_time, tickets.is_thread, tickets.join_message_id, tickets.notes_thread_id, tickets.status
+FROM thread_state
+INNER JOIN tickets
+	ON tickets.guild_id = thread_state.guild_id AND tickets.id = thread_state.ticket_id
+WHERE thread_state.guild_id = $1 AND thread_state.archived AND tickets.open AND tickets.is_thread
+ORDER BY tickets.id ASC;`
+
+	rows, err := t.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tickets []Ticket
+	for rows.Next() {
+		var ticket Ticket
+		if err := rows.Scan(
+			&ticket.Id,
+			&ticket.GuildId,
+			&ticket.ChannelId,
+			&ticket.UserId,
+			&ticket.Open,
+			&ticket.OpenTime,
+			&ticket.WelcomeMessageId,
+			&ticket.PanelId,
+			&ticket.HasTranscript,
+			&ticket.CloseTime,
+			&ticket.IsThread,
+			&ticket.JoinMessageId,
+			&ticket.NotesThreadId,
+			&ticket.Status,
+		); err != nil {
+			return nil, err
+		}
+
+		tickets = append(tickets, ticket)
+	}
+
+	return tickets, nil
+}
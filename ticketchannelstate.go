@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type TicketChannelState struct {
+	GuildId           uint64    `json:"guild_id,string"`
+	TicketId          int       `json:"ticket_id"`
+	RenderedTopicHash []byte    `json:"rendered_topic_hash"`
+	ComponentsHash    []byte    `json:"components_hash"`
+	LastSynced        time.Time `json:"last_synced"`
+}
+
+type TicketChannelStateTable struct {
+	*pgxpool.Pool
+}
+
+func newTicketChannelStateTable(db *pgxpool.Pool) *TicketChannelStateTable {
+	return &TicketChannelStateTable{
+		db,
+	}
+}
+
+func (t TicketChannelStateTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS ticket_channel_state(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"rendered_topic_hash" bytea DEFAULT NULL,
+	"components_hash" bytea DEFAULT NULL,
+	"last_synced" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "ticket_id")
+);
+`
+}
+
+func (t *TicketChannelStateTable) Get(ctx context.Context, guildId uint64, ticketId int) (TicketChannelState, bool, error) {
+	query := `
+SELECT "guild_id", "ticket_id", "rendered_topic_hash", "components_hash", "last_synced"
+FROM ticket_channel_state
+WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+
+	var state TicketChannelState
+	if err := t.QueryRow(ctx, query, guildId, ticketId).Scan(
+		&state.GuildId,
+		&state.TicketId,
+		&state.RenderedTopicHash,
+		&state.ComponentsHash,
+		&state.LastSynced,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TicketChannelState{}, false, nil
+		} else {
+			return TicketChannelState{}, false, err
+		}
+	}
+
+	return state, true, nil
+}
+
+// Upsert records the state that was last applied to the ticket's channel. The worker should compare the hashes of the
+// desired state against Get before editing the channel, and call Upsert once the edit has succeeded.
+func (t *TicketChannelStateTable) Upsert(ctx context.Context, guildId uint64, ticketId int, renderedTopicHash, componentsHash []byte) (err error) {
+	query := `
+INSERT INTO ticket_channel_state("guild_id", "ticket_id", "rendered_topic_hash", "components_hash", "last_synced")
+VALUES($1, $2, $3, $4, NOW())
+ON CONFLICT("guild_id", "ticket_id") DO UPDATE
+SET "rendered_topic_hash" = EXCLUDED."rendered_topic_hash", "components_hash" = EXCLUDED."components_hash", "last_synced" = EXCLUDED."last_synced";`
+
+	_, err = t.Exec(ctx, query, guildId, ticketId, renderedTopicHash, componentsHash)
+	return
+}
+
+func (t *TicketChannelStateTable) Delete(ctx context.Context, guildId uint64, ticketId int) (err error) {
+	query := `DELETE FROM ticket_channel_state WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+	_, err = t.Exec(ctx, query, guildId, ticketId)
+	return
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type TicketFieldType string
+
+const (
+	TicketFieldTypeText   TicketFieldType = "text"
+	TicketFieldTypeNumber TicketFieldType = "number"
+	TicketFieldTypeSelect TicketFieldType = "select"
+)
+
+// TicketFieldDefinition is a guild-defined field that can be set on any of the guild's tickets, e.g. "Order ID".
+// Options are the allowed values of select fields, and are nil for other types.
+type TicketFieldDefinition struct {
+	Id      int             `json:"id"`
+	GuildId uint64          `json:"guild_id,string"`
+	Name    string          `json:"name"`
+	Type    TicketFieldType `json:"type"`
+	Options []string        `json:"options"`
+}
+
+type TicketFieldDefinitionsTable struct {
+	*pgxpool.Pool
+}
+
+func newTicketFieldDefinitionsTable(db *pgxpool.Pool) *TicketFieldDefinitionsTable {
+	return &TicketFieldDefinitionsTable{
+		db,
+	}
+}
+
+func (t TicketFieldDefinitionsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS ticket_field_definitions(
+	"id" SERIAL NOT NULL UNIQUE,
+	"guild_id" int8 NOT NULL,
+	"name" varchar(64) NOT NULL,
+	"type" varchar(16) NOT NULL,
+	"options" text[] DEFAULT NULL,
+	CHECK("type" IN ('text', 'number', 'select')),
+	CHECK(("type" = 'select') = ("options" IS NOT NULL)),
+	UNIQUE("guild_id", "name"),
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS ticket_field_definitions_guild_id ON ticket_field_definitions("guild_id");
+`
+}
+
+func (t *TicketFieldDefinitionsTable) Get(ctx context.Context, guildId uint64, fieldId int) (TicketFieldDefinition, bool, error) {
+	query := `
+SELECT "id", "guild_id", "name", "type", "options"
+FROM ticket_field_definitions
+WHERE "guild_id" = $1 AND "id" = $2;`
+
+	var definition TicketFieldDefinition
+	if err := t.QueryRow(ctx, query, guildId, fieldId).Scan(
+		&definition.Id,
+		&definition.GuildId,
+		&definition.Name,
+		&definition.Type,
+		&definition.Options,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TicketFieldDefinition{}, false, nil
+		} else {
+			return TicketFieldDefinition{}, false, err
+		}
+	}
+
+	return definition, true, nil
+}
+
+func (t *TicketFieldDefinitionsTable) GetByGuild(ctx context.Context, guildId uint64) ([]TicketFieldDefinition, error) {
+	query := `
+SELECT "id", "guild_id", "name", "type", "options"
+FROM ticket_field_definitions
+WHERE "guild_id" = $1
+ORDER BY "id" ASC;`
+
+	rows, err := t.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var definitions []TicketFieldDefinition
+	for rows.Next() {
+		var definition TicketFieldDefinition
+		if err := rows.Scan(
+			&definition.Id,
+			&definition.GuildId,
+			&definition.Name,
+			&definition.Type,
+			&definition.Options,
+		); err != nil {
+			return nil, err
+		}
+
+		definitions = append(definitions, definition)
+	}
+
+	return definitions, nil
+}
+
+func (t *TicketFieldDefinitionsTable) Create(ctx context.Context, definition TicketFieldDefinition) (id int, err error) {
+	query := `
+INSERT INTO ticket_field_definitions("guild_id", "name", "type", "options")
+VALUES($1, $2, $3, $4)
+RETURNING "id";`
+
+	err = t.QueryRow(ctx, query, definition.GuildId, definition.Name, definition.Type, definition.Options).Scan(&id)
+	return
+}
+
+// Update changes the field's name and options. The type of a field cannot be changed, as existing values may not be
+// valid for the new type.
+func (t *TicketFieldDefinitionsTable) Update(ctx context.Context, definition TicketFieldDefinition) (err error) {
+	query := `
+UPDATE ticket_field_definitions
+SET "name" = $3, "options" = $4
+WHERE "guild_id" = $1 AND "id" = $2;`
+
+	_, err = t.Exec(ctx, query, definition.GuildId, definition.Id, definition.Name, definition.Options)
+	return
+}
+
+// Delete deletes the field, along with its value on every ticket.
+func (t *TicketFieldDefinitionsTable) Delete(ctx context.Context, guildId uint64, fieldId int) (err error) {
+	query := `DELETE FROM ticket_field_definitions WHERE "guild_id" = $1 AND "id" = $2;`
+	_, err = t.Exec(ctx, query, guildId, fieldId)
+	return
+}
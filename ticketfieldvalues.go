@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var (
+	ErrTicketFieldNotFound     = errors.New("ticket field not found")
+	ErrInvalidTicketFieldValue = errors.New("value is not valid for the ticket field's type")
+)
+
+type TicketFieldValuesTable struct {
+	*pgxpool.Pool
+}
+
+func newTicketFieldValuesTable(db *pgxpool.Pool) *TicketFieldValuesTable {
+	return &TicketFieldValuesTable{
+		db,
+	}
+}
+
+func (t TicketFieldValuesTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS ticket_field_values(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"field_id" int NOT NULL,
+	"value" varchar(1024) NOT NULL,
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	FOREIGN KEY("field_id") REFERENCES ticket_field_definitions("id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "ticket_id", "field_id")
+);
+CREATE INDEX IF NOT EXISTS ticket_field_values_field_id ON ticket_field_values("field_id");
+`
+}
+
+// Get returns field ID -> value for the ticket. Fields without a value are omitted.
+func (t *TicketFieldValuesTable) Get(ctx context.Context, guildId uint64, ticketId int) (map[int]string, error) {
+	query := `SELECT "field_id", "value" FROM ticket_field_values WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+
+	rows, err := t.Query(ctx, query, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make(map[int]string)
+	for rows.Next() {
+		var fieldId int
+		var value string
+		if err := rows.Scan(&fieldId, &value); err != nil {
+			return nil, err
+		}
+
+		values[fieldId] = value
+	}
+
+	return values, nil
+}
+
+// GetByGuild returns ticket ID -> field ID -> value for every ticket in the guild that has at least one value set.
+func (t *TicketFieldValuesTable) GetByGuild(ctx context.Context, guildId uint64) (map[int]map[int]string, error) {
+	query := `SELECT "ticket_id", "field_id", "value" FROM ticket_field_values WHERE "guild_id" = $1;`
+
+	rows, err := t.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make(map[int]map[int]string)
+	for rows.Next() {
+		var ticketId, fieldId int
+		var value string
+		if err := rows.Scan(&ticketId, &fieldId, &value); err != nil {
+			return nil, err
+		}
+
+		if _, ok := values[ticketId]; !ok {
+			values[ticketId] = make(map[int]string)
+		}
+
+		values[ticketId][fieldId] = value
+	}
+
+	return values, nil
+}
+
+// Set sets the field's value on the ticket. Returns ErrTicketFieldNotFound if the field does not belong to the guild,
+// or ErrInvalidTicketFieldValue if the value is not a number for number fields, or not one of the options for select
+// fields.
+func (t *TicketFieldValuesTable) Set(ctx context.Context, guildId uint64, ticketId, fieldId int, value string) error {
+	definition, ok, err := newTicketFieldDefinitionsTable(t.Pool).Get(ctx, guildId, fieldId)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrTicketFieldNotFound
+	}
+
+	switch definition.Type {
+	case TicketFieldTypeNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return ErrInvalidTicketFieldValue
+		}
+	case TicketFieldTypeSelect:
+		if !slices.Contains(definition.Options, value) {
+			return ErrInvalidTicketFieldValue
+		}
+	}
+
+	query := `
+INSERT INTO ticket_field_values("guild_id", "ticket_id", "field_id", "value")
+VALUES($1, $2, $3, $4)
+ON CONFLICT("guild_id", "ticket_id", "field_id") DO UPDATE SET "value" = EXCLUDED."value";`
+
+	_, err = t.Exec(ctx, query, guildId, ticketId, fieldId, value)
+	return err
+}
+
+func (t *TicketFieldValuesTable) Delete(ctx context.Context, guildId uint64, ticketId, fieldId int) (err error) {
+	query := `DELETE FROM ticket_field_values WHERE "guild_id" = $1 AND "ticket_id" = $2 AND "field_id" = $3;`
+	_, err = t.Exec(ctx, query, guildId, ticketId, fieldId)
+	return
+}
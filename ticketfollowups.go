@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type TicketFollowup struct {
+	Id                int64     `json:"id"`
+	GuildId           uint64    `json:"guild_id,string"`
+	TicketId          int       `json:"ticket_id"`
+	SendAt            time.Time `json:"send_at"`
+	Content           string    `json:"content"`
+	CancelOnUserReply bool      `json:"cancel_on_user_reply"`
+	CreatedBy         uint64    `json:"created_by,string"`
+}
+
+type TicketFollowupsTable struct {
+	*pgxpool.Pool
+}
+
+func newTicketFollowupsTable(db *pgxpool.Pool) *TicketFollowupsTable {
+	return &TicketFollowupsTable{
+		db,
+	}
+}
+
+func (t TicketFollowupsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS ticket_followups(
+	"id" BIGSERIAL NOT NULL,
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"send_at" timestamptz NOT NULL,
+	"content" text NOT NULL,
+	"cancel_on_user_reply" bool NOT NULL DEFAULT true,
+	"created_by" int8 NOT NULL,
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS ticket_followups_guild_ticket ON ticket_followups("guild_id", "ticket_id");
+CREATE INDEX IF NOT EXISTS ticket_followups_send_at ON ticket_followups("send_at");
+`
+}
+
+func (t *TicketFollowupsTable) Schedule(ctx context.Context, followup TicketFollowup) (id int64, err error) {
+	query := `
+INSERT INTO ticket_followups("guild_id", "ticket_id", "send_at", "content", "cancel_on_user_reply", "created_by")
+VALUES($1, $2, $3, $4, $5, $6)
+RETURNING "id";`
+
+	err = t.QueryRow(ctx, query,
+		followup.GuildId,
+		followup.TicketId,
+		followup.SendAt,
+		followup.Content,
+		followup.CancelOnUserReply,
+		followup.CreatedBy,
+	).Scan(&id)
+	return
+}
+
+func (t *TicketFollowupsTable) GetByTicket(ctx context.Context, guildId uint64, ticketId int) ([]TicketFollowup, error) {
+	query := `
+SELECT "id", "guild_id", "ticket_id", "send_at", "content", "cancel_on_user_reply", "created_by"
+FROM ticket_followups
+WHERE "guild_id" = $1 AND "ticket_id" = $2
+ORDER BY "send_at" ASC;`
+
+	rows, err := t.Query(ctx, query, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTicketFollowups(rows)
+}
+
+func (t *TicketFollowupsTable) Cancel(ctx context.Context, guildId uint64, id int64) (err error) {
+	query := `DELETE FROM ticket_followups WHERE "guild_id" = $1 AND "id" = $2;`
+	_, err = t.Exec(ctx, query, guildId, id)
+	return
+}
+
+func (t *TicketFollowupsTable) CancelAllForTicket(ctx context.Context, guildId uint64, ticketId int) (err error) {
+	query := `DELETE FROM ticket_followups WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+	_, err = t.Exec(ctx, query, guildId, ticketId)
+	return
+}
+
+// CancelOnUserReply should be called when the ticket opener sends a message, to remove the follow-ups that are no
+// longer needed.
+func (t *TicketFollowupsTable) CancelOnUserReply(ctx context.Context, guildId uint64, ticketId int) (err error) {
+	query := `DELETE FROM ticket_followups WHERE "guild_id" = $1 AND "ticket_id" = $2 AND "cancel_on_user_reply";`
+	_, err = t.Exec(ctx, query, guildId, ticketId)
+	return
+}
+
+// ClaimDue removes and returns up to limit follow-ups that are ready to be sent. Rows locked by another worker are
+// skipped, so multiple workers can poll concurrently without sending the same follow-up twice.
+func (t *TicketFollowupsTable) ClaimDue(ctx context.Context, limit int) ([]TicketFollowup, error) {
+	query := `
+DELETE FROM ticket_followups
+WHERE "id" IN (
+	SELECT "id"
+	FROM ticket_followups
+	WHERE "send_at" <= NOW()
+	ORDER BY "send_at" ASC
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING "id", "guild_id", "ticket_id", "send_at", "content", "cancel_on_user_reply", "created_by";`
+
+	rows, err := t.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTicketFollowups(rows)
+}
+
+func scanTicketFollowups(rows pgx.Rows) ([]TicketFollowup, error) {
+	var followups []TicketFollowup
+	for rows.Next() {
+		var followup TicketFollowup
+		if err := rows.Scan(
+			&followup.Id,
+			&followup.GuildId,
+			&followup.TicketId,
+			&followup.SendAt,
+			&followup.Content,
+			&followup.CancelOnUserReply,
+			&followup.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+
+		followups = append(followups, followup)
+	}
+
+	return followups, nil
+}
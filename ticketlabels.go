@@ -8,10 +8,11 @@ import (
 )
 
 type TicketLabel struct {
-	GuildId uint64 `json:"guild_id"`
-	LabelId int    `json:"label_id"`
-	Name    string `json:"name"`
-	Colour  int32  `json:"colour"`
+	GuildId  uint64 `json:"guild_id"`
+	LabelId  int    `json:"label_id"`
+	Name     string `json:"name"`
+	Colour   int32  `json:"colour"`
+	Archived bool   `json:"archived"`
 }
 
 type TicketLabelsTable struct {
@@ -31,15 +32,19 @@ CREATE TABLE IF NOT EXISTS ticket_labels(
 	"label_id" SERIAL,
 	"name" varchar(32) NOT NULL,
 	"colour" int4 NOT NULL DEFAULT 4869178,
-	PRIMARY KEY("guild_id", "label_id"),
-	UNIQUE("guild_id", "name")
+	"archived" bool NOT NULL DEFAULT false,
+	PRIMARY KEY("guild_id", "label_id")
 );
 CREATE INDEX IF NOT EXISTS ticket_labels_guild_id_idx ON ticket_labels("guild_id");
+ALTER TABLE ticket_labels ADD COLUMN IF NOT EXISTS "archived" bool NOT NULL DEFAULT false;
+ALTER TABLE ticket_labels DROP CONSTRAINT IF EXISTS ticket_labels_guild_id_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS ticket_labels_guild_id_name_active ON ticket_labels("guild_id", "name") WHERE NOT "archived";
 `
 }
 
+// GetByGuild returns the guild's labels, excluding archived labels.
 func (t *TicketLabelsTable) GetByGuild(ctx context.Context, guildId uint64) ([]TicketLabel, error) {
-	query := `SELECT "guild_id", "label_id", "name", "colour" FROM ticket_labels WHERE "guild_id" = $1 ORDER BY "label_id" ASC;`
+	query := `SELECT "guild_id", "label_id", "name", "colour", "archived" FROM ticket_labels WHERE "guild_id" = $1 AND NOT "archived" ORDER BY "label_id" ASC;`
 
 	rows, err := t.Query(ctx, query, guildId)
 	if err != nil {
@@ -50,7 +55,29 @@ func (t *TicketLabelsTable) GetByGuild(ctx context.Context, guildId uint64) ([]T
 	var labels []TicketLabel
 	for rows.Next() {
 		var label TicketLabel
-		if err := rows.Scan(&label.GuildId, &label.LabelId, &label.Name, &label.Colour); err != nil {
+		if err := rows.Scan(&label.GuildId, &label.LabelId, &label.Name, &label.Colour, &label.Archived); err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+
+	return labels, nil
+}
+
+// GetByGuildIncludingArchived returns all of the guild's labels, for resolving historical assignments.
+func (t *TicketLabelsTable) GetByGuildIncludingArchived(ctx context.Context, guildId uint64) ([]TicketLabel, error) {
+	query := `SELECT "guild_id", "label_id", "name", "colour", "archived" FROM ticket_labels WHERE "guild_id" = $1 ORDER BY "label_id" ASC;`
+
+	rows, err := t.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var labels []TicketLabel
+	for rows.Next() {
+		var label TicketLabel
+		if err := rows.Scan(&label.GuildId, &label.LabelId, &label.Name, &label.Colour, &label.Archived); err != nil {
 			return nil, err
 		}
 		labels = append(labels, label)
@@ -60,10 +87,10 @@ func (t *TicketLabelsTable) GetByGuild(ctx context.Context, guildId uint64) ([]T
 }
 
 func (t *TicketLabelsTable) Get(ctx context.Context, guildId uint64, labelId int) (TicketLabel, bool, error) {
-	query := `SELECT "guild_id", "label_id", "name", "colour" FROM ticket_labels WHERE "guild_id" = $1 AND "label_id" = $2;`
+	query := `SELECT "guild_id", "label_id", "name", "colour", "archived" FROM ticket_labels WHERE "guild_id" = $1 AND "label_id" = $2;`
 
 	var label TicketLabel
-	err := t.QueryRow(ctx, query, guildId, labelId).Scan(&label.GuildId, &label.LabelId, &label.Name, &label.Colour)
+	err := t.QueryRow(ctx, query, guildId, labelId).Scan(&label.GuildId, &label.LabelId, &label.Name, &label.Colour, &label.Archived)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return TicketLabel{}, false, nil
@@ -78,20 +105,44 @@ func (t *TicketLabelsTable) Create(ctx context.Context, guildId uint64, name str
 	query := `INSERT INTO ticket_labels("guild_id", "name", "colour") VALUES($1, $2, $3) RETURNING "label_id";`
 
 	var labelId int
-	err := t.QueryRow(ctx, query, guildId, name, colour).Scan(&labelId)
+	err := withAuditTx(ctx, t.Pool, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, guildId, name, colour).Scan(&labelId); err != nil {
+			return err
+		}
+
+		label := TicketLabel{GuildId: guildId, LabelId: labelId, Name: name, Colour: colour}
+		return recordAudit(ctx, tx, guildId, AuditActionTicketLabelCreate, AuditResourceTicketLabel, auditResourceId(labelId), label)
+	})
+
 	return labelId, err
 }
 
 func (t *TicketLabelsTable) Update(ctx context.Context, guildId uint64, labelId int, name string, colour int32) error {
-	query := `UPDATE ticket_labels SET "name" = $3, "colour" = $4 WHERE "guild_id" = $1 AND "label_id" = $2;`
-	_, err := t.Exec(ctx, query, guildId, labelId, name, colour)
-	return err
+	query := `UPDATE ticket_labels SET "name" = $3, "colour" = $4 WHERE "guild_id" = $1 AND "label_id" = $2 RETURNING "guild_id";`
+	label := TicketLabel{GuildId: guildId, LabelId: labelId, Name: name, Colour: colour}
+	return execAudited(ctx, t.Pool, AuditActionTicketLabelUpdate, AuditResourceTicketLabel, auditResourceId(labelId), label, query, guildId, labelId, name, colour)
+}
+
+// ArchiveLabel hides the label from GetByGuild, while keeping its assignments for historical statistics. Unlike
+// Delete, existing assignments are not removed. Names are only unique among unarchived labels, so a new label may be
+// created with the archived label's name.
+func (t *TicketLabelsTable) ArchiveLabel(ctx context.Context, guildId uint64, labelId int) error {
+	query := `UPDATE ticket_labels SET "archived" = true WHERE "guild_id" = $1 AND "label_id" = $2 RETURNING "guild_id";`
+	data := map[string]bool{"archived": true}
+	return execAudited(ctx, t.Pool, AuditActionTicketLabelUpdate, AuditResourceTicketLabel, auditResourceId(labelId), data, query, guildId, labelId)
+}
+
+// UnarchiveLabel restores an archived label. Fails with a unique violation if another unarchived label has since been
+// created with the same name.
+func (t *TicketLabelsTable) UnarchiveLabel(ctx context.Context, guildId uint64, labelId int) error {
+	query := `UPDATE ticket_labels SET "archived" = false WHERE "guild_id" = $1 AND "label_id" = $2 RETURNING "guild_id";`
+	data := map[string]bool{"archived": false}
+	return execAudited(ctx, t.Pool, AuditActionTicketLabelUpdate, AuditResourceTicketLabel, auditResourceId(labelId), data, query, guildId, labelId)
 }
 
 func (t *TicketLabelsTable) Delete(ctx context.Context, guildId uint64, labelId int) error {
-	query := `DELETE FROM ticket_labels WHERE "guild_id" = $1 AND "label_id" = $2;`
-	_, err := t.Exec(ctx, query, guildId, labelId)
-	return err
+	query := `DELETE FROM ticket_labels WHERE "guild_id" = $1 AND "label_id" = $2 RETURNING "guild_id";`
+	return execAudited(ctx, t.Pool, AuditActionTicketLabelDelete, AuditResourceTicketLabel, auditResourceId(labelId), nil, query, guildId, labelId)
 }
 
 func (t *TicketLabelsTable) GetCount(ctx context.Context, guildId uint64) (int, error) {
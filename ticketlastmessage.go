@@ -19,6 +19,14 @@ type TicketLastMessage struct {
 	UserIsStaff     *bool      `json:"last_message_user_is_staff"`
 }
 
+var (
+	ticketLastMessageSql    = loadSqlTable("ticket_last_message")
+	ticketLastMessageSchema = ticketLastMessageSql.schema()
+	ticketLastMessageGet    = ticketLastMessageSql.query("get")
+	ticketLastMessageSet    = ticketLastMessageSql.query("set")
+	ticketLastMessageDelete = ticketLastMessageSql.query("delete")
+)
+
 func newTicketLastMessageTable(db *pgxpool.Pool) *TicketLastMessageTable {
 	return &TicketLastMessageTable{
 		db,
@@ -26,27 +34,11 @@ func newTicketLastMessageTable(db *pgxpool.Pool) *TicketLastMessageTable {
 }
 
 func (m TicketLastMessageTable) Schema() string {
-	return `
-CREATE TABLE IF NOT EXISTS ticket_last_message(
-	"guild_id" int8 NOT NULL,
-	"ticket_id" int4 NOT NULL,
-	"last_message_id" int8,
-	"last_message_time" timestamptz,
-    "user_id" int8,
-	"user_is_staff" bool NOT NULL,
-	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id"),
-	PRIMARY KEY("guild_id", "ticket_id")
-);
-`
+	return ticketLastMessageSchema
 }
 
 func (m *TicketLastMessageTable) Get(ctx context.Context, guildId uint64, ticketId int) (lastMessage TicketLastMessage, e error) {
-	query := `
-SELECT "last_message_id", "last_message_time", "user_id", "user_is_staff"
-FROM ticket_last_message
-WHERE "guild_id" = $1 AND "ticket_id" = $2;`
-
-	if err := m.QueryRow(ctx, query, guildId, ticketId).Scan(
+	if err := m.QueryRow(ctx, ticketLastMessageGet, guildId, ticketId).Scan(
 		&lastMessage.LastMessageId,
 		&lastMessage.LastMessageTime,
 		&lastMessage.UserId,
@@ -77,17 +69,11 @@ func (m *TicketLastMessageTable) ImportBulk(ctx context.Context, guildId uint64,
 }
 
 func (m *TicketLastMessageTable) Set(ctx context.Context, guildId uint64, ticketId int, messageId, userId uint64, userIsStaff bool) (err error) {
-	query := `
-INSERT INTO ticket_last_message("guild_id", "ticket_id", "last_message_id", "last_message_time", "user_id", "user_is_staff")
-VALUES($1, $2, $3, NOW(), $4, $5) ON CONFLICT("guild_id", "ticket_id")
-DO UPDATE SET "last_message_id" = $3, "last_message_time" = NOW(), "user_id" = $4, "user_is_staff" = $5;`
-
-	_, err = m.Exec(ctx, query, guildId, ticketId, messageId, userId, userIsStaff)
+	_, err = m.Exec(ctx, ticketLastMessageSet, guildId, ticketId, messageId, userId, userIsStaff)
 	return
 }
 
 func (m *TicketLastMessageTable) Delete(ctx context.Context, guildId uint64, ticketId int) (err error) {
-	query := `DELETE FROM ticket_last_message WHERE "guild_id"=$1 AND "ticket_id"=$2;`
-	_, err = m.Exec(ctx, query, guildId, ticketId)
+	_, err = m.Exec(ctx, ticketLastMessageDelete, guildId, ticketId)
 	return
 }
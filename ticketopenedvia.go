@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// TicketOpenedVia is how a ticket was opened. It is only recorded for tickets created with CreateVia.
+type TicketOpenedVia string
+
+const (
+	TicketOpenedViaPanel       TicketOpenedVia = "panel"
+	TicketOpenedViaCommand     TicketOpenedVia = "command"
+	TicketOpenedViaDashboard   TicketOpenedVia = "dashboard"
+	TicketOpenedViaOpenForUser TicketOpenedVia = "open_for_user"
+
+	// TicketOpenedViaUnknown is returned by the aggregation queries for tickets created without CreateVia, e.g. before
+	// the source was recorded, or imported tickets. It cannot be passed to CreateVia.
+	TicketOpenedViaUnknown TicketOpenedVia = "unknown"
+)
+
+func (v TicketOpenedVia) IsValid() bool {
+	switch v {
+	case TicketOpenedViaPanel, TicketOpenedViaCommand, TicketOpenedViaDashboard, TicketOpenedViaOpenForUser:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetOpenedVia returns how the ticket was opened, or TicketOpenedViaUnknown if it was not recorded. The bool is false if
+// the ticket does not exist.
+func (t *TicketTable) GetOpenedVia(ctx context.Context, guildId uint64, ticketId int) (TicketOpenedVia, bool, error) {
+	query := `SELECT COALESCE("opened_via", 'unknown') FROM tickets WHERE "guild_id" = $1 AND "id" = $2;`
+
+	var openedVia TicketOpenedVia
+	if err := t.QueryRow(ctx, query, guildId, ticketId).Scan(&openedVia); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", false, nil
+		} else {
+			return "", false, err
+		}
+	}
+
+	return openedVia, true, nil
+}
+
+// GetOpenedViaCounts returns the number of the guild's tickets opened within [from, to) by how they were opened.
+// Sources with no tickets are omitted.
+func (t *TicketTable) GetOpenedViaCounts(ctx context.Context, guildId uint64, from, to time.Time) (map[TicketOpenedVia]int, error) {
+	query := `
+SELECT COALESCE("opened_via", 'unknown'), COUNT(*)
+FROM tickets
+WHERE "guild_id" = $1 AND "open_time" >= $2 AND "open_time" < $3
+GROUP BY 1;`
+
+	return scanTicketOpenedViaCounts(t.Query(ctx, query, guildId, from, to))
+}
+
+// GetGlobalOpenedViaCounts returns the number of tickets opened across all guilds within [from, to) by how they were
+// opened. Sources with no tickets are omitted.
+func (t *TicketTable) GetGlobalOpenedViaCounts(ctx context.Context, from, to time.Time) (map[TicketOpenedVia]int, error) {
+	query := `
+SELECT COALESCE("opened_via", 'unknown'), COUNT(*)
+FROM tickets
+WHERE "open_time" >= $1 AND "open_time" < $2
+GROUP BY 1;`
+
+	return scanTicketOpenedViaCounts(t.Query(ctx, query, from, to))
+}
+
+func scanTicketOpenedViaCounts(rows pgx.Rows, err error) (map[TicketOpenedVia]int, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[TicketOpenedVia]int)
+	for rows.Next() {
+		var openedVia TicketOpenedVia
+		var count int
+		if err := rows.Scan(&openedVia, &count); err != nil {
+			return nil, err
+		}
+
+		counts[openedVia] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// TicketOpenEventRetention is how long open events are kept for. Windows passed to CountRecent longer than this will
+// undercount.
+const TicketOpenEventRetention = time.Hour * 24
+
+type TicketOpenEvents struct {
+	*pgxpool.Pool
+}
+
+func newTicketOpenEvents(db *pgxpool.Pool) *TicketOpenEvents {
+	return &TicketOpenEvents{
+		db,
+	}
+}
+
+func (t TicketOpenEvents) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS ticket_open_events(
+	"guild_id" int8 NOT NULL,
+	"user_id" int8 NOT NULL,
+	"opened_at" timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS ticket_open_events_guild_user_opened_at ON ticket_open_events("guild_id", "user_id", "opened_at");
+CREATE INDEX IF NOT EXISTS ticket_open_events_guild_opened_at ON ticket_open_events("guild_id", "opened_at");
+`
+}
+
+// Record stores a ticket open event, and prunes the user's events that are older than TicketOpenEventRetention.
+func (t *TicketOpenEvents) Record(ctx context.Context, guildId, userId uint64) (err error) {
+	query := `
+WITH pruned AS (
+	DELETE FROM ticket_open_events
+	WHERE "guild_id" = $1 AND "user_id" = $2 AND "opened_at" < NOW() - $3::interval
+)
+INSERT INTO ticket_open_events("guild_id", "user_id", "opened_at")
+VALUES($1, $2, NOW());`
+
+	_, err = t.Exec(ctx, query, guildId, userId, TicketOpenEventRetention)
+	return
+}
+
+// CountRecent returns the number of tickets the user has opened in the guild within the window.
+func (t *TicketOpenEvents) CountRecent(ctx context.Context, guildId, userId uint64, window time.Duration) (count int, err error) {
+	query := `
+SELECT COUNT(*)
+FROM ticket_open_events
+WHERE "guild_id" = $1 AND "user_id" = $2 AND "opened_at" >= NOW() - $3::interval;`
+
+	err = t.QueryRow(ctx, query, guildId, userId, window).Scan(&count)
+	return
+}
+
+// CountRecentInGuild returns the number of tickets opened by any user in the guild within the window, for detecting
+// raids carried out by many accounts.
+func (t *TicketOpenEvents) CountRecentInGuild(ctx context.Context, guildId uint64, window time.Duration) (count int, err error) {
+	query := `
+SELECT COUNT(*)
+FROM ticket_open_events
+WHERE "guild_id" = $1 AND "opened_at" >= NOW() - $2::interval;`
+
+	err = t.QueryRow(ctx, query, guildId, window).Scan(&count)
+	return
+}
+
+// Prune removes all events older than TicketOpenEventRetention, including those of users who have not opened a
+// ticket since.
+func (t *TicketOpenEvents) Prune(ctx context.Context) (err error) {
+	query := `DELETE FROM ticket_open_events WHERE "opened_at" < NOW() - $1::interval;`
+	_, err = t.Exec(ctx, query, TicketOpenEventRetention)
+	return
+}
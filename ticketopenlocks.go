@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// TicketOpenLocksTable prevents a user from opening duplicate tickets by e.g. double-clicking a panel button, when the
+// two interactions may be handled by different shards.
+type TicketOpenLocksTable struct {
+	*pgxpool.Pool
+}
+
+func newTicketOpenLocksTable(db *pgxpool.Pool) *TicketOpenLocksTable {
+	return &TicketOpenLocksTable{
+		db,
+	}
+}
+
+func (t TicketOpenLocksTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS ticket_open_locks(
+	"guild_id" int8 NOT NULL,
+	"user_id" int8 NOT NULL,
+	"panel_id" int DEFAULT NULL,
+	"expires_at" timestamptz NOT NULL,
+	"owner" uuid DEFAULT NULL,
+	UNIQUE NULLS NOT DISTINCT ("guild_id", "user_id", "panel_id")
+);
+ALTER TABLE ticket_open_locks ADD COLUMN IF NOT EXISTS "owner" uuid DEFAULT NULL;
+CREATE INDEX IF NOT EXISTS ticket_open_locks_expires_at ON ticket_open_locks("expires_at");
+`
+}
+
+// TryAcquireOpenLock takes the lock for the user opening a ticket from the panel until ttl has elapsed, returning
+// false if it is already held. A nil panel ID is used for tickets not opened from a panel. The lock should be released
+// with the returned owner token once the ticket has been created, or the open flow has failed.
+func (t *TicketOpenLocksTable) TryAcquireOpenLock(ctx context.Context, guildId, userId uint64, panelId *int, ttl time.Duration) (owner uuid.UUID, acquired bool, err error) {
+	query := `
+INSERT INTO ticket_open_locks("guild_id", "user_id", "panel_id", "expires_at", "owner")
+VALUES($1, $2, $3, NOW() + $4::interval, $5)
+ON CONFLICT("guild_id", "user_id", "panel_id") DO UPDATE
+SET "expires_at" = EXCLUDED."expires_at", "owner" = EXCLUDED."owner"
+WHERE ticket_open_locks."expires_at" <= NOW();`
+
+	owner = uuid.New()
+
+	res, err := t.Exec(ctx, query, guildId, userId, panelId, ttl, owner)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+
+	if res.RowsAffected() == 0 {
+		return uuid.Nil, false, nil
+	}
+
+	return owner, true, nil
+}
+
+// ReleaseOpenLock releases the lock if it is still held by owner, as returned by TryAcquireOpenLock. If the lock has
+// expired and been taken by another open flow, it is left in place.
+func (t *TicketOpenLocksTable) ReleaseOpenLock(ctx context.Context, guildId, userId uint64, panelId *int, owner uuid.UUID) (err error) {
+	query := `
+DELETE FROM ticket_open_locks
+WHERE "guild_id" = $1 AND "user_id" = $2 AND "panel_id" IS NOT DISTINCT FROM $3 AND "owner" = $4;`
+
+	_, err = t.Exec(ctx, query, guildId, userId, panelId, owner)
+	return
+}
+
+func (t *TicketOpenLocksTable) DeleteExpired(ctx context.Context) (err error) {
+	query := `DELETE FROM ticket_open_locks WHERE "expires_at" <= NOW();`
+	_, err = t.Exec(ctx, query)
+	return
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var ErrTicketNotFound = errors.New("ticket not found")
+
+type TicketPanelTransfer struct {
+	Id            int64     `json:"id"`
+	GuildId       uint64    `json:"guild_id,string"`
+	TicketId      int       `json:"ticket_id"`
+	OldPanelId    *int      `json:"old_panel_id"` // Null if the ticket was not opened from a panel, or the panel was deleted
+	NewPanelId    *int      `json:"new_panel_id"` // Null if the panel has since been deleted
+	ActorId       uint64    `json:"actor_id,string"`
+	TransferredAt time.Time `json:"transferred_at"`
+}
+
+// TicketPanelTransferResult contains the new panel's mention and team configuration as of the transfer, so that the
+// caller can notify the new staff without reading it again outside of the transaction.
+type TicketPanelTransferResult struct {
+	Transfer        TicketPanelTransfer
+	TeamIds         []int
+	WithDefaultTeam bool
+	MentionRoleIds  []uint64
+	MentionUser     bool
+	MentionHere     bool
+	ClaimedBy       *uint64 // The claimer after the transfer, or nil if the ticket is unclaimed
+	ClaimReleased   bool
+}
+
+type TicketPanelTransfersTable struct {
+	*pgxpool.Pool
+}
+
+func newTicketPanelTransfersTable(db *pgxpool.Pool) *TicketPanelTransfersTable {
+	return &TicketPanelTransfersTable{
+		db,
+	}
+}
+
+func (t TicketPanelTransfersTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS ticket_panel_transfers(
+	"id" BIGSERIAL NOT NULL,
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"old_panel_id" int DEFAULT NULL,
+	"new_panel_id" int DEFAULT NULL,
+	"actor_id" int8 NOT NULL,
+	"transferred_at" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	FOREIGN KEY("old_panel_id") REFERENCES panels("panel_id") ON DELETE SET NULL ON UPDATE CASCADE,
+	FOREIGN KEY("new_panel_id") REFERENCES panels("panel_id") ON DELETE SET NULL ON UPDATE CASCADE,
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS ticket_panel_transfers_guild_ticket ON ticket_panel_transfers("guild_id", "ticket_id");
+`
+}
+
+func (t *TicketPanelTransfersTable) GetByTicket(ctx context.Context, guildId uint64, ticketId int) ([]TicketPanelTransfer, error) {
+	query := `
+SELECT "id", "guild_id", "ticket_id", "old_panel_id", "new_panel_id", "actor_id", "transferred_at"
+FROM ticket_panel_transfers
+WHERE "guild_id" = $1 AND "ticket_id" = $2
+ORDER BY "transferred_at" ASC;`
+
+	rows, err := t.Query(ctx, query, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []TicketPanelTransfer
+	for rows.Next() {
+		var transfer TicketPanelTransfer
+		if err := rows.Scan(
+			&transfer.Id,
+			&transfer.GuildId,
+			&transfer.TicketId,
+			&transfer.OldPanelId,
+			&transfer.NewPanelId,
+			&transfer.ActorId,
+			&transfer.TransferredAt,
+		); err != nil {
+			return nil, err
+		}
+
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, nil
+}
+
+// TransferTicketPanel moves the ticket to the new panel and records the transfer in a single transaction. If
+// releaseClaim is true, any claim on the ticket is released as part of the same transaction, e.g. if the claimer is not
+// a member of the new panel's teams. Returns ErrTicketNotFound if the ticket does not exist, or ErrPanelNotFound if
+// the new panel does not exist in the guild.
+func (d *Database) TransferTicketPanel(ctx context.Context, guildId uint64, ticketId, newPanelId int, actorId uint64, releaseClaim bool) (TicketPanelTransferResult, error) {
+	var res TicketPanelTransferResult
+	err := d.WithTx(ctx, func(tx pgx.Tx) error {
+		var oldPanelId *int
+		if err := tx.QueryRow(ctx, `SELECT "panel_id" FROM tickets WHERE "guild_id" = $1 AND "id" = $2 FOR UPDATE;`, guildId, ticketId).Scan(&oldPanelId); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrTicketNotFound
+			}
+
+			return err
+		}
+
+		// Lock the new panel so that it cannot be deleted until the transfer has been committed, and check that it
+		// belongs to the same guild as the ticket
+		var panelExists bool
+		if err := tx.QueryRow(ctx, `SELECT true FROM panels WHERE "panel_id" = $1 AND "guild_id" = $2 FOR SHARE;`, newPanelId, guildId).Scan(&panelExists); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrPanelNotFound
+			}
+
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, `UPDATE tickets SET "panel_id" = $3 WHERE "guild_id" = $1 AND "id" = $2;`, guildId, ticketId, newPanelId); err != nil {
+			return err
+		}
+
+		transferQuery := `
+INSERT INTO ticket_panel_transfers("guild_id", "ticket_id", "old_panel_id", "new_panel_id", "actor_id", "transferred_at")
+VALUES($1, $2, $3, $4, $5, NOW())
+RETURNING "id", "transferred_at";`
+
+		res.Transfer = TicketPanelTransfer{
+			GuildId:    guildId,
+			TicketId:   ticketId,
+			OldPanelId: oldPanelId,
+			NewPanelId: &newPanelId,
+			ActorId:    actorId,
+		}
+
+		if err := tx.QueryRow(ctx, transferQuery, guildId, ticketId, oldPanelId, newPanelId, actorId).Scan(&res.Transfer.Id, &res.Transfer.TransferredAt); err != nil {
+			return err
+		}
+
+		if releaseClaim {
+			if _, err := tx.Exec(ctx, `DELETE FROM ticket_claims WHERE "guild_id" = $1 AND "ticket_id" = $2;`, guildId, ticketId); err != nil {
+				return err
+			}
+
+			res.ClaimReleased = true
+		} else {
+			var claimedBy uint64
+			if err := tx.QueryRow(ctx, `SELECT "user_id" FROM ticket_claims WHERE "guild_id" = $1 AND "ticket_id" = $2;`, guildId, ticketId).Scan(&claimedBy); err != nil {
+				if !errors.Is(err, pgx.ErrNoRows) {
+					return err
+				}
+			} else {
+				res.ClaimedBy = &claimedBy
+			}
+		}
+
+		mentionQuery := `
+SELECT
+	panels.default_team,
+	COALESCE(panel_user_mentions.should_mention_user, false),
+	COALESCE(panel_here_mentions.should_mention_here, false),
+	COALESCE((SELECT array_agg(panel_teams.team_id) FROM panel_teams WHERE panel_teams.panel_id = panels.panel_id), '{}'),
+	COALESCE((SELECT array_agg(panel_role_mentions.role_id) FROM panel_role_mentions WHERE panel_role_mentions.panel_id = panels.panel_id), '{}')
+FROM panels
+LEFT OUTER JOIN panel_user_mentions ON panel_user_mentions.panel_id = panels.panel_id
+LEFT OUTER JOIN panel_here_mentions ON panel_here_mentions.panel_id = panels.panel_id
+WHERE panels.panel_id = $1;`
+
+		return tx.QueryRow(ctx, mentionQuery, newPanelId).Scan(
+			&res.WithDefaultTeam,
+			&res.MentionUser,
+			&res.MentionHere,
+			&res.TeamIds,
+			&res.MentionRoleIds,
+		)
+	})
+
+	if err != nil {
+		return TicketPanelTransferResult{}, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type TicketPinnedMessage struct {
+	GuildId   uint64    `json:"guild_id,string"`
+	TicketId  int       `json:"ticket_id"`
+	MessageId uint64    `json:"message_id,string"`
+	PinnedBy  uint64    `json:"pinned_by,string"`
+	Note      *string   `json:"note"`
+	PinnedAt  time.Time `json:"pinned_at"`
+}
+
+type TicketPinnedMessagesTable struct {
+	*pgxpool.Pool
+}
+
+func newTicketPinnedMessagesTable(db *pgxpool.Pool) *TicketPinnedMessagesTable {
+	return &TicketPinnedMessagesTable{
+		db,
+	}
+}
+
+func (t TicketPinnedMessagesTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS ticket_pinned_messages(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"message_id" int8 NOT NULL,
+	"pinned_by" int8 NOT NULL,
+	"note" varchar(255) DEFAULT NULL,
+	"pinned_at" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "ticket_id", "message_id")
+);
+`
+}
+
+// List returns the ticket's pinned messages, in the order they were pinned.
+func (t *TicketPinnedMessagesTable) List(ctx context.Context, guildId uint64, ticketId int) ([]TicketPinnedMessage, error) {
+	query := `
+SELECT "guild_id", "ticket_id", "message_id", "pinned_by", "note", "pinned_at"
+FROM ticket_pinned_messages
+WHERE "guild_id" = $1 AND "ticket_id" = $2
+ORDER BY "pinned_at" ASC;`
+
+	rows, err := t.Query(ctx, query, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []TicketPinnedMessage
+	for rows.Next() {
+		var message TicketPinnedMessage
+		if err := rows.Scan(
+			&message.GuildId,
+			&message.TicketId,
+			&message.MessageId,
+			&message.PinnedBy,
+			&message.Note,
+			&message.PinnedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+// Add pins the message. If it is already pinned, the note is updated.
+func (t *TicketPinnedMessagesTable) Add(ctx context.Context, guildId uint64, ticketId int, messageId, pinnedBy uint64, note *string) (err error) {
+	query := `
+INSERT INTO ticket_pinned_messages("guild_id", "ticket_id", "message_id", "pinned_by", "note", "pinned_at")
+VALUES($1, $2, $3, $4, $5, NOW())
+ON CONFLICT("guild_id", "ticket_id", "message_id") DO UPDATE SET "note" = EXCLUDED."note";`
+
+	_, err = t.Exec(ctx, query, guildId, ticketId, messageId, pinnedBy, note)
+	return
+}
+
+func (t *TicketPinnedMessagesTable) Remove(ctx context.Context, guildId uint64, ticketId int, messageId uint64) (err error) {
+	query := `DELETE FROM ticket_pinned_messages WHERE "guild_id" = $1 AND "ticket_id" = $2 AND "message_id" = $3;`
+	_, err = t.Exec(ctx, query, guildId, ticketId, messageId)
+	return
+}
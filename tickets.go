@@ -2,9 +2,13 @@ package database
 
 import (
 	"context"
+	"encoding/csv"
 	"errors"
 	"fmt"
+	"io"
 	"math"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/TicketsBot-cloud/common/model"
@@ -30,6 +34,22 @@ type Ticket struct {
 	Status           model.TicketStatus `json:"status"`
 }
 
+// TicketHistoryEntry is a summary of a closed ticket, for showing a user their own ticket history.
+type TicketHistoryEntry struct {
+	Id        int        `json:"id"`
+	OpenTime  time.Time  `json:"open_time"`
+	CloseTime *time.Time `json:"close_time"`
+	PanelId   *int       `json:"panel_id"`
+	Rating    *uint8     `json:"rating"`
+}
+
+// TicketHistoryPage selects a page of ticket history. BeforeId should be nil for the first page, and the ID of the last
+// entry of the previous page otherwise.
+type TicketHistoryPage struct {
+	BeforeId *int
+	Limit    int
+}
+
 type TicketQueryOptions struct {
 	Id                int       `json:"id"`
 	GuildId           uint64    `json:"guild_id"`
@@ -98,12 +118,15 @@ CREATE TABLE IF NOT EXISTS tickets(
 	FOREIGN KEY("panel_id") REFERENCES panels("panel_id") ON DELETE SET NULL ON UPDATE CASCADE,
 	PRIMARY KEY("id", "guild_id")
 );
-CREATE TABLE guild_ticket_counters (
+CREATE TABLE IF NOT EXISTS guild_ticket_counters (
     guild_id bigint PRIMARY KEY,
     last_ticket_id integer NOT NULL DEFAULT 0
 );
 CREATE INDEX IF NOT EXISTS tickets_channel_id ON tickets("channel_id");
 CREATE INDEX IF NOT EXISTS tickets_panel_id ON tickets("panel_id");
+CREATE INDEX IF NOT EXISTS tickets_user_id_open_time ON tickets("user_id", "open_time");
+CREATE INDEX IF NOT EXISTS tickets_guild_id_user_id_closed ON tickets("guild_id", "user_id", "id" DESC) WHERE "open" = false;
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS "opened_via" varchar(16) DEFAULT NULL CHECK("opened_via" IN ('panel', 'command', 'dashboard', 'open_for_user'));
 `
 }
 
@@ -140,6 +163,19 @@ func (t *TicketTable) BulkImport(ctx context.Context, guildId uint64, tickets []
 }
 
 func (t *TicketTable) Create(ctx context.Context, guildId, userId uint64, isThread bool, panelId *int) (id int, err error) {
+	return t.create(ctx, guildId, userId, isThread, panelId, nil)
+}
+
+// CreateVia creates a ticket, recording how it was opened for GetOpenedViaCounts.
+func (t *TicketTable) CreateVia(ctx context.Context, guildId, userId uint64, isThread bool, panelId *int, openedVia TicketOpenedVia) (id int, err error) {
+	if !openedVia.IsValid() {
+		return 0, fmt.Errorf("invalid ticket opened via: %s", openedVia)
+	}
+
+	return t.create(ctx, guildId, userId, isThread, panelId, &openedVia)
+}
+
+func (t *TicketTable) create(ctx context.Context, guildId, userId uint64, isThread bool, panelId *int, openedVia *TicketOpenedVia) (id int, err error) {
 	tx, err := t.Begin(ctx)
 	if err != nil {
 		return 0, err
@@ -158,13 +194,13 @@ func (t *TicketTable) Create(ctx context.Context, guildId, userId uint64, isThre
 	}
 
 	query := `
-INSERT INTO tickets("id", "guild_id", "user_id", "open", "open_time", "is_thread", "panel_id", "status")
+INSERT INTO tickets("id", "guild_id", "user_id", "open", "open_time", "is_thread", "panel_id", "status", "opened_via")
 VALUES(
-       $1, $2, $3, true, NOW(), $4, $5, $6
+       $1, $2, $3, true, NOW(), $4, $5, $6, $7
 )
 RETURNING "id";`
 
-	if err := tx.QueryRow(ctx, query, ticketId, guildId, userId, isThread, panelId, model.TicketStatusOpen).Scan(&id); err != nil {
+	if err := tx.QueryRow(ctx, query, ticketId, guildId, userId, isThread, panelId, model.TicketStatusOpen, openedVia).Scan(&id); err != nil {
 		return 0, err
 	}
 
@@ -194,12 +230,19 @@ WHERE "guild_id" = $3 AND "id" = $4;`
 	return
 }
 
-func (t *TicketTable) Get(ctx context.Context, ticketId int, guildId uint64) (ticket Ticket, e error) {
+// Deprecated: use GetById, which distinguishes a missing ticket from an error.
+func (t *TicketTable) Get(ctx context.Context, ticketId int, guildId uint64) (Ticket, error) {
+	ticket, _, err := t.GetById(ctx, ticketId, guildId)
+	return ticket, err
+}
+
+func (t *TicketTable) GetById(ctx context.Context, ticketId int, guildId uint64) (Ticket, bool, error) {
 	query := `
 SELECT id, guild_id, channel_id, user_id, open, open_time, welcome_message_id, panel_id, has_transcript, close_time, is_thread, join_message_id, notes_thread_id, status
 FROM tickets
 WHERE "id" = $1 AND "guild_id" = $2;`
 
+	var ticket Ticket
 	if err := t.QueryRow(ctx, query, ticketId, guildId).Scan(
 		&ticket.Id,
 		&ticket.GuildId,
@@ -215,10 +258,15 @@ WHERE "id" = $1 AND "guild_id" = $2;`
 		&ticket.JoinMessageId,
 		&ticket.NotesThreadId,
 		&ticket.Status,
-	); err != nil && err != pgx.ErrNoRows {
-		e = err
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Ticket{}, false, nil
+		} else {
+			return Ticket{}, false, err
+		}
 	}
-	return
+
+	return ticket, true, nil
 }
 
 func (t *TicketTable) GetByOptions(ctx context.Context, options TicketQueryOptions) (tickets []Ticket, e error) {
@@ -695,6 +743,97 @@ WHERE "guild_id" = $1 AND "user_id" = $2;`
 	return
 }
 
+// GetRecentByUserGlobal returns the tickets opened by the user in any guild since the given time, most recent first.
+// This crosses guild boundaries, so must only be exposed to bot staff tooling, never to guild-scoped callers.
+func (t *TicketTable) GetRecentByUserGlobal(ctx context.Context, userId uint64, since time.Time, limit int) ([]Ticket, error) {
+	query := `
+SELECT id, guild_id, channel_id, user_id, open, open_time, welcome_message_id, panel_id, has_transcript, close_time, is_thread, join_message_id, notes_thread_id, status
+FROM tickets
+WHERE "user_id" = $1 AND "open_time" >= $2
+ORDER BY "open_time" DESC
+LIMIT $3;`
+
+	rows, err := t.Query(ctx, query, userId, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tickets []Ticket
+	for rows.Next() {
+		var ticket Ticket
+		if err := rows.Scan(
+			&ticket.Id,
+			&ticket.GuildId,
+			&ticket.ChannelId,
+			&ticket.UserId,
+			&ticket.Open,
+			&ticket.OpenTime,
+			&ticket.WelcomeMessageId,
+			&ticket.PanelId,
+			&ticket.HasTranscript,
+			&ticket.CloseTime,
+			&ticket.IsThread,
+			&ticket.JoinMessageId,
+			&ticket.NotesThreadId,
+			&ticket.Status,
+		); err != nil {
+			return nil, err
+		}
+
+		tickets = append(tickets, ticket)
+	}
+
+	return tickets, nil
+}
+
+// GetClosedByUser returns a page of the user's closed tickets in the guild, most recent first.
+func (t *TicketTable) GetClosedByUser(ctx context.Context, guildId, userId uint64, page TicketHistoryPage) ([]TicketHistoryEntry, error) {
+	query := `
+SELECT tickets.id, tickets.open_time, tickets.close_time, tickets.panel_id, service_ratings.rating
+FROM tickets
+LEFT JOIN service_ratings
+ON service_ratings.guild_id = tickets.guild_id AND service_ratings.ticket_id = tickets.id
+WHERE tickets.guild_id = $1
+	AND tickets.user_id = $2
+	AND tickets.open = false
+	AND ($3::int4 IS NULL OR tickets.id < $3)
+ORDER BY tickets.id DESC
+LIMIT $4;`
+
+	rows, err := t.Query(ctx, query, guildId, userId, page.BeforeId, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []TicketHistoryEntry
+	for rows.Next() {
+		var entry TicketHistoryEntry
+		if err := rows.Scan(&entry.Id, &entry.OpenTime, &entry.CloseTime, &entry.PanelId, &entry.Rating); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (t *TicketTable) GetClosedCountByUser(ctx context.Context, guildId, userId uint64) (int, error) {
+	query := `
+SELECT COUNT(id)
+FROM tickets
+WHERE "guild_id" = $1 AND "user_id" = $2 AND "open" = false;`
+
+	var count int
+	if err := t.QueryRow(ctx, query, guildId, userId).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
 func (t *TicketTable) GetTotalCountByUser(ctx context.Context, guildId, userId uint64) (int, error) {
 	query := `
 SELECT COUNT(id)
@@ -749,6 +888,34 @@ WHERE "user_id" = $1 AND "open" = true AND "guild_id" = $2;`
 	return
 }
 
+// GetOpenWithChannels returns ticket ID -> channel ID for each of the guild's open tickets that has a channel, for
+// reconciling tickets against the channels that actually exist in the guild.
+func (t *TicketTable) GetOpenWithChannels(ctx context.Context, guildId uint64) (map[int]uint64, error) {
+	query := `
+SELECT "id", "channel_id"
+FROM tickets
+WHERE "guild_id" = $1 AND "open" = true AND "channel_id" IS NOT NULL;`
+
+	rows, err := t.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	channels := make(map[int]uint64)
+	for rows.Next() {
+		var ticketId int
+		var channelId uint64
+		if err := rows.Scan(&ticketId, &channelId); err != nil {
+			return nil, err
+		}
+
+		channels[ticketId] = channelId
+	}
+
+	return channels, nil
+}
+
 func (t *TicketTable) GetOpenCountByUser(ctx context.Context, guildId, userId uint64) (int, error) {
 	query := `
 SELECT COUNT(id)
@@ -1023,6 +1190,8 @@ type TicketWithMetadata struct {
 	ClaimedBy *uint64 `json:"claimed_by"`
 }
 
+// GetGuildOpenTicketsWithMetadata returns the guild's open tickets for the stale ticket and auto close checks, excluding
+// snoozed tickets.
 func (t *TicketTable) GetGuildOpenTicketsWithMetadata(ctx context.Context, guildId uint64) ([]TicketWithMetadata, error) {
 	query := `
 SELECT 
@@ -1032,7 +1201,11 @@ SELECT
 FROM tickets
 LEFT OUTER JOIN ticket_claims ON tickets.id = ticket_claims.ticket_id AND tickets.guild_id = ticket_claims.guild_id
 LEFT OUTER JOIN ticket_last_message ON tickets.id = ticket_last_message.ticket_id AND tickets.guild_id = ticket_last_message.guild_id
-WHERE tickets.guild_id = $1 AND tickets.open = true
+WHERE tickets.guild_id = $1 AND tickets.open = true AND NOT EXISTS(
+	SELECT 1
+	FROM ticket_snoozes
+	WHERE ticket_snoozes.guild_id = tickets.guild_id AND ticket_snoozes.ticket_id = tickets.id AND ticket_snoozes.until > NOW()
+)
 ORDER BY tickets.id DESC;`
 
 	rows, err := t.Query(ctx, query, guildId)
@@ -1327,6 +1500,54 @@ func (t *TicketTable) GetTotalTicketCount(ctx context.Context, guildId uint64) (
 	return
 }
 
+// GetDurationHistogram counts the guild's tickets closed within [from, to) by how long they were open. buckets must be
+// in ascending order. The returned slice has len(buckets)+1 entries: index 0 counts tickets open for less than
+// buckets[0], index i counts tickets open for at least buckets[i-1] but less than buckets[i], and the final index
+// counts tickets open for at least the last bucket.
+func (t *TicketTable) GetDurationHistogram(ctx context.Context, guildId uint64, from, to time.Time, buckets []time.Duration) ([]int, error) {
+	if !slices.IsSorted(buckets) {
+		return nil, errors.New("buckets must be in ascending order")
+	}
+
+	// A nil slice is encoded as NULL, for which width_bucket returns NULL rather than bucket 0
+	if buckets == nil {
+		buckets = []time.Duration{}
+	}
+
+	thresholds := make([]float64, len(buckets))
+	for i, bucket := range buckets {
+		thresholds[i] = bucket.Seconds()
+	}
+
+	query := `
+SELECT width_bucket(EXTRACT(EPOCH FROM tickets.close_time - tickets.open_time)::float8, $4::float8[]) AS bucket, COUNT(*)
+FROM tickets
+WHERE tickets.guild_id = $1 AND NOT tickets.open AND tickets.close_time >= $2 AND tickets.close_time < $3
+GROUP BY bucket;`
+
+	rows, err := t.Query(ctx, query, guildId, from, to, thresholds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]int, len(buckets)+1)
+	for rows.Next() {
+		var bucket, count int
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, err
+		}
+
+		counts[bucket] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
 func (t *TicketTable) Close(ctx context.Context, ticketId int, guildId uint64) (err error) {
 	query := `UPDATE tickets SET "open"=false, "close_time"=NOW(), "status"='CLOSED' WHERE "id"=$1 AND "guild_id"=$2;`
 	_, err = t.Exec(ctx, query, ticketId, guildId)
@@ -1389,3 +1610,189 @@ SET last_ticket_id = EXCLUDED.last_ticket_id;`
 	_, err = t.Exec(ctx, query, guildId)
 	return
 }
+
+// ReserveNext atomically reserves n consecutive ticket IDs, returning the first. The reserved IDs are first through
+// first+n-1, and will not be returned by Create or any other call to ReserveNext.
+func (t *TicketTable) ReserveNext(ctx context.Context, guildId uint64, n int) (first int, err error) {
+	if n < 1 {
+		return 0, fmt.Errorf("cannot reserve %d ticket ids", n)
+	}
+
+	query := `
+INSERT INTO guild_ticket_counters (guild_id, last_ticket_id)
+VALUES ($1, $2)
+ON CONFLICT (guild_id) DO UPDATE
+SET last_ticket_id = guild_ticket_counters.last_ticket_id + EXCLUDED.last_ticket_id
+RETURNING last_ticket_id;`
+
+	var last int
+	if err := t.QueryRow(ctx, query, guildId, n).Scan(&last); err != nil {
+		return 0, err
+	}
+
+	return last - n + 1, nil
+}
+
+// SyncCounter fast-forwards the guild's counter past the highest existing ticket ID, e.g. after tickets have been
+// imported. Unlike SeedTicketsCounter, the counter is never moved backwards. Returns the new value of the counter.
+func (t *TicketTable) SyncCounter(ctx context.Context, guildId uint64) (lastTicketId int, err error) {
+	query := `
+INSERT INTO guild_ticket_counters (guild_id, last_ticket_id)
+SELECT $1, COALESCE(MAX(id), 0)
+FROM tickets
+WHERE guild_id = $1
+ON CONFLICT (guild_id) DO UPDATE
+SET last_ticket_id = GREATEST(guild_ticket_counters.last_ticket_id, EXCLUDED.last_ticket_id)
+RETURNING last_ticket_id;`
+
+	err = t.QueryRow(ctx, query, guildId).Scan(&lastTicketId)
+	return
+}
+
+// ExportCSV writes the guild's tickets closed within [from, to) to w as CSV, ordered by close time. Rows are streamed
+// from the database as they are read, so the export is never held in memory in full. Pinned message IDs are
+// space-separated. User IDs and close reasons are masked according to opts.
+func (t *TicketTable) ExportCSV(ctx context.Context, guildId uint64, from, to time.Time, opts ExportOptions, w io.Writer) error {
+	query := fmt.Sprintf(`
+SELECT
+	tickets.id,
+	%s,
+	panels.title,
+	tickets.open_time,
+	tickets.close_time,
+	EXTRACT(EPOCH FROM tickets.close_time - tickets.open_time)::int8,
+	%s,
+	%s,
+	%s,
+	service_ratings.rating,
+	(
+		SELECT string_agg(ticket_pinned_messages.message_id::text, ' ' ORDER BY ticket_pinned_messages.pinned_at)
+		FROM ticket_pinned_messages
+		WHERE ticket_pinned_messages.guild_id = tickets.guild_id AND ticket_pinned_messages.ticket_id = tickets.id
+	)
+FROM tickets
+LEFT OUTER JOIN panels
+	ON panels.panel_id = tickets.panel_id
+LEFT OUTER JOIN ticket_claims
+	ON ticket_claims.guild_id = tickets.guild_id AND ticket_claims.ticket_id = tickets.id
+LEFT OUTER JOIN close_reason
+	ON close_reason.guild_id = tickets.guild_id AND close_reason.ticket_id = tickets.id
+LEFT OUTER JOIN service_ratings
+	ON service_ratings.guild_id = tickets.guild_id AND service_ratings.ticket_id = tickets.id
+WHERE tickets.guild_id = $1 AND NOT tickets.open AND tickets.close_time >= $2 AND tickets.close_time < $3
+ORDER BY tickets.close_time ASC, tickets.id ASC;`,
+		maskedUserIdColumn("tickets.user_id", 4, 5, 6),
+		maskedUserIdColumn("ticket_claims.user_id", 4, 5, 6),
+		maskedUserIdColumn("close_reason.closed_by", 4, 5, 6),
+		maskedContentColumn("close_reason.close_reason", 4),
+	)
+
+	salt, err := newExportSalt()
+	if err != nil {
+		return err
+	}
+
+	rows, err := t.Query(ctx, query, guildId, from, to, opts.Masking, opts.SubjectUserId, salt)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{
+		"ticket_id",
+		"user_id",
+		"panel",
+		"opened_at",
+		"closed_at",
+		"duration_seconds",
+		"claimed_by",
+		"closed_by",
+		"close_reason",
+		"rating",
+		"pinned_message_ids",
+	}); err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var (
+			ticketId        int
+			userId          string
+			panelTitle      *string
+			openTime        time.Time
+			closeTime       time.Time
+			durationSeconds int64
+			claimedBy       *string
+			closedBy        *string
+			closeReason     *string
+			rating          *int16
+			pinnedMessages  *string
+		)
+
+		if err := rows.Scan(
+			&ticketId,
+			&userId,
+			&panelTitle,
+			&openTime,
+			&closeTime,
+			&durationSeconds,
+			&claimedBy,
+			&closedBy,
+			&closeReason,
+			&rating,
+			&pinnedMessages,
+		); err != nil {
+			return err
+		}
+
+		record := []string{
+			strconv.Itoa(ticketId),
+			userId,
+			"",
+			openTime.UTC().Format(time.RFC3339),
+			closeTime.UTC().Format(time.RFC3339),
+			strconv.FormatInt(durationSeconds, 10),
+			"",
+			"",
+			"",
+			"",
+			"",
+		}
+
+		if panelTitle != nil {
+			record[2] = *panelTitle
+		}
+
+		if claimedBy != nil {
+			record[6] = *claimedBy
+		}
+
+		if closedBy != nil {
+			record[7] = *closedBy
+		}
+
+		if closeReason != nil {
+			record[8] = *closeReason
+		}
+
+		if rating != nil {
+			record[9] = strconv.Itoa(int(*rating))
+		}
+
+		if pinnedMessages != nil {
+			record[10] = *pinnedMessages
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
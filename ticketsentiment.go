@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type TicketSentiment struct {
+	GuildId     uint64    `json:"guild_id,string"`
+	TicketId    int       `json:"ticket_id"`
+	Score       float64   `json:"score"` // -1 (negative) to 1 (positive)
+	LastUpdated time.Time `json:"last_updated"`
+	SampleCount int       `json:"sample_count"`
+}
+
+type TicketSentimentTable struct {
+	*pgxpool.Pool
+}
+
+func newTicketSentimentTable(db *pgxpool.Pool) *TicketSentimentTable {
+	return &TicketSentimentTable{
+		db,
+	}
+}
+
+func (t TicketSentimentTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS ticket_sentiment(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"score" float8 NOT NULL,
+	"last_updated" timestamptz NOT NULL DEFAULT NOW(),
+	"sample_count" int4 NOT NULL DEFAULT 1,
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	CHECK("score" >= -1 AND "score" <= 1),
+	PRIMARY KEY("guild_id", "ticket_id")
+);
+CREATE INDEX IF NOT EXISTS ticket_sentiment_guild_id_score ON ticket_sentiment("guild_id", "score");
+`
+}
+
+func (t *TicketSentimentTable) Get(ctx context.Context, guildId uint64, ticketId int) (TicketSentiment, bool, error) {
+	query := `
+SELECT "guild_id", "ticket_id", "score", "last_updated", "sample_count"
+FROM ticket_sentiment
+WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+
+	var sentiment TicketSentiment
+	if err := t.QueryRow(ctx, query, guildId, ticketId).Scan(
+		&sentiment.GuildId,
+		&sentiment.TicketId,
+		&sentiment.Score,
+		&sentiment.LastUpdated,
+		&sentiment.SampleCount,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TicketSentiment{}, false, nil
+		} else {
+			return TicketSentiment{}, false, err
+		}
+	}
+
+	return sentiment, true, nil
+}
+
+// Upsert adds a new sample to the ticket's rolling score, which is the mean of all samples recorded so far.
+func (t *TicketSentimentTable) Upsert(ctx context.Context, guildId uint64, ticketId int, sampleScore float64) (err error) {
+	query := `
+INSERT INTO ticket_sentiment("guild_id", "ticket_id", "score", "last_updated", "sample_count")
+VALUES($1, $2, $3, NOW(), 1)
+ON CONFLICT("guild_id", "ticket_id") DO UPDATE
+SET
+	"score" = (ticket_sentiment."score" * ticket_sentiment."sample_count" + EXCLUDED."score") / (ticket_sentiment."sample_count" + 1),
+	"last_updated" = NOW(),
+	"sample_count" = ticket_sentiment."sample_count" + 1;`
+
+	_, err = t.Exec(ctx, query, guildId, ticketId, sampleScore)
+	return
+}
+
+// GetNegativeOpenTickets returns the open tickets in the guild with a score below the threshold, most negative first.
+func (t *TicketSentimentTable) GetNegativeOpenTickets(ctx context.Context, guildId uint64, threshold float64) ([]TicketSentiment, error) {
+	query := `
+SELECT ticket_sentiment."guild_id", ticket_sentiment."ticket_id", ticket_sentiment."score", ticket_sentiment."last_updated", ticket_sentiment."sample_count"
+FROM ticket_sentiment
+INNER JOIN tickets
+	ON tickets."guild_id" = ticket_sentiment."guild_id" AND tickets."id" = ticket_sentiment."ticket_id"
+WHERE ticket_sentiment."guild_id" = $1 AND ticket_sentiment."score" < $2 AND tickets."open"
+ORDER BY ticket_sentiment."score" ASC;`
+
+	rows, err := t.Query(ctx, query, guildId, threshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sentiments []TicketSentiment
+	for rows.Next() {
+		var sentiment TicketSentiment
+		if err := rows.Scan(
+			&sentiment.GuildId,
+			&sentiment.TicketId,
+			&sentiment.Score,
+			&sentiment.LastUpdated,
+			&sentiment.SampleCount,
+		); err != nil {
+			return nil, err
+		}
+
+		sentiments = append(sentiments, sentiment)
+	}
+
+	return sentiments, nil
+}
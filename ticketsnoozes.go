@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// TicketSnooze removes a ticket from staff's active queue until a time, e.g. while waiting on the ticket opener.
+// Snoozed tickets are excluded from alert threshold metrics and staff capacity until they wake.
+type TicketSnooze struct {
+	GuildId   uint64    `json:"guild_id,string"`
+	TicketId  int       `json:"ticket_id"`
+	Until     time.Time `json:"until"`
+	SnoozedBy uint64    `json:"snoozed_by,string"`
+	Reason    *string   `json:"reason"`
+}
+
+type TicketSnoozesTable struct {
+	*pgxpool.Pool
+}
+
+func newTicketSnoozesTable(db *pgxpool.Pool) *TicketSnoozesTable {
+	return &TicketSnoozesTable{
+		db,
+	}
+}
+
+func (t TicketSnoozesTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS ticket_snoozes(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"until" timestamptz NOT NULL,
+	"snoozed_by" int8 NOT NULL,
+	"reason" varchar(255) DEFAULT NULL,
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "ticket_id")
+);
+CREATE INDEX IF NOT EXISTS ticket_snoozes_until ON ticket_snoozes("until");
+`
+}
+
+// Get returns the ticket's snooze, if it has not yet woken.
+func (t *TicketSnoozesTable) Get(ctx context.Context, guildId uint64, ticketId int) (TicketSnooze, bool, error) {
+	query := `
+SELECT "guild_id", "ticket_id", "until", "snoozed_by", "reason"
+FROM ticket_snoozes
+WHERE "guild_id" = $1 AND "ticket_id" = $2 AND "until" > NOW();`
+
+	var snooze TicketSnooze
+	if err := t.QueryRow(ctx, query, guildId, ticketId).Scan(
+		&snooze.GuildId,
+		&snooze.TicketId,
+		&snooze.Until,
+		&snooze.SnoozedBy,
+		&snooze.Reason,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TicketSnooze{}, false, nil
+		} else {
+			return TicketSnooze{}, false, err
+		}
+	}
+
+	return snooze, true, nil
+}
+
+// Set snoozes the ticket, replacing any existing snooze.
+func (t *TicketSnoozesTable) Set(ctx context.Context, snooze TicketSnooze) (err error) {
+	query := `
+INSERT INTO ticket_snoozes("guild_id", "ticket_id", "until", "snoozed_by", "reason")
+VALUES($1, $2, $3, $4, $5)
+ON CONFLICT("guild_id", "ticket_id") DO UPDATE
+SET "until" = EXCLUDED."until", "snoozed_by" = EXCLUDED."snoozed_by", "reason" = EXCLUDED."reason";`
+
+	_, err = t.Exec(ctx, query, snooze.GuildId, snooze.TicketId, snooze.Until, snooze.SnoozedBy, snooze.Reason)
+	return
+}
+
+// Clear wakes the ticket early, or removes a snooze that has woken once it has been processed.
+func (t *TicketSnoozesTable) Clear(ctx context.Context, guildId uint64, ticketId int) (err error) {
+	query := `DELETE FROM ticket_snoozes WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+	_, err = t.Exec(ctx, query, guildId, ticketId)
+	return
+}
+
+// GetExpired returns up to limit snoozes that have woken, oldest first, so that staff can be notified. The caller
+// should Clear each snooze once processed.
+func (t *TicketSnoozesTable) GetExpired(ctx context.Context, limit int) ([]TicketSnooze, error) {
+	query := `
+SELECT "guild_id", "ticket_id", "until", "snoozed_by", "reason"
+FROM ticket_snoozes
+WHERE "until" <= NOW()
+ORDER BY "until" ASC
+LIMIT $1;`
+
+	rows, err := t.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snoozes []TicketSnooze
+	for rows.Next() {
+		var snooze TicketSnooze
+		if err := rows.Scan(&snooze.GuildId, &snooze.TicketId, &snooze.Until, &snooze.SnoozedBy, &snooze.Reason); err != nil {
+			return nil, err
+		}
+
+		snoozes = append(snoozes, snooze)
+	}
+
+	return snoozes, nil
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type TicketSummary struct {
+	GuildId     uint64    `json:"guild_id,string"`
+	TicketId    int       `json:"ticket_id"`
+	Summary     string    `json:"summary"`
+	Model       string    `json:"model"`
+	GeneratedAt time.Time `json:"generated_at"`
+	TokenCost   int       `json:"token_cost"`
+}
+
+type TicketSummariesTable struct {
+	*pgxpool.Pool
+}
+
+func newTicketSummariesTable(db *pgxpool.Pool) *TicketSummariesTable {
+	return &TicketSummariesTable{
+		db,
+	}
+}
+
+// Schema also creates ticket_summary_usage, which records the token cost of every generated summary, including those
+// since replaced or deleted, and is backfilled from ticket_summaries when it is first created.
+func (t TicketSummariesTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS ticket_summaries(
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"summary" text NOT NULL,
+	"model" varchar(100) NOT NULL,
+	"generated_at" timestamptz NOT NULL DEFAULT NOW(),
+	"token_cost" int4 NOT NULL,
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE CASCADE,
+	PRIMARY KEY("guild_id", "ticket_id")
+);
+CREATE INDEX IF NOT EXISTS ticket_summaries_guild_id_generated_at ON ticket_summaries("guild_id", "generated_at");
+CREATE TABLE IF NOT EXISTS ticket_summary_usage(
+	"id" BIGSERIAL NOT NULL,
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 NOT NULL,
+	"token_cost" int4 NOT NULL,
+	"generated_at" timestamptz NOT NULL DEFAULT NOW(),
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS ticket_summary_usage_guild_id_generated_at ON ticket_summary_usage("guild_id", "generated_at");
+INSERT INTO ticket_summary_usage("guild_id", "ticket_id", "token_cost", "generated_at")
+SELECT "guild_id", "ticket_id", "token_cost", "generated_at"
+FROM ticket_summaries
+WHERE NOT EXISTS(SELECT 1 FROM ticket_summary_usage);
+`
+}
+
+func (t *TicketSummariesTable) Get(ctx context.Context, guildId uint64, ticketId int) (TicketSummary, bool, error) {
+	query := `
+SELECT "guild_id", "ticket_id", "summary", "model", "generated_at", "token_cost"
+FROM ticket_summaries
+WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+
+	var summary TicketSummary
+	if err := t.QueryRow(ctx, query, guildId, ticketId).Scan(
+		&summary.GuildId,
+		&summary.TicketId,
+		&summary.Summary,
+		&summary.Model,
+		&summary.GeneratedAt,
+		&summary.TokenCost,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TicketSummary{}, false, nil
+		} else {
+			return TicketSummary{}, false, err
+		}
+	}
+
+	return summary, true, nil
+}
+
+// Upsert replaces any existing summary for the ticket, and records the token cost of generating it, which is counted by
+// GetMonthlyTokenUsage even once the summary is replaced. GeneratedAt is set to the current time.
+func (t *TicketSummariesTable) Upsert(ctx context.Context, guildId uint64, ticketId int, summary, model string, tokenCost int) error {
+	tx, err := t.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	query := `
+INSERT INTO ticket_summaries("guild_id", "ticket_id", "summary", "model", "generated_at", "token_cost")
+VALUES($1, $2, $3, $4, NOW(), $5)
+ON CONFLICT("guild_id", "ticket_id") DO UPDATE
+SET "summary" = EXCLUDED."summary", "model" = EXCLUDED."model", "generated_at" = EXCLUDED."generated_at", "token_cost" = EXCLUDED."token_cost";`
+
+	if _, err := tx.Exec(ctx, query, guildId, ticketId, summary, model, tokenCost); err != nil {
+		return err
+	}
+
+	usageQuery := `
+INSERT INTO ticket_summary_usage("guild_id", "ticket_id", "token_cost", "generated_at")
+VALUES($1, $2, $3, NOW());`
+
+	if _, err := tx.Exec(ctx, usageQuery, guildId, ticketId, tokenCost); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (t *TicketSummariesTable) Delete(ctx context.Context, guildId uint64, ticketId int) (err error) {
+	query := `DELETE FROM ticket_summaries WHERE "guild_id" = $1 AND "ticket_id" = $2;`
+	_, err = t.Exec(ctx, query, guildId, ticketId)
+	return
+}
+
+// GetMonthlyTokenUsage returns the total token cost of the summaries generated for the guild during the calendar
+// month (UTC) containing month, including summaries that have since been regenerated or deleted.
+func (t *TicketSummariesTable) GetMonthlyTokenUsage(ctx context.Context, guildId uint64, month time.Time) (usage int64, err error) {
+	query := `
+SELECT COALESCE(SUM("token_cost"), 0)
+FROM ticket_summary_usage
+WHERE "guild_id" = $1
+	AND "generated_at" >= date_trunc('month', $2::timestamptz AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'
+	AND "generated_at" < (date_trunc('month', $2::timestamptz AT TIME ZONE 'UTC') + INTERVAL '1 month') AT TIME ZONE 'UTC';`
+
+	err = t.QueryRow(ctx, query, guildId, month).Scan(&usage)
+	return
+}
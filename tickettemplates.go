@@ -0,0 +1,155 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type TicketTemplate struct {
+	Id      int     `json:"id"`
+	GuildId uint64  `json:"guild_id,string"`
+	PanelId *int    `json:"panel_id"` // Null if the template can be used with any panel
+	Name    string  `json:"name"`
+	Content *string `json:"content"`
+	EmbedId *int    `json:"embed_id"`
+}
+
+type TicketTemplatesTable struct {
+	*pgxpool.Pool
+}
+
+func newTicketTemplatesTable(db *pgxpool.Pool) *TicketTemplatesTable {
+	return &TicketTemplatesTable{
+		db,
+	}
+}
+
+func (t TicketTemplatesTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS ticket_templates(
+	"id" SERIAL NOT NULL UNIQUE,
+	"guild_id" int8 NOT NULL,
+	"panel_id" int DEFAULT NULL,
+	"name" varchar(100) NOT NULL,
+	"content" text DEFAULT NULL,
+	"embed_id" int DEFAULT NULL,
+	FOREIGN KEY("panel_id") REFERENCES panels("panel_id") ON DELETE CASCADE,
+	FOREIGN KEY("embed_id") REFERENCES embeds("id") ON DELETE SET NULL,
+	UNIQUE("guild_id", "name"),
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS ticket_templates_guild_id ON ticket_templates("guild_id");
+CREATE INDEX IF NOT EXISTS ticket_templates_panel_id ON ticket_templates("panel_id");
+`
+}
+
+func (t *TicketTemplatesTable) Get(ctx context.Context, guildId uint64, id int) (TicketTemplate, bool, error) {
+	query := `
+SELECT "id", "guild_id", "panel_id", "name", "content", "embed_id"
+FROM ticket_templates
+WHERE "guild_id" = $1 AND "id" = $2;`
+
+	var template TicketTemplate
+	if err := t.QueryRow(ctx, query, guildId, id).Scan(
+		&template.Id,
+		&template.GuildId,
+		&template.PanelId,
+		&template.Name,
+		&template.Content,
+		&template.EmbedId,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TicketTemplate{}, false, nil
+		} else {
+			return TicketTemplate{}, false, err
+		}
+	}
+
+	return template, true, nil
+}
+
+func (t *TicketTemplatesTable) GetByGuild(ctx context.Context, guildId uint64) ([]TicketTemplate, error) {
+	query := `
+SELECT "id", "guild_id", "panel_id", "name", "content", "embed_id"
+FROM ticket_templates
+WHERE "guild_id" = $1
+ORDER BY "id" ASC;`
+
+	rows, err := t.Query(ctx, query, guildId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTicketTemplates(rows)
+}
+
+// GetForPanel returns the templates that can be used to open a ticket from the panel: those tied to the panel
+// itself, and those available to every panel in the guild.
+func (t *TicketTemplatesTable) GetForPanel(ctx context.Context, panelId int) ([]TicketTemplate, error) {
+	query := `
+SELECT ticket_templates."id", ticket_templates."guild_id", ticket_templates."panel_id", ticket_templates."name", ticket_templates."content", ticket_templates."embed_id"
+FROM ticket_templates
+INNER JOIN panels
+	ON panels."guild_id" = ticket_templates."guild_id"
+WHERE panels."panel_id" = $1 AND (ticket_templates."panel_id" = $1 OR ticket_templates."panel_id" IS NULL)
+ORDER BY ticket_templates."id" ASC;`
+
+	rows, err := t.Query(ctx, query, panelId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTicketTemplates(rows)
+}
+
+func (t *TicketTemplatesTable) Create(ctx context.Context, template TicketTemplate) (id int, err error) {
+	query := `
+INSERT INTO ticket_templates("guild_id", "panel_id", "name", "content", "embed_id")
+VALUES($1, $2, $3, $4, $5)
+RETURNING "id";`
+
+	err = t.QueryRow(ctx, query, template.GuildId, template.PanelId, template.Name, template.Content, template.EmbedId).Scan(&id)
+	return
+}
+
+func (t *TicketTemplatesTable) Update(ctx context.Context, template TicketTemplate) (err error) {
+	query := `
+UPDATE ticket_templates
+SET "panel_id" = $3, "name" = $4, "content" = $5, "embed_id" = $6
+WHERE "guild_id" = $1 AND "id" = $2;`
+
+	_, err = t.Exec(ctx, query, template.GuildId, template.Id, template.PanelId, template.Name, template.Content, template.EmbedId)
+	return
+}
+
+func (t *TicketTemplatesTable) Delete(ctx context.Context, guildId uint64, id int) (err error) {
+	query := `DELETE FROM ticket_templates WHERE "guild_id" = $1 AND "id" = $2;`
+	_, err = t.Exec(ctx, query, guildId, id)
+	return
+}
+
+func scanTicketTemplates(rows pgx.Rows) ([]TicketTemplate, error) {
+	var templates []TicketTemplate
+	for rows.Next() {
+		var template TicketTemplate
+		if err := rows.Scan(
+			&template.Id,
+			&template.GuildId,
+			&template.PanelId,
+			&template.Name,
+			&template.Content,
+			&template.EmbedId,
+		); err != nil {
+			return nil, err
+		}
+
+		templates = append(templates, template)
+	}
+
+	return templates, nil
+}
@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type LimitResource string
+
+const (
+	LimitResourcePanels LimitResource = "panels"
+	LimitResourceForms  LimitResource = "forms"
+	LimitResourceTeams  LimitResource = "teams"
+)
+
+// TierLimitsFreeTier is the tier that applies to every guild, whether or not it has premium.
+const TierLimitsFreeTier = "free"
+
+// TierLimit is the maximum number of a resource that a guild with the tier may have. Tier is either
+// TierLimitsFreeTier or a model.EntitlementTier.
+type TierLimit struct {
+	Tier     string
+	Resource LimitResource
+	MaxCount int
+}
+
+// LimitReachedError is returned by Database.CheckLimit if the guild cannot create any more of the resource.
+type LimitReachedError struct {
+	Resource LimitResource
+	Limit    int
+}
+
+func (e LimitReachedError) Error() string {
+	return fmt.Sprintf("limit of %d %s reached", e.Limit, e.Resource)
+}
+
+type TierLimits struct {
+	*pgxpool.Pool
+}
+
+var (
+	//go:embed sql/tier_limits/schema.sql
+	tierLimitsSchema string
+
+	//go:embed sql/tier_limits/get_all.sql
+	tierLimitsGetAll string
+
+	//go:embed sql/tier_limits/set.sql
+	tierLimitsSet string
+
+	//go:embed sql/tier_limits/delete.sql
+	tierLimitsDelete string
+
+	//go:embed sql/tier_limits/check.sql
+	tierLimitsCheckTemplate string
+	tierLimitsCheck         = withGuildTiers(tierLimitsCheckTemplate)
+
+	//go:embed sql/tier_limits/count.sql
+	tierLimitsCount string
+
+	//go:embed sql/tier_limits/lock.sql
+	tierLimitsLock string
+)
+
+func newTierLimits(db *pgxpool.Pool) *TierLimits {
+	return &TierLimits{
+		db,
+	}
+}
+
+func (TierLimits) Schema() string {
+	return tierLimitsSchema
+}
+
+func (t *TierLimits) GetAll(ctx context.Context) ([]TierLimit, error) {
+	rows, err := t.Query(ctx, tierLimitsGetAll)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var limits []TierLimit
+	for rows.Next() {
+		var limit TierLimit
+		if err := rows.Scan(&limit.Tier, &limit.Resource, &limit.MaxCount); err != nil {
+			return nil, err
+		}
+
+		limits = append(limits, limit)
+	}
+
+	return limits, nil
+}
+
+func (t *TierLimits) Set(ctx context.Context, limit TierLimit) error {
+	_, err := t.Exec(ctx, tierLimitsSet, limit.Tier, limit.Resource, limit.MaxCount)
+	return err
+}
+
+func (t *TierLimits) Delete(ctx context.Context, tier string, resource LimitResource) error {
+	_, err := t.Exec(ctx, tierLimitsDelete, tier, resource)
+	return err
+}
+
+// CheckLimit returns LimitReachedError if the guild already has as many of the resource as its tiers allow. The
+// guild's tiers are resolved in the same way as Entitlements.GetGuildTiers, and the highest limit of the tiers applies.
+// If any of the tiers has no limit for the resource, it is unlimited, so that a tier is never held to a lower tier's
+// limit because its own is missing. The check is not atomic with the creation of the resource, so CheckLimitTx should
+// be used where the resource is created.
+func (d *Database) CheckLimit(ctx context.Context, guildId, ownerId uint64, resource LimitResource, gracePeriod time.Duration, includeVoting bool) error {
+	guildDb, err := d.primaryDatabase().ForGuild(ctx, guildId)
+	if err != nil {
+		return err
+	}
+
+	return guildDb.WithTx(ctx, func(tx pgx.Tx) error {
+		return d.CheckLimitTx(ctx, tx, guildId, ownerId, resource, gracePeriod, includeVoting)
+	})
+}
+
+// CheckLimitTx checks the limit as CheckLimit does, within tx, which must be a transaction on the database returned by
+// ForGuild for the guild. A per-guild lock on the resource is held until tx ends, so if the resource is created in the
+// same transaction, concurrent creations cannot exceed the limit. The limit is read from the primary database.
+func (d *Database) CheckLimitTx(ctx context.Context, tx pgx.Tx, guildId, ownerId uint64, resource LimitResource, gracePeriod time.Duration, includeVoting bool) error {
+	switch resource {
+	case LimitResourcePanels, LimitResourceForms, LimitResourceTeams:
+	default:
+		return fmt.Errorf("unknown limit resource %s", resource)
+	}
+
+	var limit *int
+	if err := d.primaryDatabase().pool.QueryRow(ctx, tierLimitsCheck, guildId, ownerId, gracePeriod, includeVoting, resource).Scan(&limit); err != nil {
+		return err
+	}
+
+	if limit == nil {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, tierLimitsLock, guildId, resource); err != nil {
+		return err
+	}
+
+	var count int
+	if err := tx.QueryRow(ctx, tierLimitsCount, guildId, resource).Scan(&count); err != nil {
+		return err
+	}
+
+	if count >= *limit {
+		return LimitReachedError{
+			Resource: resource,
+			Limit:    *limit,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type VerificationMethod string
+
+const (
+	VerificationMethodCaptcha VerificationMethod = "captcha"
+	VerificationMethodOAuth   VerificationMethod = "oauth"
+	VerificationMethodManual  VerificationMethod = "manual"
+)
+
+type UserVerification struct {
+	GuildId    uint64             `json:"guild_id,string"`
+	UserId     uint64             `json:"user_id,string"`
+	Method     VerificationMethod `json:"method"`
+	VerifiedAt time.Time          `json:"verified_at"`
+	ExpiresAt  *time.Time         `json:"expires_at"` // Null if the verification never expires
+}
+
+type UserVerificationTable struct {
+	*pgxpool.Pool
+}
+
+func newUserVerificationTable(db *pgxpool.Pool) *UserVerificationTable {
+	return &UserVerificationTable{
+		db,
+	}
+}
+
+func (u UserVerificationTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS user_verification(
+	"guild_id" int8 NOT NULL,
+	"user_id" int8 NOT NULL,
+	"method" varchar(32) NOT NULL,
+	"verified_at" timestamptz NOT NULL DEFAULT NOW(),
+	"expires_at" timestamptz DEFAULT NULL,
+	PRIMARY KEY("guild_id", "user_id")
+);
+`
+}
+
+// Get returns the user's verification, even if it has expired.
+func (u *UserVerificationTable) Get(ctx context.Context, guildId, userId uint64) (UserVerification, bool, error) {
+	query := `
+SELECT "guild_id", "user_id", "method", "verified_at", "expires_at"
+FROM user_verification
+WHERE "guild_id" = $1 AND "user_id" = $2;`
+
+	var verification UserVerification
+	if err := u.QueryRow(ctx, query, guildId, userId).Scan(
+		&verification.GuildId,
+		&verification.UserId,
+		&verification.Method,
+		&verification.VerifiedAt,
+		&verification.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return UserVerification{}, false, nil
+		} else {
+			return UserVerification{}, false, err
+		}
+	}
+
+	return verification, true, nil
+}
+
+// Set marks the user as verified now, replacing any previous verification.
+func (u *UserVerificationTable) Set(ctx context.Context, guildId, userId uint64, method VerificationMethod, expiresAt *time.Time) (err error) {
+	query := `
+INSERT INTO user_verification("guild_id", "user_id", "method", "verified_at", "expires_at")
+VALUES($1, $2, $3, NOW(), $4)
+ON CONFLICT("guild_id", "user_id") DO UPDATE
+SET "method" = EXCLUDED."method", "verified_at" = EXCLUDED."verified_at", "expires_at" = EXCLUDED."expires_at";`
+
+	_, err = u.Exec(ctx, query, guildId, userId, method, expiresAt)
+	return
+}
+
+// Expire revokes the user's verification immediately, requiring them to verify again.
+func (u *UserVerificationTable) Expire(ctx context.Context, guildId, userId uint64) (err error) {
+	query := `
+UPDATE user_verification
+SET "expires_at" = NOW()
+WHERE "guild_id" = $1 AND "user_id" = $2 AND ("expires_at" IS NULL OR "expires_at" > NOW());`
+
+	_, err = u.Exec(ctx, query, guildId, userId)
+	return
+}
+
+func (u *UserVerificationTable) Delete(ctx context.Context, guildId, userId uint64) (err error) {
+	query := `DELETE FROM user_verification WHERE "guild_id" = $1 AND "user_id" = $2;`
+	_, err = u.Exec(ctx, query, guildId, userId)
+	return
+}
+
+// RequiresVerification returns true if the user does not hold an unexpired verification for the guild.
+func (u *UserVerificationTable) RequiresVerification(ctx context.Context, guildId, userId uint64) (required bool, err error) {
+	query := `
+SELECT NOT EXISTS(
+	SELECT 1
+	FROM user_verification
+	WHERE "guild_id" = $1 AND "user_id" = $2 AND ("expires_at" IS NULL OR "expires_at" > NOW())
+);`
+
+	err = u.QueryRow(ctx, query, guildId, userId).Scan(&required)
+	return
+}
+
+// PanelVerificationResult is the outcome of checking a user against a panel's verification requirements. The user may
+// open a ticket from the panel only if none of the fields are true.
+type PanelVerificationResult struct {
+	RequiresVerification bool `json:"requires_verification"` // The panel requires verification, and the user is not verified
+	AccountTooNew        bool `json:"account_too_new"`       // The user's account is younger than the panel's minimum account age
+	JoinedTooRecently    bool `json:"joined_too_recently"`   // The user joined the guild more recently than the panel's minimum join age
+}
+
+// Passed returns true if the user meets all of the panel's requirements.
+func (r PanelVerificationResult) Passed() bool {
+	return !r.RequiresVerification && !r.AccountTooNew && !r.JoinedTooRecently
+}
+
+// RequiresVerificationForPanel evaluates the panel's stored requirements against the user: whether verification is
+// required and the user does not hold an unexpired verification for the guild, and whether the user's account and
+// membership are older than the panel's minimum ages. accountCreatedAt and joinedAt must be provided by the caller, as
+// they depend on data from Discord. A panel without requirements, or that does not belong to the guild, is always
+// passed.
+func (u *UserVerificationTable) RequiresVerificationForPanel(ctx context.Context, guildId, userId uint64, panelId int, accountCreatedAt, joinedAt time.Time) (PanelVerificationResult, error) {
+	query := `
+SELECT
+	pvr."required" AND NOT EXISTS(
+		SELECT 1
+		FROM user_verification
+		WHERE "guild_id" = $1 AND "user_id" = $2 AND ("expires_at" IS NULL OR "expires_at" > NOW())
+	),
+	COALESCE($4 > NOW() - make_interval(secs => pvr."min_account_age_seconds"), false),
+	COALESCE($5 > NOW() - make_interval(secs => pvr."min_join_age_seconds"), false)
+FROM panel_verification_requirements pvr
+INNER JOIN panels ON panels."panel_id" = pvr."panel_id"
+WHERE pvr."panel_id" = $3 AND panels."guild_id" = $1;`
+
+	var result PanelVerificationResult
+	if err := u.QueryRow(ctx, query, guildId, userId, panelId, accountCreatedAt, joinedAt).Scan(
+		&result.RequiresVerification,
+		&result.AccountTooNew,
+		&result.JoinedTooRecently,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PanelVerificationResult{}, nil
+		} else {
+			return PanelVerificationResult{}, err
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type VoiceSession struct {
+	Id        int64      `json:"id"`
+	GuildId   uint64     `json:"guild_id,string"`
+	TicketId  *int       `json:"ticket_id"` // Null if the session is not tied to a ticket
+	ChannelId uint64     `json:"channel_id,string"`
+	StaffIds  []uint64   `json:"staff_ids"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"` // Null while the session is in progress
+}
+
+type VoiceSessionsTable struct {
+	*pgxpool.Pool
+}
+
+func newVoiceSessionsTable(db *pgxpool.Pool) *VoiceSessionsTable {
+	return &VoiceSessionsTable{
+		db,
+	}
+}
+
+func (v VoiceSessionsTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS voice_sessions(
+	"id" BIGSERIAL NOT NULL,
+	"guild_id" int8 NOT NULL,
+	"ticket_id" int4 DEFAULT NULL,
+	"channel_id" int8 NOT NULL,
+	"staff_ids" int8[] NOT NULL DEFAULT '{}',
+	"started_at" timestamptz NOT NULL DEFAULT NOW(),
+	"ended_at" timestamptz DEFAULT NULL,
+	FOREIGN KEY("guild_id", "ticket_id") REFERENCES tickets("guild_id", "id") ON DELETE SET NULL ("ticket_id"),
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS voice_sessions_guild_id_started_at ON voice_sessions("guild_id", "started_at");
+CREATE UNIQUE INDEX IF NOT EXISTS voice_sessions_active_channel ON voice_sessions("channel_id") WHERE "ended_at" IS NULL;
+`
+}
+
+// Start begins a session in the channel. Only one session can be in progress per channel at a time.
+func (v *VoiceSessionsTable) Start(ctx context.Context, guildId uint64, ticketId *int, channelId uint64, staffIds []uint64) (id int64, err error) {
+	query := `
+INSERT INTO voice_sessions("guild_id", "ticket_id", "channel_id", "staff_ids", "started_at")
+VALUES($1, $2, $3, $4, NOW())
+RETURNING "id";`
+
+	if staffIds == nil {
+		staffIds = []uint64{}
+	}
+
+	err = v.QueryRow(ctx, query, guildId, ticketId, channelId, staffIds).Scan(&id)
+	return
+}
+
+// GetActive returns the session in progress in the channel, if there is one.
+func (v *VoiceSessionsTable) GetActive(ctx context.Context, channelId uint64) (VoiceSession, bool, error) {
+	query := `
+SELECT "id", "guild_id", "ticket_id", "channel_id", "staff_ids", "started_at", "ended_at"
+FROM voice_sessions
+WHERE "channel_id" = $1 AND "ended_at" IS NULL;`
+
+	var session VoiceSession
+	if err := v.QueryRow(ctx, query, channelId).Scan(
+		&session.Id,
+		&session.GuildId,
+		&session.TicketId,
+		&session.ChannelId,
+		&session.StaffIds,
+		&session.StartedAt,
+		&session.EndedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return VoiceSession{}, false, nil
+		} else {
+			return VoiceSession{}, false, err
+		}
+	}
+
+	return session, true, nil
+}
+
+// AddStaff records that a staff member joined the session. Adding a staff member more than once has no effect.
+func (v *VoiceSessionsTable) AddStaff(ctx context.Context, id int64, staffId uint64) (err error) {
+	query := `
+UPDATE voice_sessions
+SET "staff_ids" = array_append("staff_ids", $2)
+WHERE "id" = $1 AND NOT ($2 = ANY("staff_ids"));`
+
+	_, err = v.Exec(ctx, query, id, staffId)
+	return
+}
+
+func (v *VoiceSessionsTable) End(ctx context.Context, id int64) (err error) {
+	query := `UPDATE voice_sessions SET "ended_at" = NOW() WHERE "id" = $1 AND "ended_at" IS NULL;`
+	_, err = v.Exec(ctx, query, id)
+	return
+}
+
+func (v *VoiceSessionsTable) GetByTicket(ctx context.Context, guildId uint64, ticketId int) ([]VoiceSession, error) {
+	query := `
+SELECT "id", "guild_id", "ticket_id", "channel_id", "staff_ids", "started_at", "ended_at"
+FROM voice_sessions
+WHERE "guild_id" = $1 AND "ticket_id" = $2
+ORDER BY "started_at" ASC;`
+
+	rows, err := v.Query(ctx, query, guildId, ticketId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []VoiceSession
+	for rows.Next() {
+		var session VoiceSession
+		if err := rows.Scan(
+			&session.Id,
+			&session.GuildId,
+			&session.TicketId,
+			&session.ChannelId,
+			&session.StaffIds,
+			&session.StartedAt,
+			&session.EndedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// GetStaffVoiceMinutes returns staff ID -> total minutes spent in sessions that ended at or after since. Every staff
+// member in a session is credited with the session's full duration.
+func (v *VoiceSessionsTable) GetStaffVoiceMinutes(ctx context.Context, guildId uint64, since time.Time) (map[uint64]float64, error) {
+	query := `
+SELECT staff_id, SUM(EXTRACT(EPOCH FROM "ended_at" - "started_at") / 60)::float8
+FROM voice_sessions, unnest("staff_ids") AS staff_id
+WHERE "guild_id" = $1 AND "ended_at" IS NOT NULL AND "ended_at" >= $2
+GROUP BY staff_id;`
+
+	rows, err := v.Query(ctx, query, guildId, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	minutes := make(map[uint64]float64)
+	for rows.Next() {
+		var staffId uint64
+		var staffMinutes float64
+		if err := rows.Scan(&staffId, &staffMinutes); err != nil {
+			return nil, err
+		}
+
+		minutes[staffId] = staffMinutes
+	}
+
+	return minutes, nil
+}
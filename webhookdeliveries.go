@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type WebhookDelivery struct {
+	Id          int64      `json:"id"`
+	WebhookId   uint64     `json:"webhook_id,string"`
+	Event       string     `json:"event"`
+	Payload     []byte     `json:"payload"`
+	Attempt     int        `json:"attempt"`
+	StatusCode  *int       `json:"status_code"` // Null if no response was received
+	NextRetryAt *time.Time `json:"next_retry_at"`
+	GaveUp      bool       `json:"gave_up"`
+	AttemptedAt time.Time  `json:"attempted_at"`
+}
+
+type WebhookDeliveriesTable struct {
+	*pgxpool.Pool
+}
+
+func newWebhookDeliveriesTable(db *pgxpool.Pool) *WebhookDeliveriesTable {
+	return &WebhookDeliveriesTable{
+		db,
+	}
+}
+
+func (w WebhookDeliveriesTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS webhook_deliveries(
+	"id" BIGSERIAL NOT NULL,
+	"webhook_id" int8 NOT NULL,
+	"event" varchar(64) NOT NULL,
+	"payload" jsonb NOT NULL,
+	"attempt" int4 NOT NULL,
+	"status_code" int4 DEFAULT NULL,
+	"next_retry_at" timestamptz DEFAULT NULL,
+	"gave_up" bool NOT NULL DEFAULT false,
+	"attempted_at" timestamptz NOT NULL DEFAULT NOW(),
+	FOREIGN KEY("webhook_id") REFERENCES webhooks("webhook_id") ON DELETE CASCADE,
+	PRIMARY KEY("id")
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_next_retry_at ON webhook_deliveries("next_retry_at") WHERE "next_retry_at" IS NOT NULL AND NOT "gave_up";
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id ON webhook_deliveries("webhook_id");
+CREATE INDEX IF NOT EXISTS webhook_deliveries_attempted_at ON webhook_deliveries("attempted_at");
+`
+}
+
+// RecordAttempt logs a delivery attempt. If NextRetryAt is set and GaveUp is false, the attempt will be returned by
+// GetPendingRetries once the retry time has passed.
+func (w *WebhookDeliveriesTable) RecordAttempt(ctx context.Context, delivery WebhookDelivery) (id int64, err error) {
+	query := `
+INSERT INTO webhook_deliveries("webhook_id", "event", "payload", "attempt", "status_code", "next_retry_at", "gave_up", "attempted_at")
+VALUES($1, $2, $3, $4, $5, $6, $7, NOW())
+RETURNING "id";`
+
+	err = w.QueryRow(ctx, query,
+		delivery.WebhookId,
+		delivery.Event,
+		delivery.Payload,
+		delivery.Attempt,
+		delivery.StatusCode,
+		delivery.NextRetryAt,
+		delivery.GaveUp,
+	).Scan(&id)
+	return
+}
+
+// GetPendingRetries claims up to limit attempts whose retry is due, clearing their retry time so they are not
+// returned again. Rows locked by another worker are skipped. The caller should record the outcome of the retry as a
+// new attempt.
+func (w *WebhookDeliveriesTable) GetPendingRetries(ctx context.Context, limit int) ([]WebhookDelivery, error) {
+	query := `
+UPDATE webhook_deliveries
+SET "next_retry_at" = NULL
+WHERE "id" IN (
+	SELECT "id"
+	FROM webhook_deliveries
+	WHERE "next_retry_at" IS NOT NULL AND "next_retry_at" <= NOW() AND NOT "gave_up"
+	ORDER BY "next_retry_at" ASC
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING "id", "webhook_id", "event", "payload", "attempt", "status_code", "next_retry_at", "gave_up", "attempted_at";`
+
+	rows, err := w.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanWebhookDeliveries(rows)
+}
+
+func (w *WebhookDeliveriesTable) GetByWebhook(ctx context.Context, webhookId uint64, limit int) ([]WebhookDelivery, error) {
+	query := `
+SELECT "id", "webhook_id", "event", "payload", "attempt", "status_code", "next_retry_at", "gave_up", "attempted_at"
+FROM webhook_deliveries
+WHERE "webhook_id" = $1
+ORDER BY "attempted_at" DESC
+LIMIT $2;`
+
+	rows, err := w.Query(ctx, query, webhookId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanWebhookDeliveries(rows)
+}
+
+// Prune removes attempts older than maxAge that are not awaiting a retry.
+func (w *WebhookDeliveriesTable) Prune(ctx context.Context, maxAge time.Duration) (err error) {
+	query := `
+DELETE FROM webhook_deliveries
+WHERE "attempted_at" < NOW() - $1::interval AND ("next_retry_at" IS NULL OR "gave_up");`
+
+	_, err = w.Exec(ctx, query, maxAge)
+	return
+}
+
+func scanWebhookDeliveries(rows pgx.Rows) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		var delivery WebhookDelivery
+		if err := rows.Scan(
+			&delivery.Id,
+			&delivery.WebhookId,
+			&delivery.Event,
+			&delivery.Payload,
+			&delivery.Attempt,
+			&delivery.StatusCode,
+			&delivery.NextRetryAt,
+			&delivery.GaveUp,
+			&delivery.AttemptedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
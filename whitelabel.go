@@ -3,6 +3,10 @@ package database
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
@@ -12,6 +16,30 @@ type WhitelabelBot struct {
 	BotId     uint64
 	PublicKey string
 	Token     string
+	Branding  *WhitelabelBranding // Null if the bot has no custom branding
+}
+
+// WhitelabelBotSummary is a whitelabel bot as listed in admin tooling. It deliberately excludes the bot's token.
+type WhitelabelBotSummary struct {
+	UserId        uint64     `json:"user_id,string"`
+	BotId         uint64     `json:"bot_id,string"`
+	LastHeartbeat *time.Time `json:"last_heartbeat"` // Nil if the bot has never sent a heartbeat
+	ErrorCount    int        `json:"error_count"`
+	GuildCount    int        `json:"guild_count"`
+	PremiumActive bool       `json:"premium_active"`
+}
+
+type WhitelabelListOptions struct {
+	// Only list bots with at least one error since ErrorsSince, or ever if ErrorsSince is nil
+	HasErrors   bool
+	ErrorsSince *time.Time // Also limits ErrorCount
+	// Only list bots whose last heartbeat is before this time, or that have never sent one
+	HeartbeatBefore *time.Time
+	// Only list bots whose owner no longer has an active whitelabel subscription
+	PremiumExpired bool
+	// Keyset pagination: only list bots with a greater bot ID, i.e. the BotId of the last bot of the previous page
+	AfterBotId uint64
+	Limit      int
 }
 
 type WhitelabelBotTable struct {
@@ -34,14 +62,19 @@ CREATE TABLE IF NOT EXISTS whitelabel(
 	PRIMARY KEY("user_id")
 );
 CREATE INDEX IF NOT EXISTS whitelabel_bot_id ON whitelabel("bot_id");
+ALTER TABLE whitelabel ADD COLUMN IF NOT EXISTS "last_heartbeat" timestamptz DEFAULT NULL;
 `
 }
 
 func (w *WhitelabelBotTable) GetByUserId(ctx context.Context, userId uint64) (WhitelabelBot, error) {
-	query := `SELECT "user_id", "bot_id", "public_key", "token" FROM whitelabel WHERE "user_id" = $1;`
+	query := `
+SELECT whitelabel.user_id, whitelabel.bot_id, whitelabel.public_key, whitelabel.token, whitelabel_branding.bot_id, whitelabel_branding.footer_text, whitelabel_branding.accent_colour, whitelabel_branding.icon_url, whitelabel_branding.hide_powered_by
+FROM whitelabel
+LEFT OUTER JOIN whitelabel_branding ON whitelabel_branding.bot_id = whitelabel.bot_id
+WHERE whitelabel.user_id = $1;`
 
-	var bot WhitelabelBot
-	if err := w.QueryRow(ctx, query, userId).Scan(&bot.UserId, &bot.BotId, &bot.PublicKey, &bot.Token); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+	bot, err := scanWhitelabelBot(w.QueryRow(ctx, query, userId))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		return WhitelabelBot{}, err
 	}
 
@@ -49,13 +82,45 @@ func (w *WhitelabelBotTable) GetByUserId(ctx context.Context, userId uint64) (Wh
 }
 
 func (w *WhitelabelBotTable) GetByBotId(ctx context.Context, botId uint64) (WhitelabelBot, error) {
-	query := `SELECT "user_id", "bot_id", "public_key", "token" FROM whitelabel WHERE "bot_id" = $1;`
+	query := `
+SELECT whitelabel.user_id, whitelabel.bot_id, whitelabel.public_key, whitelabel.token, whitelabel_branding.bot_id, whitelabel_branding.footer_text, whitelabel_branding.accent_colour, whitelabel_branding.icon_url, whitelabel_branding.hide_powered_by
+FROM whitelabel
+LEFT OUTER JOIN whitelabel_branding ON whitelabel_branding.bot_id = whitelabel.bot_id
+WHERE whitelabel.bot_id = $1;`
+
+	bot, err := scanWhitelabelBot(w.QueryRow(ctx, query, botId))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return WhitelabelBot{}, err
+	}
 
+	return bot, nil
+}
+
+func scanWhitelabelBot(row pgx.Row) (WhitelabelBot, error) {
 	var bot WhitelabelBot
-	if err := w.QueryRow(ctx, query, botId).Scan(&bot.UserId, &bot.BotId, &bot.PublicKey, &bot.Token); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+	var brandingBotId *uint64
+	var branding WhitelabelBranding
+	var hidePoweredBy *bool
+	if err := row.Scan(
+		&bot.UserId,
+		&bot.BotId,
+		&bot.PublicKey,
+		&bot.Token,
+		&brandingBotId,
+		&branding.FooterText,
+		&branding.AccentColour,
+		&branding.IconUrl,
+		&hidePoweredBy,
+	); err != nil {
 		return WhitelabelBot{}, err
 	}
 
+	if brandingBotId != nil {
+		branding.BotId = *brandingBotId
+		branding.HidePoweredBy = hidePoweredBy != nil && *hidePoweredBy
+		bot.Branding = &branding
+	}
+
 	return bot, nil
 }
 
@@ -88,3 +153,98 @@ func (w *WhitelabelBotTable) DeleteByToken(ctx context.Context, token string) er
 	_, err := w.Exec(ctx, query, token)
 	return err
 }
+
+func (w *WhitelabelBotTable) UpdateHeartbeat(ctx context.Context, botId uint64) error {
+	query := `UPDATE whitelabel SET "last_heartbeat" = NOW() WHERE "bot_id" = $1;`
+	_, err := w.Exec(ctx, query, botId)
+	return err
+}
+
+// List returns whitelabel bots matching the filters, ordered by bot ID, with their error and guild counts. A whitelabel
+// subscription is active if the owner has an unexpired whitelabel tier entitlement, or an unexpired legacy
+// whitelabel_users row.
+func (w *WhitelabelBotTable) List(ctx context.Context, opts WhitelabelListOptions) ([]WhitelabelBotSummary, error) {
+	args := []interface{}{opts.AfterBotId, opts.ErrorsSince}
+
+	query := `
+SELECT *
+FROM (
+	SELECT
+		whitelabel.user_id,
+		whitelabel.bot_id,
+		whitelabel.last_heartbeat,
+		(
+			SELECT COUNT(*)
+			FROM whitelabel_errors
+			WHERE whitelabel_errors.user_id = whitelabel.user_id
+				AND ($2::timestamptz IS NULL OR whitelabel_errors.error_time >= $2::timestamptz)
+		) AS error_count,
+		(SELECT COUNT(*) FROM whitelabel_guilds WHERE whitelabel_guilds.bot_id = whitelabel.bot_id) AS guild_count,
+		(
+			EXISTS(
+				SELECT 1
+				FROM entitlements
+				INNER JOIN subscription_skus ON subscription_skus.sku_id = entitlements.sku_id
+				WHERE entitlements.user_id = whitelabel.user_id
+					AND subscription_skus.tier = 'whitelabel'
+					AND (entitlements.expires_at IS NULL OR entitlements.expires_at > NOW())
+			) OR EXISTS(
+				SELECT 1
+				FROM whitelabel_users
+				WHERE whitelabel_users.user_id = whitelabel.user_id AND whitelabel_users.expiry > NOW()
+			)
+		) AS premium_active
+	FROM whitelabel
+	WHERE whitelabel.bot_id > $1
+) AS bots`
+
+	var conditions []string
+	if opts.HasErrors {
+		conditions = append(conditions, "bots.error_count > 0")
+	}
+
+	if opts.HeartbeatBefore != nil {
+		args = append(args, *opts.HeartbeatBefore)
+		conditions = append(conditions, fmt.Sprintf("(bots.last_heartbeat IS NULL OR bots.last_heartbeat < $%d)", len(args)))
+	}
+
+	if opts.PremiumExpired {
+		conditions = append(conditions, "NOT bots.premium_active")
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += " ORDER BY bots.bot_id ASC"
+
+	if opts.Limit > 0 {
+		args = append(args, opts.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := w.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bots []WhitelabelBotSummary
+	for rows.Next() {
+		var bot WhitelabelBotSummary
+		if err := rows.Scan(
+			&bot.UserId,
+			&bot.BotId,
+			&bot.LastHeartbeat,
+			&bot.ErrorCount,
+			&bot.GuildCount,
+			&bot.PremiumActive,
+		); err != nil {
+			return nil, err
+		}
+
+		bots = append(bots, bot)
+	}
+
+	return bots, nil
+}
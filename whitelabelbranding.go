@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type WhitelabelBranding struct {
+	BotId         uint64  `json:"bot_id,string"`
+	FooterText    *string `json:"footer_text"`
+	AccentColour  *int32  `json:"accent_colour"`
+	IconUrl       *string `json:"icon_url"`
+	HidePoweredBy bool    `json:"hide_powered_by"`
+}
+
+type WhitelabelBrandingTable struct {
+	*pgxpool.Pool
+}
+
+func newWhitelabelBrandingTable(db *pgxpool.Pool) *WhitelabelBrandingTable {
+	return &WhitelabelBrandingTable{
+		db,
+	}
+}
+
+func (w WhitelabelBrandingTable) Schema() string {
+	return `
+CREATE TABLE IF NOT EXISTS whitelabel_branding(
+	"bot_id" int8 NOT NULL,
+	"footer_text" varchar(2048) DEFAULT NULL,
+	"accent_colour" int4 DEFAULT NULL,
+	"icon_url" varchar(255) DEFAULT NULL,
+	"hide_powered_by" bool NOT NULL DEFAULT false,
+	FOREIGN KEY("bot_id") REFERENCES whitelabel("bot_id") ON DELETE CASCADE ON UPDATE CASCADE,
+	CHECK("accent_colour" IS NULL OR ("accent_colour" >= 0 AND "accent_colour" <= 16777215)),
+	PRIMARY KEY("bot_id")
+);
+`
+}
+
+func (w *WhitelabelBrandingTable) Get(ctx context.Context, botId uint64) (WhitelabelBranding, bool, error) {
+	query := `
+SELECT "bot_id", "footer_text", "accent_colour", "icon_url", "hide_powered_by"
+FROM whitelabel_branding
+WHERE "bot_id" = $1;`
+
+	var branding WhitelabelBranding
+	if err := w.QueryRow(ctx, query, botId).Scan(
+		&branding.BotId,
+		&branding.FooterText,
+		&branding.AccentColour,
+		&branding.IconUrl,
+		&branding.HidePoweredBy,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return WhitelabelBranding{}, false, nil
+		} else {
+			return WhitelabelBranding{}, false, err
+		}
+	}
+
+	return branding, true, nil
+}
+
+func (w *WhitelabelBrandingTable) Upsert(ctx context.Context, branding WhitelabelBranding) (err error) {
+	query := `
+INSERT INTO whitelabel_branding("bot_id", "footer_text", "accent_colour", "icon_url", "hide_powered_by")
+VALUES($1, $2, $3, $4, $5)
+ON CONFLICT("bot_id") DO UPDATE
+SET "footer_text" = EXCLUDED."footer_text", "accent_colour" = EXCLUDED."accent_colour", "icon_url" = EXCLUDED."icon_url", "hide_powered_by" = EXCLUDED."hide_powered_by";`
+
+	_, err = w.Exec(ctx, query, branding.BotId, branding.FooterText, branding.AccentColour, branding.IconUrl, branding.HidePoweredBy)
+	return
+}
+
+func (w *WhitelabelBrandingTable) Delete(ctx context.Context, botId uint64) (err error) {
+	query := `DELETE FROM whitelabel_branding WHERE "bot_id" = $1;`
+	_, err = w.Exec(ctx, query, botId)
+	return
+}
@@ -30,6 +30,7 @@ CREATE TABLE IF NOT EXISTS whitelabel_errors(
 	"error_time" timestamptz NOT NULL,
 	PRIMARY KEY("error_id")
 );
+CREATE INDEX IF NOT EXISTS whitelabel_errors_user_id_error_time ON whitelabel_errors("user_id", "error_time");
 `
 }
 